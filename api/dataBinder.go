@@ -5,6 +5,11 @@ import (
 	"github.com/riser-platform/riser-server/pkg/core"
 )
 
+// Converter converts a model from a deprecated version before defaults are applied
+type Converter interface {
+	Convert() error
+}
+
 type DefaultApplier interface {
 	ApplyDefaults() error
 }
@@ -21,6 +26,13 @@ func (b *DataBinder) Bind(i interface{}, c echo.Context) (err error) {
 		return err
 	}
 
+	if modelWithConverter, ok := i.(Converter); ok {
+		err = modelWithConverter.Convert()
+		if err != nil {
+			return core.NewValidationErrorMessage(err.Error())
+		}
+	}
+
 	if modelWithDefaults, ok := i.(DefaultApplier); ok {
 		err = modelWithDefaults.ApplyDefaults()
 		if err != nil {
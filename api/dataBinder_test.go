@@ -35,6 +35,16 @@ func (d *decoratedModel) Validate() error {
 	return nil
 }
 
+type convertedModel struct {
+	decoratedModel
+	convertErr error
+}
+
+func (c *convertedModel) Convert() error {
+	c.bindVal = 3
+	return c.convertErr
+}
+
 type plainModel struct {
 	val int
 }
@@ -70,6 +80,28 @@ func Test_Bind_WhenValidationFails(t *testing.T) {
 	assert.Equal(t, testValidationError, cve.ValidationError)
 }
 
+func Test_Bind_ConvertsBeforeApplyingDefaults(t *testing.T) {
+	model := &convertedModel{decoratedModel: decoratedModel{val: 1, bindVal: 2, validationVal: 3}}
+
+	ctx := setupDataBinderTest(model)
+
+	err := ctx.Bind(model)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, model.val)
+}
+
+func Test_Bind_WhenConvertFails(t *testing.T) {
+	model := &convertedModel{convertErr: errors.New("test convert error")}
+
+	ctx := setupDataBinderTest(model)
+
+	err := ctx.Bind(model)
+
+	require.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, "test convert error", err.Error())
+}
+
 func Test_Bind_NoBindingOrValidation(t *testing.T) {
 	model := &plainModel{
 		val: 1,
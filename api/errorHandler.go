@@ -17,6 +17,12 @@ type ValidationErrorResponse struct {
 	ValidationErrors map[string]string `json:"validationErrors,omitempty"`
 }
 
+// Note: This model is shared between API versions. Therefore any change here is breaking for all API versions.
+type RevisionConflictResponse struct {
+	Message              string `json:"message"`
+	CurrentRiserRevision int64  `json:"currentRiserRevision"`
+}
+
 func ErrorHandler(err error, c echo.Context) {
 	var (
 		code          = http.StatusInternalServerError
@@ -62,6 +68,15 @@ func ErrorHandler(err error, c echo.Context) {
 		}
 	}
 
+	if conflictError, ok := err.(*core.RevisionConflictError); ok {
+		internalError = nil
+		code = http.StatusConflict
+		jsonResponse = &RevisionConflictResponse{
+			Message:              conflictError.Error(),
+			CurrentRiserRevision: conflictError.CurrentRiserRevision,
+		}
+	}
+
 	// Checking Response().Committed is required to prevent duplicate log entries
 	// I could not figure out a way to repro this in a unit test so tests will still pass if removed
 	if !c.Response().Committed {
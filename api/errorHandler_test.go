@@ -174,6 +174,22 @@ func Test_ErrorHandler_WhenValidationErrorIsInternal_Returns500AndLogsInternal(t
 	assert.Equal(t, jsonResponse.Message, http.StatusText(http.StatusInternalServerError))
 }
 
+func Test_ErrorHandler_WhenRevisionConflict_Returns409WithCurrentRevision(t *testing.T) {
+	logBuf := &bytes.Buffer{}
+	ctx, rec := errorHandlerTestSetup(logBuf)
+
+	err := core.NewRevisionConflictError(3)
+
+	ErrorHandler(err, ctx)
+
+	assert.Empty(t, logBuf)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	jsonResponse := RevisionConflictResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &jsonResponse), rec.Body.String())
+	assert.Equal(t, "the deployment has been updated by another process (current revision: 3)", jsonResponse.Message)
+	assert.EqualValues(t, 3, jsonResponse.CurrentRiserRevision)
+}
+
 func errorHandlerTestSetup(logWriter io.Writer) (echo.Context, *httptest.ResponseRecorder) {
 	e := echo.New()
 	e.Logger.SetOutput(logWriter)
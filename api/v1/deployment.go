@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/pkg/errors"
 
@@ -15,6 +17,7 @@ import (
 	model "github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/git"
 	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 
 	"github.com/labstack/echo/v4"
 )
@@ -34,7 +37,17 @@ func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService
 		return err
 	}
 
-	newDeployment, err := mapDeploymentRequestToDomain(deploymentRequest)
+	environmentConfig, err := environmentService.GetConfig(deploymentRequest.Environment)
+	if err != nil {
+		return err
+	}
+
+	newDeployment, err := mapDeploymentRequestToDomain(deploymentRequest, environmentConfig.AppDefaults)
+	if err != nil {
+		return err
+	}
+
+	newDeployment.ExpectedRiserRevision, err = expectedRiserRevision(c, deploymentRequest.ExpectedRiserRevision)
 	if err != nil {
 		return err
 	}
@@ -44,6 +57,12 @@ func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService
 		return err
 	}
 
+	warnings, err := deploymentService.UnresolvedAppReferences(newDeployment.App, newDeployment.Name,
+		&core.Environment{Name: newDeployment.EnvironmentName, Doc: core.EnvironmentDoc{Config: *environmentConfig}})
+	if err != nil {
+		return err
+	}
+
 	var committer state.Committer
 
 	if isDryRun {
@@ -59,7 +78,7 @@ func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService
 	riserRevision, err := deploymentService.Update(newDeployment, committer, isDryRun)
 	if err != nil {
 		if err == git.ErrNoChanges {
-			return c.JSON(http.StatusOK, model.SaveDeploymentResponse{Message: "No changes to deploy"})
+			return c.JSON(http.StatusOK, model.SaveDeploymentResponse{Message: "No changes to deploy", Warnings: warnings})
 		}
 		return err
 	}
@@ -68,16 +87,33 @@ func PostDeployment(c echo.Context, repoCache *environment.RepoCache, appService
 		dryRunCommitter := committer.(*state.DryRunCommitter)
 		return c.JSON(http.StatusAccepted, model.SaveDeploymentResponse{
 
-			Message:       "Dry run: changes not applied",
-			DryRunCommits: mapDryRunCommitsFromDomain(dryRunCommitter.Commits),
+			Message:         "Dry run: changes not applied",
+			DryRunCommits:   mapDryRunCommitsFromDomain(dryRunCommitter.Commits),
+			Warnings:        warnings,
+			AppConfigLayers: deploymentRequest.App.AppConfigLayers(deploymentRequest.Environment, environmentConfig.AppDefaults),
 		})
 	}
 
-	return c.JSON(http.StatusAccepted, model.SaveDeploymentResponse{RiserRevision: riserRevision, Message: "Deployment requested"})
+	return c.JSON(http.StatusAccepted, model.SaveDeploymentResponse{RiserRevision: riserRevision, Message: "Deployment requested", Warnings: warnings})
 }
 
 func DeleteDeployment(c echo.Context, repoCache *environment.RepoCache, deploymentService deployment.Service) error {
 	envName := c.Param("envName")
+
+	var fromQuery int64
+	if c.QueryParam("expectedRiserRevision") != "" {
+		parsed, err := strconv.ParseInt(c.QueryParam("expectedRiserRevision"), 10, 64)
+		if err != nil || parsed < 0 {
+			return core.NewValidationErrorMessage("expectedRiserRevision must be a riser revision")
+		}
+		fromQuery = parsed
+	}
+
+	expectedRevision, err := expectedRiserRevision(c, fromQuery)
+	if err != nil {
+		return err
+	}
+
 	gitRepo, err := repoCache.GetRepo(envName)
 	if err != nil {
 		return err
@@ -86,6 +122,8 @@ func DeleteDeployment(c echo.Context, repoCache *environment.RepoCache, deployme
 	err = deploymentService.Delete(
 		core.NewNamespacedName(c.Param("deploymentName"), c.Param("namespace")),
 		envName,
+		expectedRevision,
+		c.QueryParam("overrideLock") == "true",
 		state.NewGitCommitter(gitRepo))
 
 	if err != nil {
@@ -98,7 +136,38 @@ func DeleteDeployment(c echo.Context, repoCache *environment.RepoCache, deployme
 	return c.JSON(http.StatusAccepted, model.APIResponse{Message: "Deployment deletion requested"})
 }
 
-func PutDeploymentStatus(c echo.Context, deployments core.DeploymentRepository) error {
+func PutDeploymentLock(c echo.Context, deploymentService deployment.Service) error {
+	lockRequest := &model.DeploymentLockRequest{}
+	err := c.Bind(lockRequest)
+	if err != nil {
+		return err
+	}
+
+	holder, _ := c.Get("username").(string)
+	lock := &core.DeploymentLock{
+		Holder:    holder,
+		Reason:    lockRequest.Reason,
+		ExpiresAt: lockRequest.ExpiresAt,
+	}
+
+	err = deploymentService.Lock(core.NewNamespacedName(c.Param("deploymentName"), c.Param("namespace")), c.Param("envName"), lock)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, mapDeploymentLockFromDomain(lock))
+}
+
+func DeleteDeploymentLock(c echo.Context, deploymentService deployment.Service) error {
+	err := deploymentService.Unlock(core.NewNamespacedName(c.Param("deploymentName"), c.Param("namespace")), c.Param("envName"))
+	if err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func PutDeploymentStatus(c echo.Context, deployments core.DeploymentRepository, webhookService webhook.Service) error {
 	deploymentStatus := &model.DeploymentStatusMutable{}
 	err := c.Bind(deploymentStatus)
 	if err != nil {
@@ -108,13 +177,32 @@ func PutDeploymentStatus(c echo.Context, deployments core.DeploymentRepository)
 	deploymentName := c.Param("deploymentName")
 	namespace := c.Param("namespace")
 	envName := c.Param("envName")
+	name := core.NewNamespacedName(deploymentName, namespace)
+
+	// The previous status is used to only publish events for revisions whose status has changed
+	var previousStatus *core.DeploymentStatus
+	existingDeployment, err := deployments.GetByName(name, envName)
+	if err == nil {
+		previousStatus = existingDeployment.Doc.Status
+	} else if err != core.ErrNotFound {
+		return err
+	}
 
-	err = deployments.UpdateStatus(core.NewNamespacedName(deploymentName, namespace), envName, mapDeploymentStatusFromModel(deploymentStatus))
+	status := mapDeploymentStatusFromModel(deploymentStatus)
+	err = deployments.UpdateStatus(name, envName, status)
 	if err == core.ErrConflictNewerVersion {
 		return echo.NewHTTPError(http.StatusConflict, "A newer revision of the deployment has been observed or the deployment does not exist in this environment")
 	}
+	if err != nil {
+		return err
+	}
+
+	events := webhook.RevisionStatusEvents(name, envName, previousStatus, status)
+	for idx := range events {
+		webhookService.Publish(&events[idx])
+	}
 
-	return err
+	return nil
 }
 
 func mapDryRunCommitsFromDomain(commits []state.DryRunCommit) []model.DryRunCommit {
@@ -124,7 +212,7 @@ func mapDryRunCommitsFromDomain(commits []state.DryRunCommit) []model.DryRunComm
 		modelCommit.Message = commit.Message
 		modelCommit.Files = []model.DryRunFile{}
 		for _, file := range commit.Files {
-			modelCommit.Files = append(modelCommit.Files, model.DryRunFile{Name: file.Name, Contents: string(file.Contents)})
+			modelCommit.Files = append(modelCommit.Files, model.DryRunFile{Name: file.Name, Contents: string(file.Contents), Delete: file.Delete})
 		}
 		out = append(out, modelCommit)
 	}
@@ -132,11 +220,19 @@ func mapDryRunCommitsFromDomain(commits []state.DryRunCommit) []model.DryRunComm
 	return out
 }
 
-func mapDeploymentRequestToDomain(deploymentRequest *model.SaveDeploymentRequest) (*core.DeploymentConfig, error) {
-	app, err := deploymentRequest.App.ApplyOverrides(deploymentRequest.Environment)
+func mapDeploymentRequestToDomain(deploymentRequest *model.SaveDeploymentRequest, envAppDefaults *model.OverrideableAppConfig) (*core.DeploymentConfig, error) {
+	app, err := deploymentRequest.App.ApplyEnvironment(deploymentRequest.Environment, envAppDefaults)
 	if err != nil {
 		return nil, err
 	}
+	// The request is validated when it's bound but the environment's app defaults are not known until now
+	if envAppDefaults != nil {
+		err = app.Validate()
+		if err != nil {
+			return nil, core.NewValidationError(
+				fmt.Sprintf("The app config is not valid with the app defaults of environment %q", deploymentRequest.Environment), err)
+		}
+	}
 	return &core.DeploymentConfig{
 		Name:            deploymentRequest.Name,
 		Namespace:       string(app.Namespace),
@@ -146,5 +242,15 @@ func mapDeploymentRequestToDomain(deploymentRequest *model.SaveDeploymentRequest
 		},
 		App:           app,
 		ManualRollout: deploymentRequest.ManualRollout,
+		OverrideLock:  deploymentRequest.OverrideLock,
 	}, nil
 }
+
+func mapDeploymentLockFromDomain(domain *core.DeploymentLock) *model.DeploymentLock {
+	return &model.DeploymentLock{
+		Holder:    domain.Holder,
+		Reason:    domain.Reason,
+		Created:   domain.Created,
+		ExpiresAt: domain.ExpiresAt,
+	}
+}
@@ -6,11 +6,13 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/pkg/environment"
 	"github.com/riser-platform/riser-server/pkg/git"
 	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 
 	"github.com/riser-platform/riser-server/pkg/deployment"
 
@@ -31,7 +33,8 @@ func Test_DeleteDeployment(t *testing.T) {
 	ctx.SetParamValues("dev")
 
 	deploymentService := &deployment.FakeService{
-		DeleteFn: func(name *core.NamespacedName, envName string, committer state.Committer) error {
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
+			assert.Zero(t, expectedRiserRevision)
 			return nil
 		},
 	}
@@ -54,7 +57,7 @@ func Test_DeleteDeployment_NothingToDelete(t *testing.T) {
 	ctx.SetParamValues("dev")
 
 	deploymentService := &deployment.FakeService{
-		DeleteFn: func(name *core.NamespacedName, envName string, committer state.Committer) error {
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
 			return git.ErrNoChanges
 		},
 	}
@@ -68,6 +71,131 @@ func Test_DeleteDeployment_NothingToDelete(t *testing.T) {
 	assert.Equal(t, "Deployment not found", apiResponse.Message)
 }
 
+func Test_DeleteDeployment_ExpectedRevision(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/deployments/dev/myns/mydep?expectedRiserRevision=3", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("envName")
+	ctx.SetParamValues("dev")
+
+	deploymentService := &deployment.FakeService{
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
+			assert.EqualValues(t, 3, expectedRiserRevision)
+			return core.NewRevisionConflictError(4)
+		},
+	}
+
+	err := DeleteDeployment(ctx, environment.NewFakeRepoCache(), deploymentService)
+
+	assert.IsType(t, &core.RevisionConflictError{}, err)
+	assert.Equal(t, 1, deploymentService.DeleteCallCount)
+}
+
+func Test_DeleteDeployment_ExpectedRevisionIfMatch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/deployments/dev/myns/mydep", nil)
+	req.Header.Add("If-Match", `"3"`)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("envName")
+	ctx.SetParamValues("dev")
+
+	deploymentService := &deployment.FakeService{
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
+			assert.EqualValues(t, 3, expectedRiserRevision)
+			return nil
+		},
+	}
+
+	err := DeleteDeployment(ctx, environment.NewFakeRepoCache(), deploymentService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentService.DeleteCallCount)
+}
+
+func Test_DeleteDeployment_OverrideLock(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/deployments/dev/myns/mydep?overrideLock=true", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("envName")
+	ctx.SetParamValues("dev")
+
+	deploymentService := &deployment.FakeService{
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
+			assert.True(t, overrideLock)
+			return nil
+		},
+	}
+
+	err := DeleteDeployment(ctx, environment.NewFakeRepoCache(), deploymentService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentService.DeleteCallCount)
+}
+
+func Test_PutDeploymentLock(t *testing.T) {
+	lockRequest := &model.DeploymentLockRequest{Reason: "incident"}
+	req := httptest.NewRequest(http.MethodPut, "/deployments/prod/myns/mydep/lock", safeMarshal(lockRequest))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("prod", "myns", "mydep")
+	ctx.Set("username", "alice")
+
+	deploymentService := &deployment.FakeService{
+		LockFn: func(name *core.NamespacedName, envName string, lock *core.DeploymentLock) error {
+			assert.Equal(t, core.NewNamespacedName("mydep", "myns"), name)
+			assert.Equal(t, "prod", envName)
+			assert.Equal(t, "alice", lock.Holder)
+			assert.Equal(t, "incident", lock.Reason)
+			assert.Nil(t, lock.ExpiresAt)
+			return nil
+		},
+	}
+
+	err := PutDeploymentLock(ctx, deploymentService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentService.LockCallCount)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	response := model.DeploymentLock{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "alice", response.Holder)
+	assert.Equal(t, "incident", response.Reason)
+}
+
+func Test_DeleteDeploymentLock(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/deployments/prod/myns/mydep/lock", nil)
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("prod", "myns", "mydep")
+
+	deploymentService := &deployment.FakeService{
+		UnlockFn: func(name *core.NamespacedName, envName string) error {
+			assert.Equal(t, core.NewNamespacedName("mydep", "myns"), name)
+			assert.Equal(t, "prod", envName)
+			return nil
+		},
+	}
+
+	err := DeleteDeploymentLock(ctx, deploymentService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentService.UnlockCallCount)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func Test_DeleteDeployment_InvalidExpectedRevision(t *testing.T) {
+	req := httptest.NewRequest(http.MethodDelete, "/deployments/dev/myns/mydep?expectedRiserRevision=abc", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("envName")
+	ctx.SetParamValues("dev")
+
+	deploymentService := &deployment.FakeService{}
+
+	err := DeleteDeployment(ctx, environment.NewFakeRepoCache(), deploymentService)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, "expectedRiserRevision must be a riser revision", err.Error())
+	assert.Equal(t, 0, deploymentService.DeleteCallCount)
+}
+
 func Test_PutDeploymentStatus_UpdatesStatus(t *testing.T) {
 	deploymentStatus := &model.DeploymentStatusMutable{
 		ObservedRiserRevision: 1,
@@ -78,17 +206,74 @@ func Test_PutDeploymentStatus_UpdatesStatus(t *testing.T) {
 	ctx, rec := newContextWithRecorder(req)
 
 	deploymentRepository := core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
 		UpdateStatusFn: func(name *core.NamespacedName, envName string, status *core.DeploymentStatus) error {
 			assert.EqualValues(t, 1, status.ObservedRiserRevision)
 			return nil
 		},
 	}
 
-	err := PutDeploymentStatus(ctx, &deploymentRepository)
+	webhookService := &webhook.FakeService{}
+
+	err := PutDeploymentStatus(ctx, &deploymentRepository, webhookService)
 
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
 	assert.Equal(t, 1, deploymentRepository.UpdateStatusCallCount)
+	assert.Equal(t, 0, webhookService.PublishCallCount)
+}
+
+func Test_PutDeploymentStatus_PublishesRevisionEvents(t *testing.T) {
+	deploymentStatus := &model.DeploymentStatusMutable{
+		ObservedRiserRevision: 2,
+		Revisions: []model.DeploymentRevisionStatus{
+			{Name: "mydep-1", RiserRevision: 1, RevisionStatus: model.RevisionStatusReady},
+			{Name: "mydep-2", RiserRevision: 2, RevisionStatus: model.RevisionStatusReady},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/deployments/dev/myns/mydep/status", safeMarshal(deploymentStatus))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("envName", "namespace", "deploymentName")
+	ctx.SetParamValues("dev", "myns", "mydep")
+
+	deploymentRepository := core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentRecord: core.DeploymentRecord{
+					Doc: core.DeploymentDoc{
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{
+								{Name: "mydep-1", RiserRevision: 1, RevisionStatus: model.RevisionStatusReady},
+								{Name: "mydep-2", RiserRevision: 2, RevisionStatus: model.RevisionStatusWaiting},
+							},
+						},
+					},
+				},
+			}, nil
+		},
+		UpdateStatusFn: func(name *core.NamespacedName, envName string, status *core.DeploymentStatus) error {
+			return nil
+		},
+	}
+
+	webhookService := &webhook.FakeService{
+		PublishFn: func(event *core.WebhookEvent) {
+			assert.Equal(t, model.WebhookEvent_RevisionReady, event.Type)
+			assert.Equal(t, "mydep", event.Name)
+			assert.Equal(t, "myns", event.Namespace)
+			assert.Equal(t, "dev", event.EnvironmentName)
+			assert.EqualValues(t, 2, event.RiserRevision)
+		},
+	}
+
+	err := PutDeploymentStatus(ctx, &deploymentRepository, webhookService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, webhookService.PublishCallCount)
 }
 
 func Test_PutDeploymentStatus_Returns401IfConflict(t *testing.T) {
@@ -101,12 +286,15 @@ func Test_PutDeploymentStatus_Returns401IfConflict(t *testing.T) {
 	ctx, _ := newContextWithRecorder(req)
 
 	deploymentRepository := core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
 		UpdateStatusFn: func(name *core.NamespacedName, envName string, status *core.DeploymentStatus) error {
 			return core.ErrConflictNewerVersion
 		},
 	}
 
-	err := PutDeploymentStatus(ctx, &deploymentRepository)
+	err := PutDeploymentStatus(ctx, &deploymentRepository, &webhook.FakeService{})
 
 	require.IsType(t, &echo.HTTPError{}, err)
 	httpErr := err.(*echo.HTTPError)
@@ -124,12 +312,15 @@ func Test_PutDeploymentStatus_ReturnsErr(t *testing.T) {
 	ctx, _ := newContextWithRecorder(req)
 
 	deploymentRepository := core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
 		UpdateStatusFn: func(name *core.NamespacedName, envName string, status *core.DeploymentStatus) error {
 			return errors.New("failed")
 		},
 	}
 
-	err := PutDeploymentStatus(ctx, &deploymentRepository)
+	err := PutDeploymentStatus(ctx, &deploymentRepository, &webhook.FakeService{})
 
 	assert.Error(t, err)
 }
@@ -147,6 +338,10 @@ func Test_mapDryRunCommitsFromDomain(t *testing.T) {
 					Name:     "file2",
 					Contents: []byte("contents2"),
 				},
+				{
+					Name:   "file3",
+					Delete: true,
+				},
 			},
 		},
 		{
@@ -158,11 +353,14 @@ func Test_mapDryRunCommitsFromDomain(t *testing.T) {
 
 	assert.Len(t, result, 2)
 	assert.Equal(t, "commit1", result[0].Message)
-	assert.Len(t, result[0].Files, 2)
+	assert.Len(t, result[0].Files, 3)
 	assert.Equal(t, "file1", result[0].Files[0].Name)
 	assert.Equal(t, "contents1", result[0].Files[0].Contents)
 	assert.Equal(t, "file2", result[0].Files[1].Name)
 	assert.Equal(t, "contents2", result[0].Files[1].Contents)
+	assert.False(t, result[0].Files[1].Delete)
+	assert.Equal(t, "file3", result[0].Files[2].Name)
+	assert.True(t, result[0].Files[2].Delete)
 	assert.Equal(t, result[1].Message, "commit2")
 	assert.Empty(t, result[1].Files)
 }
@@ -185,7 +383,7 @@ func Test_mapDeploymentRequestToDomain(t *testing.T) {
 		},
 	}
 
-	result, err := mapDeploymentRequestToDomain(request)
+	result, err := mapDeploymentRequestToDomain(request, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "mydeployment", result.Name)
@@ -217,9 +415,73 @@ func Test_mapDeploymentRequestToDomain_Overrides(t *testing.T) {
 		},
 	}
 
-	result, err := mapDeploymentRequestToDomain(request)
+	result, err := mapDeploymentRequestToDomain(request, nil)
 
 	assert.NoError(t, err)
 	assert.Equal(t, 1, *result.App.Autoscale.Min)
 
 }
+
+func Test_mapDeploymentRequestToDomain_AppDefaults(t *testing.T) {
+	request := &model.SaveDeploymentRequest{
+		DeploymentMeta: model.DeploymentMeta{
+			Name:        "mydeployment",
+			Environment: "myenv",
+		},
+		App: &model.AppConfigWithOverrides{
+			AppConfig: model.AppConfig{
+				Name:      "myapp",
+				Namespace: "myns",
+				Id:        uuid.New(),
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Image:  "myimage",
+					Expose: &model.AppConfigExpose{ContainerPort: 8000},
+				},
+			},
+		},
+	}
+	appDefaults := &model.OverrideableAppConfig{
+		Autoscale: &model.AppConfigAutoscale{
+			Min: util.PtrInt(1),
+		},
+	}
+
+	result, err := mapDeploymentRequestToDomain(request, appDefaults)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, *result.App.Autoscale.Min)
+}
+
+func Test_mapDeploymentRequestToDomain_AppDefaultsInvalid(t *testing.T) {
+	request := &model.SaveDeploymentRequest{
+		DeploymentMeta: model.DeploymentMeta{
+			Name:        "mydeployment",
+			Environment: "myenv",
+		},
+		App: &model.AppConfigWithOverrides{
+			AppConfig: model.AppConfig{
+				Name:      "myapp",
+				Namespace: "myns",
+				Id:        uuid.New(),
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Image:  "myimage",
+					Expose: &model.AppConfigExpose{ContainerPort: 8000},
+					Autoscale: &model.AppConfigAutoscale{
+						Max: util.PtrInt(1),
+					},
+				},
+			},
+		},
+	}
+	appDefaults := &model.OverrideableAppConfig{
+		Autoscale: &model.AppConfigAutoscale{
+			Min: util.PtrInt(2),
+		},
+	}
+
+	result, err := mapDeploymentRequestToDomain(request, appDefaults)
+
+	assert.Nil(t, result)
+	require.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The app config is not valid with the app defaults of environment "myenv"`, err.(*core.ValidationError).Message)
+}
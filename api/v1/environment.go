@@ -89,15 +89,104 @@ func mapEnvironmentMetaArrayFromDomain(domainArray []core.Environment) []model.E
 }
 
 func mapEnvironmentConfigToDomain(in *model.EnvironmentConfig) *core.EnvironmentConfig {
-	return &core.EnvironmentConfig{
-		SealedSecretCert:  in.SealedSecretCert,
-		PublicGatewayHost: in.PublicGatewayHost,
+	out := &core.EnvironmentConfig{
+		SealedSecretCert:                 in.SealedSecretCert,
+		PublicGatewayHost:                in.PublicGatewayHost,
+		TLSClusterIssuer:                 in.TLSClusterIssuer,
+		AllowedServiceAccountAnnotations: in.AllowedServiceAccountAnnotations,
+		AppDefaults:                      in.AppDefaults,
 	}
+	for _, namespace := range in.DefaultDenyNamespaces {
+		out.DefaultDenyNamespaces = append(out.DefaultDenyNamespaces, string(namespace))
+	}
+	if in.Resources != nil {
+		out.Resources = core.EnvironmentResources{
+			DefaultRequests: mapResourceQuantitiesToDomain(in.Resources.DefaultRequests),
+			DefaultLimits:   mapResourceQuantitiesToDomain(in.Resources.DefaultLimits),
+			Max:             mapResourceQuantitiesToDomain(in.Resources.Max),
+		}
+	}
+	if in.Security != nil {
+		out.Security = core.EnvironmentSecurity{
+			RunAsNonRoot:           in.Security.RunAsNonRoot,
+			ReadOnlyRootFilesystem: in.Security.ReadOnlyRootFilesystem,
+			DropCapabilities:       in.Security.DropCapabilities,
+			SeccompProfile:         in.Security.SeccompProfile,
+			AllowedOptOuts:         in.Security.AllowedOptOuts,
+		}
+	}
+	for _, policy := range in.Policies {
+		out.Policies = append(out.Policies, core.EnvironmentPolicy{
+			Name:   policy.Name,
+			Type:   policy.Type,
+			Field:  policy.Field,
+			Min:    policy.Min,
+			Max:    policy.Max,
+			Values: policy.Values,
+		})
+	}
+	if in.Requests != nil {
+		out.Requests = core.EnvironmentRequests{
+			MaxContainerConcurrency: in.Requests.MaxContainerConcurrency,
+			MaxTimeoutSeconds:       in.Requests.MaxTimeoutSeconds,
+		}
+	}
+	return out
 }
 
 func mapEnvironmentConfigFromDomain(in *core.EnvironmentConfig) *model.EnvironmentConfig {
-	return &model.EnvironmentConfig{
-		SealedSecretCert:  in.SealedSecretCert,
-		PublicGatewayHost: in.PublicGatewayHost,
+	out := &model.EnvironmentConfig{
+		SealedSecretCert:                 in.SealedSecretCert,
+		PublicGatewayHost:                in.PublicGatewayHost,
+		TLSClusterIssuer:                 in.TLSClusterIssuer,
+		AllowedServiceAccountAnnotations: in.AllowedServiceAccountAnnotations,
+		AppDefaults:                      in.AppDefaults,
+		Resources: &model.EnvironmentResources{
+			DefaultRequests: mapResourceQuantitiesFromDomain(in.Resources.DefaultRequests),
+			DefaultLimits:   mapResourceQuantitiesFromDomain(in.Resources.DefaultLimits),
+			Max:             mapResourceQuantitiesFromDomain(in.Resources.Max),
+		},
+		Requests: &model.EnvironmentRequests{
+			MaxContainerConcurrency: in.Requests.MaxContainerConcurrency,
+			MaxTimeoutSeconds:       in.Requests.MaxTimeoutSeconds,
+		},
+		Security: &model.EnvironmentSecurity{
+			RunAsNonRoot:           in.Security.RunAsNonRoot,
+			ReadOnlyRootFilesystem: in.Security.ReadOnlyRootFilesystem,
+			DropCapabilities:       in.Security.DropCapabilities,
+			SeccompProfile:         in.Security.SeccompProfile,
+			AllowedOptOuts:         in.Security.AllowedOptOuts,
+		},
+	}
+	for _, namespace := range in.DefaultDenyNamespaces {
+		out.DefaultDenyNamespaces = append(out.DefaultDenyNamespaces, model.NamespaceName(namespace))
+	}
+	for _, policy := range in.Policies {
+		out.Policies = append(out.Policies, model.EnvironmentPolicy{
+			Name:   policy.Name,
+			Type:   policy.Type,
+			Field:  policy.Field,
+			Min:    policy.Min,
+			Max:    policy.Max,
+			Values: policy.Values,
+		})
+	}
+	return out
+}
+
+func mapResourceQuantitiesToDomain(in *model.ResourceQuantities) core.ResourceQuantities {
+	if in == nil {
+		return core.ResourceQuantities{}
+	}
+	return core.ResourceQuantities{
+		CpuCores: in.CpuCores,
+		MemoryMB: in.MemoryMB,
+	}
+}
+
+func mapResourceQuantitiesFromDomain(in core.ResourceQuantities) *model.ResourceQuantities {
+	return &model.ResourceQuantities{
+		CpuCores: in.CpuCores,
+		MemoryMB: in.MemoryMB,
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/riser-platform/riser-server/api/v1/model"
 
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -34,26 +35,85 @@ func Test_mapEnvironmentMetaArrayFromDomain(t *testing.T) {
 
 func Test_mapEnvironmentConfigToDomain(t *testing.T) {
 	config := &model.EnvironmentConfig{
-		SealedSecretCert:  []byte{0x1},
-		PublicGatewayHost: "myhost",
+		SealedSecretCert:                 []byte{0x1},
+		PublicGatewayHost:                "myhost",
+		TLSClusterIssuer:                 "letsencrypt",
+		DefaultDenyNamespaces:            []model.NamespaceName{"myns"},
+		AllowedServiceAccountAnnotations: []string{"iam.gke.io/gcp-service-account"},
+		Resources: &model.EnvironmentResources{
+			DefaultRequests: &model.ResourceQuantities{CpuCores: util.PtrFloat32(0.5)},
+			Max:             &model.ResourceQuantities{MemoryMB: util.PtrInt32(1024)},
+		},
+		Requests: &model.EnvironmentRequests{MaxTimeoutSeconds: util.PtrInt64(900)},
+		Security: &model.EnvironmentSecurity{
+			RunAsNonRoot:     true,
+			DropCapabilities: []string{"ALL"},
+			AllowedOptOuts:   []string{model.SecuritySetting_RunAsNonRoot},
+		},
+		AppDefaults: &model.OverrideableAppConfig{Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(0)}},
+		Policies: []model.EnvironmentPolicy{
+			{Name: "no-latest", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "docker.tag", Values: []string{"latest"}},
+		},
 	}
 
 	result := mapEnvironmentConfigToDomain(config)
 
 	assert.Equal(t, []byte{0x1}, result.SealedSecretCert)
 	assert.Equal(t, "myhost", result.PublicGatewayHost)
+	assert.Equal(t, "letsencrypt", result.TLSClusterIssuer)
+	assert.Equal(t, []string{"myns"}, result.DefaultDenyNamespaces)
+	assert.Equal(t, []string{"iam.gke.io/gcp-service-account"}, result.AllowedServiceAccountAnnotations)
+	assert.EqualValues(t, 0.5, *result.Resources.DefaultRequests.CpuCores)
+	assert.Nil(t, result.Resources.DefaultRequests.MemoryMB)
+	assert.Empty(t, result.Resources.DefaultLimits)
+	assert.EqualValues(t, 1024, *result.Resources.Max.MemoryMB)
+	assert.EqualValues(t, 900, *result.Requests.MaxTimeoutSeconds)
+	assert.Nil(t, result.Requests.MaxContainerConcurrency)
+	assert.Equal(t, core.EnvironmentSecurity{
+		RunAsNonRoot:     true,
+		DropCapabilities: []string{"ALL"},
+		AllowedOptOuts:   []string{model.SecuritySetting_RunAsNonRoot},
+	}, result.Security)
+	assert.Equal(t, config.AppDefaults, result.AppDefaults)
+	assert.Equal(t, core.EnvironmentPolicies{
+		{Name: "no-latest", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "docker.tag", Values: []string{"latest"}},
+	}, result.Policies)
 }
 
 func Test_mapEnvironmentConfigFromDomain(t *testing.T) {
 	domain := &core.EnvironmentConfig{
-		SealedSecretCert:  []byte{0x1},
-		PublicGatewayHost: "myhost",
+		SealedSecretCert:                 []byte{0x1},
+		PublicGatewayHost:                "myhost",
+		TLSClusterIssuer:                 "letsencrypt",
+		DefaultDenyNamespaces:            []string{"myns"},
+		AllowedServiceAccountAnnotations: []string{"eks.amazonaws.com/role-arn"},
+		Resources: core.EnvironmentResources{
+			DefaultLimits: core.ResourceQuantities{CpuCores: util.PtrFloat32(1)},
+		},
+		Requests:    core.EnvironmentRequests{MaxContainerConcurrency: util.PtrInt64(100)},
+		Security:    core.EnvironmentSecurity{ReadOnlyRootFilesystem: true, SeccompProfile: "RuntimeDefault"},
+		AppDefaults: &model.OverrideableAppConfig{Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(2)}},
+		Policies: core.EnvironmentPolicies{
+			{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: util.PtrFloat64(2)},
+		},
 	}
 
 	result := mapEnvironmentConfigFromDomain(domain)
 
 	assert.Equal(t, []byte{0x1}, result.SealedSecretCert)
 	assert.Equal(t, "myhost", result.PublicGatewayHost)
+	assert.Equal(t, "letsencrypt", result.TLSClusterIssuer)
+	assert.Equal(t, []model.NamespaceName{"myns"}, result.DefaultDenyNamespaces)
+	assert.Equal(t, []string{"eks.amazonaws.com/role-arn"}, result.AllowedServiceAccountAnnotations)
+	assert.EqualValues(t, 1, *result.Resources.DefaultLimits.CpuCores)
+	assert.Nil(t, result.Resources.Max.CpuCores)
+	assert.EqualValues(t, 100, *result.Requests.MaxContainerConcurrency)
+	assert.Nil(t, result.Requests.MaxTimeoutSeconds)
+	assert.Equal(t, &model.EnvironmentSecurity{ReadOnlyRootFilesystem: true, SeccompProfile: "RuntimeDefault"}, result.Security)
+	assert.Equal(t, domain.AppDefaults, result.AppDefaults)
+	assert.Equal(t, []model.EnvironmentPolicy{
+		{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: util.PtrFloat64(2)},
+	}, result.Policies)
 }
 
 func Test_validateEnvironmentName_Error(t *testing.T) {
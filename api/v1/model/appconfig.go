@@ -2,7 +2,13 @@ package model
 
 import (
 	"fmt"
+	"path"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/docker/distribution/reference"
 	validation "github.com/go-ozzo/ozzo-validation/v3"
@@ -10,129 +16,658 @@ import (
 	"github.com/imdario/mergo"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
+	// Files are stored in a ConfigMap which is limited to 1MiB
+	maxFilesSizeBytes = 1024 * 1024
+
+	AppWorkload_Knative    = "knative"
+	AppWorkload_Deployment = "deployment"
+
+	AppExposeProtocol_HTTP  = "http"
+	AppExposeProtocol_HTTP2 = "http2"
+	AppExposeProtocol_GRPC  = "grpc"
+	AppExposeProtocol_TCP   = "tcp"
+
+	// Port names are limited to 15 characters by Kubernetes
+	appPortNameMaxLength = 15
+	// The name of the primary container port when it uses http2 or grpc. See createPodPorts.
+	appPortNameH2C = "h2c"
+
 	AppExposeScope_External = "external"
 	AppExposeScope_Cluster  = "cluster"
+
+	AppAutoscaleMetric_Concurrency = "concurrency"
+	AppAutoscaleMetric_RPS         = "rps"
+	AppAutoscaleMetric_CPU         = "cpu"
+
+	AppHealthCheckMode_HTTP = "http"
+	AppHealthCheckMode_TCP  = "tcp"
+	AppHealthCheckMode_GRPC = "grpc"
+	AppHealthCheckMode_Exec = "exec"
+
+	AppJobConcurrencyPolicy_Allow   = "allow"
+	AppJobConcurrencyPolicy_Forbid  = "forbid"
+	AppJobConcurrencyPolicy_Replace = "replace"
+
+	// Security settings that an environment may enforce and an app may opt out of
+	SecuritySetting_RunAsNonRoot           = "runAsNonRoot"
+	SecuritySetting_ReadOnlyRootFilesystem = "readOnlyRootFilesystem"
+	SecuritySetting_DropCapabilities       = "dropCapabilities"
+	SecuritySetting_SeccompProfile         = "seccompProfile"
+
+	// The layers that are merged to create an app's config for an environment. See AppConfigWithOverrides.AppConfigLayers.
+	AppConfigLayer_StaticDefaults       = "staticDefaults"
+	AppConfigLayer_EnvironmentDefaults  = "environmentDefaults"
+	AppConfigLayer_App                  = "app"
+	AppConfigLayer_EnvironmentOverrides = "environmentOverrides"
+
+	// AllowFromNamespacePrefix prefixes an expose.allowFrom entry that allows all apps in a namespace
+	AllowFromNamespacePrefix = "ns:"
+
+	// A cron field is a list of values or ranges (e.g. "1-5" or "mon") or "*", each with an optional step (e.g. "*/15")
+	cronFieldExpr = `(\*|\?|[0-9A-Za-z]+(-[0-9A-Za-z]+)?)(/[0-9]+)?(,[0-9A-Za-z]+(-[0-9A-Za-z]+)?(/[0-9]+)?)*`
+)
+
+// The allowed values for enum fields. These are shared with the app config schema.
+var (
+	appWorkloads              = []string{AppWorkload_Knative, AppWorkload_Deployment}
+	appExposeProtocols        = []string{AppExposeProtocol_HTTP, AppExposeProtocol_HTTP2, AppExposeProtocol_GRPC}
+	appPortProtocols          = []string{AppExposeProtocol_HTTP, AppExposeProtocol_HTTP2, AppExposeProtocol_GRPC, AppExposeProtocol_TCP}
+	appExposeScopes           = []string{AppExposeScope_External, AppExposeScope_Cluster}
+	appAutoscaleMetrics       = []string{AppAutoscaleMetric_Concurrency, AppAutoscaleMetric_RPS, AppAutoscaleMetric_CPU}
+	appHealthCheckModes       = []string{AppHealthCheckMode_HTTP, AppHealthCheckMode_TCP, AppHealthCheckMode_GRPC, AppHealthCheckMode_Exec}
+	appJobConcurrencyPolicies = []string{AppJobConcurrencyPolicy_Allow, AppJobConcurrencyPolicy_Forbid, AppJobConcurrencyPolicy_Replace}
+	securitySettings          = []string{SecuritySetting_RunAsNonRoot, SecuritySetting_ReadOnlyRootFilesystem, SecuritySetting_DropCapabilities, SecuritySetting_SeccompProfile}
 )
 
+// The ports used by the KNative queue-proxy which shares the network namespace of the app's container
+var knativeReservedPorts = []int32{8012, 8013, 8022, 9090, 9091}
+
 var (
 	// Put all static app config defaults here
 	appConfigDefaults = &AppConfig{
-		Namespace: "apps",
-		Expose: &AppConfigExpose{
-			Protocol: "http",
-			Scope:    AppExposeScope_External,
-		},
+		ApiVersion: AppConfigApiVersion,
+		Namespace:  "apps",
+		Workload:   AppWorkload_Knative,
+	}
+	// Only applied when expose is set since apps without expose are workers
+	appConfigExposeDefaults = &AppConfigExpose{
+		Protocol: AppExposeProtocol_HTTP,
+		Scope:    AppExposeScope_External,
 	}
 
 	envVarKeyPattern      = regexp.MustCompile("^[A-Z][A-Z0-9_]*$")
 	envVarKeyRiserPattern = regexp.MustCompile("^RISER_")
+	filePathPattern       = regexp.MustCompile("^(/[-._a-zA-Z0-9]+)+$")
+	secretModePattern     = regexp.MustCompile("^0?[0-7]{3}$")
+	// A cron schedule with five fields (e.g. "*/15 * * * *") or a predefined schedule (e.g. "@hourly")
+	cronSchedulePattern = regexp.MustCompile(fmt.Sprintf(`^(@(yearly|annually|monthly|weekly|daily|midnight|hourly)|%[1]s(\s+%[1]s){4})$`, cronFieldExpr))
+	// A subset of RFC 1123 that requires at least two labels
+	domainPattern = regexp.MustCompile(`^([a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?\.)+[a-z]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	// These paths are reserved by KNative
+	reservedFilePaths = map[string]struct{}{"/dev": {}, "/dev/log": {}, "/tmp": {}, "/var": {}, "/var/log": {}}
 )
 
-// TODO: Move outside the API and into a separate module. The AppConfig should version independently of the API via a Version field on the root AppConfig object
+// TODO: Move outside the API and into a separate module. The AppConfig versions independently of the API via the apiVersion field.
 // Also, pkg/* should not have a dependency here. However, moving this into pkg/core (for example) would cause a circular module dependency so we
 // may need to create a separate module e.g. pkg/core/appconfig
 
 // AppConfigWithOverrides contains an app with environment level overrides
 type AppConfigWithOverrides struct {
 	AppConfig `json:",inline"`
+	// Overrides are keyed by environment name. Only the fields that an override sets are changed.
 	Overrides map[string]OverrideableAppConfig `json:"environmentOverrides,omitempty"`
+	// unconverted is the raw form of an unmarshalled app config with a deprecated version. See Convert.
+	unconverted map[string]interface{}
+	// convertedFrom is the deprecated version that the app config was converted from by Convert
+	convertedFrom string
+}
+
+// AppConfigLayer is a source of app config values for an environment
+type AppConfigLayer struct {
+	Name string `json:"name"`
+	// Config contains the values from the layer. It's omitted for the static defaults since they're only known once applied.
+	Config *OverrideableAppConfig `json:"config,omitempty"`
+}
+
+// AppConfigValidationResult is returned when an app config is valid
+type AppConfigValidationResult struct {
+	// Warnings are potential problems that do not prevent the app from being deployed
+	Warnings []string `json:"warnings,omitempty"`
 }
 
+// ApplyOverrides returns the app config with the environment's overrides applied. Each overrideable field that is set for the environment
+// replaces the app's value, with the exception of maps which are merged by key and structs which are merged field by field. A health check
+// that changes the mode replaces the app's health check since each mode uses different fields.
 func (cfg *AppConfigWithOverrides) ApplyOverrides(envName string) (*AppConfig, error) {
+	return cfg.ApplyEnvironment(envName, nil)
+}
+
+// ApplyEnvironment returns the app config for an environment. The layers from AppConfigLayers are merged in order using the same rules as
+// ApplyOverrides. The static defaults are applied by ApplyDefaults before this is called. This does not change the order since the
+// static defaults only fill in fields that environment app defaults may not set. The expose defaults are applied again after merging
+// since a worker may be exposed by the environment's layers.
+func (cfg *AppConfigWithOverrides) ApplyEnvironment(envName string, envAppDefaults *OverrideableAppConfig) (*AppConfig, error) {
 	app := cfg.AppConfig
+	if envAppDefaults != nil {
+		merged := OverrideableAppConfig{}
+		applyOverrides(&merged, envAppDefaults, false)
+		// The app's health checks stand alone, so one without a mode is http and replaces a default health check of another mode
+		applyOverrides(&merged, &app.OverrideableAppConfig, true)
+		app.OverrideableAppConfig = merged
+	}
 	if overrideApp, ok := cfg.Overrides[envName]; ok {
-		err := mergo.Merge(&app.OverrideableAppConfig, overrideApp, mergo.WithOverride, mergo.WithOverwriteWithEmptyValue)
-		if err != nil {
-			return nil, err
-		}
+		applyOverrides(&app.OverrideableAppConfig, &overrideApp, false)
 	}
 
+	if err := app.applyExposeDefaults(); err != nil {
+		return nil, err
+	}
 	return &app, nil
 }
 
+// AppConfigLayers returns the layers that ApplyEnvironment merges in the order that they are applied. Later layers take precedence.
+func (cfg *AppConfigWithOverrides) AppConfigLayers(envName string, envAppDefaults *OverrideableAppConfig) []AppConfigLayer {
+	layers := []AppConfigLayer{{Name: AppConfigLayer_StaticDefaults}}
+	if envAppDefaults != nil {
+		layers = append(layers, AppConfigLayer{Name: AppConfigLayer_EnvironmentDefaults, Config: envAppDefaults})
+	}
+	layers = append(layers, AppConfigLayer{Name: AppConfigLayer_App, Config: &cfg.OverrideableAppConfig})
+	if overrideApp, ok := cfg.Overrides[envName]; ok {
+		layers = append(layers, AppConfigLayer{Name: AppConfigLayer_EnvironmentOverrides, Config: &overrideApp})
+	}
+	return layers
+}
+
+// applyOverrides uses reflection so that new overrideable fields do not have to be added here. It replaced a mergo merge that cleared every
+// field that an override did not set (other than maps) and replaced nested structs such as resources.requests. applyOverrides only changes
+// the fields that an override sets, merges maps by key and structs field by field, and copies maps and structs so that the original config
+// is never mutated. When emptyModeIsHTTP is false, an override's health check without a mode only changes the fields that it sets.
+func applyOverrides(base *OverrideableAppConfig, override *OverrideableAppConfig, emptyModeIsHTTP bool) {
+	replaceHealthCheck := healthCheckModeChanged(base.HealthCheck, override.HealthCheck, emptyModeIsHTTP)
+	replaceLiveness := healthCheckModeChanged(base.Liveness, override.Liveness, emptyModeIsHTTP)
+
+	mergeStructFields(reflect.ValueOf(base).Elem(), reflect.ValueOf(override).Elem())
+
+	if replaceHealthCheck {
+		healthCheck := *override.HealthCheck
+		base.HealthCheck = &healthCheck
+	}
+	if replaceLiveness {
+		liveness := *override.Liveness
+		base.Liveness = &liveness
+	}
+}
+
+func healthCheckModeChanged(base *AppConfigHealthCheck, override *AppConfigHealthCheck, emptyModeIsHTTP bool) bool {
+	if base == nil || override == nil || (override.Mode == "" && !emptyModeIsHTTP) {
+		return false
+	}
+	if override.IsHTTP() {
+		return !base.IsHTTP()
+	}
+	return override.Mode != base.Mode
+}
+
+func mergeStructFields(baseValue reflect.Value, overrideValue reflect.Value) {
+	for i := 0; i < baseValue.NumField(); i++ {
+		baseField := baseValue.Field(i)
+		overrideField := overrideValue.Field(i)
+		if overrideField.IsZero() {
+			continue
+		}
+
+		switch {
+		case overrideField.Kind() == reflect.Map && !baseField.IsNil():
+			merged := reflect.MakeMapWithSize(baseField.Type(), baseField.Len()+overrideField.Len())
+			for _, mapValue := range []reflect.Value{baseField, overrideField} {
+				iter := mapValue.MapRange()
+				for iter.Next() {
+					merged.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+			baseField.Set(merged)
+		case overrideField.Kind() == reflect.Ptr && overrideField.Elem().Kind() == reflect.Struct && !baseField.IsNil():
+			merged := reflect.New(baseField.Elem().Type())
+			merged.Elem().Set(baseField.Elem())
+			mergeStructFields(merged.Elem(), overrideField.Elem())
+			baseField.Set(merged)
+		default:
+			baseField.Set(overrideField)
+		}
+	}
+}
+
+// Validate validates the app config and each environment's config with its overrides applied
+func (cfg AppConfigWithOverrides) Validate() error {
+	validationErrors := cfg.AppConfig.Validate()
+	baseErrors, _ := validationErrors.(validation.Errors)
+	for envName := range cfg.Overrides {
+		merged, err := cfg.ApplyOverrides(envName)
+		if err != nil {
+			return err
+		}
+
+		mergedErrors, isValidationErrors := merged.Validate().(validation.Errors)
+		if !isValidationErrors {
+			continue
+		}
+
+		// Only report errors against the environment if they are caused by the environment's overrides
+		envErrors := validation.Errors{}
+		for fieldName, fieldErr := range mergedErrors {
+			if baseErr, ok := baseErrors[fieldName]; ok && baseErr.Error() == fieldErr.Error() {
+				continue
+			}
+			envErrors[fieldName] = fieldErr
+		}
+		if len(envErrors) > 0 {
+			validationErrors = mergeValidationErrors(validationErrors, envErrors, fmt.Sprintf("environmentOverrides.%s", envName))
+		}
+	}
+	return validationErrors
+}
+
 // AppConfig is the root of the application config object graph without environment overrides
 type AppConfig struct {
-	Id                    uuid.UUID             `json:"id"`
-	Name                  AppName               `json:"name"`
-	Namespace             NamespaceName         `json:"namespace"`
-	Expose                *AppConfigExpose      `json:"expose,omitempty"`
-	HealthCheck           *AppConfigHealthCheck `json:"healthcheck,omitempty"`
-	Image                 string                `json:"image"`
+	// ApiVersion is the version of the app config. See AppConfigApiVersion.
+	ApiVersion string        `json:"apiVersion,omitempty"`
+	Id         uuid.UUID     `json:"id"`
+	Name       AppName       `json:"name"`
+	Namespace  NamespaceName `json:"namespace"`
+	// Workload is one of knative (default) or deployment. The deployment workload renders a Kubernetes Deployment which never scales to
+	// zero and keeps a stable set of pods. It does not support traffic rollouts or exposing the app outside of the cluster. Workers
+	// (apps without expose) default to and require the deployment workload.
+	Workload              string `json:"workload,omitempty"`
 	OverrideableAppConfig `json:",inline"`
 }
 
 // OverrideableAppConfig contains properties that are overrideable
 type OverrideableAppConfig struct {
-	Autoscale   *AppConfigAutoscale           `json:"autoscale,omitempty"`
+	Image       string                `json:"image,omitempty"`
+	HealthCheck *AppConfigHealthCheck `json:"healthcheck,omitempty"`
+	// Liveness is an optional probe that restarts the container when it fails. The HealthCheck is only used for readiness.
+	Liveness  *AppConfigHealthCheck `json:"liveness,omitempty"`
+	Autoscale *AppConfigAutoscale   `json:"autoscale,omitempty"`
+	// Command overrides the image's entrypoint
+	Command []string `json:"command,omitempty"`
+	// Args overrides the image's cmd
+	Args        []string                      `json:"args,omitempty"`
+	WorkingDir  string                        `json:"workingDir,omitempty"`
 	Environment map[string]intstr.IntOrString `json:"env,omitempty"`
-	Resources   *AppConfigResources           `json:"resources,omitempty"`
+	// Expose is not set for workers (e.g. queue consumers) which do not receive requests
+	Expose *AppConfigExpose `json:"expose,omitempty"`
+	// Files maps an absolute file path in the container to its content
+	Files     map[string]string   `json:"files,omitempty"`
+	Resources *AppConfigResources `json:"resources,omitempty"`
+	// Secrets contains options for each secret keyed by the secret name. All secrets are available as env vars whether or not they are
+	// configured here.
+	Secrets map[string]AppConfigSecret `json:"secrets,omitempty"`
+	// Jobs run the app's image on a schedule keyed by the job name
+	Jobs map[string]AppConfigJob `json:"jobs,omitempty"`
+	// Security contains opt-outs from the security settings enforced by the environment
+	Security *AppConfigSecurity `json:"security,omitempty"`
+	// ServiceAccount configures the Kubernetes service account that the app runs under. The service account is named "riser-<app name>" and
+	// shared by all of the app's deployments in an environment, so each deployment must have the same annotations. Only the deployment
+	// named after the app may change them. Since apps do not run under the namespace's default service account, image pull secrets that
+	// are attached to it do not apply.
+	ServiceAccount *AppConfigServiceAccount `json:"serviceAccount,omitempty"`
+}
+
+// AppConfigServiceAccount configures the app's Kubernetes service account
+type AppConfigServiceAccount struct {
+	// Annotations are added to the service account (e.g. for GKE Workload Identity or IRSA). Each environment decides which annotation
+	// keys are allowed.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AppConfigSecurity contains opt-outs from the security settings enforced by the environment
+type AppConfigSecurity struct {
+	// OptOut are the environment security settings (e.g. readOnlyRootFilesystem) that are not applied to the app. Each environment
+	// decides which settings may be opted out of.
+	OptOut []string `json:"optOut,omitempty"`
+}
+
+// OptsOutOf returns true if the app opts out of the environment security setting
+func (cfg *AppConfig) OptsOutOf(securitySetting string) bool {
+	if cfg.Security == nil {
+		return false
+	}
+	for _, optOut := range cfg.Security.OptOut {
+		if optOut == securitySetting {
+			return true
+		}
+	}
+	return false
+}
+
+// AppConfigJob runs the app's image on a schedule. A job uses the app's env vars, secrets, files and resources.
+type AppConfigJob struct {
+	// Schedule is a cron schedule (e.g. "0 3 * * *")
+	Schedule string `json:"schedule,omitempty"`
+	// Command overrides the image's entrypoint. The app's args are not used. The command is run with /bin/sh, which then stops the Istio
+	// sidecar using curl or wget so that the job can complete. The image must include /bin/sh and either curl or wget.
+	Command []string `json:"command,omitempty"`
+	// ConcurrencyPolicy is one of allow (default), forbid, or replace. It determines what happens when a job is scheduled while the
+	// previous run is still active.
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+	// HistoryLimit is the number of successful and failed runs to keep
+	HistoryLimit *int32 `json:"historyLimit,omitempty"`
+}
+
+// AppConfigSecret contains options for a secret
+type AppConfigSecret struct {
+	// Path is an optional absolute file path in the container that the secret is mounted to
+	Path string `json:"path,omitempty"`
+	// Mode is the octal file mode of the mounted secret (e.g. "0400"). Defaults to "0644".
+	Mode string `json:"mode,omitempty"`
+}
+
+// FileMode returns the parsed Mode or nil if the mode is not set or is not valid
+func (secret AppConfigSecret) FileMode() *int32 {
+	if secret.Mode == "" {
+		return nil
+	}
+	mode, err := strconv.ParseInt(secret.Mode, 8, 32)
+	if err != nil {
+		return nil
+	}
+	fileMode := int32(mode)
+	return &fileMode
 }
 
 type AppConfigAutoscale struct {
 	Min *int `json:"min,omitempty"`
 	Max *int `json:"max,omitempty"`
+	// Metric is one of concurrency (default), rps, or cpu. The cpu metric uses the Kubernetes HPA which is unable to scale to zero.
+	Metric string `json:"metric,omitempty"`
+	// Target is the value of the metric to target for each replica (e.g. concurrent requests, requests per second, or cpu percent)
+	Target *float64 `json:"target,omitempty"`
+	// TargetUtilizationPercentage is the percentage of the target at which the autoscaler scales up
+	TargetUtilizationPercentage *float64 `json:"targetUtilizationPercentage,omitempty"`
+	// ScaleDownDelay is a duration (e.g. "15m") that a replica must be unneeded before it's removed
+	ScaleDownDelay string `json:"scaleDownDelay,omitempty"`
+	// StableWindow is a duration (e.g. "60s") that metrics are averaged over
+	StableWindow string `json:"stableWindow,omitempty"`
+	// PanicWindowPercentage is the percentage of the stable window that metrics are averaged over when in panic mode
+	PanicWindowPercentage *float64 `json:"panicWindowPercentage,omitempty"`
+	InitialScale          *int     `json:"initialScale,omitempty"`
 }
 
 type AppConfigExpose struct {
-	ContainerPort int32  `json:"containerPort"`
-	Protocol      string `json:"protocol,omitempty"`
-	Scope         string `json:"scope,omitempty"`
+	// ContainerPort is the primary port that serves the app's requests. It's the only port that is exposed by the app's route.
+	ContainerPort int32 `json:"containerPort,omitempty"`
+	// Protocol is one of http (default), http2, or grpc
+	Protocol string `json:"protocol,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// Domains are custom domains that are mapped to the app in addition to the environment's default domain
+	Domains []string `json:"domains,omitempty"`
+	// AllowFrom restricts which apps may call this app. Each entry is either an app (e.g. "checkout.apps" or "checkout" for an app
+	// in the same namespace) or all apps in a namespace (e.g. "ns:billing"). All apps are allowed when empty. Apps are identified by
+	// their service account, so only requests sent through the mesh are allowed and not those routed through a gateway.
+	AllowFrom []string `json:"allowFrom,omitempty"`
+	// ContainerConcurrency is the max number of requests that each replica handles at once. Zero allows unlimited concurrent requests.
+	// The cluster default is used when not set. Only supported by the knative workload.
+	ContainerConcurrency *int64 `json:"containerConcurrency,omitempty"`
+	// TimeoutSeconds is the max duration of a request including streamed responses. The cluster default is used when not set.
+	// Only supported by the knative workload.
+	// TODO: Add a response start timeout once knative.dev/serving is upgraded past v0.27, which does not have the revision's
+	// responseStartTimeoutSeconds field. The cluster's Knative (see config/infra/knative) must also be upgraded to a release that supports it.
+	// Test_CreateKNativeConfiguration_ResponseStartTimeoutNotSupported fails once the field is available.
+	TimeoutSeconds *int64 `json:"timeoutSeconds,omitempty"`
+	// Ports are additional ports keyed by the port name (e.g. for metrics or admin) that are only reachable inside the cluster
+	Ports map[string]AppConfigPort `json:"ports,omitempty"`
+}
+
+// AppConfigPort is an additional port that is only reachable inside the cluster
+type AppConfigPort struct {
+	ContainerPort int32 `json:"containerPort,omitempty"`
+	// Protocol is one of http (default), http2, grpc, or tcp
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// PortNames returns the names of the additional ports in sorted order
+func (cfg *AppConfigExpose) PortNames() []string {
+	names := make([]string, 0, len(cfg.Ports))
+	for name := range cfg.Ports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AllowFromSource is a parsed expose.allowFrom entry. App is empty when all apps in the namespace are allowed.
+type AllowFromSource struct {
+	App       string
+	Namespace string
+}
+
+// AllowFromSources parses expose.allowFrom. Apps without a namespace are assumed to be in the app's namespace.
+func (cfg *AppConfig) AllowFromSources() []AllowFromSource {
+	if cfg.Expose == nil {
+		return nil
+	}
+	sources := []AllowFromSource{}
+	for _, entry := range cfg.Expose.AllowFrom {
+		if strings.HasPrefix(entry, AllowFromNamespacePrefix) {
+			sources = append(sources, AllowFromSource{Namespace: strings.TrimPrefix(entry, AllowFromNamespacePrefix)})
+			continue
+		}
+		parts := strings.SplitN(entry, ".", 2)
+		source := AllowFromSource{App: parts[0], Namespace: string(cfg.Namespace)}
+		if len(parts) == 2 {
+			source.Namespace = parts[1]
+		}
+		sources = append(sources, source)
+	}
+	return sources
 }
 
-// Mode is not yet implemented (httpGet = default)
 type AppConfigHealthCheck struct {
+	// Mode is one of http (default), tcp, grpc, or exec
+	Mode string `json:"mode,omitempty"`
+	// Path is required for the http mode. A path without a leading "/" is treated as relative to the root (see HTTPPath).
 	Path string `json:"path,omitempty"`
+	// Command is required for the exec mode
+	Command []string `json:"command,omitempty"`
+	// GRPCService is the optional service name to check for the grpc mode. An empty value checks the overall server health.
+	GRPCService         string `json:"grpcService,omitempty"`
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32 `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32 `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    *int32 `json:"failureThreshold,omitempty"`
+}
+
+// HTTPPath returns the path with a leading "/". Configs from before health check modes were added did not require one.
+func (healthCheck *AppConfigHealthCheck) HTTPPath() string {
+	if strings.HasPrefix(healthCheck.Path, "/") {
+		return healthCheck.Path
+	}
+	return "/" + healthCheck.Path
 }
 
+// IsHTTP returns true if the health check uses the http mode
+func (healthCheck *AppConfigHealthCheck) IsHTTP() bool {
+	return healthCheck != nil && (healthCheck.Mode == "" || healthCheck.Mode == AppHealthCheckMode_HTTP)
+}
+
+// AppConfigResources contains the container limits. Requests are optional and default to the environment's default requests.
 type AppConfigResources struct {
+	CpuCores *float32            `json:"cpuCores,omitempty"`
+	MemoryMB *int32              `json:"memoryMB,omitempty"`
+	Requests *ResourceQuantities `json:"requests,omitempty"`
+}
+
+type ResourceQuantities struct {
 	CpuCores *float32 `json:"cpuCores,omitempty"`
 	MemoryMB *int32   `json:"memoryMB,omitempty"`
 }
 
+// IsWorker returns true if the app does not expose a port. Workers are rendered without a Route or Service and never receive requests.
+func (appConfig *AppConfig) IsWorker() bool {
+	return appConfig.Expose == nil
+}
+
 // ApplyDefaults sets any unset values with their defaults
 func (appConfig *AppConfig) ApplyDefaults() error {
+	// KNative requires a port so workers use the deployment workload
+	if appConfig.Workload == "" && appConfig.IsWorker() {
+		appConfig.Workload = AppWorkload_Deployment
+	}
+	if err := appConfig.applyExposeDefaults(); err != nil {
+		return err
+	}
 	return mergo.Merge(appConfig, appConfigDefaults)
 }
 
+// applyExposeDefaults sets any unset expose values with their defaults. The expose is copied so that a shared expose is never mutated.
+func (appConfig *AppConfig) applyExposeDefaults() error {
+	if appConfig.Expose == nil {
+		return nil
+	}
+	expose := *appConfig.Expose
+	// The deployment workload is not exposed outside of the cluster
+	if appConfig.Workload == AppWorkload_Deployment && expose.Scope == "" {
+		expose.Scope = AppExposeScope_Cluster
+	}
+	if err := mergo.Merge(&expose, appConfigExposeDefaults); err != nil {
+		return err
+	}
+	appConfig.Expose = &expose
+	return nil
+}
+
 func (appConfig AppConfig) Validate() error {
 	validationErrors := validation.ValidateStruct(&appConfig,
+		validation.Field(&appConfig.ApiVersion, validation.By(validAppConfigApiVersion)),
 		validation.Field(&appConfig.Name),
 		validation.Field(&appConfig.Namespace),
 		validation.Field(&appConfig.Id, validation.By(validId)),
+		validation.Field(&appConfig.Workload, inStrings(appWorkloads)),
 		validation.Field(&appConfig.Image, validation.Required, validation.By(validDockerImageWithoutTagOrDigest)),
-		validation.Field(&appConfig.Expose, validation.Required),
+	)
+
+	if appConfig.Workload != AppWorkload_Deployment && appConfig.IsWorker() {
+		validationErrors = mergeValidationErrors(validationErrors,
+			validation.Errors{"expose": errors.New("is required unless the workload is deployment")}, "")
+	}
+
+	validationErrors = mergeValidationErrors(validationErrors, appConfig.OverrideableAppConfig.validate(), "")
+
+	if appConfig.HealthCheck != nil {
+		validationErrors = mergeValidationErrors(validationErrors, validateHealthCheck(appConfig.HealthCheck), "healthcheck")
+	}
+
+	if appConfig.Liveness != nil {
+		validationErrors = mergeValidationErrors(validationErrors, validateHealthCheck(appConfig.Liveness), "liveness")
+	}
+
+	if appConfig.Workload == AppWorkload_Deployment {
+		validationErrors = mergeValidationErrors(validationErrors, validateDeploymentWorkload(&appConfig.OverrideableAppConfig), "")
+	} else if appConfig.Expose != nil {
+		validationErrors = mergeValidationErrors(validationErrors, validateKNativePorts(appConfig.Expose), "expose.ports")
+	}
+
+	if appConfig.IsWorker() {
+		validationErrors = mergeValidationErrors(validationErrors, validateWorker(&appConfig.OverrideableAppConfig), "")
+	}
+
+	return validationErrors
+}
+
+// validate validates the fields that may be overridden per environment
+func (cfg OverrideableAppConfig) validate() error {
+	validationErrors := validation.ValidateStruct(&cfg,
+		validation.Field(&cfg.Command, validation.By(validCommand)),
+		validation.Field(&cfg.WorkingDir, validation.By(validAbsolutePath)),
+		validation.Field(&cfg.Files, validation.By(validFilesSize)),
 	)
 
 	// Break out each struct so that we can have better error messages than the default
 	// This has the downside of not allowing nested structs to implement their own Validate.
 
 	// Env is treated similar to a struct so that we can map each env var key as a field with its own error (e.g. env.BAD-VAR)
-	envErr := validation.Validate(appConfig.Environment, validation.By(validEnvMap))
+	envErr := validation.Validate(cfg.Environment, validation.By(validEnvMap))
 	validationErrors = mergeValidationErrors(validationErrors, envErr, "env")
 
-	if appConfig.Expose != nil {
-		exposeErr := validation.ValidateStruct(appConfig.Expose,
-			validation.Field(&appConfig.Expose.ContainerPort, validation.Required, validation.Min(1), validation.Max(65535)),
-			validation.Field(&appConfig.Expose.Protocol, validation.In("http", "http2").Error("must be one of: http, http2")),
-			validation.Field(&appConfig.Expose.Scope,
-				validation.In(AppExposeScope_External, AppExposeScope_Cluster).Error(
-					fmt.Sprintf("must be one of: %s, %s", AppExposeScope_External, AppExposeScope_Cluster))),
+	// Files are treated the same as env so that each file path has its own error
+	filesErr := validation.Validate(cfg.Files, validation.By(validFilesMap))
+	validationErrors = mergeValidationErrors(validationErrors, filesErr, "files")
+
+	if cfg.Expose != nil {
+		exposeErr := validation.ValidateStruct(cfg.Expose,
+			validation.Field(&cfg.Expose.ContainerPort, validation.Required, validation.Min(1), validation.Max(65535)),
+			validation.Field(&cfg.Expose.Protocol, inStrings(appExposeProtocols)),
+			validation.Field(&cfg.Expose.Scope, inStrings(appExposeScopes)),
+			validation.Field(&cfg.Expose.Domains, validation.By(validDomains)),
+			validation.Field(&cfg.Expose.AllowFrom, validation.By(validAllowFrom)),
+			validation.Field(&cfg.Expose.ContainerConcurrency, validation.Min(int64(0))),
+			validation.Field(&cfg.Expose.TimeoutSeconds, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(int64(1))),
 		)
 		validationErrors = mergeValidationErrors(validationErrors, exposeErr, "expose")
+		validationErrors = mergeValidationErrors(validationErrors, validatePorts(cfg.Expose), "expose.ports")
+	}
+
+	if cfg.Resources != nil {
+		validationErrors = mergeValidationErrors(validationErrors, validateResources(cfg.Resources), "resources")
+	}
+
+	if cfg.ServiceAccount != nil {
+		annotationsErr := validation.Validate(cfg.ServiceAccount.Annotations, validation.By(validAnnotationsMap))
+		validationErrors = mergeValidationErrors(validationErrors, annotationsErr, "serviceAccount.annotations")
+	}
+
+	if cfg.Security != nil {
+		securityErr := validation.ValidateStruct(cfg.Security,
+			validation.Field(&cfg.Security.OptOut, validation.By(validSecuritySettings)),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, securityErr, "security")
+	}
+
+	// Secrets may not be mounted to the same path as a file or another secret
+	usedPaths := map[string]bool{}
+	for filePath := range cfg.Files {
+		usedPaths[filePath] = true
+	}
+	for _, name := range sortedSecretNames(cfg.Secrets) {
+		validationErrors = mergeValidationErrors(validationErrors, validateSecret(cfg.Secrets[name], usedPaths), fmt.Sprintf("secrets.%s", name))
+		usedPaths[cfg.Secrets[name].Path] = true
 	}
 
-	if appConfig.Autoscale != nil {
+	for name, job := range cfg.Jobs {
+		if err := validation.Validate(name, RulesNamingIdentifier()...); err != nil {
+			validationErrors = mergeValidationErrors(validationErrors, validation.Errors{name: err}, "jobs")
+			continue
+		}
+		validationErrors = mergeValidationErrors(validationErrors, validateJob(job), fmt.Sprintf("jobs.%s", name))
+	}
+
+	if cfg.Autoscale != nil {
 		maxMinRule := validation.Min(1)
-		if appConfig.Autoscale.Min != nil {
-			maxMinRule = validation.Min(*appConfig.Autoscale.Min).Error("must be greater than or equal to autoscale.min")
+		if cfg.Autoscale.Min != nil {
+			maxMinRule = validation.Min(*cfg.Autoscale.Min).Error("must be greater than or equal to autoscale.min")
 		}
-		autoscaleErr := validation.ValidateStruct(appConfig.Autoscale,
-			validation.Field(&appConfig.Autoscale.Min, validation.Min(0)),
+		minRules := []validation.Rule{validation.Min(0)}
+		if cfg.Autoscale.Metric == AppAutoscaleMetric_CPU {
+			minRules = []validation.Rule{validation.NilOrNotEmpty.Error("must be no less than 1 when the metric is cpu"), validation.Min(1)}
+		}
+		// The ranges below are from https://knative.dev/docs/serving/autoscaling/
+		autoscaleErr := validation.ValidateStruct(cfg.Autoscale,
+			validation.Field(&cfg.Autoscale.Min, minRules...),
 			// We have to customize the NilOrEmpty error to match "Min since "Min" does not get applied to nillable 0 value
-			validation.Field(&appConfig.Autoscale.Max, validation.NilOrNotEmpty.Error("must be no less than 1"), maxMinRule),
+			validation.Field(&cfg.Autoscale.Max, validation.NilOrNotEmpty.Error("must be no less than 1"), maxMinRule),
+			validation.Field(&cfg.Autoscale.Metric, inStrings(appAutoscaleMetrics)),
+			validation.Field(&cfg.Autoscale.Target, validation.NilOrNotEmpty.Error("must be no less than 0.01"), validation.Min(0.01)),
+			validation.Field(&cfg.Autoscale.TargetUtilizationPercentage,
+				validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(float64(1)), validation.Max(float64(100))),
+			validation.Field(&cfg.Autoscale.ScaleDownDelay, validation.By(validDurationRange(0, time.Hour))),
+			validation.Field(&cfg.Autoscale.StableWindow, validation.By(validDurationRange(6*time.Second, time.Hour))),
+			validation.Field(&cfg.Autoscale.PanicWindowPercentage,
+				validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(float64(1)), validation.Max(float64(100))),
+			validation.Field(&cfg.Autoscale.InitialScale, validation.Min(0)),
 		)
 
 		validationErrors = mergeValidationErrors(validationErrors, autoscaleErr, "autoscale")
@@ -141,6 +676,314 @@ func (appConfig AppConfig) Validate() error {
 	return validationErrors
 }
 
+// validateDeploymentWorkload validates the fields that are not supported by the deployment workload. Autoscaling uses the Kubernetes HPA
+// which only supports the cpu metric.
+func validateDeploymentWorkload(cfg *OverrideableAppConfig) error {
+	var validationErrors error
+	if cfg.Expose != nil {
+		exposeErr := validation.ValidateStruct(cfg.Expose,
+			validation.Field(&cfg.Expose.Scope, validation.In(AppExposeScope_Cluster).Error("must be cluster when the workload is deployment")),
+			validation.Field(&cfg.Expose.Domains, blankUnless("the workload is knative")),
+			validation.Field(&cfg.Expose.ContainerConcurrency, blankUnless("the workload is knative")),
+			validation.Field(&cfg.Expose.TimeoutSeconds, blankUnless("the workload is knative")),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, exposeErr, "expose")
+	}
+	if cfg.Autoscale != nil {
+		autoscaleErr := validation.ValidateStruct(cfg.Autoscale,
+			validation.Field(&cfg.Autoscale.Min, validation.NilOrNotEmpty.Error("must be no less than 1 when the workload is deployment"), validation.Min(1)),
+			validation.Field(&cfg.Autoscale.Metric, validation.In(AppAutoscaleMetric_CPU).Error("must be cpu when the workload is deployment")),
+			validation.Field(&cfg.Autoscale.TargetUtilizationPercentage, blankUnless("the workload is knative")),
+			validation.Field(&cfg.Autoscale.ScaleDownDelay, blankUnless("the workload is knative")),
+			validation.Field(&cfg.Autoscale.StableWindow, blankUnless("the workload is knative")),
+			validation.Field(&cfg.Autoscale.PanicWindowPercentage, blankUnless("the workload is knative")),
+			validation.Field(&cfg.Autoscale.InitialScale, blankUnless("the workload is knative")),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, autoscaleErr, "autoscale")
+	}
+	return validationErrors
+}
+
+// validateKNativePorts validates that additional ports do not conflict with the KNative queue-proxy
+func validateKNativePorts(expose *AppConfigExpose) error {
+	var validationErrors error
+	for _, name := range expose.PortNames() {
+		for _, reservedPort := range knativeReservedPorts {
+			if expose.Ports[name].ContainerPort == reservedPort {
+				validationErrors = mergeValidationErrors(validationErrors,
+					validation.Errors{"containerPort": fmt.Errorf("must not be %d which is reserved by knative", reservedPort)}, name)
+			}
+		}
+	}
+	return validationErrors
+}
+
+// validateWorker validates that health checks do not require a port since workers do not expose one
+func validateWorker(cfg *OverrideableAppConfig) error {
+	var validationErrors error
+	healthChecks := map[string]*AppConfigHealthCheck{"healthcheck": cfg.HealthCheck, "liveness": cfg.Liveness}
+	for _, fieldName := range []string{"healthcheck", "liveness"} {
+		healthCheck := healthChecks[fieldName]
+		if healthCheck == nil || healthCheck.Mode == AppHealthCheckMode_Exec {
+			continue
+		}
+		validationErrors = mergeValidationErrors(validationErrors,
+			validation.Errors{"mode": errors.New("must be exec when expose is not set")}, fieldName)
+	}
+	return validationErrors
+}
+
+func validAbsolutePath(value interface{}) error {
+	dir, _ := value.(string)
+	if dir != "" && (!strings.HasPrefix(dir, "/") || path.Clean(dir) != dir) {
+		return errors.New("must be a clean absolute path")
+	}
+	return nil
+}
+
+// validDurationRange validates an optional duration string. KNative requires durations to be in whole seconds.
+func validDurationRange(min, max time.Duration) validation.RuleFunc {
+	return func(value interface{}) error {
+		durationString, _ := value.(string)
+		if durationString == "" {
+			return nil
+		}
+		duration, err := time.ParseDuration(durationString)
+		if err != nil {
+			return errors.New(`must be a valid duration (e.g. "30s" or "5m")`)
+		}
+		if duration < min || duration > max || duration.Truncate(time.Second) != duration {
+			return fmt.Errorf("must be in whole seconds between %s and %s", min, max)
+		}
+		return nil
+	}
+}
+
+func validDomains(value interface{}) error {
+	domains, _ := value.([]string)
+	seen := map[string]bool{}
+	for _, domain := range domains {
+		if !domainPattern.MatchString(domain) {
+			return fmt.Errorf("the domain %q is not valid: must be a lowercase fully qualified domain name (e.g. app.example.com)", domain)
+		}
+		if seen[domain] {
+			return fmt.Errorf("the domain %q is duplicated", domain)
+		}
+		seen[domain] = true
+	}
+	return nil
+}
+
+func validAllowFrom(value interface{}) error {
+	entries, _ := value.([]string)
+	for _, entry := range entries {
+		var names []string
+		if strings.HasPrefix(entry, AllowFromNamespacePrefix) {
+			names = []string{strings.TrimPrefix(entry, AllowFromNamespacePrefix)}
+		} else {
+			names = strings.Split(entry, ".")
+		}
+		if len(names) > 2 {
+			return fmt.Errorf("the entry %q is not valid: must be an app (e.g. myapp.mynamespace) or a namespace (e.g. %smynamespace)", entry, AllowFromNamespacePrefix)
+		}
+		for _, name := range names {
+			if err := validation.Validate(name, append(RulesNamingIdentifier(), validation.Required)...); err != nil {
+				return fmt.Errorf("the entry %q is not valid: %s", entry, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validAnnotationsMap returns an error for each annotation key that is not a valid Kubernetes annotation key
+func validAnnotationsMap(value interface{}) error {
+	annotations, _ := value.(map[string]string)
+	validationErrors := validation.Errors{}
+	for key := range annotations {
+		if err := validAnnotationKey(key); err != nil {
+			validationErrors[key] = err
+		}
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+	return nil
+}
+
+func validAnnotationKey(key string) error {
+	if errs := k8svalidation.IsQualifiedName(key); len(errs) > 0 {
+		return fmt.Errorf("must be a valid annotation key (e.g. iam.gke.io/gcp-service-account): %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func validSecuritySettings(value interface{}) error {
+	settings, _ := value.([]string)
+	for _, setting := range settings {
+		if err := validation.Validate(setting, inStrings(securitySettings)); err != nil {
+			return fmt.Errorf("the entry %q is not valid: %s", setting, err)
+		}
+	}
+	return nil
+}
+
+func validCommand(value interface{}) error {
+	command, _ := value.([]string)
+	for _, arg := range command {
+		if strings.TrimSpace(arg) == "" {
+			return errors.New("must not contain blank values")
+		}
+	}
+	return nil
+}
+
+func validateResources(resources *AppConfigResources) error {
+	validationErrors := validation.ValidateStruct(resources,
+		validation.Field(&resources.CpuCores, validation.Min(float32(0))),
+		validation.Field(&resources.MemoryMB, validation.Min(0)),
+	)
+
+	if resources.Requests != nil {
+		requests := resources.Requests
+		cpuRules := []validation.Rule{validation.Min(float32(0))}
+		if resources.CpuCores != nil {
+			cpuRules = append(cpuRules, validation.Max(*resources.CpuCores).Error("must be less than or equal to resources.cpuCores"))
+		}
+		memoryRules := []validation.Rule{validation.Min(0)}
+		if resources.MemoryMB != nil {
+			memoryRules = append(memoryRules, validation.Max(*resources.MemoryMB).Error("must be less than or equal to resources.memoryMB"))
+		}
+		requestsErr := validation.ValidateStruct(requests,
+			validation.Field(&requests.CpuCores, cpuRules...),
+			validation.Field(&requests.MemoryMB, memoryRules...),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, requestsErr, "requests")
+	}
+
+	return validationErrors
+}
+
+func sortedSecretNames(secrets map[string]AppConfigSecret) []string {
+	names := []string{}
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateSecret(secret AppConfigSecret, usedPaths map[string]bool) error {
+	modeRules := []validation.Rule{validation.Match(secretModePattern).Error("must be an octal file mode (e.g. 0400)")}
+	if secret.Path == "" {
+		modeRules = append(modeRules, blankUnless("the path is set"))
+	}
+	return validation.ValidateStruct(&secret,
+		validation.Field(&secret.Path, validation.By(func(value interface{}) error {
+			secretPath, _ := value.(string)
+			if secretPath == "" {
+				return nil
+			}
+			if usedPaths[secretPath] {
+				return fmt.Errorf("the path %q is already in use", secretPath)
+			}
+			return validFilePath(secretPath)
+		})),
+		validation.Field(&secret.Mode, modeRules...),
+	)
+}
+
+// validatePorts validates each additional port and that no two ports, including the primary port, use the same container port
+func validatePorts(expose *AppConfigExpose) error {
+	var validationErrors error
+	usedPorts := map[int32]string{expose.ContainerPort: "expose.containerPort"}
+	nameRules := append(RulesNamingIdentifier(),
+		validation.RuneLength(namingIdentifierMinLength, appPortNameMaxLength),
+		validation.NotIn(appPortNameH2C).Error("is reserved for the primary port"))
+	for _, name := range expose.PortNames() {
+		if err := validation.Validate(name, nameRules...); err != nil {
+			validationErrors = mergeValidationErrors(validationErrors, validation.Errors{name: err}, "")
+			continue
+		}
+		port := expose.Ports[name]
+		portErr := validation.ValidateStruct(&port,
+			validation.Field(&port.ContainerPort, validation.Required, validation.Min(1), validation.Max(65535)),
+			validation.Field(&port.Protocol, inStrings(appPortProtocols)),
+		)
+		if portErr == nil {
+			if usedBy, ok := usedPorts[port.ContainerPort]; ok {
+				portErr = validation.Errors{"containerPort": fmt.Errorf("must not be the same as %s", usedBy)}
+			}
+			usedPorts[port.ContainerPort] = fmt.Sprintf("expose.ports.%s.containerPort", name)
+		}
+		validationErrors = mergeValidationErrors(validationErrors, portErr, name)
+	}
+	return validationErrors
+}
+
+func validateJob(job AppConfigJob) error {
+	return validation.ValidateStruct(&job,
+		validation.Field(&job.Schedule, validation.Required, validation.Match(cronSchedulePattern).Error("must be a cron schedule (e.g. \"0 3 * * *\")")),
+		validation.Field(&job.Command, validation.Required, validation.By(validCommand)),
+		validation.Field(&job.ConcurrencyPolicy, inStrings(appJobConcurrencyPolicies)),
+		validation.Field(&job.HistoryLimit, validation.Min(0)),
+	)
+}
+
+func validateHealthCheck(healthCheck *AppConfigHealthCheck) error {
+	mode := healthCheck.Mode
+	if mode == "" {
+		mode = AppHealthCheckMode_HTTP
+	}
+
+	pathRules := []validation.Rule{blankUnlessMode(AppHealthCheckMode_HTTP)}
+	if mode == AppHealthCheckMode_HTTP {
+		pathRules = []validation.Rule{validation.Required}
+	}
+	commandRules := []validation.Rule{blankUnlessMode(AppHealthCheckMode_Exec)}
+	if mode == AppHealthCheckMode_Exec {
+		commandRules = []validation.Rule{validation.Required}
+	}
+	grpcServiceRules := []validation.Rule{}
+	if mode != AppHealthCheckMode_GRPC {
+		grpcServiceRules = append(grpcServiceRules, blankUnlessMode(AppHealthCheckMode_GRPC))
+	}
+
+	return validation.ValidateStruct(healthCheck,
+		validation.Field(&healthCheck.Mode, inStrings(appHealthCheckModes)),
+		validation.Field(&healthCheck.Path, pathRules...),
+		validation.Field(&healthCheck.Command, commandRules...),
+		validation.Field(&healthCheck.GRPCService, grpcServiceRules...),
+		validation.Field(&healthCheck.InitialDelaySeconds, validation.Min(0)),
+		// See autoscale.max for why we need NilOrNotEmpty
+		validation.Field(&healthCheck.PeriodSeconds, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+		validation.Field(&healthCheck.TimeoutSeconds, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+		validation.Field(&healthCheck.FailureThreshold, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(1)),
+	)
+}
+
+// inStrings is validation.In with an error message that lists the allowed values
+func inStrings(values []string) validation.Rule {
+	allowed := make([]interface{}, len(values))
+	for i, value := range values {
+		allowed[i] = value
+	}
+	return validation.In(allowed...).Error(fmt.Sprintf("must be one of: %s", strings.Join(values, ", ")))
+}
+
+// We have to do this until ozzo supports validation.Empty
+func blankUnlessMode(mode string) validation.Rule {
+	return blankUnless(fmt.Sprintf("the mode is %s", mode))
+}
+
+func blankUnless(condition string) validation.Rule {
+	return validation.By(func(v interface{}) error {
+		if !validation.IsEmpty(v) {
+			return fmt.Errorf("must be blank unless %s", condition)
+		}
+		return nil
+	})
+}
+
 func validDockerImageWithoutTagOrDigest(value interface{}) error {
 	dockerImageURL, _ := value.(string)
 	named, err := reference.ParseNormalizedNamed(dockerImageURL)
@@ -158,11 +1001,15 @@ func validDockerImageWithoutTagOrDigest(value interface{}) error {
 func validEnvMap(value interface{}) error {
 	validationErrors := validation.Errors{}
 	envMap, _ := value.(map[string]intstr.IntOrString)
-	for k := range envMap {
+	for k, v := range envMap {
 		err := validation.Validate(k,
 			validation.Match(envVarKeyPattern).Error(fmt.Sprintf(`The env var %q is not valid: Must start with A-Z and only contain A-Z, 0-9, and underscores (_)`, k)),
 			validation.By(validateEnvKeyNoRiserPrefix),
 		)
+		if err == nil {
+			// The namespace is only used to resolve references so it's not needed to validate them
+			_, err = ParseAppReferences(v.String(), "")
+		}
 		if err != nil {
 			validationErrors[k] = err
 		}
@@ -173,6 +1020,41 @@ func validEnvMap(value interface{}) error {
 	return nil
 }
 
+func validFilesMap(value interface{}) error {
+	validationErrors := validation.Errors{}
+	files, _ := value.(map[string]string)
+	for filePath := range files {
+		if err := validFilePath(filePath); err != nil {
+			validationErrors[filePath] = err
+		}
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+	return nil
+}
+
+func validFilePath(filePath string) error {
+	if !filePathPattern.MatchString(filePath) || path.Clean(filePath) != filePath {
+		return fmt.Errorf(`The file path %q is not valid: Must be an absolute path and only contain A-Z, a-z, 0-9, dashes (-), underscores (_), and periods (.)`, filePath)
+	} else if _, reserved := reservedFilePaths[filePath]; reserved {
+		return fmt.Errorf(`The file path %q is reserved`, filePath)
+	}
+	return nil
+}
+
+func validFilesSize(value interface{}) error {
+	files, _ := value.(map[string]string)
+	totalSize := 0
+	for _, content := range files {
+		totalSize += len(content)
+	}
+	if totalSize > maxFilesSizeBytes {
+		return fmt.Errorf("the total size of all files must be no more than %d bytes", maxFilesSizeBytes)
+	}
+	return nil
+}
+
 // We have to do this until ozzo supports validation.NotMatch
 func validateEnvKeyNoRiserPrefix(v interface{}) error {
 	strVal, _ := v.(string)
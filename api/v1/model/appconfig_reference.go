@@ -0,0 +1,99 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+)
+
+const (
+	// AppReferenceField_URL is the cluster-local url of the deployment (e.g. http://checkout.apps.svc.cluster.local)
+	AppReferenceField_URL = "url"
+	// AppReferenceField_Host is the cluster-local host of the deployment (e.g. checkout.apps.svc.cluster.local)
+	AppReferenceField_Host = "host"
+	// AppReferenceField_ExternalURL is the url of the deployment on the environment's public gateway (e.g. https://checkout.apps.example.com)
+	AppReferenceField_ExternalURL = "externalUrl"
+	// AppReferenceField_ExternalHost is the host of the deployment on the environment's public gateway (e.g. checkout.apps.example.com)
+	AppReferenceField_ExternalHost = "externalHost"
+)
+
+var (
+	appReferenceFields  = []string{AppReferenceField_URL, AppReferenceField_Host, AppReferenceField_ExternalURL, AppReferenceField_ExternalHost}
+	appReferencePattern = regexp.MustCompile(`\$\{app:([^}]*)\}`)
+)
+
+// AppReference is a reference in an env var value to a deployment in the same environment (e.g. "${app:checkout.apps.url}"). The
+// deployment is referenced by name, which is the app's name unless the app was deployed with a different deployment name.
+type AppReference struct {
+	// Expression is the reference as it appears in the env var value
+	Expression string
+	Name       string
+	Namespace  string
+	Field      string
+}
+
+// ParseAppReferences returns the app references in an env var value. References without a namespace (e.g. "${app:checkout.url}") are
+// assumed to be in the namespace provided.
+func ParseAppReferences(value string, namespace string) ([]AppReference, error) {
+	references := []AppReference{}
+	for _, match := range appReferencePattern.FindAllStringSubmatch(value, -1) {
+		parts := strings.Split(match[1], ".")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("the app reference %q is not valid: must be in the form ${app:<name>.<namespace>.<field>}", match[0])
+		}
+		reference := AppReference{
+			Expression: match[0],
+			Name:       parts[0],
+			Namespace:  namespace,
+			Field:      parts[len(parts)-1],
+		}
+		if len(parts) == 3 {
+			reference.Namespace = parts[1]
+		}
+		for _, name := range parts[:len(parts)-1] {
+			if err := validation.Validate(name, append(RulesNamingIdentifier(), validation.Required)...); err != nil {
+				return nil, fmt.Errorf("the app reference %q is not valid: %s", match[0], err)
+			}
+		}
+		if !stringInSlice(reference.Field, appReferenceFields) {
+			return nil, fmt.Errorf("the app reference %q is not valid: the field must be one of: %s", match[0], strings.Join(appReferenceFields, ", "))
+		}
+		references = append(references, reference)
+	}
+	return references, nil
+}
+
+// AppReferences returns the app references in each env var keyed by the env var name. Invalid references are reported by Validate and
+// are ignored here.
+func (cfg *AppConfig) AppReferences() map[string][]AppReference {
+	references := map[string][]AppReference{}
+	for key, value := range cfg.Environment {
+		envReferences, err := ParseAppReferences(value.String(), string(cfg.Namespace))
+		if err == nil && len(envReferences) > 0 {
+			references[key] = envReferences
+		}
+	}
+	return references
+}
+
+// AppReferenceKeys returns the env var names from AppReferences in sorted order
+func AppReferenceKeys(references map[string][]AppReference) []string {
+	keys := []string{}
+	for key := range references {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func stringInSlice(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
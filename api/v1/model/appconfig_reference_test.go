@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_ParseAppReferences(t *testing.T) {
+	result, err := ParseAppReferences("${app:checkout.apps.url}/api?callback=${app:myapp.host}", "myns")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []AppReference{
+		{Expression: "${app:checkout.apps.url}", Name: "checkout", Namespace: "apps", Field: AppReferenceField_URL},
+		{Expression: "${app:myapp.host}", Name: "myapp", Namespace: "myns", Field: AppReferenceField_Host},
+	}, result)
+}
+
+func Test_ParseAppReferences_None(t *testing.T) {
+	result, err := ParseAppReferences("http://checkout.apps ${notapp:checkout.url}", "myns")
+
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func Test_ParseAppReferences_Invalid(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected string
+	}{
+		{"${app:checkout}", `the app reference "${app:checkout}" is not valid: must be in the form ${app:<name>.<namespace>.<field>}`},
+		{"${app:a.b.c.url}", `the app reference "${app:a.b.c.url}" is not valid: must be in the form ${app:<name>.<namespace>.<field>}`},
+		{"${app:checkout.apps.port}", `the app reference "${app:checkout.apps.port}" is not valid: the field must be one of: url, host, externalUrl, externalHost`},
+		{"${app:Checkout.url}", `the app reference "${app:Checkout.url}" is not valid: must be lowercase, alphanumeric, and start with a letter`},
+		{"${app:checkout..url}", `the app reference "${app:checkout..url}" is not valid: cannot be blank`},
+	}
+
+	for _, tt := range tests {
+		result, err := ParseAppReferences(tt.value, "myns")
+
+		assert.Nil(t, result, tt.value)
+		assert.EqualError(t, err, tt.expected, tt.value)
+	}
+}
+
+func Test_AppConfig_AppReferences(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Environment = map[string]intstr.IntOrString{
+		"CHECKOUT_URL": intstr.FromString("${app:checkout.url}"),
+		"INVALID":      intstr.FromString("${app:checkout}"),
+		"PLAIN":        intstr.FromString("plain"),
+		"NUMBER":       intstr.FromInt(1),
+	}
+
+	result := appConfig.AppReferences()
+
+	assert.Equal(t, map[string][]AppReference{
+		"CHECKOUT_URL": {{Expression: "${app:checkout.url}", Name: "checkout", Namespace: "myns", Field: AppReferenceField_URL}},
+	}, result)
+	assert.Equal(t, []string{"CHECKOUT_URL"}, AppReferenceKeys(result))
+}
+
+func Test_AppConfig_ValidateEnvironmentAppReferences(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Environment = map[string]intstr.IntOrString{
+		"CHECKOUT_URL": intstr.FromString("${app:checkout.apps.url}"),
+		"CHECKOUT":     intstr.FromString("${app:checkout.apps.port}"),
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t,
+		`the app reference "${app:checkout.apps.port}" is not valid: the field must be one of: url, host, externalUrl, externalHost`,
+		validationErrors["env.CHECKOUT"].Error())
+}
@@ -0,0 +1,312 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const jsonSchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is the subset of JSON Schema (draft-07) needed to describe the app config
+type JSONSchema struct {
+	Schema      string                 `json:"$schema,omitempty"`
+	Ref         string                 `json:"$ref,omitempty"`
+	Title       string                 `json:"title,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Definitions map[string]*JSONSchema `json:"definitions,omitempty"`
+	// Type is either a string or a []string
+	Type       interface{}            `json:"type,omitempty"`
+	Format     string                 `json:"format,omitempty"`
+	Properties map[string]*JSONSchema `json:"properties,omitempty"`
+	// AdditionalProperties is either a bool or a *JSONSchema
+	AdditionalProperties interface{} `json:"additionalProperties,omitempty"`
+	PropertyNames        *JSONSchema `json:"propertyNames,omitempty"`
+	Required             []string    `json:"required,omitempty"`
+	Items                *JSONSchema `json:"items,omitempty"`
+	UniqueItems          bool        `json:"uniqueItems,omitempty"`
+	Enum                 []string    `json:"enum,omitempty"`
+	Pattern              string      `json:"pattern,omitempty"`
+	MinLength            *int        `json:"minLength,omitempty"`
+	MaxLength            *int        `json:"maxLength,omitempty"`
+	Minimum              *float64    `json:"minimum,omitempty"`
+	Maximum              *float64    `json:"maximum,omitempty"`
+	Not                  *JSONSchema `json:"not,omitempty"`
+}
+
+var (
+	appConfigSchema     *JSONSchema
+	appConfigSchemaOnce sync.Once
+
+	uuidType        = reflect.TypeOf(uuid.UUID{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+	appNameType     = reflect.TypeOf(AppName(""))
+	namespaceType   = reflect.TypeOf(NamespaceName(""))
+)
+
+// appConfigSchemaRequired contains the required properties of each struct. Nested structs such as expose do not have required properties
+// since an environment override may only set some of their properties. Those are still enforced by AppConfig.Validate. Jobs and ports
+// are the exception since an environment override replaces the whole job or port.
+var appConfigSchemaRequired = map[string][]string{
+	"AppConfig":     {"id", "name", "image"},
+	"AppConfigJob":  {"schedule", "command"},
+	"AppConfigPort": {"containerPort"},
+}
+
+// appConfigSchemaRules contains the constraints from AppConfig.Validate keyed by "<struct name>.<json property name>". They are merged into
+// the schema generated from the property's type. Rules that can't be expressed in JSON Schema (e.g. comparing two properties) are
+// left to AppConfig.Validate.
+var appConfigSchemaRules = map[string]JSONSchema{
+	"AppConfigWithOverrides.environmentOverrides": {
+		Description:   "Overrides the app config for an environment. Only the fields that are set are overridden: maps are merged by key, objects are merged field by field, and other values replace the app's value.",
+		PropertyNames: namingIdentifierSchema(namingIdentifierMaxLength),
+	},
+	"AppConfig.apiVersion": {
+		Description: fmt.Sprintf("The version of the app config. Deprecated versions are converted to %s.", AppConfigApiVersion),
+		Enum:        SupportedAppConfigApiVersions(),
+	},
+	"AppConfig.workload": {
+		Description: "The kind of workload that runs the app. The deployment workload never scales to zero and is only exposed inside the cluster.",
+		Enum:        appWorkloads,
+	},
+	"AppConfig.id": {Description: "The id of the app. Use \"riser apps new\" to create an app."},
+	"OverrideableAppConfig.image": {
+		Description: "The docker image without a tag or digest",
+		Pattern:     "^[^:@]+(:[0-9]+/[^:@]+)?$",
+	},
+	"OverrideableAppConfig.command": {
+		Description: "Overrides the image's entrypoint",
+		Items:       &JSONSchema{Pattern: `\S`},
+	},
+	"OverrideableAppConfig.args": {Description: "Overrides the image's cmd"},
+	"OverrideableAppConfig.workingDir": {
+		Pattern: "^/",
+	},
+	"OverrideableAppConfig.env": {
+		PropertyNames: &JSONSchema{
+			Pattern: envVarKeyPattern.String(),
+			Not:     &JSONSchema{Pattern: envVarKeyRiserPattern.String()},
+		},
+	},
+	"OverrideableAppConfig.files": {
+		Description:   "Maps an absolute file path in the container to its content",
+		PropertyNames: &JSONSchema{Pattern: filePathPattern.String()},
+	},
+	"OverrideableAppConfig.expose": {
+		Description: "Omit for workers (e.g. queue consumers) that do not receive requests. Workers require the deployment workload.",
+	},
+	"OverrideableAppConfig.liveness": {
+		Description: "An optional probe that restarts the container when it fails. The healthcheck is only used for readiness.",
+	},
+	"OverrideableAppConfig.secrets": {
+		Description: "Options for each secret keyed by the secret name. All secrets are available as env vars.",
+	},
+	"AppConfigSecret.path": {
+		Description: "An optional absolute file path in the container that the secret is mounted to",
+		Pattern:     filePathPattern.String(),
+	},
+	"AppConfigSecret.mode": {
+		Description: "The octal file mode of the mounted secret (e.g. 0400)",
+		Pattern:     secretModePattern.String(),
+	},
+	"OverrideableAppConfig.jobs": {
+		Description:   "Jobs that run the app's image on a schedule keyed by the job name",
+		PropertyNames: namingIdentifierSchema(namingIdentifierMaxLength),
+	},
+	"AppConfigJob.schedule": {
+		Description: "A cron schedule (e.g. \"0 3 * * *\")",
+		Pattern:     cronSchedulePattern.String(),
+	},
+	"AppConfigJob.command": {
+		Description: "Overrides the image's entrypoint. The app's args are not used. The image must include /bin/sh and either curl or wget, which are used to stop the Istio sidecar when the command exits.",
+		Items:       &JSONSchema{Pattern: `\S`},
+	},
+	"AppConfigJob.concurrencyPolicy": {Enum: appJobConcurrencyPolicies},
+	"AppConfigJob.historyLimit": {
+		Description: "The number of successful and failed runs to keep",
+		Minimum:     floatPtr(0),
+	},
+	"OverrideableAppConfig.serviceAccount": {
+		Description: "Configures the Kubernetes service account that the app runs under. The service account is named \"riser-<app name>\".",
+	},
+	"AppConfigServiceAccount.annotations": {
+		Description:   "Annotations that are added to the service account (e.g. for GKE Workload Identity or IRSA). Each environment decides which annotation keys are allowed.",
+		PropertyNames: &JSONSchema{Pattern: annotationKeyPattern},
+	},
+	"OverrideableAppConfig.security": {
+		Description: "Opt-outs from the security settings enforced by the environment. Each environment decides which settings may be opted out of.",
+	},
+	"AppConfigSecurity.optOut": {
+		Items:       &JSONSchema{Enum: securitySettings},
+		UniqueItems: true,
+	},
+	"AppConfigExpose.containerPort": {Minimum: floatPtr(1), Maximum: floatPtr(65535)},
+	"AppConfigExpose.protocol":      {Enum: appExposeProtocols},
+	"AppConfigExpose.scope":         {Enum: appExposeScopes},
+	"AppConfigExpose.domains": {
+		Description: "Custom domains that are mapped to the app in addition to the environment's default domain",
+		Items:       &JSONSchema{Pattern: domainPattern.String()},
+		UniqueItems: true,
+	},
+	"AppConfigExpose.allowFrom": {
+		Description: fmt.Sprintf("Restricts which apps may call this app. Each entry is either an app (e.g. checkout.apps) or all apps in a namespace (e.g. %sbilling).", AllowFromNamespacePrefix),
+		Items: &JSONSchema{
+			Pattern: fmt.Sprintf(`^(%[1]s(\.%[1]s)?|%[2]s%[1]s)$`, namingIdentifierExpr, AllowFromNamespacePrefix),
+		},
+	},
+	"AppConfigExpose.ports": {
+		Description:   "Additional ports keyed by the port name (e.g. for metrics or admin) that are only reachable inside the cluster",
+		PropertyNames: namingIdentifierSchema(appPortNameMaxLength),
+	},
+	"AppConfigPort.containerPort": {Minimum: floatPtr(1), Maximum: floatPtr(65535)},
+	"AppConfigPort.protocol":      {Enum: appPortProtocols},
+	"AppConfigExpose.containerConcurrency": {
+		Description: "The max number of requests that each replica handles at once. Zero allows unlimited concurrent requests. Requires the knative workload.",
+		Minimum:     floatPtr(0),
+	},
+	"AppConfigExpose.timeoutSeconds": {
+		Description: "The max duration of a request including streamed responses. Requires the knative workload. There is not yet a separate timeout for the start of the response.",
+		Minimum:     floatPtr(1),
+	},
+	"AppConfigAutoscale.min":    {Minimum: floatPtr(0)},
+	"AppConfigAutoscale.max":    {Minimum: floatPtr(1)},
+	"AppConfigAutoscale.metric": {Enum: appAutoscaleMetrics},
+	"AppConfigAutoscale.target": {
+		Description: "The value of the metric to target for each replica",
+		Minimum:     floatPtr(0.01),
+	},
+	"AppConfigAutoscale.targetUtilizationPercentage": {Minimum: floatPtr(1), Maximum: floatPtr(100)},
+	"AppConfigAutoscale.scaleDownDelay":              {Pattern: durationPattern},
+	"AppConfigAutoscale.stableWindow":                {Pattern: durationPattern},
+	"AppConfigAutoscale.panicWindowPercentage":       {Minimum: floatPtr(1), Maximum: floatPtr(100)},
+	"AppConfigAutoscale.initialScale":                {Minimum: floatPtr(0)},
+	"AppConfigHealthCheck.mode":                      {Enum: appHealthCheckModes},
+	"AppConfigHealthCheck.initialDelaySeconds":       {Minimum: floatPtr(0)},
+	"AppConfigHealthCheck.periodSeconds":             {Minimum: floatPtr(1)},
+	"AppConfigHealthCheck.timeoutSeconds":            {Minimum: floatPtr(1)},
+	"AppConfigHealthCheck.failureThreshold":          {Minimum: floatPtr(1)},
+	"AppConfigResources.cpuCores":                    {Minimum: floatPtr(0)},
+	"AppConfigResources.memoryMB":                    {Minimum: floatPtr(0)},
+	"ResourceQuantities.cpuCores":                    {Minimum: floatPtr(0)},
+	"ResourceQuantities.memoryMB":                    {Minimum: floatPtr(0)},
+}
+
+// annotationKeyPattern matches a Kubernetes annotation key with an optional DNS subdomain prefix (e.g. iam.gke.io/gcp-service-account).
+// Length limits are only checked by validAnnotationKey.
+const annotationKeyPattern = `^([a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*/)?[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`
+
+// durationPattern matches the durations allowed by validDurationRange (e.g. "30s" or "1m30s")
+const durationPattern = `^([0-9]+(\.[0-9]+)?(h|m|s))*$`
+
+// AppConfigSchema returns the JSON Schema for AppConfigWithOverrides. The schema is generated from the app config types so that new fields
+// are included automatically. Constraints are added in appConfigSchemaRules.
+func AppConfigSchema() *JSONSchema {
+	appConfigSchemaOnce.Do(func() {
+		generator := &schemaGenerator{definitions: map[string]*JSONSchema{}}
+		appConfigSchema = generator.structSchema(reflect.TypeOf(AppConfigWithOverrides{}))
+		appConfigSchema.Schema = jsonSchemaDraft07
+		appConfigSchema.Title = "Riser App Config"
+		appConfigSchema.Definitions = generator.definitions
+	})
+	return appConfigSchema
+}
+
+type schemaGenerator struct {
+	definitions map[string]*JSONSchema
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) *JSONSchema {
+	switch t {
+	case uuidType:
+		return &JSONSchema{Type: "string", Format: "uuid"}
+	case intOrStringType:
+		return &JSONSchema{Type: []string{"string", "integer"}}
+	case appNameType:
+		return namingIdentifierSchema(appNameMaxLength)
+	case namespaceType:
+		schema := namingIdentifierSchema(namingIdentifierMaxLength)
+		schema.Not = &JSONSchema{Pattern: fmt.Sprintf("^(%s)", strings.Join(bannedNamespacePrefixes, "|"))}
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(t.Elem())
+	case reflect.Struct:
+		if _, ok := g.definitions[t.Name()]; !ok {
+			// Add a placeholder first in case the type references itself
+			g.definitions[t.Name()] = &JSONSchema{}
+			g.definitions[t.Name()] = g.structSchema(t)
+		}
+		return &JSONSchema{Ref: "#/definitions/" + t.Name()}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem())}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	}
+	// Allow any value for types that we don't know how to describe
+	return &JSONSchema{}
+}
+
+// structSchema returns the schema for a struct with the properties of inline structs merged into it
+func (g *schemaGenerator) structSchema(t reflect.Type) *JSONSchema {
+	schema := &JSONSchema{
+		Type:                 "object",
+		Properties:           map[string]*JSONSchema{},
+		AdditionalProperties: false,
+		Required:             append([]string{}, appConfigSchemaRequired[t.Name()]...),
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			inline := g.structSchema(field.Type)
+			for propertyName, property := range inline.Properties {
+				schema.Properties[propertyName] = property
+			}
+			schema.Required = append(schema.Required, inline.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		property := g.typeSchema(field.Type)
+		if rule, ok := appConfigSchemaRules[fmt.Sprintf("%s.%s", t.Name(), name)]; ok {
+			mergeStructFields(reflect.ValueOf(property).Elem(), reflect.ValueOf(&rule).Elem())
+		}
+		schema.Properties[name] = property
+	}
+	return schema
+}
+
+func namingIdentifierSchema(maxLength int) *JSONSchema {
+	minLength := namingIdentifierMinLength
+	return &JSONSchema{
+		Type:      "string",
+		Pattern:   namingIdentifierPattern.String(),
+		MinLength: &minLength,
+		MaxLength: &maxLength,
+	}
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
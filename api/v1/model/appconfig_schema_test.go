@@ -0,0 +1,335 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_AppConfigSchema(t *testing.T) {
+	schema := AppConfigSchema()
+
+	assert.Equal(t, jsonSchemaDraft07, schema.Schema)
+	assert.ElementsMatch(t, []string{"id", "name", "image"}, schema.Required)
+	assert.Equal(t, false, schema.AdditionalProperties)
+	assert.Contains(t, schema.Properties, "environmentOverrides")
+	assert.Equal(t, &JSONSchema{Ref: "#/definitions/OverrideableAppConfig"}, schema.Properties["environmentOverrides"].AdditionalProperties)
+	assert.Equal(t, "#/definitions/AppConfigExpose", schema.Properties["expose"].Ref)
+	assert.Equal(t, appExposeProtocols, schema.Definitions["AppConfigExpose"].Properties["protocol"].Enum)
+	assert.Empty(t, schema.Definitions["OverrideableAppConfig"].Required)
+	// Private fields must not be in the schema
+	assert.NotContains(t, schema.Properties, "convertedFrom")
+}
+
+func Test_AppConfigSchema_RulesMatchProperties(t *testing.T) {
+	properties := map[string]bool{}
+	collectSchemaPropertyKeys(reflect.TypeOf(AppConfigWithOverrides{}), properties)
+
+	for key := range appConfigSchemaRules {
+		assert.True(t, properties[key], "The schema rule %q does not match a property", key)
+	}
+	for typeName, required := range appConfigSchemaRequired {
+		for _, name := range required {
+			assert.True(t, properties[fmt.Sprintf("%s.%s", typeName, name)] || properties[fmt.Sprintf("OverrideableAppConfig.%s", name)],
+				"The required property %q does not exist", name)
+		}
+	}
+}
+
+func Test_AppConfigSchema_MinimumValidAppConfig(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Expose: &AppConfigExpose{Scope: AppExposeScope_Cluster},
+			},
+		},
+	}
+
+	assert.Empty(t, validateAgainstAppConfigSchema(t, appConfig))
+}
+
+func Test_AppConfigSchema_ValidAppConfig(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+	}
+	appConfig.ApiVersion = AppConfigApiVersion
+	appConfig.Autoscale = &AppConfigAutoscale{Min: intPtr(0), Max: intPtr(5), Metric: AppAutoscaleMetric_RPS, StableWindow: "1m30s"}
+	appConfig.Environment = map[string]intstr.IntOrString{"MY_ENV": intstr.FromInt(1), "OTHER": intstr.FromString("val")}
+	appConfig.Expose.Domains = []string{"app.example.com"}
+	appConfig.Expose.AllowFrom = []string{"checkout", "checkout.apps", "ns:billing"}
+	appConfig.Expose.ContainerConcurrency = int64Ptr(0)
+	appConfig.Expose.TimeoutSeconds = int64Ptr(900)
+	appConfig.Expose.Ports = map[string]AppConfigPort{"metrics": {ContainerPort: 9100}, "admin": {ContainerPort: 8081, Protocol: AppExposeProtocol_GRPC}}
+	appConfig.Files = map[string]string{"/etc/config.yaml": "a: b"}
+	appConfig.HealthCheck = &AppConfigHealthCheck{Path: "/health", PeriodSeconds: int32Ptr(5)}
+	appConfig.Security = &AppConfigSecurity{OptOut: []string{SecuritySetting_ReadOnlyRootFilesystem}}
+	appConfig.ServiceAccount = &AppConfigServiceAccount{Annotations: map[string]string{"iam.gke.io/gcp-service-account": "a@b.iam.gserviceaccount.com", "owner": "me"}}
+	appConfig.Secrets = map[string]AppConfigSecret{"tls-key": {Path: "/etc/tls/tls.key", Mode: "0400"}, "creds": {}}
+	appConfig.Jobs = map[string]AppConfigJob{
+		"cleanup": {Schedule: "*/15 1-5 * * mon,wed", Command: []string{"cleanup"}, ConcurrencyPolicy: AppJobConcurrencyPolicy_Forbid, HistoryLimit: int32Ptr(0)},
+		"report":  {Schedule: "@daily", Command: []string{"report", "--all"}},
+	}
+
+	assert.Empty(t, validateAgainstAppConfigSchema(t, appConfig))
+}
+
+func Test_AppConfigSchema_Required(t *testing.T) {
+	result := validateAgainstAppConfigSchema(t, map[string]interface{}{})
+
+	assert.ElementsMatch(t, []string{
+		`: missing required property "id"`,
+		`: missing required property "name"`,
+		`: missing required property "image"`,
+	}, result)
+}
+
+func Test_AppConfigSchema_Image(t *testing.T) {
+	for _, tt := range imageTests {
+		appConfig := createMinAppConfig()
+		appConfig.Image = tt.image
+
+		result := validateAgainstAppConfigSchema(t, appConfig)
+
+		assert.Equal(t, tt.valid, len(result) == 0, tt.image)
+	}
+}
+
+func Test_AppConfigSchema_ExposeProtocol(t *testing.T) {
+	for _, tt := range protocolTests {
+		appConfig := createMinAppConfig()
+		appConfig.Expose.Protocol = tt.protocol
+
+		result := validateAgainstAppConfigSchema(t, appConfig)
+
+		if tt.valid {
+			assert.Empty(t, result, tt.protocol)
+		} else {
+			assert.Equal(t, []string{`expose.protocol: "redis" is not one of [http http2 grpc]`}, result, tt.protocol)
+		}
+	}
+}
+
+func Test_AppConfigSchema_Invalid(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Name = "1abc"
+	appConfig.Namespace = "kube-system"
+	appConfig.Expose.ContainerPort = 70000
+	appConfig.Expose.Scope = "nope"
+	appConfig.Workload = "statefulset"
+	appConfig.Expose.AllowFrom = []string{"a.b.c"}
+	appConfig.Expose.ContainerConcurrency = int64Ptr(-1)
+	appConfig.Expose.TimeoutSeconds = int64Ptr(0)
+	appConfig.Expose.Ports = map[string]AppConfigPort{"reallylongportname": {ContainerPort: 1}, "metrics": {ContainerPort: 0, Protocol: "udp"}}
+	appConfig.Autoscale = &AppConfigAutoscale{Max: intPtr(0), StableWindow: "1d"}
+	appConfig.Environment = map[string]intstr.IntOrString{"bad": intstr.FromInt(1), "RISER_ENV": intstr.FromInt(1)}
+	appConfig.Files = map[string]string{"relative": ""}
+	appConfig.HealthCheck = &AppConfigHealthCheck{Mode: "udp", Path: "health"}
+	appConfig.Secrets = map[string]AppConfigSecret{"tls-key": {Path: "tls.key", Mode: "rw"}}
+	appConfig.Security = &AppConfigSecurity{OptOut: []string{"privileged"}}
+	appConfig.ServiceAccount = &AppConfigServiceAccount{Annotations: map[string]string{"Bad/Key": ""}}
+	appConfig.Jobs = map[string]AppConfigJob{
+		"cleanup": {Schedule: "every day", Command: []string{" "}, ConcurrencyPolicy: "queue", HistoryLimit: int32Ptr(-1)},
+		"report":  {},
+	}
+
+	result := validateAgainstAppConfigSchema(t, appConfig)
+
+	assert.ElementsMatch(t, []string{
+		`name: "1abc" does not match pattern "^[a-z][a-z0-9-]*[a-z0-9]+$"`,
+		`namespace: "kube-system" must not match the schema`,
+		`expose.containerPort: 70000 is greater than the maximum 65535`,
+		`expose.scope: "nope" is not one of [external cluster]`,
+		`workload: "statefulset" is not one of [knative deployment]`,
+		`expose.allowFrom.0: "a.b.c" does not match pattern "^([a-z][a-z0-9-]*[a-z0-9]+(\.[a-z][a-z0-9-]*[a-z0-9]+)?|ns:[a-z][a-z0-9-]*[a-z0-9]+)$"`,
+		`expose.containerConcurrency: -1 is less than the minimum 0`,
+		`expose.timeoutSeconds: 0 is less than the minimum 1`,
+		`expose.ports.reallylongportname: "reallylongportname" is longer than 15`,
+		`expose.ports.metrics: missing required property "containerPort"`,
+		`expose.ports.metrics.protocol: "udp" is not one of [http http2 grpc tcp]`,
+		`autoscale.max: 0 is less than the minimum 1`,
+		`autoscale.stableWindow: "1d" does not match pattern "^([0-9]+(\.[0-9]+)?(h|m|s))*$"`,
+		`env.bad: "bad" does not match pattern "^[A-Z][A-Z0-9_]*$"`,
+		`env.RISER_ENV: "RISER_ENV" must not match the schema`,
+		`files.relative: "relative" does not match pattern "^(/[-._a-zA-Z0-9]+)+$"`,
+		`healthcheck.mode: "udp" is not one of [http tcp grpc exec]`,
+		`secrets.tls-key.path: "tls.key" does not match pattern "^(/[-._a-zA-Z0-9]+)+$"`,
+		`secrets.tls-key.mode: "rw" does not match pattern "^0?[0-7]{3}$"`,
+		`security.optOut.0: "privileged" is not one of [runAsNonRoot readOnlyRootFilesystem dropCapabilities seccompProfile]`,
+		fmt.Sprintf(`serviceAccount.annotations.Bad/Key: "Bad/Key" does not match pattern "%s"`, annotationKeyPattern),
+		fmt.Sprintf(`jobs.cleanup.schedule: "every day" does not match pattern "%s"`, cronSchedulePattern.String()),
+		`jobs.cleanup.command.0: " " does not match pattern "\S"`,
+		`jobs.cleanup.concurrencyPolicy: "queue" is not one of [allow forbid replace]`,
+		`jobs.cleanup.historyLimit: -1 is less than the minimum 0`,
+		`jobs.report: missing required property "schedule"`,
+		`jobs.report: missing required property "command"`,
+	}, result)
+}
+
+func Test_AppConfigSchema_UnknownProperty(t *testing.T) {
+	appConfig := map[string]interface{}{
+		"id":     uuid.New().String(),
+		"name":   "myapp",
+		"image":  "myimage",
+		"expose": map[string]interface{}{"containerPort": 80, "port": 80},
+		"environmentOverrides": map[string]interface{}{
+			"PROD": map[string]interface{}{},
+		},
+	}
+
+	result := validateAgainstAppConfigSchema(t, appConfig)
+
+	assert.ElementsMatch(t, []string{
+		`expose: unknown property "port"`,
+		`environmentOverrides.PROD: "PROD" does not match pattern "^[a-z][a-z0-9-]*[a-z0-9]+$"`,
+	}, result)
+}
+
+// collectSchemaPropertyKeys collects "<struct name>.<json property name>" keys for all structs reachable from the type
+func collectSchemaPropertyKeys(t reflect.Type, keys map[string]bool) {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		collectSchemaPropertyKeys(t.Elem(), keys)
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name != "" {
+			keys[fmt.Sprintf("%s.%s", t.Name(), name)] = true
+		}
+		if field.PkgPath == "" {
+			collectSchemaPropertyKeys(field.Type, keys)
+		}
+	}
+}
+
+// validateAgainstAppConfigSchema validates the value against the app config schema. Only the keywords used by JSONSchema are supported.
+func validateAgainstAppConfigSchema(t *testing.T, value interface{}) []string {
+	serialized, err := json.Marshal(value)
+	require.NoError(t, err)
+	var doc interface{}
+	require.NoError(t, json.Unmarshal(serialized, &doc))
+
+	schema := AppConfigSchema()
+	return validateSchema(schema, schema, doc, "")
+}
+
+func validateSchema(root *JSONSchema, schema *JSONSchema, value interface{}, path string) []string {
+	if schema.Ref != "" {
+		return validateSchema(root, root.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")], value, path)
+	}
+
+	errs := []string{}
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf("%s: %s", path, fmt.Sprintf(format, args...)))
+	}
+
+	if schema.Type != nil && !matchesSchemaType(schema.Type, value) {
+		fail("%v is not of type %v", value, schema.Type)
+		return errs
+	}
+
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := typed[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		for name, propertyValue := range typed {
+			propertyPath := strings.TrimPrefix(fmt.Sprintf("%s.%s", path, name), ".")
+			if schema.PropertyNames != nil {
+				errs = append(errs, validateSchema(root, schema.PropertyNames, name, propertyPath)...)
+			}
+			if property, ok := schema.Properties[name]; ok {
+				errs = append(errs, validateSchema(root, property, propertyValue, propertyPath)...)
+			} else if additional, ok := schema.AdditionalProperties.(*JSONSchema); ok {
+				errs = append(errs, validateSchema(root, additional, propertyValue, propertyPath)...)
+			} else if schema.AdditionalProperties == false {
+				fail("unknown property %q", name)
+			}
+		}
+	case []interface{}:
+		seen := map[string]bool{}
+		for idx, item := range typed {
+			if schema.Items != nil {
+				errs = append(errs, validateSchema(root, schema.Items, item, fmt.Sprintf("%s.%d", path, idx))...)
+			}
+			key := fmt.Sprintf("%v", item)
+			if schema.UniqueItems && seen[key] {
+				fail("%v is duplicated", item)
+			}
+			seen[key] = true
+		}
+	case string:
+		if len(schema.Enum) > 0 && !stringInSlice(typed, schema.Enum) {
+			fail("%q is not one of %v", typed, schema.Enum)
+		}
+		if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(typed) {
+			fail("%q does not match pattern \"%s\"", typed, schema.Pattern)
+		}
+		if schema.MinLength != nil && len(typed) < *schema.MinLength {
+			fail("%q is shorter than %d", typed, *schema.MinLength)
+		}
+		if schema.MaxLength != nil && len(typed) > *schema.MaxLength {
+			fail("%q is longer than %d", typed, *schema.MaxLength)
+		}
+	case float64:
+		if schema.Minimum != nil && typed < *schema.Minimum {
+			fail("%v is less than the minimum %v", typed, *schema.Minimum)
+		}
+		if schema.Maximum != nil && typed > *schema.Maximum {
+			fail("%v is greater than the maximum %v", typed, *schema.Maximum)
+		}
+	}
+
+	if schema.Not != nil && len(validateSchema(root, schema.Not, value, path)) == 0 {
+		fail("%q must not match the schema", value)
+	}
+
+	return errs
+}
+
+func matchesSchemaType(schemaType interface{}, value interface{}) bool {
+	types, ok := schemaType.([]string)
+	if !ok {
+		types = []string{schemaType.(string)}
+	}
+	for _, typeName := range types {
+		switch value.(type) {
+		case map[string]interface{}:
+			if typeName == "object" {
+				return true
+			}
+		case []interface{}:
+			if typeName == "array" {
+				return true
+			}
+		case string:
+			if typeName == "string" {
+				return true
+			}
+		case bool:
+			if typeName == "boolean" {
+				return true
+			}
+		case float64:
+			if typeName == "number" || (typeName == "integer" && value.(float64) == float64(int64(value.(float64)))) {
+				return true
+			}
+		}
+	}
+	return false
+}
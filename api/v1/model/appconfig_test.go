@@ -2,11 +2,13 @@ package model
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/google/uuid"
+	"github.com/imdario/mergo"
 	"github.com/jinzhu/copier"
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
@@ -21,15 +23,20 @@ var minimumValidAppConfig = &AppConfig{
 	Name:      "myapp",
 	Namespace: "myns",
 	Id:        uuid.New(),
-	Image:     "myimage",
-	Expose: &AppConfigExpose{
-		ContainerPort: 80,
+	OverrideableAppConfig: OverrideableAppConfig{
+		Image: "myimage",
+		Expose: &AppConfigExpose{
+			ContainerPort: 80,
+		},
 	},
 }
 
 func Test_AppConfig_ApplyDefaults(t *testing.T) {
 	appConfig := &AppConfig{
 		Name: "myapp",
+		OverrideableAppConfig: OverrideableAppConfig{
+			Expose: &AppConfigExpose{ContainerPort: 8000},
+		},
 	}
 
 	err := appConfig.ApplyDefaults()
@@ -37,19 +44,21 @@ func Test_AppConfig_ApplyDefaults(t *testing.T) {
 	assert.NoError(t, err)
 	assert.EqualValues(t, "myapp", appConfig.Name)
 	assert.EqualValues(t, "apps", appConfig.Namespace)
-	assert.NotNil(t, appConfig.Expose)
 	assert.Equal(t, "http", appConfig.Expose.Protocol)
 	assert.Equal(t, "external", appConfig.Expose.Scope)
+	assert.Equal(t, AppWorkload_Knative, appConfig.Workload)
 }
 
 func Test_AppConfig_ApplyDefaults_AllowsNonDefaultValues(t *testing.T) {
 	appConfig := &AppConfig{
 		Name:      "myapp",
 		Namespace: "myns",
-		Expose: &AppConfigExpose{
-			ContainerPort: 8000,
-			Protocol:      "http2",
-			Scope:         AppExposeScope_Cluster,
+		OverrideableAppConfig: OverrideableAppConfig{
+			Expose: &AppConfigExpose{
+				ContainerPort: 8000,
+				Protocol:      "http2",
+				Scope:         AppExposeScope_Cluster,
+			},
 		},
 	}
 
@@ -61,6 +70,37 @@ func Test_AppConfig_ApplyDefaults_AllowsNonDefaultValues(t *testing.T) {
 	assert.Equal(t, "http2", appConfig.Expose.Protocol)
 	assert.Equal(t, AppExposeScope_Cluster, appConfig.Expose.Scope)
 	assert.EqualValues(t, 8000, appConfig.Expose.ContainerPort)
+	assert.Equal(t, AppConfigApiVersion, appConfig.ApiVersion)
+}
+
+func Test_AppConfig_ApplyDefaults_DeploymentWorkload(t *testing.T) {
+	appConfig := &AppConfig{
+		Name:     "myapp",
+		Workload: AppWorkload_Deployment,
+		OverrideableAppConfig: OverrideableAppConfig{
+			Expose: &AppConfigExpose{ContainerPort: 8000},
+		},
+	}
+
+	err := appConfig.ApplyDefaults()
+
+	assert.NoError(t, err)
+	assert.Equal(t, AppWorkload_Deployment, appConfig.Workload)
+	assert.Equal(t, "http", appConfig.Expose.Protocol)
+	assert.Equal(t, AppExposeScope_Cluster, appConfig.Expose.Scope)
+}
+
+func Test_AppConfig_ApplyDefaults_Worker(t *testing.T) {
+	appConfig := &AppConfig{
+		Name: "myapp",
+	}
+
+	err := appConfig.ApplyDefaults()
+
+	assert.NoError(t, err)
+	assert.True(t, appConfig.IsWorker())
+	assert.Nil(t, appConfig.Expose)
+	assert.Equal(t, AppWorkload_Deployment, appConfig.Workload)
 }
 
 func Test_AppConfig_ValidateName(t *testing.T) {
@@ -76,6 +116,18 @@ func Test_AppConfig_ValidateName(t *testing.T) {
 	assert.Equal(t, "must be lowercase, alphanumeric, and start with a letter", validationErrors["namespace"].Error())
 }
 
+func Test_AppConfig_ValidateApiVersion(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.ApiVersion = "riser.dev/v9"
+
+	err := appConfig.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	require.Len(t, validationErrors, 1)
+	assert.Equal(t, "unsupported apiVersion: must be one of: riser.dev/v1, riser.dev/v1beta1", validationErrors["apiVersion"].Error())
+}
+
 func Test_AppConfig_ValidateRequired(t *testing.T) {
 	appConfig := AppConfig{}
 
@@ -84,7 +136,47 @@ func Test_AppConfig_ValidateRequired(t *testing.T) {
 	assert.IsType(t, validation.Errors{}, err)
 	validationErrors := err.(validation.Errors)
 	assert.Len(t, validationErrors, 5)
-	assertFieldsRequired(t, validationErrors, "name", "namespace", "id", "image", "expose")
+	assertFieldsRequired(t, validationErrors, "name", "namespace", "id", "image")
+	assert.Equal(t, "is required unless the workload is deployment", validationErrors["expose"].Error())
+}
+
+func Test_AppConfig_ValidateWorker(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Workload = AppWorkload_Deployment
+	appConfig.Expose = nil
+	appConfig.HealthCheck = &AppConfigHealthCheck{Path: "/health"}
+	appConfig.Liveness = &AppConfigHealthCheck{Mode: AppHealthCheckMode_TCP}
+
+	err := appConfig.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must be exec when expose is not set", validationErrors["healthcheck.mode"].Error())
+	assert.Equal(t, "must be exec when expose is not set", validationErrors["liveness.mode"].Error())
+}
+
+func Test_AppConfig_ValidateWorker_Valid(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Workload = AppWorkload_Deployment
+	appConfig.Expose = nil
+	appConfig.HealthCheck = &AppConfigHealthCheck{Mode: AppHealthCheckMode_Exec, Command: []string{"healthcheck"}}
+	appConfig.Autoscale = &AppConfigAutoscale{Min: intPtr(2)}
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfig_ValidateWorker_KnativeWorkload(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Workload = AppWorkload_Knative
+	appConfig.Expose = nil
+
+	err := appConfig.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "is required unless the workload is deployment", validationErrors["expose"].Error())
 }
 
 func Test_AppConfig_ValidateExposeRequired(t *testing.T) {
@@ -148,6 +240,145 @@ func Test_AppConfig_ValidateAutoscaleMax_NilMin(t *testing.T) {
 	assert.Equal(t, "must be no less than 1", validationErrors["autoscale.max"].Error())
 }
 
+func Test_AppConfig_ValidateAutoscaleOptions(t *testing.T) {
+	zero := float64(0)
+	over := float64(101)
+	appConfig := createMinAppConfig()
+	appConfig.Autoscale = &AppConfigAutoscale{
+		Metric:                      "memory",
+		Target:                      &zero,
+		TargetUtilizationPercentage: &over,
+		ScaleDownDelay:              "2h",
+		StableWindow:                "5s",
+		PanicWindowPercentage:       &zero,
+		InitialScale:                intPtr(-1),
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 7)
+	assert.Equal(t, "must be one of: concurrency, rps, cpu", validationErrors["autoscale.metric"].Error())
+	assert.Equal(t, "must be no less than 0.01", validationErrors["autoscale.target"].Error())
+	assert.Equal(t, "must be no greater than 100", validationErrors["autoscale.targetUtilizationPercentage"].Error())
+	assert.Equal(t, "must be in whole seconds between 0s and 1h0m0s", validationErrors["autoscale.scaleDownDelay"].Error())
+	assert.Equal(t, "must be in whole seconds between 6s and 1h0m0s", validationErrors["autoscale.stableWindow"].Error())
+	assert.Equal(t, "must be no less than 1", validationErrors["autoscale.panicWindowPercentage"].Error())
+	assert.Equal(t, "must be no less than 0", validationErrors["autoscale.initialScale"].Error())
+}
+
+func Test_AppConfig_ValidateAutoscaleOptions_Valid(t *testing.T) {
+	target := 0.5
+	percentage := float64(100)
+	appConfig := createMinAppConfig()
+	appConfig.Autoscale = &AppConfigAutoscale{
+		Metric:                      AppAutoscaleMetric_Concurrency,
+		Target:                      &target,
+		TargetUtilizationPercentage: &percentage,
+		ScaleDownDelay:              "0s",
+		StableWindow:                "1h",
+		PanicWindowPercentage:       &percentage,
+		InitialScale:                intPtr(0),
+	}
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfig_ValidateAutoscaleDuration(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Autoscale = &AppConfigAutoscale{
+		ScaleDownDelay: "soon",
+		StableWindow:   "60500ms",
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, `must be a valid duration (e.g. "30s" or "5m")`, validationErrors["autoscale.scaleDownDelay"].Error())
+	assert.Equal(t, "must be in whole seconds between 6s and 1h0m0s", validationErrors["autoscale.stableWindow"].Error())
+}
+
+func Test_AppConfig_ValidateAutoscaleCpuMin(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Autoscale = &AppConfigAutoscale{
+		Metric: AppAutoscaleMetric_CPU,
+		Min:    intPtr(0),
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be no less than 1 when the metric is cpu", validationErrors["autoscale.min"].Error())
+}
+
+func Test_AppConfig_ValidateWorkload(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Workload = "statefulset"
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be one of: knative, deployment", validationErrors["workload"].Error())
+}
+
+func Test_AppConfig_ValidateDeploymentWorkload(t *testing.T) {
+	percentage := float64(50)
+	appConfig := createMinAppConfig()
+	appConfig.Workload = AppWorkload_Deployment
+	appConfig.Expose.Scope = AppExposeScope_External
+	appConfig.Expose.Domains = []string{"myapp.example.com"}
+	appConfig.Expose.ContainerConcurrency = int64Ptr(10)
+	appConfig.Expose.TimeoutSeconds = int64Ptr(60)
+	appConfig.Autoscale = &AppConfigAutoscale{
+		Min:                         intPtr(0),
+		Metric:                      AppAutoscaleMetric_RPS,
+		TargetUtilizationPercentage: &percentage,
+		ScaleDownDelay:              "1m",
+		StableWindow:                "1m",
+		PanicWindowPercentage:       &percentage,
+		InitialScale:                intPtr(1),
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 11)
+	assert.Equal(t, "must be cluster when the workload is deployment", validationErrors["expose.scope"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["expose.domains"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["expose.containerConcurrency"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["expose.timeoutSeconds"].Error())
+	assert.Equal(t, "must be no less than 1 when the workload is deployment", validationErrors["autoscale.min"].Error())
+	assert.Equal(t, "must be cpu when the workload is deployment", validationErrors["autoscale.metric"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["autoscale.targetUtilizationPercentage"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["autoscale.scaleDownDelay"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["autoscale.stableWindow"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["autoscale.panicWindowPercentage"].Error())
+	assert.Equal(t, "must be blank unless the workload is knative", validationErrors["autoscale.initialScale"].Error())
+}
+
+func Test_AppConfig_ValidateDeploymentWorkload_Valid(t *testing.T) {
+	target := float64(70)
+	appConfig := createMinAppConfig()
+	appConfig.Workload = AppWorkload_Deployment
+	appConfig.Expose.Scope = AppExposeScope_Cluster
+	appConfig.Autoscale = &AppConfigAutoscale{
+		Min:    intPtr(2),
+		Max:    intPtr(5),
+		Metric: AppAutoscaleMetric_CPU,
+		Target: &target,
+	}
+
+	assert.NoError(t, appConfig.Validate())
+}
+
 // Note: We may not allow registry to be set here - it may be dictated by an admin on a per environment basis instead.
 var imageTests = []struct {
 	image string
@@ -187,6 +418,7 @@ var protocolTests = []struct {
 }{
 	{"http", true},
 	{"http2", true},
+	{"grpc", true},
 	{"", true},
 	{"redis", false},
 }
@@ -204,7 +436,7 @@ func Test_AppConfig_ValidateExposeProtocol(t *testing.T) {
 			validationErrors := err.(validation.Errors)
 			assert.Len(t, validationErrors, 1, tt.protocol)
 			require.Contains(t, validationErrors, "expose.protocol", tt.protocol)
-			assert.Equal(t, "must be one of: http, http2", validationErrors["expose.protocol"].Error(), tt.protocol)
+			assert.Equal(t, "must be one of: http, http2, grpc", validationErrors["expose.protocol"].Error(), tt.protocol)
 		}
 	}
 }
@@ -259,16 +491,16 @@ func Test_ApplyOverrides_WithOverrides(t *testing.T) {
 	autoscaleMinOverride := int(0)
 	appConfig := &AppConfigWithOverrides{
 		AppConfig: AppConfig{
-			Id:    appId,
-			Name:  "myapp",
-			Image: "hashicorp/http-echo",
-			HealthCheck: &AppConfigHealthCheck{
-				Path: "/health",
-			},
-			Expose: &AppConfigExpose{
-				ContainerPort: 1337,
-			},
+			Id:   appId,
+			Name: "myapp",
 			OverrideableAppConfig: OverrideableAppConfig{
+				HealthCheck: &AppConfigHealthCheck{
+					Path: "/health",
+				},
+				Image: "hashicorp/http-echo",
+				Expose: &AppConfigExpose{
+					ContainerPort: 1337,
+				},
 				Autoscale: &AppConfigAutoscale{
 					Min: &autoscaleMin,
 				},
@@ -312,6 +544,7 @@ func Test_ApplyOverrides_WithOverrides(t *testing.T) {
 	assert.Equal(t, 0, *result.Autoscale.Min)
 	// Ensure that we don't mutate the original config
 	assert.Equal(t, appConfig.Resources.CpuCores, &cpuCores)
+	assert.Len(t, appConfig.Environment, 2)
 }
 
 func Test_AppConfig_ValidateExposeScope(t *testing.T) {
@@ -389,6 +622,1134 @@ func Test_AppConfig_ValidateEnvironment_MultipleErrors(t *testing.T) {
 	assert.Equal(t, `The env var "9MYENV" is not valid: Must start with A-Z and only contain A-Z, 0-9, and underscores (_)`, validationErrors["env.9MYENV"].Error())
 }
 
+func Test_AppConfig_ValidateHealthCheck(t *testing.T) {
+	var tests = []struct {
+		healthCheck *AppConfigHealthCheck
+		errField    string
+		errMessage  string
+	}{
+		{&AppConfigHealthCheck{Path: "/health"}, "", ""},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_HTTP, Path: "/health"}, "", ""},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_TCP}, "", ""},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_GRPC, GRPCService: "mysvc"}, "", ""},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_Exec, Command: []string{"cat", "/tmp/healthy"}}, "", ""},
+		{&AppConfigHealthCheck{Mode: "udp"}, "mode", "must be one of: http, tcp, grpc, exec"},
+		{&AppConfigHealthCheck{}, "path", "cannot be blank"},
+		{&AppConfigHealthCheck{Path: "health"}, "", ""},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_TCP, Path: "/health"}, "path", "must be blank unless the mode is http"},
+		{&AppConfigHealthCheck{Mode: AppHealthCheckMode_Exec}, "command", "cannot be blank"},
+		{&AppConfigHealthCheck{Path: "/health", Command: []string{"true"}}, "command", "must be blank unless the mode is exec"},
+		{&AppConfigHealthCheck{Path: "/health", GRPCService: "mysvc"}, "grpcService", "must be blank unless the mode is grpc"},
+		{&AppConfigHealthCheck{Path: "/health", InitialDelaySeconds: int32Ptr(-1)}, "initialDelaySeconds", "must be no less than 0"},
+		{&AppConfigHealthCheck{Path: "/health", PeriodSeconds: int32Ptr(0)}, "periodSeconds", "must be no less than 1"},
+		{&AppConfigHealthCheck{Path: "/health", TimeoutSeconds: int32Ptr(0)}, "timeoutSeconds", "must be no less than 1"},
+		{&AppConfigHealthCheck{Path: "/health", FailureThreshold: int32Ptr(0)}, "failureThreshold", "must be no less than 1"},
+	}
+
+	for _, tt := range tests {
+		for _, prefix := range []string{"healthcheck", "liveness"} {
+			appConfig := createMinAppConfig()
+			if prefix == "healthcheck" {
+				appConfig.HealthCheck = tt.healthCheck
+			} else {
+				appConfig.Liveness = tt.healthCheck
+			}
+			err := appConfig.Validate()
+
+			if tt.errField == "" {
+				assert.NoError(t, err, "%s: %v", prefix, tt.healthCheck)
+			} else {
+				errKey := fmt.Sprintf("%s.%s", prefix, tt.errField)
+				require.IsType(t, validation.Errors{}, err, errKey)
+				validationErrors := err.(validation.Errors)
+				assert.Len(t, validationErrors, 1, errKey)
+				require.Contains(t, validationErrors, errKey)
+				assert.Equal(t, tt.errMessage, validationErrors[errKey].Error(), errKey)
+			}
+		}
+	}
+}
+
+func Test_AppConfigHealthCheck_IsHTTP(t *testing.T) {
+	var nilHealthCheck *AppConfigHealthCheck
+
+	assert.False(t, nilHealthCheck.IsHTTP())
+	assert.True(t, (&AppConfigHealthCheck{}).IsHTTP())
+	assert.True(t, (&AppConfigHealthCheck{Mode: AppHealthCheckMode_HTTP}).IsHTTP())
+	assert.False(t, (&AppConfigHealthCheck{Mode: AppHealthCheckMode_GRPC}).IsHTTP())
+}
+
+func Test_AppConfigHealthCheck_HTTPPath(t *testing.T) {
+	assert.Equal(t, "/health", (&AppConfigHealthCheck{Path: "/health"}).HTTPPath())
+	assert.Equal(t, "/health", (&AppConfigHealthCheck{Path: "health"}).HTTPPath())
+}
+
+func Test_AppConfig_ValidateResources(t *testing.T) {
+	cpuCores := float32(1)
+	cpuCoresRequest := float32(2)
+	appConfig := createMinAppConfig()
+	appConfig.Resources = &AppConfigResources{
+		CpuCores: &cpuCores,
+		MemoryMB: int32Ptr(512),
+		Requests: &ResourceQuantities{
+			CpuCores: &cpuCoresRequest,
+			MemoryMB: int32Ptr(1024),
+		},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must be less than or equal to resources.cpuCores", validationErrors["resources.requests.cpuCores"].Error())
+	assert.Equal(t, "must be less than or equal to resources.memoryMB", validationErrors["resources.requests.memoryMB"].Error())
+}
+
+func Test_AppConfig_ValidateResources_RequestsWithoutLimits(t *testing.T) {
+	cpuCoresRequest := float32(2)
+	appConfig := createMinAppConfig()
+	appConfig.Resources = &AppConfigResources{
+		Requests: &ResourceQuantities{
+			CpuCores: &cpuCoresRequest,
+			MemoryMB: int32Ptr(1024),
+		},
+	}
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfig_ValidateResources_Negative(t *testing.T) {
+	cpuCores := float32(-1)
+	appConfig := createMinAppConfig()
+	appConfig.Resources = &AppConfigResources{
+		CpuCores: &cpuCores,
+		MemoryMB: int32Ptr(-1),
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must be no less than 0", validationErrors["resources.cpuCores"].Error())
+	assert.Equal(t, "must be no less than 0", validationErrors["resources.memoryMB"].Error())
+}
+
+func Test_AppConfigWithOverrides_ValidateResources(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Resources: &AppConfigResources{
+					MemoryMB: int32Ptr(512),
+					Requests: &ResourceQuantities{
+						MemoryMB: int32Ptr(1024),
+					},
+				},
+			},
+		},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be less than or equal to resources.memoryMB", validationErrors["environmentOverrides.prod.resources.requests.memoryMB"].Error())
+}
+
+func Test_ApplyEnvironment_AppDefaults(t *testing.T) {
+	envAppDefaults := &OverrideableAppConfig{
+		Autoscale: &AppConfigAutoscale{Min: intPtr(1), Max: intPtr(5)},
+		Environment: map[string]intstr.IntOrString{
+			"LOG_LEVEL": intstr.Parse("info"),
+			"REGION":    intstr.Parse("us-east1"),
+		},
+	}
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: OverrideableAppConfig{
+				Autoscale: &AppConfigAutoscale{Max: intPtr(10)},
+				Environment: map[string]intstr.IntOrString{
+					"LOG_LEVEL": intstr.Parse("debug"),
+				},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"dev": {
+				Environment: map[string]intstr.IntOrString{
+					"REGION": intstr.Parse("us-west1"),
+				},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyEnvironment("dev", envAppDefaults)
+
+	require.NoError(t, err)
+	assert.EqualValues(t, "myapp", result.Name)
+	assert.Equal(t, 1, *result.Autoscale.Min)
+	assert.Equal(t, 10, *result.Autoscale.Max)
+	assert.Equal(t, "debug", result.Environment["LOG_LEVEL"].StrVal)
+	assert.Equal(t, "us-west1", result.Environment["REGION"].StrVal)
+	// Ensure that neither the defaults nor the app were mutated
+	assert.Equal(t, 5, *envAppDefaults.Autoscale.Max)
+	assert.Equal(t, "info", envAppDefaults.Environment["LOG_LEVEL"].StrVal)
+	assert.Nil(t, appConfig.Autoscale.Min)
+	assert.Len(t, appConfig.Environment, 1)
+}
+
+func Test_ApplyEnvironment_AppHealthCheckWithoutModeReplacesDefault(t *testing.T) {
+	envAppDefaults := &OverrideableAppConfig{
+		HealthCheck: &AppConfigHealthCheck{Mode: AppHealthCheckMode_TCP, PeriodSeconds: int32Ptr(5)},
+	}
+	appConfig := &AppConfigWithOverrides{AppConfig: *createMinAppConfig()}
+	appConfig.HealthCheck = &AppConfigHealthCheck{Path: "/health"}
+
+	result, err := appConfig.ApplyEnvironment("dev", envAppDefaults)
+
+	require.NoError(t, err)
+	assert.Equal(t, &AppConfigHealthCheck{Path: "/health"}, result.HealthCheck)
+	assert.NoError(t, result.Validate())
+}
+
+func Test_ApplyEnvironment_AppHealthCheckWithoutModeMergesHTTPDefault(t *testing.T) {
+	envAppDefaults := &OverrideableAppConfig{
+		HealthCheck: &AppConfigHealthCheck{Mode: AppHealthCheckMode_HTTP, Path: "/healthz", PeriodSeconds: int32Ptr(5)},
+	}
+	appConfig := &AppConfigWithOverrides{AppConfig: *createMinAppConfig()}
+	appConfig.HealthCheck = &AppConfigHealthCheck{Path: "/health"}
+
+	result, err := appConfig.ApplyEnvironment("dev", envAppDefaults)
+
+	require.NoError(t, err)
+	assert.Equal(t, &AppConfigHealthCheck{Mode: AppHealthCheckMode_HTTP, Path: "/health", PeriodSeconds: int32Ptr(5)}, result.HealthCheck)
+}
+
+func Test_ApplyEnvironment_NoAppDefaults(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: OverrideableAppConfig{
+				Autoscale: &AppConfigAutoscale{Max: intPtr(10)},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyEnvironment("dev", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, appConfig.AppConfig, *result)
+}
+
+func Test_AppConfigLayers(t *testing.T) {
+	envAppDefaults := &OverrideableAppConfig{Autoscale: &AppConfigAutoscale{Min: intPtr(1)}}
+	devOverrides := OverrideableAppConfig{Autoscale: &AppConfigAutoscale{Max: intPtr(2)}}
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			Name: "myapp",
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"dev": devOverrides,
+		},
+	}
+
+	result := appConfig.AppConfigLayers("dev", envAppDefaults)
+
+	require.Len(t, result, 4)
+	assert.Equal(t, AppConfigLayer_StaticDefaults, result[0].Name)
+	assert.Nil(t, result[0].Config)
+	assert.Equal(t, AppConfigLayer_EnvironmentDefaults, result[1].Name)
+	assert.Equal(t, envAppDefaults, result[1].Config)
+	assert.Equal(t, AppConfigLayer_App, result[2].Name)
+	assert.Equal(t, &appConfig.OverrideableAppConfig, result[2].Config)
+	assert.Equal(t, AppConfigLayer_EnvironmentOverrides, result[3].Name)
+	assert.Equal(t, &devOverrides, result[3].Config)
+
+	result = appConfig.AppConfigLayers("prod", nil)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, AppConfigLayer_StaticDefaults, result[0].Name)
+	assert.Equal(t, AppConfigLayer_App, result[1].Name)
+}
+
+func Test_ApplyOverrides_Resources(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			OverrideableAppConfig: OverrideableAppConfig{
+				Resources: &AppConfigResources{
+					MemoryMB: int32Ptr(512),
+					Requests: &ResourceQuantities{
+						MemoryMB: int32Ptr(256),
+					},
+				},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Resources: &AppConfigResources{
+					MemoryMB: int32Ptr(2048),
+					Requests: &ResourceQuantities{
+						MemoryMB: int32Ptr(1024),
+					},
+				},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 2048, *result.Resources.MemoryMB)
+	assert.EqualValues(t, 1024, *result.Resources.Requests.MemoryMB)
+}
+
+func Test_AppConfig_ValidateFiles(t *testing.T) {
+	var tests = []struct {
+		path  string
+		valid bool
+	}{
+		// good
+		{"/config.yaml", true},
+		{"/etc/my-app/app_config.yaml", true},
+		// bad
+		{"config.yaml", false},
+		{"/etc/../config.yaml", false},
+		{"/etc//config.yaml", false},
+		{"/etc/config.yaml/", false},
+		{"/etc/my config.yaml", false},
+	}
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.Files = map[string]string{tt.path: "content"}
+		err := appConfig.Validate()
+
+		if tt.valid {
+			assert.NoError(t, err, tt.path)
+		} else {
+			errKey := fmt.Sprintf("files.%s", tt.path)
+			require.IsType(t, validation.Errors{}, err, tt.path)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, 1, tt.path)
+			assert.Contains(t, validationErrors[errKey].Error(), "Must be an absolute path", tt.path)
+		}
+	}
+}
+
+func Test_AppConfig_ValidateFiles_Reserved(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Files = map[string]string{"/var/log": "content"}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, `The file path "/var/log" is reserved`, validationErrors["files./var/log"].Error())
+}
+
+func Test_AppConfig_ValidateFiles_Size(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Files = map[string]string{
+		"/a.txt": strings.Repeat("a", 512*1024),
+		"/b.txt": strings.Repeat("b", 512*1024+1),
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "the total size of all files must be no more than 1048576 bytes", validationErrors["files"].Error())
+}
+
+func Test_AppConfigWithOverrides_ValidateFiles(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Files: map[string]string{"bad": "content"},
+			},
+		},
+	}
+	appConfig.Files = map[string]string{"/a.txt": strings.Repeat("a", 1024*1024)}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Contains(t, validationErrors["environmentOverrides.prod.files.bad"].Error(), "Must be an absolute path")
+	assert.Equal(t, "the total size of all files must be no more than 1048576 bytes", validationErrors["environmentOverrides.prod.files"].Error())
+	// Ensure that validation does not mutate the app's files
+	assert.Len(t, appConfig.Files, 1)
+}
+
+func Test_ApplyOverrides_Files(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			OverrideableAppConfig: OverrideableAppConfig{
+				Files: map[string]string{
+					"/etc/base.yaml":   "base",
+					"/etc/config.yaml": "config",
+				},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Files: map[string]string{
+					"/etc/config.yaml": "prodconfig",
+					"/etc/prod.yaml":   "prod",
+				},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"/etc/base.yaml":   "base",
+		"/etc/config.yaml": "prodconfig",
+		"/etc/prod.yaml":   "prod",
+	}, result.Files)
+	// Ensure that we don't mutate the original config
+	assert.Len(t, appConfig.Files, 2)
+	assert.Equal(t, "config", appConfig.Files["/etc/config.yaml"])
+}
+
+func Test_AppConfig_ValidateCommand(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Command = []string{"/bin/myapp", " "}
+	appConfig.Args = []string{"serve", ""}
+	appConfig.WorkingDir = "opt/myapp"
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must not contain blank values", validationErrors["command"].Error())
+	assert.Equal(t, "must be a clean absolute path", validationErrors["workingDir"].Error())
+}
+
+func Test_AppConfig_ValidateCommand_Valid(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Command = []string{"/bin/myapp"}
+	appConfig.Args = []string{"serve", "--port=8080"}
+	appConfig.WorkingDir = "/"
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfigWithOverrides_Validate(t *testing.T) {
+	maxReplicas := 0
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Command:    []string{""},
+				WorkingDir: "../myapp",
+				Autoscale:  &AppConfigAutoscale{Max: &maxReplicas},
+				Environment: map[string]intstr.IntOrString{
+					"RISER_BAD": intstr.FromString("val"),
+				},
+			},
+		},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 4)
+	assert.Contains(t, validationErrors, "environmentOverrides.prod.command")
+	assert.Contains(t, validationErrors, "environmentOverrides.prod.workingDir")
+	assert.Contains(t, validationErrors, "environmentOverrides.prod.autoscale.max")
+	assert.Contains(t, validationErrors, "environmentOverrides.prod.env.RISER_BAD")
+}
+
+func Test_ApplyOverrides_UnsetValuesDoNotOverride(t *testing.T) {
+	autoscaleMin := 1
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			OverrideableAppConfig: OverrideableAppConfig{
+				Autoscale:  &AppConfigAutoscale{Min: &autoscaleMin},
+				Command:    []string{"/bin/myapp"},
+				Args:       []string{"serve"},
+				WorkingDir: "/opt/myapp",
+				Resources:  &AppConfigResources{MemoryMB: int32Ptr(512)},
+				Environment: map[string]intstr.IntOrString{
+					"KEY": intstr.FromString("val"),
+				},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Args: []string{"serve", "--prod"},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/bin/myapp"}, result.Command)
+	assert.Equal(t, []string{"serve", "--prod"}, result.Args)
+	assert.Equal(t, "/opt/myapp", result.WorkingDir)
+	assert.Equal(t, 1, *result.Autoscale.Min)
+	assert.EqualValues(t, 512, *result.Resources.MemoryMB)
+	assert.Equal(t, "val", result.Environment["KEY"].StrVal)
+}
+
+func Test_ApplyOverrides_EmptySliceClearsValue(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			OverrideableAppConfig: OverrideableAppConfig{
+				Args: []string{"serve"},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Args: []string{},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Args)
+	assert.Equal(t, []string{"serve"}, appConfig.Args)
+}
+
+// The cases that ApplyOverrides supported before field level overrides must have the same result as the mergo merge it replaced
+func Test_applyOverrides_MatchesMergoForOverriddenFields(t *testing.T) {
+	cpuCores := float32(2)
+	cpuCoresDev := float32(0.1)
+	tests := []struct {
+		name     string
+		base     func() OverrideableAppConfig
+		override OverrideableAppConfig
+	}{
+		{
+			name: "values, zero values and env maps",
+			base: func() OverrideableAppConfig {
+				return OverrideableAppConfig{
+					Autoscale: &AppConfigAutoscale{Min: intPtr(1)},
+					Resources: &AppConfigResources{CpuCores: &cpuCores},
+					Environment: map[string]intstr.IntOrString{
+						"envKey":     intstr.Parse("envVal"),
+						"envKeyBase": intstr.Parse("envValBase"),
+					},
+				}
+			},
+			override: OverrideableAppConfig{
+				// mergo does not override 0 by default even for an *int
+				Autoscale: &AppConfigAutoscale{Min: intPtr(0)},
+				Resources: &AppConfigResources{CpuCores: &cpuCoresDev},
+				Environment: map[string]intstr.IntOrString{
+					"envKey":    intstr.Parse("envValDevOverride"),
+					"envKeyDev": intstr.Parse("envValDev"),
+				},
+			},
+		},
+		{
+			name: "nested structs",
+			base: func() OverrideableAppConfig {
+				return OverrideableAppConfig{
+					Resources: &AppConfigResources{MemoryMB: int32Ptr(512), Requests: &ResourceQuantities{MemoryMB: int32Ptr(256)}},
+				}
+			},
+			override: OverrideableAppConfig{
+				Resources: &AppConfigResources{MemoryMB: int32Ptr(2048), Requests: &ResourceQuantities{MemoryMB: int32Ptr(1024)}},
+			},
+		},
+		{
+			name: "files maps",
+			base: func() OverrideableAppConfig {
+				return OverrideableAppConfig{
+					Files: map[string]string{"/etc/base.yaml": "base", "/etc/config.yaml": "config"},
+				}
+			},
+			override: OverrideableAppConfig{
+				Files: map[string]string{"/etc/config.yaml": "prodconfig", "/etc/prod.yaml": "prod"},
+			},
+		},
+		{
+			name: "empty slices",
+			base: func() OverrideableAppConfig {
+				return OverrideableAppConfig{Args: []string{"serve"}}
+			},
+			override: OverrideableAppConfig{Args: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		result := tt.base()
+		applyOverrides(&result, &tt.override, false)
+
+		assert.Equal(t, mergoOverrides(tt.base(), tt.override), result, tt.name)
+	}
+}
+
+// Unlike mergo, applyOverrides does not clear the fields that an override does not set and merges structs field by field
+func Test_applyOverrides_DiffersFromMergoForUnsetFields(t *testing.T) {
+	base := func() OverrideableAppConfig {
+		return OverrideableAppConfig{
+			Image:     "myimage",
+			Autoscale: &AppConfigAutoscale{Min: intPtr(1)},
+			Resources: &AppConfigResources{MemoryMB: int32Ptr(512), Requests: &ResourceQuantities{MemoryMB: int32Ptr(256)}},
+		}
+	}
+	override := OverrideableAppConfig{
+		Resources: &AppConfigResources{MemoryMB: int32Ptr(2048)},
+	}
+
+	result := base()
+	applyOverrides(&result, &override, false)
+	mergoResult := mergoOverrides(base(), override)
+
+	assert.Equal(t, "myimage", result.Image)
+	assert.Equal(t, 1, *result.Autoscale.Min)
+	assert.EqualValues(t, 2048, *result.Resources.MemoryMB)
+	assert.EqualValues(t, 256, *result.Resources.Requests.MemoryMB)
+	assert.Empty(t, mergoResult.Image)
+	assert.Nil(t, mergoResult.Autoscale)
+	assert.EqualValues(t, 2048, *mergoResult.Resources.MemoryMB)
+	assert.Nil(t, mergoResult.Resources.Requests)
+}
+
+// mergoOverrides is the merge that ApplyOverrides used before field level overrides
+func mergoOverrides(base OverrideableAppConfig, override OverrideableAppConfig) OverrideableAppConfig {
+	_ = mergo.Merge(&base, override, mergo.WithOverride, mergo.WithOverwriteWithEmptyValue)
+	return base
+}
+
+func Test_AppConfig_ValidateExposeDomains(t *testing.T) {
+	tests := []struct {
+		domains []string
+		err     string
+	}{
+		{[]string{"myapp.example.com", "api.myapp.example.com"}, ""},
+		{[]string{"xn--bcher-kva.example"}, ""},
+		{[]string{"localhost"}, `the domain "localhost" is not valid: must be a lowercase fully qualified domain name (e.g. app.example.com)`},
+		{[]string{"MyApp.example.com"}, `the domain "MyApp.example.com" is not valid: must be a lowercase fully qualified domain name (e.g. app.example.com)`},
+		{[]string{"*.example.com"}, `the domain "*.example.com" is not valid: must be a lowercase fully qualified domain name (e.g. app.example.com)`},
+		{[]string{"-bad.example.com"}, `the domain "-bad.example.com" is not valid: must be a lowercase fully qualified domain name (e.g. app.example.com)`},
+		{[]string{"myapp.example.com", "myapp.example.com"}, `the domain "myapp.example.com" is duplicated`},
+	}
+
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.Expose.Domains = tt.domains
+
+		err := appConfig.Validate()
+
+		if tt.err == "" {
+			assert.NoError(t, err, tt.domains)
+		} else {
+			require.IsType(t, validation.Errors{}, err, tt.domains)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, 1)
+			assert.Equal(t, tt.err, validationErrors["expose.domains"].Error())
+		}
+	}
+}
+
+func Test_AppConfigWithOverrides_ValidateExposeDomains(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Expose: &AppConfigExpose{
+					Domains: []string{"bad"},
+				},
+			},
+		},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Contains(t, validationErrors["environmentOverrides.prod.expose.domains"].Error(), `the domain "bad" is not valid`)
+}
+
+func Test_AppConfigWithOverrides_Validate_BaseErrorsNotRepeated(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Args: []string{"serve"},
+			},
+		},
+	}
+	appConfig.WorkingDir = "../myapp"
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Contains(t, validationErrors, "workingDir")
+}
+
+func Test_ApplyOverrides_Expose(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Expose: &AppConfigExpose{
+					Domains: []string{"myapp.example.com"},
+				},
+			},
+		},
+	}
+	appConfig.Expose.Scope = AppExposeScope_Cluster
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.EqualValues(t, 80, result.Expose.ContainerPort)
+	assert.Equal(t, AppExposeScope_Cluster, result.Expose.Scope)
+	assert.Equal(t, []string{"myapp.example.com"}, result.Expose.Domains)
+	// Ensure that the app's expose is not mutated
+	assert.Empty(t, appConfig.Expose.Domains)
+}
+
+func Test_AppConfig_ValidateExposeAllowFrom(t *testing.T) {
+	tests := []struct {
+		allowFrom []string
+		err       string
+	}{
+		{[]string{"checkout", "checkout.apps", "ns:billing"}, ""},
+		{[]string{"checkout.apps.extra"}, `the entry "checkout.apps.extra" is not valid: must be an app (e.g. myapp.mynamespace) or a namespace (e.g. ns:mynamespace)`},
+		{[]string{"Checkout"}, `the entry "Checkout" is not valid: must be lowercase, alphanumeric, and start with a letter`},
+		{[]string{"ns:"}, `the entry "ns:" is not valid: cannot be blank`},
+		{[]string{"checkout."}, `the entry "checkout." is not valid: cannot be blank`},
+	}
+
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.Expose.AllowFrom = tt.allowFrom
+
+		err := appConfig.Validate()
+
+		if tt.err == "" {
+			assert.NoError(t, err, tt.allowFrom)
+		} else {
+			require.IsType(t, validation.Errors{}, err, tt.allowFrom)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, 1)
+			assert.Equal(t, tt.err, validationErrors["expose.allowFrom"].Error())
+		}
+	}
+}
+
+func Test_AppConfig_ValidateExposePorts(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Expose.ContainerPort = 8080
+	appConfig.Expose.Ports = map[string]AppConfigPort{
+		"admin":               {ContainerPort: 8081, Protocol: AppExposeProtocol_GRPC},
+		"debug":               {ContainerPort: 8080},
+		"h2c":                 {ContainerPort: 8082},
+		"metrics":             {ContainerPort: 8081, Protocol: "udp"},
+		"reallylongportname":  {ContainerPort: 8083},
+		"stats":               {},
+		"telemetry":           {ContainerPort: 8081},
+		"zzz-conflicts-later": {ContainerPort: 70000},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 7)
+	assert.Equal(t, "must not be the same as expose.containerPort", validationErrors["expose.ports.debug.containerPort"].Error())
+	assert.Equal(t, "is reserved for the primary port", validationErrors["expose.ports.h2c"].Error())
+	assert.Equal(t, "must be one of: http, http2, grpc, tcp", validationErrors["expose.ports.metrics.protocol"].Error())
+	assert.Equal(t, "the length must be between 3 and 15", validationErrors["expose.ports.reallylongportname"].Error())
+	assert.Equal(t, "cannot be blank", validationErrors["expose.ports.stats.containerPort"].Error())
+	assert.Equal(t, "must not be the same as expose.ports.admin.containerPort", validationErrors["expose.ports.telemetry.containerPort"].Error())
+	assert.Equal(t, "the length must be between 3 and 15", validationErrors["expose.ports.zzz-conflicts-later"].Error())
+}
+
+func Test_AppConfig_ValidateExposePorts_Valid(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Expose.ContainerPort = 8080
+	appConfig.Expose.Protocol = AppExposeProtocol_GRPC
+	appConfig.Expose.Ports = map[string]AppConfigPort{
+		"admin":   {ContainerPort: 8081, Protocol: AppExposeProtocol_GRPC},
+		"metrics": {ContainerPort: 9100},
+		"debug":   {ContainerPort: 6060, Protocol: AppExposeProtocol_TCP},
+	}
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfig_ValidateExposePorts_KNativeReservedPorts(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Expose.Ports = map[string]AppConfigPort{"metrics": {ContainerPort: 9090}}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must not be 9090 which is reserved by knative", validationErrors["expose.ports.metrics.containerPort"].Error())
+
+	appConfig.Workload = AppWorkload_Deployment
+	appConfig.Expose.Scope = AppExposeScope_Cluster
+
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfigExpose_PortNames(t *testing.T) {
+	expose := &AppConfigExpose{Ports: map[string]AppConfigPort{"metrics": {}, "admin": {}}}
+
+	assert.Equal(t, []string{"admin", "metrics"}, expose.PortNames())
+	assert.Empty(t, (&AppConfigExpose{}).PortNames())
+}
+
+func Test_AppConfig_ValidateExposeRequestSettings(t *testing.T) {
+	tests := []struct {
+		containerConcurrency *int64
+		timeoutSeconds       *int64
+		errs                 map[string]string
+	}{
+		{nil, nil, nil},
+		{int64Ptr(0), int64Ptr(1), nil},
+		{int64Ptr(100), int64Ptr(3600), nil},
+		{int64Ptr(-1), int64Ptr(0), map[string]string{
+			"expose.containerConcurrency": "must be no less than 0",
+			"expose.timeoutSeconds":       "must be no less than 1",
+		}},
+	}
+
+	for _, tt := range tests {
+		appConfig := createMinAppConfig()
+		appConfig.Expose.ContainerConcurrency = tt.containerConcurrency
+		appConfig.Expose.TimeoutSeconds = tt.timeoutSeconds
+
+		err := appConfig.Validate()
+
+		if tt.errs == nil {
+			assert.NoError(t, err)
+		} else {
+			require.IsType(t, validation.Errors{}, err)
+			validationErrors := err.(validation.Errors)
+			assert.Len(t, validationErrors, len(tt.errs))
+			for field, message := range tt.errs {
+				assert.Equal(t, message, validationErrors[field].Error(), field)
+			}
+		}
+	}
+}
+
+func Test_AppConfig_ValidateSecurityOptOut(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Security = &AppConfigSecurity{OptOut: []string{SecuritySetting_ReadOnlyRootFilesystem, "privileged"}}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, `the entry "privileged" is not valid: must be one of: runAsNonRoot, readOnlyRootFilesystem, dropCapabilities, seccompProfile`,
+		validationErrors["security.optOut"].Error())
+}
+
+func Test_AppConfig_ValidateServiceAccountAnnotations(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.ServiceAccount = &AppConfigServiceAccount{
+		Annotations: map[string]string{
+			"iam.gke.io/gcp-service-account": "myapp@myproject.iam.gserviceaccount.com",
+			"owner":                          "team-a",
+			"Bad/Key":                        "value",
+		},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Contains(t, validationErrors["serviceAccount.annotations.Bad/Key"].Error(), "must be a valid annotation key (e.g. iam.gke.io/gcp-service-account)")
+}
+
+func Test_AppConfig_OptsOutOf(t *testing.T) {
+	appConfig := createMinAppConfig()
+	assert.False(t, appConfig.OptsOutOf(SecuritySetting_RunAsNonRoot))
+
+	appConfig.Security = &AppConfigSecurity{OptOut: []string{SecuritySetting_RunAsNonRoot}}
+
+	assert.True(t, appConfig.OptsOutOf(SecuritySetting_RunAsNonRoot))
+	assert.False(t, appConfig.OptsOutOf(SecuritySetting_SeccompProfile))
+}
+
+func Test_AppConfig_AllowFromSources(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Expose.AllowFrom = []string{"checkout", "billing.payments", "ns:ops"}
+
+	result := appConfig.AllowFromSources()
+
+	assert.Equal(t, []AllowFromSource{
+		{App: "checkout", Namespace: "myns"},
+		{App: "billing", Namespace: "payments"},
+		{Namespace: "ops"},
+	}, result)
+}
+
+func Test_AppConfig_AllowFromSources_NoExpose(t *testing.T) {
+	appConfig := &AppConfig{}
+
+	assert.Empty(t, appConfig.AllowFromSources())
+}
+
+func Test_ApplyOverrides_ExposeAppliesDefaults(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{Name: "myapp"},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Expose: &AppConfigExpose{ContainerPort: 8080},
+			},
+		},
+	}
+	require.NoError(t, appConfig.ApplyDefaults())
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.Equal(t, AppWorkload_Deployment, result.Workload)
+	assert.EqualValues(t, 8080, result.Expose.ContainerPort)
+	assert.Equal(t, AppExposeProtocol_HTTP, result.Expose.Protocol)
+	assert.Equal(t, AppExposeScope_Cluster, result.Expose.Scope)
+	// Ensure that the override is not mutated
+	assert.Empty(t, appConfig.Overrides["prod"].Expose.Protocol)
+}
+
+func Test_ApplyOverrides_ImageExposeAndHealthCheck(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Image: "mirror.example.com/myimage",
+				Expose: &AppConfigExpose{
+					ContainerPort: 8080,
+					Scope:         AppExposeScope_External,
+				},
+				HealthCheck: &AppConfigHealthCheck{
+					Path: "/ready",
+				},
+			},
+		},
+	}
+	appConfig.Expose.Scope = AppExposeScope_Cluster
+	appConfig.Expose.Protocol = "http2"
+	appConfig.HealthCheck = &AppConfigHealthCheck{Path: "/health", PeriodSeconds: int32Ptr(5)}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.Equal(t, "mirror.example.com/myimage", result.Image)
+	assert.EqualValues(t, 8080, result.Expose.ContainerPort)
+	assert.Equal(t, AppExposeScope_External, result.Expose.Scope)
+	assert.Equal(t, "http2", result.Expose.Protocol)
+	assert.Equal(t, "/ready", result.HealthCheck.Path)
+	assert.EqualValues(t, 5, *result.HealthCheck.PeriodSeconds)
+	// Ensure that the app is not mutated
+	assert.Equal(t, "myimage", appConfig.Image)
+	assert.Equal(t, AppExposeScope_Cluster, appConfig.Expose.Scope)
+	assert.Equal(t, "/health", appConfig.HealthCheck.Path)
+}
+
+func Test_ApplyOverrides_HealthCheckModeChangeReplaces(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				HealthCheck: &AppConfigHealthCheck{
+					Mode: AppHealthCheckMode_TCP,
+				},
+				Liveness: &AppConfigHealthCheck{
+					Mode: AppHealthCheckMode_HTTP,
+					Path: "/live",
+				},
+			},
+		},
+	}
+	appConfig.HealthCheck = &AppConfigHealthCheck{Path: "/health", PeriodSeconds: int32Ptr(5)}
+	appConfig.Liveness = &AppConfigHealthCheck{Path: "/healthz", PeriodSeconds: int32Ptr(10)}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	require.NoError(t, err)
+	assert.Equal(t, &AppConfigHealthCheck{Mode: AppHealthCheckMode_TCP}, result.HealthCheck)
+	// The mode is unchanged since an empty mode is http so the liveness probe is merged
+	assert.Equal(t, "/live", result.Liveness.Path)
+	assert.EqualValues(t, 10, *result.Liveness.PeriodSeconds)
+	assert.NoError(t, appConfig.Validate())
+}
+
+func Test_AppConfigWithOverrides_ValidateImageExposeAndHealthCheck(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: *createMinAppConfig(),
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Image: "myimage:0.0.1",
+				Expose: &AppConfigExpose{
+					Scope: "public",
+				},
+				HealthCheck: &AppConfigHealthCheck{
+					Mode: "udp",
+				},
+			},
+		},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 3)
+	assert.Contains(t, validationErrors, "environmentOverrides.prod.image")
+	assert.Contains(t, validationErrors, "environmentOverrides.prod.expose.scope")
+	assert.Contains(t, validationErrors, "environmentOverrides.prod.healthcheck.mode")
+}
+
+func Test_AppConfig_ValidateSecrets(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Files = map[string]string{"/etc/config.yaml": "a: b"}
+	appConfig.Secrets = map[string]AppConfigSecret{
+		"tls-key":    {Path: "/etc/tls/tls.key", Mode: "0400"},
+		"creds":      {Path: "/etc/tls/tls.key"},
+		"config":     {Path: "/etc/config.yaml"},
+		"badpath":    {Path: "relative"},
+		"badmode":    {Path: "/etc/badmode", Mode: "999"},
+		"modeonly":   {Mode: "0400"},
+		"envonly":    {},
+		"short-mode": {Path: "/etc/short", Mode: "644"},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 5)
+	assert.Equal(t, `the path "/etc/tls/tls.key" is already in use`, validationErrors["secrets.tls-key.path"].Error())
+	assert.Equal(t, `the path "/etc/config.yaml" is already in use`, validationErrors["secrets.config.path"].Error())
+	assert.Contains(t, validationErrors["secrets.badpath.path"].Error(), `The file path "relative" is not valid`)
+	assert.Equal(t, "must be an octal file mode (e.g. 0400)", validationErrors["secrets.badmode.mode"].Error())
+	assert.Equal(t, "must be blank unless the path is set", validationErrors["secrets.modeonly.mode"].Error())
+}
+
+func Test_AppConfigSecret_FileMode(t *testing.T) {
+	assert.Nil(t, AppConfigSecret{}.FileMode())
+	assert.Nil(t, AppConfigSecret{Mode: "999"}.FileMode())
+	assert.Equal(t, int32Ptr(0400), AppConfigSecret{Mode: "0400"}.FileMode())
+	assert.Equal(t, int32Ptr(0644), AppConfigSecret{Mode: "644"}.FileMode())
+}
+
+func Test_ApplyOverrides_Secrets(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			OverrideableAppConfig: OverrideableAppConfig{
+				Secrets: map[string]AppConfigSecret{
+					"creds":   {Path: "/etc/creds.json"},
+					"tls-key": {Path: "/etc/tls.key"},
+				},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Secrets: map[string]AppConfigSecret{
+					"tls-key": {Path: "/etc/prod/tls.key", Mode: "0400"},
+				},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]AppConfigSecret{
+		"creds":   {Path: "/etc/creds.json"},
+		"tls-key": {Path: "/etc/prod/tls.key", Mode: "0400"},
+	}, result.Secrets)
+	assert.Len(t, appConfig.Secrets, 2)
+	assert.Equal(t, "/etc/tls.key", appConfig.Secrets["tls-key"].Path)
+}
+
+func Test_AppConfig_ValidateJobs(t *testing.T) {
+	appConfig := createMinAppConfig()
+	appConfig.Jobs = map[string]AppConfigJob{
+		"cleanup":  {Schedule: "*/15 * * * *", Command: []string{"cleanup"}, ConcurrencyPolicy: AppJobConcurrencyPolicy_Replace, HistoryLimit: int32Ptr(3)},
+		"report":   {Schedule: "@weekly", Command: []string{"report"}},
+		"Bad_Name": {Schedule: "@daily", Command: []string{"bad"}},
+		"badcron":  {Schedule: "* * *", Command: []string{"badcron"}},
+		"empty":    {},
+		"invalid":  {Schedule: "0 3 * * *", Command: []string{" "}, ConcurrencyPolicy: "queue", HistoryLimit: int32Ptr(-1)},
+	}
+
+	err := appConfig.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 7)
+	assert.Equal(t, "must be lowercase, alphanumeric, and start with a letter", validationErrors["jobs.Bad_Name"].Error())
+	assert.Equal(t, `must be a cron schedule (e.g. "0 3 * * *")`, validationErrors["jobs.badcron.schedule"].Error())
+	assert.Equal(t, "cannot be blank", validationErrors["jobs.empty.schedule"].Error())
+	assert.Equal(t, "cannot be blank", validationErrors["jobs.empty.command"].Error())
+	assert.Equal(t, "must not contain blank values", validationErrors["jobs.invalid.command"].Error())
+	assert.Equal(t, "must be one of: allow, forbid, replace", validationErrors["jobs.invalid.concurrencyPolicy"].Error())
+	assert.Equal(t, "must be no less than 0", validationErrors["jobs.invalid.historyLimit"].Error())
+}
+
+func Test_ApplyOverrides_Jobs(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{
+		AppConfig: AppConfig{
+			OverrideableAppConfig: OverrideableAppConfig{
+				Jobs: map[string]AppConfigJob{
+					"cleanup": {Schedule: "@hourly", Command: []string{"cleanup"}},
+					"report":  {Schedule: "@daily", Command: []string{"report"}, HistoryLimit: int32Ptr(1)},
+				},
+			},
+		},
+		Overrides: map[string]OverrideableAppConfig{
+			"prod": {
+				Jobs: map[string]AppConfigJob{
+					"report": {Schedule: "0 3 * * *", Command: []string{"report", "--all"}},
+				},
+			},
+		},
+	}
+
+	result, err := appConfig.ApplyOverrides("prod")
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]AppConfigJob{
+		"cleanup": {Schedule: "@hourly", Command: []string{"cleanup"}},
+		"report":  {Schedule: "0 3 * * *", Command: []string{"report", "--all"}},
+	}, result.Jobs)
+	assert.Equal(t, "@daily", appConfig.Jobs["report"].Schedule)
+}
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
 func createMinAppConfig() *AppConfig {
 	appConfig := &AppConfig{}
 	_ = copier.Copy(appConfig, minimumValidAppConfig)
@@ -0,0 +1,161 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// AppConfigApiVersionV1Beta1 is assumed for app configs written before the apiVersion field was introduced
+	AppConfigApiVersionV1Beta1 = "riser.dev/v1beta1"
+	AppConfigApiVersionV1      = "riser.dev/v1"
+	// AppConfigApiVersion is the current app config version. All app configs are converted to this version before they are used.
+	AppConfigApiVersion = AppConfigApiVersionV1
+)
+
+// appConfigConverter converts an app config in its raw form from one version to the next
+type appConfigConverter struct {
+	toVersion string
+	convert   func(raw map[string]interface{}) error
+}
+
+// appConfigConverters maps each deprecated version to the converter that upgrades it to the next version. Add a converter here when
+// introducing a new version so that older app configs continue to work.
+var appConfigConverters = map[string]appConfigConverter{
+	// v1 has the same shape as v1beta1
+	AppConfigApiVersionV1Beta1: {toVersion: AppConfigApiVersionV1, convert: func(map[string]interface{}) error { return nil }},
+}
+
+// SupportedAppConfigApiVersions returns all versions that may be used in an app config, with the current version first
+func SupportedAppConfigApiVersions() []string {
+	deprecated := []string{}
+	for version := range appConfigConverters {
+		deprecated = append(deprecated, version)
+	}
+	sort.Strings(deprecated)
+	return append([]string{AppConfigApiVersion}, deprecated...)
+}
+
+// UnmarshalJSON keeps the apiVersion as is so that clients re-encode the app config in the version that it was written in. The raw
+// form of an app config with a deprecated or implied version is kept so that the server may convert it with Convert.
+func (cfg *AppConfigWithOverrides) UnmarshalJSON(data []byte) error {
+	raw := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Avoid converting numbers to floats
+	decoder.UseNumber()
+	err := decoder.Decode(&raw)
+	if err != nil {
+		return err
+	}
+
+	out := appConfigWithOverrides{}
+	err = json.Unmarshal(data, &out)
+	if err != nil {
+		return err
+	}
+
+	*cfg = AppConfigWithOverrides(out)
+	if _, ok := appConfigConverters[rawAppConfigApiVersion(raw)]; ok {
+		cfg.unconverted = raw
+	}
+	return nil
+}
+
+// appConfigWithOverrides is a type without the UnmarshalJSON method to avoid recursion
+type appConfigWithOverrides AppConfigWithOverrides
+
+// Convert converts an unmarshalled app config from a deprecated version to the current version. This is only done by the server so that
+// clients never see a version that they did not write. Convert must be called before the app config is changed since the changes are
+// replaced with the converted raw form.
+func (cfg *AppConfigWithOverrides) Convert() error {
+	if cfg.unconverted == nil {
+		return nil
+	}
+
+	raw := cfg.unconverted
+	// Configs written before the apiVersion field was introduced are converted without a deprecation warning
+	implied := raw["apiVersion"] == nil
+	convertedFrom, err := convertAppConfig(raw)
+	if err != nil {
+		return err
+	}
+
+	converted, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	out := appConfigWithOverrides{}
+	err = json.Unmarshal(converted, &out)
+	if err != nil {
+		return err
+	}
+
+	*cfg = AppConfigWithOverrides(out)
+	if !implied {
+		cfg.convertedFrom = convertedFrom
+	}
+	return nil
+}
+
+// ConvertedFrom returns the deprecated version that the app config was converted from. Empty if no conversion was needed or the
+// app config did not set an apiVersion.
+func (cfg *AppConfigWithOverrides) ConvertedFrom() string {
+	return cfg.convertedFrom
+}
+
+// DeprecationWarning returns a warning when the app config was converted from a deprecated version
+func (cfg *AppConfigWithOverrides) DeprecationWarning() string {
+	if cfg.convertedFrom == "" {
+		return ""
+	}
+	return fmt.Sprintf("The apiVersion %q is deprecated and was converted to %q. Update your app config to use \"apiVersion: %s\"",
+		cfg.convertedFrom, AppConfigApiVersion, AppConfigApiVersion)
+}
+
+// rawAppConfigApiVersion returns the version of a raw app config. Configs without an apiVersion are assumed to be v1beta1.
+func rawAppConfigApiVersion(raw map[string]interface{}) string {
+	version, _ := raw["apiVersion"].(string)
+	if version == "" {
+		return AppConfigApiVersionV1Beta1
+	}
+	return version
+}
+
+// convertAppConfig converts the raw app config to the current version in place and returns the version that it was converted from
+func convertAppConfig(raw map[string]interface{}) (convertedFrom string, err error) {
+	version := rawAppConfigApiVersion(raw)
+	fromVersion := version
+	for {
+		converter, ok := appConfigConverters[version]
+		if !ok {
+			break
+		}
+		err = converter.convert(raw)
+		if err != nil {
+			return "", fmt.Errorf("error converting app config from %q to %q: %v", version, converter.toVersion, err)
+		}
+		version = converter.toVersion
+		raw["apiVersion"] = version
+	}
+
+	if version != AppConfigApiVersion || fromVersion == version {
+		return "", nil
+	}
+	return fromVersion, nil
+}
+
+// validAppConfigApiVersion allows deprecated versions since clients validate app configs before the server converts them
+func validAppConfigApiVersion(value interface{}) error {
+	version, _ := value.(string)
+	if version == "" {
+		return nil
+	}
+	if _, ok := appConfigConverters[version]; !ok && version != AppConfigApiVersion {
+		return fmt.Errorf("unsupported apiVersion: must be one of: %s", strings.Join(SupportedAppConfigApiVersions(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AppConfigWithOverrides_UnmarshalJSON_Current(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{}
+
+	err := json.Unmarshal([]byte(`{"apiVersion": "riser.dev/v1", "name": "myapp", "expose": {"containerPort": 8000}}`), appConfig)
+
+	require.NoError(t, err)
+	assert.Equal(t, AppConfigApiVersion, appConfig.ApiVersion)
+	assert.EqualValues(t, "myapp", appConfig.Name)
+	assert.EqualValues(t, 8000, appConfig.Expose.ContainerPort)
+	assert.Empty(t, appConfig.ConvertedFrom())
+	assert.Empty(t, appConfig.DeprecationWarning())
+}
+
+func Test_AppConfigWithOverrides_UnmarshalJSON_KeepsDeprecatedVersion(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{}
+
+	err := json.Unmarshal([]byte(`{"apiVersion": "riser.dev/v1beta1", "name": "myapp"}`), appConfig)
+
+	require.NoError(t, err)
+	assert.Equal(t, AppConfigApiVersionV1Beta1, appConfig.ApiVersion)
+	assert.Empty(t, appConfig.ConvertedFrom())
+	encoded, err := json.Marshal(appConfig)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"apiVersion":"riser.dev/v1beta1"`)
+}
+
+func Test_AppConfigWithOverrides_Convert(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{}
+	require.NoError(t, json.Unmarshal([]byte(`{"apiVersion": "riser.dev/v1beta1", "name": "myapp", "environmentOverrides": {"prod": {"image": "myimage"}}}`), appConfig))
+
+	err := appConfig.Convert()
+
+	require.NoError(t, err)
+	assert.Equal(t, AppConfigApiVersion, appConfig.ApiVersion)
+	assert.EqualValues(t, "myapp", appConfig.Name)
+	assert.Equal(t, "myimage", appConfig.Overrides["prod"].Image)
+	assert.Equal(t, AppConfigApiVersionV1Beta1, appConfig.ConvertedFrom())
+	assert.Contains(t, appConfig.DeprecationWarning(), `The apiVersion "riser.dev/v1beta1" is deprecated and was converted to "riser.dev/v1"`)
+}
+
+func Test_AppConfigWithOverrides_Convert_ImpliedV1Beta1(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{}
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "myapp"}`), appConfig))
+	assert.Empty(t, appConfig.ApiVersion)
+
+	err := appConfig.Convert()
+
+	require.NoError(t, err)
+	assert.Equal(t, AppConfigApiVersion, appConfig.ApiVersion)
+	assert.EqualValues(t, "myapp", appConfig.Name)
+	assert.Empty(t, appConfig.ConvertedFrom())
+	assert.Empty(t, appConfig.DeprecationWarning())
+}
+
+func Test_AppConfigWithOverrides_Convert_NotUnmarshalled(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{AppConfig: AppConfig{ApiVersion: AppConfigApiVersionV1Beta1, Name: "myapp"}}
+
+	err := appConfig.Convert()
+
+	require.NoError(t, err)
+	assert.Equal(t, AppConfigApiVersionV1Beta1, appConfig.ApiVersion)
+	assert.EqualValues(t, "myapp", appConfig.Name)
+}
+
+func Test_AppConfigWithOverrides_Convert_Error(t *testing.T) {
+	appConfigConverters["riser.dev/vtest"] = appConfigConverter{
+		toVersion: AppConfigApiVersion,
+		convert:   func(map[string]interface{}) error { return assert.AnError },
+	}
+	defer delete(appConfigConverters, "riser.dev/vtest")
+	appConfig := &AppConfigWithOverrides{}
+	require.NoError(t, json.Unmarshal([]byte(`{"apiVersion": "riser.dev/vtest", "name": "myapp"}`), appConfig))
+
+	err := appConfig.Convert()
+
+	assert.Contains(t, err.Error(), `error converting app config from "riser.dev/vtest" to "riser.dev/v1"`)
+}
+
+func Test_AppConfigWithOverrides_UnmarshalJSON_Unknown(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{}
+
+	err := json.Unmarshal([]byte(`{"apiVersion": "riser.dev/v9", "name": "myapp"}`), appConfig)
+
+	require.NoError(t, err)
+	assert.Equal(t, "riser.dev/v9", appConfig.ApiVersion)
+	require.NoError(t, appConfig.Convert())
+	assert.Equal(t, "riser.dev/v9", appConfig.ApiVersion)
+	assert.Empty(t, appConfig.ConvertedFrom())
+}
+
+func Test_AppConfigWithOverrides_UnmarshalJSON_Invalid(t *testing.T) {
+	appConfig := &AppConfigWithOverrides{}
+
+	err := json.Unmarshal([]byte(`{"name": 1}`), appConfig)
+
+	assert.Error(t, err)
+}
+
+func Test_convertAppConfig_Error(t *testing.T) {
+	appConfigConverters["riser.dev/vtest"] = appConfigConverter{
+		toVersion: AppConfigApiVersion,
+		convert:   func(map[string]interface{}) error { return assert.AnError },
+	}
+	defer delete(appConfigConverters, "riser.dev/vtest")
+
+	result, err := convertAppConfig(map[string]interface{}{"apiVersion": "riser.dev/vtest"})
+
+	assert.Empty(t, result)
+	assert.Contains(t, err.Error(), `error converting app config from "riser.dev/vtest" to "riser.dev/v1"`)
+}
+
+func Test_convertAppConfig_Chained(t *testing.T) {
+	appConfigConverters["riser.dev/v0"] = appConfigConverter{
+		toVersion: AppConfigApiVersionV1Beta1,
+		convert: func(raw map[string]interface{}) error {
+			raw["name"] = raw["appName"]
+			delete(raw, "appName")
+			return nil
+		},
+	}
+	defer delete(appConfigConverters, "riser.dev/v0")
+	raw := map[string]interface{}{"apiVersion": "riser.dev/v0", "appName": "myapp"}
+
+	result, err := convertAppConfig(raw)
+
+	require.NoError(t, err)
+	assert.Equal(t, "riser.dev/v0", result)
+	assert.Equal(t, map[string]interface{}{"apiVersion": AppConfigApiVersion, "name": "myapp"}, raw)
+}
+
+func Test_SupportedAppConfigApiVersions(t *testing.T) {
+	assert.Equal(t, []string{"riser.dev/v1", "riser.dev/v1beta1"}, SupportedAppConfigApiVersions())
+}
+
+func Test_validAppConfigApiVersion(t *testing.T) {
+	assert.NoError(t, validAppConfigApiVersion(""))
+	assert.NoError(t, validAppConfigApiVersion(AppConfigApiVersion))
+	assert.NoError(t, validAppConfigApiVersion(AppConfigApiVersionV1Beta1))
+	assert.EqualError(t, validAppConfigApiVersion("riser.dev/v9"), "unsupported apiVersion: must be one of: riser.dev/v1, riser.dev/v1beta1")
+}
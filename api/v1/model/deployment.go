@@ -1,10 +1,27 @@
 package model
 
-import validation "github.com/go-ozzo/ozzo-validation/v3"
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+)
 
 type SaveDeploymentRequest struct {
 	DeploymentMeta `json:",inline"`
 	App            *AppConfigWithOverrides `json:"app"`
+	// ExpectedRiserRevision optionally rejects the request with a conflict if the deployment's current revision does not match.
+	// The If-Match header may be used instead.
+	ExpectedRiserRevision int64 `json:"expectedRiserRevision,omitempty"`
+	// OverrideLock allows a locked deployment to be updated
+	OverrideLock bool `json:"overrideLock,omitempty"`
+}
+
+// Convert converts the app config from a deprecated version
+func (d *SaveDeploymentRequest) Convert() error {
+	if d.App == nil {
+		return nil
+	}
+	return d.App.Convert()
 }
 
 func (d *SaveDeploymentRequest) ApplyDefaults() error {
@@ -17,13 +34,18 @@ func (d *SaveDeploymentRequest) ApplyDefaults() error {
 func (d SaveDeploymentRequest) Validate() error {
 	return validation.ValidateStruct(&d,
 		validation.Field(&d.DeploymentMeta),
-		validation.Field(&d.App, validation.Required))
+		validation.Field(&d.App, validation.Required),
+		validation.Field(&d.ExpectedRiserRevision, validation.Min(int64(0))))
 }
 
 type SaveDeploymentResponse struct {
 	RiserRevision int64          `json:"riserRevision"`
 	Message       string         `json:"message"`
 	DryRunCommits []DryRunCommit `json:"dryRunCommits,omitempty"`
+	// Warnings are potential problems that did not prevent the deployment (e.g. a reference to an app that is not deployed yet)
+	Warnings []string `json:"warnings,omitempty"`
+	// AppConfigLayers are the layers that were merged to create the app config in the order that they were applied. Only set for dry runs.
+	AppConfigLayers []AppConfigLayer `json:"appConfigLayers,omitempty"`
 }
 
 type DryRunCommit struct {
@@ -34,6 +56,8 @@ type DryRunCommit struct {
 type DryRunFile struct {
 	Name     string `json:"name"`
 	Contents string `json:"contents"`
+	// Delete is true when the file would be removed
+	Delete bool `json:"delete,omitempty"`
 }
 
 type DeploymentMeta struct {
@@ -55,3 +79,22 @@ func (d DeploymentMeta) Validate() error {
 type DeploymentDocker struct {
 	Tag string `json:"tag"`
 }
+
+// DeploymentLockRequest locks a deployment in an environment. Changes to the deployment are rejected until it's unlocked, the lock expires,
+// or a change explicitly overrides the lock.
+type DeploymentLockRequest struct {
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+func (d DeploymentLockRequest) Validate() error {
+	return validation.ValidateStruct(&d,
+		validation.Field(&d.Reason, validation.Required, validation.RuneLength(1, 500)))
+}
+
+type DeploymentLock struct {
+	Holder    string     `json:"holder"`
+	Reason    string     `json:"reason"`
+	Created   time.Time  `json:"created"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
@@ -46,6 +46,17 @@ func Test_DeploymentRequest_ValidateName(t *testing.T) {
 	assert.Equal(t, "must be lowercase, alphanumeric, and start with a letter", validationErrors["name"].Error())
 }
 
+func Test_DeploymentRequest_ValidateExpectedRiserRevision(t *testing.T) {
+	model := createMinDeploymentRequest()
+	model.ExpectedRiserRevision = -1
+
+	err := model.Validate()
+	assert.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be no less than 0", validationErrors["expectedRiserRevision"].Error())
+}
+
 func Test_DeploymentRequest_ValidateRequired(t *testing.T) {
 	model := &SaveDeploymentRequest{}
 	err := model.Validate()
@@ -67,6 +78,15 @@ func Test_DeploymentRequest_ValidateEmptyApp(t *testing.T) {
 	assert.IsType(t, validation.Errors{}, err)
 }
 
+func Test_DeploymentLockRequest_Validate(t *testing.T) {
+	assert.NoError(t, DeploymentLockRequest{Reason: "incident"}.Validate())
+
+	err := DeploymentLockRequest{}.Validate()
+
+	assert.IsType(t, validation.Errors{}, err)
+	assertFieldsRequired(t, err.(validation.Errors), "reason")
+}
+
 func createMinDeploymentRequest() *SaveDeploymentRequest {
 	model := &SaveDeploymentRequest{}
 	_ = copier.Copy(model, minimumValidDeploymentRequest)
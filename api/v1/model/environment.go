@@ -1,10 +1,169 @@
 package model
 
+import (
+	"fmt"
+	"regexp"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/pkg/errors"
+)
+
+const EnvironmentSeccompProfile_RuntimeDefault = "RuntimeDefault"
+
+var (
+	environmentSeccompProfiles = []string{EnvironmentSeccompProfile_RuntimeDefault}
+	// A Linux capability without the CAP_ prefix (e.g. NET_RAW) or ALL
+	capabilityPattern = regexp.MustCompile("^[A-Z][A-Z_]*$")
+)
+
 type EnvironmentMeta struct {
 	Name string
 }
 
 type EnvironmentConfig struct {
-	SealedSecretCert  []byte `json:"sealedSecretCert,omitempty"`
-	PublicGatewayHost string `json:"publicGatewayHost,omitempty"`
+	SealedSecretCert  []byte                `json:"sealedSecretCert,omitempty"`
+	PublicGatewayHost string                `json:"publicGatewayHost,omitempty"`
+	Resources         *EnvironmentResources `json:"resources,omitempty"`
+	Requests          *EnvironmentRequests  `json:"requests,omitempty"`
+	Security          *EnvironmentSecurity  `json:"security,omitempty"`
+	// TLSClusterIssuer is the name of the cert-manager ClusterIssuer used to issue certificates for custom domains. TLS is disabled when empty.
+	TLSClusterIssuer string `json:"tlsClusterIssuer,omitempty"`
+	// DefaultDenyNamespaces are namespaces where requests between apps are denied unless allowed by an app's expose.allowFrom
+	DefaultDenyNamespaces []NamespaceName `json:"defaultDenyNamespaces,omitempty"`
+	// AllowedServiceAccountAnnotations are the annotation keys that apps may set with serviceAccount.annotations
+	AllowedServiceAccountAnnotations []string `json:"allowedServiceAccountAnnotations,omitempty"`
+	// AppDefaults are merged into the config of every app deployed to the environment. The app's config and its environmentOverrides
+	// take precedence.
+	AppDefaults *OverrideableAppConfig `json:"appDefaults,omitempty"`
+	// Policies are rules that every deployment to the environment must satisfy (e.g. app.autoscale.min must be at least 2)
+	Policies []EnvironmentPolicy `json:"policies,omitempty"`
+}
+
+// EnvironmentResources contains container resource defaults and maximums for all apps deployed to an environment. The defaults only apply
+// to values that are still unset after appDefaults.resources and the app's config are merged.
+type EnvironmentResources struct {
+	DefaultRequests *ResourceQuantities `json:"defaultRequests,omitempty"`
+	DefaultLimits   *ResourceQuantities `json:"defaultLimits,omitempty"`
+	// Max applies to both requests and limits
+	Max *ResourceQuantities `json:"max,omitempty"`
+}
+
+// EnvironmentRequests contains maximums for how apps deployed to an environment handle requests
+type EnvironmentRequests struct {
+	// MaxContainerConcurrency is the max expose.containerConcurrency. Apps may not allow unlimited concurrent requests when set.
+	MaxContainerConcurrency *int64 `json:"maxContainerConcurrency,omitempty"`
+	// MaxTimeoutSeconds is the max expose.timeoutSeconds. It should not exceed KNative's max-revision-timeout-seconds.
+	MaxTimeoutSeconds *int64 `json:"maxTimeoutSeconds,omitempty"`
+}
+
+// EnvironmentSecurity contains the security settings that are applied to the containers of all apps deployed to an environment
+type EnvironmentSecurity struct {
+	RunAsNonRoot           bool `json:"runAsNonRoot,omitempty"`
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+	// DropCapabilities are the Linux capabilities that are dropped from containers (e.g. ALL)
+	DropCapabilities []string `json:"dropCapabilities,omitempty"`
+	// SeccompProfile is the seccomp profile type of containers. Only RuntimeDefault is currently supported.
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+	// AllowedOptOuts are the settings that apps may opt out of with security.optOut
+	AllowedOptOuts []string `json:"allowedOptOuts,omitempty"`
+}
+
+func (v EnvironmentConfig) Validate() error {
+	var validationErrors error
+	for idx, namespace := range v.DefaultDenyNamespaces {
+		validationErrors = mergeValidationErrors(validationErrors,
+			validation.Errors{fmt.Sprintf("%d", idx): namespace.Validate()}.Filter(), "defaultDenyNamespaces")
+	}
+
+	annotationsErr := validation.Validate(v.AllowedServiceAccountAnnotations, validation.By(validAnnotationKeys))
+	validationErrors = mergeValidationErrors(validationErrors, validation.Errors{"allowedServiceAccountAnnotations": annotationsErr}.Filter(), "")
+
+	if v.Requests != nil {
+		requestsErr := validation.ValidateStruct(v.Requests,
+			validation.Field(&v.Requests.MaxContainerConcurrency, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(int64(1))),
+			validation.Field(&v.Requests.MaxTimeoutSeconds, validation.NilOrNotEmpty.Error("must be no less than 1"), validation.Min(int64(1))),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, requestsErr, "requests")
+	}
+
+	if v.Security != nil {
+		securityErr := validation.ValidateStruct(v.Security,
+			validation.Field(&v.Security.DropCapabilities, validation.By(validCapabilities)),
+			validation.Field(&v.Security.SeccompProfile, inStrings(environmentSeccompProfiles)),
+			validation.Field(&v.Security.AllowedOptOuts, validation.By(validSecuritySettings)),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, securityErr, "security")
+	}
+
+	if v.AppDefaults != nil {
+		validationErrors = mergeValidationErrors(validationErrors, validateEnvironmentAppDefaults(v.AppDefaults), "appDefaults")
+	}
+
+	validationErrors = mergeValidationErrors(validationErrors, validateEnvironmentPolicies(v.Policies), "")
+
+	if v.Resources == nil {
+		return validationErrors
+	}
+
+	// Defaults are compared with the maximums once merged with the existing config since the config may be set partially
+	for fieldName, quantities := range map[string]*ResourceQuantities{
+		"defaultRequests": v.Resources.DefaultRequests,
+		"defaultLimits":   v.Resources.DefaultLimits,
+	} {
+		if quantities == nil {
+			continue
+		}
+		err := validation.ValidateStruct(quantities,
+			validation.Field(&quantities.CpuCores, validation.Min(float32(0))),
+			validation.Field(&quantities.MemoryMB, validation.Min(0)),
+		)
+		validationErrors = mergeValidationErrors(validationErrors, err, fmt.Sprintf("resources.%s", fieldName))
+	}
+
+	return validationErrors
+}
+
+// validateEnvironmentAppDefaults does not allow fields that are specific to an app. Expose is not allowed since it would turn workers into
+// apps that receive requests, and secrets and jobs would apply to apps that do not have them.
+func validateEnvironmentAppDefaults(cfg *OverrideableAppConfig) error {
+	notSupported := validation.By(func(v interface{}) error {
+		if !validation.IsEmpty(v) {
+			return errors.New("is not supported in environment app defaults")
+		}
+		return nil
+	})
+	validationErrors := validation.ValidateStruct(cfg,
+		validation.Field(&cfg.Image, notSupported),
+		validation.Field(&cfg.Command, notSupported),
+		validation.Field(&cfg.Args, notSupported),
+		validation.Field(&cfg.WorkingDir, notSupported),
+		validation.Field(&cfg.Expose, notSupported),
+		validation.Field(&cfg.Secrets, notSupported),
+		validation.Field(&cfg.Jobs, notSupported),
+	)
+	if validationErrors != nil {
+		return validationErrors
+	}
+
+	return cfg.validate()
+}
+
+func validAnnotationKeys(value interface{}) error {
+	keys, _ := value.([]string)
+	for _, key := range keys {
+		if err := validAnnotationKey(key); err != nil {
+			return fmt.Errorf("the entry %q is not valid: %s", key, err)
+		}
+	}
+	return nil
+}
+
+func validCapabilities(value interface{}) error {
+	capabilities, _ := value.([]string)
+	for _, capability := range capabilities {
+		if !capabilityPattern.MatchString(capability) {
+			return fmt.Errorf("the entry %q is not valid: must be an uppercase Linux capability (e.g. NET_RAW) or ALL", capability)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+)
+
+const (
+	// EnvironmentPolicyType_Range requires a numeric field to be set and between min and max (inclusive)
+	EnvironmentPolicyType_Range = "range"
+	// EnvironmentPolicyType_AllowedPrefixes requires a string field to start with one of the values (e.g. an image registry)
+	EnvironmentPolicyType_AllowedPrefixes = "allowedPrefixes"
+	// EnvironmentPolicyType_DisallowedValues requires a string field to not be one of the values (e.g. the "latest" docker tag)
+	EnvironmentPolicyType_DisallowedValues = "disallowedValues"
+	// EnvironmentPolicyType_Required requires a field to be set
+	EnvironmentPolicyType_Required = "required"
+
+	// The roots of a policy field. See EnvironmentPolicy.Field.
+	EnvironmentPolicyField_AppPrefix = "app."
+	EnvironmentPolicyField_DockerTag = "docker.tag"
+)
+
+var environmentPolicyTypes = []string{
+	EnvironmentPolicyType_Range,
+	EnvironmentPolicyType_AllowedPrefixes,
+	EnvironmentPolicyType_DisallowedValues,
+	EnvironmentPolicyType_Required,
+}
+
+// EnvironmentPolicy is a rule that every deployment to an environment must satisfy
+type EnvironmentPolicy struct {
+	// Name is returned with any violation of the policy
+	Name string `json:"name"`
+	// Type is the type of rule (e.g. range). See the EnvironmentPolicyType constants.
+	Type string `json:"type"`
+	// Field is the path of the field that the policy applies to. This is either an app config field prefixed with "app."
+	// (e.g. app.autoscale.min) or docker.tag. App config fields must exist in the app config schema (see AppConfigSchema).
+	Field string `json:"field"`
+	// Min and Max are the bounds of a range policy. At least one is required.
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Values are the values of an allowedPrefixes or disallowedValues policy
+	Values []string `json:"values,omitempty"`
+}
+
+func (v EnvironmentPolicy) Validate() error {
+	minRules := []validation.Rule{}
+	maxRules := []validation.Rule{}
+	valuesRules := []validation.Rule{}
+	switch v.Type {
+	case EnvironmentPolicyType_Range:
+		if v.Min == nil {
+			maxRules = append(maxRules, validation.NotNil.Error("min or max is required when the type is range"))
+		} else {
+			maxRules = append(maxRules, validation.Min(*v.Min).Error("must be greater than or equal to min"))
+		}
+		valuesRules = append(valuesRules, blankUnless("the type is allowedPrefixes or disallowedValues"))
+	case EnvironmentPolicyType_AllowedPrefixes, EnvironmentPolicyType_DisallowedValues:
+		minRules = append(minRules, blankUnless("the type is range"))
+		maxRules = append(maxRules, blankUnless("the type is range"))
+		valuesRules = append(valuesRules, validation.Required.Error(fmt.Sprintf("is required when the type is %s", v.Type)))
+	default:
+		minRules = append(minRules, blankUnless("the type is range"))
+		maxRules = append(maxRules, blankUnless("the type is range"))
+		valuesRules = append(valuesRules, blankUnless("the type is allowedPrefixes or disallowedValues"))
+	}
+
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Name, append([]validation.Rule{validation.Required}, RulesNamingIdentifier()...)...),
+		validation.Field(&v.Type, validation.Required, inStrings(environmentPolicyTypes)),
+		validation.Field(&v.Field, validation.Required, validation.By(v.validField)),
+		validation.Field(&v.Min, minRules...),
+		validation.Field(&v.Max, maxRules...),
+		validation.Field(&v.Values, valuesRules...),
+	)
+}
+
+// validField requires the field to exist and to be of a type that the policy can evaluate
+func (v EnvironmentPolicy) validField(value interface{}) error {
+	field, _ := value.(string)
+	var schema *JSONSchema
+	if field == EnvironmentPolicyField_DockerTag {
+		schema = &JSONSchema{Type: "string"}
+	} else if strings.HasPrefix(field, EnvironmentPolicyField_AppPrefix) {
+		schema = appConfigFieldSchema(strings.Split(strings.TrimPrefix(field, EnvironmentPolicyField_AppPrefix), "."))
+	}
+	if schema == nil {
+		return fmt.Errorf("must be %s or an app config field prefixed with %q (e.g. app.autoscale.min)", EnvironmentPolicyField_DockerTag, EnvironmentPolicyField_AppPrefix)
+	}
+
+	switch v.Type {
+	case EnvironmentPolicyType_Range:
+		if !schemaAllowsType(schema, "integer", "number") {
+			return fmt.Errorf("must be a numeric field when the type is %s", v.Type)
+		}
+	case EnvironmentPolicyType_AllowedPrefixes, EnvironmentPolicyType_DisallowedValues:
+		if !schemaAllowsType(schema, "string") {
+			return fmt.Errorf("must be a string field when the type is %s", v.Type)
+		}
+	}
+	return nil
+}
+
+// appConfigFieldSchema returns the schema of an app config field from the JSON names in its path, or nil if there is no such field.
+// Any key of a map field (e.g. env.LOG_LEVEL) is allowed. Environment overrides are not fields since policies are evaluated
+// after the overrides are applied.
+func appConfigFieldSchema(path []string) *JSONSchema {
+	root := AppConfigSchema()
+	schema := root
+	for _, name := range path {
+		schema = resolveSchemaRef(root, schema)
+		if valueSchema, ok := schema.AdditionalProperties.(*JSONSchema); ok {
+			schema = valueSchema
+			continue
+		}
+		property, ok := schema.Properties[name]
+		if !ok || (schema == root && name == "environmentOverrides") {
+			return nil
+		}
+		schema = property
+	}
+	return resolveSchemaRef(root, schema)
+}
+
+func resolveSchemaRef(root *JSONSchema, schema *JSONSchema) *JSONSchema {
+	if schema.Ref == "" {
+		return schema
+	}
+	return root.Definitions[strings.TrimPrefix(schema.Ref, "#/definitions/")]
+}
+
+// schemaAllowsType returns true if the schema allows any of the types. A schema without a type allows any value.
+func schemaAllowsType(schema *JSONSchema, types ...string) bool {
+	var schemaTypes []string
+	switch t := schema.Type.(type) {
+	case nil:
+		return true
+	case string:
+		schemaTypes = []string{t}
+	case []string:
+		schemaTypes = t
+	}
+	for _, schemaType := range schemaTypes {
+		for _, allowed := range types {
+			if schemaType == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func validateEnvironmentPolicies(policies []EnvironmentPolicy) error {
+	var validationErrors error
+	names := map[string]bool{}
+	for idx, policy := range policies {
+		err := policy.Validate()
+		if err == nil && names[policy.Name] {
+			err = validation.Errors{"name": fmt.Errorf("the policy %q is defined more than once", policy.Name)}
+		}
+		names[policy.Name] = true
+		validationErrors = mergeValidationErrors(validationErrors, err, fmt.Sprintf("policies[%d]", idx))
+	}
+	return validationErrors
+}
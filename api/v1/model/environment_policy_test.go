@@ -0,0 +1,84 @@
+package model
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvironmentPolicy_Validate(t *testing.T) {
+	min := float64(2)
+	max := float64(4096)
+	policies := []EnvironmentPolicy{
+		{Name: "min-scale", Type: EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: &min},
+		{Name: "max-memory", Type: EnvironmentPolicyType_Range, Field: "app.resources.memoryMB", Max: &max},
+		{Name: "registry", Type: EnvironmentPolicyType_AllowedPrefixes, Field: "app.image", Values: []string{"registry.example.com/"}},
+		{Name: "no-latest", Type: EnvironmentPolicyType_DisallowedValues, Field: "docker.tag", Values: []string{"latest"}},
+		{Name: "health", Type: EnvironmentPolicyType_Required, Field: "app.healthcheck"},
+		{Name: "log-level", Type: EnvironmentPolicyType_DisallowedValues, Field: "app.env.LOG_LEVEL", Values: []string{"debug"}},
+	}
+
+	for _, policy := range policies {
+		assert.NoError(t, policy.Validate(), policy.Name)
+	}
+}
+
+func Test_EnvironmentPolicy_Validate_Invalid(t *testing.T) {
+	min := float64(2)
+	max := float64(1)
+	tt := []struct {
+		policy   EnvironmentPolicy
+		field    string
+		expected string
+	}{
+		{EnvironmentPolicy{Name: "Bad Name", Type: EnvironmentPolicyType_Required, Field: "app.image"}, "name", "must be lowercase, alphanumeric, and start with a letter"},
+		{EnvironmentPolicy{Name: "policy", Type: "cel", Field: "app.image"}, "type", "must be one of: range, allowedPrefixes, disallowedValues, required"},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Required, Field: "image"}, "field",
+			`must be docker.tag or an app config field prefixed with "app." (e.g. app.autoscale.min)`},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Required, Field: "docker.image"}, "field",
+			`must be docker.tag or an app config field prefixed with "app." (e.g. app.autoscale.min)`},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Required, Field: "app.nope"}, "field",
+			`must be docker.tag or an app config field prefixed with "app." (e.g. app.autoscale.min)`},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Required, Field: "app.environmentOverrides.prod.image"}, "field",
+			`must be docker.tag or an app config field prefixed with "app." (e.g. app.autoscale.min)`},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Range, Field: "app.image", Min: &min}, "field", "must be a numeric field when the type is range"},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_AllowedPrefixes, Field: "app.autoscale.min", Values: []string{"a"}}, "field",
+			"must be a string field when the type is allowedPrefixes"},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Range, Field: "app.autoscale.min"}, "max", "min or max is required when the type is range"},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: &min, Max: &max}, "max", "must be greater than or equal to min"},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: &min, Values: []string{"a"}}, "values",
+			"must be blank unless the type is allowedPrefixes or disallowedValues"},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_AllowedPrefixes, Field: "app.image"}, "values", "is required when the type is allowedPrefixes"},
+		{EnvironmentPolicy{Name: "policy", Type: EnvironmentPolicyType_Required, Field: "app.image", Min: &min}, "min", "must be blank unless the type is range"},
+	}
+
+	for _, test := range tt {
+		err := test.policy.Validate()
+
+		require.IsType(t, validation.Errors{}, err, test.expected)
+		validationErrors := err.(validation.Errors)
+		assert.Len(t, validationErrors, 1, test.expected)
+		require.Contains(t, validationErrors, test.field, test.expected)
+		assert.Equal(t, test.expected, validationErrors[test.field].Error())
+	}
+}
+
+func Test_EnvironmentConfig_Validate_Policies(t *testing.T) {
+	config := EnvironmentConfig{
+		Policies: []EnvironmentPolicy{
+			{Name: "health", Type: EnvironmentPolicyType_Required, Field: "app.healthcheck"},
+			{Name: "health", Type: EnvironmentPolicyType_Required, Field: "app.image"},
+			{Name: "no-latest", Type: EnvironmentPolicyType_DisallowedValues, Field: "docker.tag"},
+		},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, `the policy "health" is defined more than once`, validationErrors["policies[1].name"].Error())
+	assert.Equal(t, "is required when the type is disallowedValues", validationErrors["policies[2].values"].Error())
+}
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_EnvironmentConfig_Validate(t *testing.T) {
+	cpuCores := float32(0.5)
+	maxCpuCores := float32(2)
+	config := EnvironmentConfig{
+		Resources: &EnvironmentResources{
+			DefaultRequests: &ResourceQuantities{CpuCores: &cpuCores, MemoryMB: int32Ptr(128)},
+			DefaultLimits:   &ResourceQuantities{CpuCores: &maxCpuCores, MemoryMB: int32Ptr(512)},
+			Max:             &ResourceQuantities{CpuCores: &maxCpuCores, MemoryMB: int32Ptr(1024)},
+		},
+		Requests: &EnvironmentRequests{MaxContainerConcurrency: int64Ptr(100), MaxTimeoutSeconds: int64Ptr(900)},
+		Security: &EnvironmentSecurity{
+			RunAsNonRoot:     true,
+			DropCapabilities: []string{"ALL"},
+			SeccompProfile:   EnvironmentSeccompProfile_RuntimeDefault,
+			AllowedOptOuts:   []string{SecuritySetting_RunAsNonRoot},
+		},
+	}
+
+	assert.NoError(t, config.Validate())
+	assert.NoError(t, EnvironmentConfig{}.Validate())
+}
+
+func Test_EnvironmentConfig_Validate_NegativeDefaults(t *testing.T) {
+	cpuCores := float32(-1)
+	maxCpuCores := float32(2)
+	config := EnvironmentConfig{
+		Resources: &EnvironmentResources{
+			DefaultRequests: &ResourceQuantities{MemoryMB: int32Ptr(-1)},
+			DefaultLimits:   &ResourceQuantities{CpuCores: &cpuCores},
+			// The defaults are compared with the maximums by the environment service once merged with the existing config
+			Max: &ResourceQuantities{CpuCores: &maxCpuCores, MemoryMB: int32Ptr(1024)},
+		},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must be no less than 0", validationErrors["resources.defaultRequests.memoryMB"].Error())
+	assert.Equal(t, "must be no less than 0", validationErrors["resources.defaultLimits.cpuCores"].Error())
+}
+
+func Test_EnvironmentConfig_Validate_DefaultDenyNamespaces(t *testing.T) {
+	config := EnvironmentConfig{
+		DefaultDenyNamespaces: []NamespaceName{"myns", "kube-system"},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, `namespace names may not begin with "kube-"`, validationErrors["defaultDenyNamespaces.1"].Error())
+}
+
+func Test_EnvironmentConfig_Validate_Requests(t *testing.T) {
+	config := EnvironmentConfig{
+		Requests: &EnvironmentRequests{MaxContainerConcurrency: int64Ptr(0), MaxTimeoutSeconds: int64Ptr(-1)},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must be no less than 1", validationErrors["requests.maxContainerConcurrency"].Error())
+	assert.Equal(t, "must be no less than 1", validationErrors["requests.maxTimeoutSeconds"].Error())
+}
+
+func Test_EnvironmentConfig_Validate_Security(t *testing.T) {
+	config := EnvironmentConfig{
+		Security: &EnvironmentSecurity{
+			DropCapabilities: []string{"ALL", "net_raw"},
+			SeccompProfile:   "Unconfined",
+			AllowedOptOuts:   []string{"privileged"},
+		},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 3)
+	assert.Equal(t, `the entry "net_raw" is not valid: must be an uppercase Linux capability (e.g. NET_RAW) or ALL`, validationErrors["security.dropCapabilities"].Error())
+	assert.Equal(t, "must be one of: RuntimeDefault", validationErrors["security.seccompProfile"].Error())
+	assert.Equal(t, `the entry "privileged" is not valid: must be one of: runAsNonRoot, readOnlyRootFilesystem, dropCapabilities, seccompProfile`,
+		validationErrors["security.allowedOptOuts"].Error())
+}
+
+func Test_EnvironmentConfig_Validate_AllowedServiceAccountAnnotations(t *testing.T) {
+	config := EnvironmentConfig{
+		AllowedServiceAccountAnnotations: []string{"iam.gke.io/gcp-service-account", "bad key"},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Contains(t, validationErrors["allowedServiceAccountAnnotations"].Error(), `the entry "bad key" is not valid: must be a valid annotation key`)
+}
+
+func Test_EnvironmentConfig_Validate_AppDefaults(t *testing.T) {
+	config := EnvironmentConfig{
+		AppDefaults: &OverrideableAppConfig{
+			Image:       "myimage",
+			Expose:      &AppConfigExpose{ContainerPort: 8080},
+			Environment: map[string]intstr.IntOrString{"LOG_LEVEL": intstr.FromString("info")},
+		},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "is not supported in environment app defaults", validationErrors["appDefaults.image"].Error())
+	assert.Equal(t, "is not supported in environment app defaults", validationErrors["appDefaults.expose"].Error())
+}
+
+func Test_EnvironmentConfig_Validate_AppDefaultsValid(t *testing.T) {
+	config := EnvironmentConfig{
+		AppDefaults: &OverrideableAppConfig{
+			Environment: map[string]intstr.IntOrString{"LOG_LEVEL": intstr.FromString("info")},
+			Autoscale:   &AppConfigAutoscale{Min: intPtr(1)},
+		},
+	}
+
+	assert.NoError(t, config.Validate())
+}
@@ -6,6 +6,7 @@ import (
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 /*
@@ -39,3 +40,48 @@ func bannedNamespaceRule(v interface{}) error {
 
 	return nil
 }
+
+// NamespaceConfig is config that is shared by all apps in a namespace in an environment
+type NamespaceConfig struct {
+	Environment string `json:"environment"`
+	// Env contains env vars that are merged into the env of every app in the namespace. An app's own env vars and secrets take precedence.
+	Env map[string]intstr.IntOrString `json:"env,omitempty"`
+}
+
+func (v NamespaceConfig) Validate() error {
+	validationErrors := validation.ValidateStruct(&v,
+		validation.Field(&v.Environment, append(RulesNamingIdentifier(), validation.Required)...),
+	)
+	envErr := validation.Validate(v.Env, validation.By(validNamespaceEnvMap))
+	return mergeValidationErrors(validationErrors, envErr, "env")
+}
+
+// SaveNamespaceConfigResponse lists the deployments that must be redeployed for the namespace config to take effect
+type SaveNamespaceConfigResponse struct {
+	Message     string   `json:"message"`
+	Deployments []string `json:"deployments"`
+}
+
+// validNamespaceEnvMap validates env vars the same as an app's env except that app references are not allowed since namespace config is
+// not validated against the deployments in the environment
+func validNamespaceEnvMap(value interface{}) error {
+	validationErrors, _ := validEnvMap(value).(validation.Errors)
+	if validationErrors == nil {
+		validationErrors = validation.Errors{}
+	}
+
+	envMap, _ := value.(map[string]intstr.IntOrString)
+	for k, v := range envMap {
+		if _, hasErr := validationErrors[k]; hasErr {
+			continue
+		}
+		references, _ := ParseAppReferences(v.String(), "")
+		if len(references) > 0 {
+			validationErrors[k] = errors.New("app references are not allowed in namespace config")
+		}
+	}
+	if len(validationErrors) > 0 {
+		return validationErrors
+	}
+	return nil
+}
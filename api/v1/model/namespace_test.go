@@ -6,6 +6,7 @@ import (
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func Test_Namespace_Validate_Name(t *testing.T) {
@@ -34,3 +35,35 @@ func Test_Namespace_Validate_Name(t *testing.T) {
 		}
 	}
 }
+
+func Test_NamespaceConfig_Validate(t *testing.T) {
+	config := &NamespaceConfig{
+		Environment: "dev",
+		Env: map[string]intstr.IntOrString{
+			"LOG_LEVEL":       intstr.FromString("debug"),
+			"TRACING_ENABLED": intstr.FromInt(1),
+		},
+	}
+
+	assert.NoError(t, config.Validate())
+}
+
+func Test_NamespaceConfig_Validate_Errors(t *testing.T) {
+	config := &NamespaceConfig{
+		Env: map[string]intstr.IntOrString{
+			"bad-key":      intstr.FromString("val"),
+			"RISER_APP":    intstr.FromString("val"),
+			"CHECKOUT_URL": intstr.FromString("${app:checkout.url}"),
+		},
+	}
+
+	err := config.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 4)
+	assert.Equal(t, "cannot be blank", validationErrors["environment"].Error())
+	assert.Equal(t, `The env var "bad-key" is not valid: Must start with A-Z and only contain A-Z, 0-9, and underscores (_)`, validationErrors["env.bad-key"].Error())
+	assert.Equal(t, `The env var "RISER_APP" is not valid: Must not start with the reserved word "RISER_"`, validationErrors["env.RISER_APP"].Error())
+	assert.Equal(t, "app references are not allowed in namespace config", validationErrors["env.CHECKOUT_URL"].Error())
+}
@@ -9,6 +9,11 @@ import (
 
 type RolloutRequest struct {
 	Traffic []TrafficRule `json:"traffic"`
+	// ExpectedRiserRevision optionally rejects the request with a conflict if the deployment's current revision does not match.
+	// The If-Match header may be used instead.
+	ExpectedRiserRevision int64 `json:"expectedRiserRevision,omitempty"`
+	// OverrideLock allows traffic to be changed on a locked deployment
+	OverrideLock bool `json:"overrideLock,omitempty"`
 }
 
 type TrafficRule struct {
@@ -34,15 +39,18 @@ func (rolloutRequest *RolloutRequest) Validate() error {
 		}
 	}
 
-	rolloutErr := validation.ValidateStruct(rolloutRequest, validation.Field(&rolloutRequest.Traffic,
-		validation.Required.Error("must specify one or more traffic rules"),
-		validation.By(func(interface{}) error {
-			if percentage != 100 {
-				return errors.New("rule percentages must add up to 100")
-			}
-			return nil
-		}),
-	))
+	rolloutErr := validation.ValidateStruct(rolloutRequest,
+		validation.Field(&rolloutRequest.Traffic,
+			validation.Required.Error("must specify one or more traffic rules"),
+			validation.By(func(interface{}) error {
+				if percentage != 100 {
+					return errors.New("rule percentages must add up to 100")
+				}
+				return nil
+			}),
+		),
+		validation.Field(&rolloutRequest.ExpectedRiserRevision, validation.Min(int64(0))),
+	)
 
 	if rolloutErr != nil {
 		err = mergeValidationErrors(err, rolloutErr, "")
@@ -27,6 +27,17 @@ func Test_RolloutRequest_Valid(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_RolloutRequest_ValidateExpectedRiserRevision(t *testing.T) {
+	rolloutRequest := &RolloutRequest{
+		Traffic:               []TrafficRule{{RiserRevision: 1, Percent: 100}},
+		ExpectedRiserRevision: -1,
+	}
+
+	err := rolloutRequest.Validate()
+
+	assert.Equal(t, "expectedRiserRevision: must be no less than 0.", err.Error())
+}
+
 func Test_RolloutRequest_ValidateTrafficRequired(t *testing.T) {
 	rolloutRequest := &RolloutRequest{}
 
@@ -16,11 +16,18 @@ type AppStatus struct {
 }
 
 type DeploymentStatus struct {
-	AppId                   uuid.UUID `json:"appId"`
-	DeploymentName          string    `json:"deployment"`
-	Namespace               string    `json:"namespace"`
-	EnvironmentName         string    `json:"environment"`
-	RiserRevision           int64     `json:"riserRevision"`
+	AppId           uuid.UUID `json:"appId"`
+	DeploymentName  string    `json:"deployment"`
+	Namespace       string    `json:"namespace"`
+	EnvironmentName string    `json:"environment"`
+	RiserRevision   int64     `json:"riserRevision"`
+	// MappedUrls are the URLs of the deployment's custom domains
+	MappedUrls []string `json:"mappedUrls,omitempty"`
+	// Lock is only set when the deployment has an active lock
+	Lock *DeploymentLock `json:"lock,omitempty"`
+	// Worker is true when the app does not expose a port. Workers do not receive requests so they never have traffic or URLs, and a
+	// revision is ready once its pods are ready.
+	Worker                  bool `json:"worker,omitempty"`
 	DeploymentStatusMutable `json:",inline"`
 }
 
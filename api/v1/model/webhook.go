@@ -0,0 +1,129 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	WebhookEvent_DeploymentUpdated      = "deployment.updated"
+	WebhookEvent_DeploymentDeleted      = "deployment.deleted"
+	WebhookEvent_RevisionReady          = "revision.ready"
+	WebhookEvent_RevisionFailed         = "revision.failed"
+	WebhookEvent_RolloutUpdated         = "rollout.updated"
+	WebhookEvent_SecretUpdated          = "secret.updated"
+	WebhookEvent_NamespaceConfigUpdated = "namespace.config.updated"
+
+	WebhookDeliveryStatusPending   = "Pending"
+	WebhookDeliveryStatusSucceeded = "Succeeded"
+	WebhookDeliveryStatusFailed    = "Failed"
+)
+
+// WebhookEventTypes contains all event types that a webhook may subscribe to
+var WebhookEventTypes = []string{
+	WebhookEvent_DeploymentUpdated,
+	WebhookEvent_DeploymentDeleted,
+	WebhookEvent_RevisionReady,
+	WebhookEvent_RevisionFailed,
+	WebhookEvent_RolloutUpdated,
+	WebhookEvent_SecretUpdated,
+	WebhookEvent_NamespaceConfigUpdated,
+}
+
+// NewWebhook is used to subscribe to platform events.
+type NewWebhook struct {
+	Url string `json:"url"`
+	// Secret is used to sign the payload with HMAC-SHA256. The signature is sent in the X-Riser-Signature header.
+	Secret string `json:"secret"`
+	// Events filters by event type. An empty list subscribes to all events.
+	Events []string `json:"events,omitempty"`
+	// Namespace optionally filters events to a single namespace
+	Namespace NamespaceName `json:"namespace,omitempty"`
+	// Environment optionally filters events to a single environment
+	Environment string `json:"environment,omitempty"`
+}
+
+func (v NewWebhook) Validate() error {
+	// The namespace is optional so we must skip NamespaceName.Validate when it's not set
+	namespaceRules := []validation.Rule{}
+	if v.Namespace == "" {
+		namespaceRules = append(namespaceRules, validation.Skip)
+	}
+	return validation.ValidateStruct(&v,
+		validation.Field(&v.Url, validation.Required, validation.By(validWebhookUrl)),
+		validation.Field(&v.Secret, validation.Required, validation.RuneLength(16, 256)),
+		validation.Field(&v.Events, validation.By(validWebhookEvents)),
+		validation.Field(&v.Namespace, namespaceRules...),
+		validation.Field(&v.Environment, RulesNamingIdentifier()...),
+	)
+}
+
+// Webhook is a webhook subscription. The secret is intentionally omitted.
+type Webhook struct {
+	Id          uuid.UUID     `json:"id"`
+	Url         string        `json:"url"`
+	Events      []string      `json:"events,omitempty"`
+	Namespace   NamespaceName `json:"namespace,omitempty"`
+	Environment string        `json:"environment,omitempty"`
+	Created     time.Time     `json:"created"`
+}
+
+type WebhookDelivery struct {
+	Id            uuid.UUID `json:"id"`
+	WebhookId     uuid.UUID `json:"webhookId"`
+	EventType     string    `json:"eventType"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	Created       time.Time `json:"created"`
+	// ResponseCode is the HTTP status code of the last attempt
+	ResponseCode int    `json:"responseCode,omitempty"`
+	LastError    string `json:"lastError,omitempty"`
+}
+
+// WebhookPayload is the JSON body sent to a webhook subscriber
+type WebhookPayload struct {
+	Id            uuid.UUID   `json:"id"`
+	Type          string      `json:"type"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Namespace     string      `json:"namespace"`
+	Environment   string      `json:"environment"`
+	Name          string      `json:"name"`
+	RiserRevision int64       `json:"riserRevision,omitempty"`
+	Data          interface{} `json:"data,omitempty"`
+}
+
+func validWebhookUrl(value interface{}) error {
+	rawUrl, _ := value.(string)
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || parsed.Host == "" {
+		return errors.New("must be a valid absolute url")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.New("must use the http or https scheme")
+	}
+	return nil
+}
+
+func validWebhookEvents(value interface{}) error {
+	events, _ := value.([]string)
+	for _, event := range events {
+		found := false
+		for _, eventType := range WebhookEventTypes {
+			if event == eventType {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("invalid event %q: must be one of: %s", event, strings.Join(WebhookEventTypes, ", "))
+		}
+	}
+	return nil
+}
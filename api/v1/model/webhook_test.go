@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewWebhook_Validate(t *testing.T) {
+	webhook := NewWebhook{
+		Url:         "https://chat.acme.org/hooks/riser",
+		Secret:      "0123456789abcdef",
+		Events:      []string{WebhookEvent_DeploymentUpdated, WebhookEvent_RevisionFailed},
+		Namespace:   "myns",
+		Environment: "prod",
+	}
+
+	assert.NoError(t, webhook.Validate())
+}
+
+func Test_NewWebhook_Validate_Required(t *testing.T) {
+	err := NewWebhook{}.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assertFieldsRequired(t, validationErrors, "url", "secret")
+}
+
+func Test_NewWebhook_Validate_Invalid(t *testing.T) {
+	webhook := NewWebhook{
+		Url:         "ftp://chat.acme.org",
+		Secret:      "short",
+		Events:      []string{"deployment.exploded"},
+		Namespace:   "kube-system",
+		Environment: "BAD",
+	}
+
+	err := webhook.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Len(t, validationErrors, 5)
+	assert.Equal(t, "must use the http or https scheme", validationErrors["url"].Error())
+	assert.Equal(t, "the length must be between 16 and 256", validationErrors["secret"].Error())
+	assert.Contains(t, validationErrors["events"].Error(), `invalid event "deployment.exploded"`)
+	assert.Equal(t, `namespace names may not begin with "kube-"`, validationErrors["namespace"].Error())
+	assert.Equal(t, "must be lowercase, alphanumeric, and start with a letter", validationErrors["environment"].Error())
+}
+
+func Test_NewWebhook_Validate_RelativeUrl(t *testing.T) {
+	webhook := NewWebhook{
+		Url:    "/hooks/riser",
+		Secret: "0123456789abcdef",
+	}
+
+	err := webhook.Validate()
+
+	require.IsType(t, validation.Errors{}, err)
+	validationErrors := err.(validation.Errors)
+	assert.Equal(t, "must be a valid absolute url", validationErrors["url"].Error())
+}
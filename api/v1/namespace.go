@@ -1,11 +1,14 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/environment"
 	"github.com/riser-platform/riser-server/pkg/namespace"
 )
 
@@ -27,6 +30,45 @@ func GetNamespaces(c echo.Context, namespaces core.NamespaceRepository) error {
 	return c.JSON(http.StatusOK, mapNamespaceArrayFromDomain(domainArray))
 }
 
+func GetNamespaceConfigs(c echo.Context, namespaceConfigs core.NamespaceConfigRepository) error {
+	domainArray, err := namespaceConfigs.ListByNamespace(c.Param("namespace"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapNamespaceConfigArrayFromDomain(domainArray))
+}
+
+func PutNamespaceConfig(c echo.Context, namespaceService namespace.Service, environmentService environment.Service) error {
+	namespaceConfig := &model.NamespaceConfig{}
+	err := c.Bind(namespaceConfig)
+	if err != nil {
+		return err
+	}
+
+	err = environmentService.ValidateDeployable(namespaceConfig.Environment)
+	if err != nil {
+		return err
+	}
+
+	deployments, err := namespaceService.SaveConfig(mapNamespaceConfigToDomain(c.Param("namespace"), namespaceConfig))
+	if err != nil {
+		return err
+	}
+
+	deploymentNames := []string{}
+	for _, deployment := range deployments {
+		deploymentNames = append(deploymentNames, deployment.Name)
+	}
+
+	message := "Namespace config saved."
+	if len(deploymentNames) > 0 {
+		message = fmt.Sprintf("Namespace config saved. The following deployments must be redeployed for the changes to take effect: %s",
+			strings.Join(deploymentNames, ", "))
+	}
+
+	return c.JSON(http.StatusAccepted, model.SaveNamespaceConfigResponse{Message: message, Deployments: deploymentNames})
+}
+
 func mapNamespaceArrayFromDomain(domainArray []core.Namespace) []model.Namespace {
 	modelArray := []model.Namespace{}
 	for _, domain := range domainArray {
@@ -39,3 +81,29 @@ func mapNamespaceArrayFromDomain(domainArray []core.Namespace) []model.Namespace
 func mapNamespaceFromDomain(domain core.Namespace) model.Namespace {
 	return model.Namespace{Name: model.NamespaceName(domain.Name)}
 }
+
+func mapNamespaceConfigToDomain(namespaceName string, in *model.NamespaceConfig) *core.NamespaceConfig {
+	return &core.NamespaceConfig{
+		Namespace:       namespaceName,
+		EnvironmentName: in.Environment,
+		Doc: core.NamespaceConfigDoc{
+			Environment: in.Env,
+		},
+	}
+}
+
+func mapNamespaceConfigFromDomain(domain core.NamespaceConfig) model.NamespaceConfig {
+	return model.NamespaceConfig{
+		Environment: domain.EnvironmentName,
+		Env:         domain.Doc.Environment,
+	}
+}
+
+func mapNamespaceConfigArrayFromDomain(domainArray []core.NamespaceConfig) []model.NamespaceConfig {
+	modelArray := []model.NamespaceConfig{}
+	for _, domain := range domainArray {
+		modelArray = append(modelArray, mapNamespaceConfigFromDomain(domain))
+	}
+
+	return modelArray
+}
@@ -1,12 +1,136 @@
 package v1
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/environment"
+	"github.com/riser-platform/riser-server/pkg/namespace"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+func Test_PutNamespaceConfig(t *testing.T) {
+	namespaceConfig := model.NamespaceConfig{
+		Environment: "dev",
+		Env:         map[string]intstr.IntOrString{"LOG_LEVEL": intstr.FromString("debug")},
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/", safeMarshal(namespaceConfig))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("namespace")
+	ctx.SetParamValues("myns")
+
+	namespaceService := &namespace.FakeService{
+		SaveConfigFn: func(config *core.NamespaceConfig) ([]core.Deployment, error) {
+			assert.Equal(t, mapNamespaceConfigToDomain("myns", &namespaceConfig), config)
+			return []core.Deployment{
+				{DeploymentReservation: core.DeploymentReservation{Name: "app1"}},
+				{DeploymentReservation: core.DeploymentReservation{Name: "app2"}},
+			}, nil
+		},
+	}
+
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(envName string) error {
+			assert.Equal(t, "dev", envName)
+			return nil
+		},
+	}
+
+	err := PutNamespaceConfig(ctx, namespaceService, environmentService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Result().StatusCode)
+	assert.Equal(t, 1, namespaceService.SaveConfigCallCount)
+	response := model.SaveNamespaceConfigResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, []string{"app1", "app2"}, response.Deployments)
+	assert.Equal(t, "Namespace config saved. The following deployments must be redeployed for the changes to take effect: app1, app2", response.Message)
+}
+
+func Test_PutNamespaceConfig_NoDeployments(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/", safeMarshal(model.NamespaceConfig{Environment: "dev"}))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("namespace")
+	ctx.SetParamValues("myns")
+
+	namespaceService := &namespace.FakeService{
+		SaveConfigFn: func(config *core.NamespaceConfig) ([]core.Deployment, error) {
+			return []core.Deployment{}, nil
+		},
+	}
+
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(envName string) error {
+			return nil
+		},
+	}
+
+	err := PutNamespaceConfig(ctx, namespaceService, environmentService)
+
+	assert.NoError(t, err)
+	response := model.SaveNamespaceConfigResponse{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Empty(t, response.Deployments)
+	assert.Equal(t, "Namespace config saved.", response.Message)
+}
+
+func Test_PutNamespaceConfig_WhenEnvironmentNotDeployable(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/", safeMarshal(model.NamespaceConfig{Environment: "dev"}))
+	req.Header.Add("CONTENT-TYPE", "application/json")
+
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("namespace")
+	ctx.SetParamValues("myns")
+
+	namespaceService := &namespace.FakeService{}
+	environmentService := &environment.FakeService{
+		ValidateDeployableFn: func(envName string) error {
+			return core.NewValidationErrorMessage("bad env")
+		},
+	}
+
+	err := PutNamespaceConfig(ctx, namespaceService, environmentService)
+
+	assert.Equal(t, "bad env", err.Error())
+	assert.Equal(t, 0, namespaceService.SaveConfigCallCount)
+}
+
+func Test_GetNamespaceConfigs(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("namespace")
+	ctx.SetParamValues("myns")
+
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{
+		ListByNamespaceFn: func(namespaceName string) ([]core.NamespaceConfig, error) {
+			assert.Equal(t, "myns", namespaceName)
+			return []core.NamespaceConfig{
+				{Namespace: "myns", EnvironmentName: "dev"},
+			}, nil
+		},
+	}
+
+	err := GetNamespaceConfigs(ctx, namespaceConfigs)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	response := []model.NamespaceConfig{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, []model.NamespaceConfig{{Environment: "dev"}}, response)
+}
+
 func Test_mapNamespaceFromDomain(t *testing.T) {
 	domain := core.Namespace{Name: "myns"}
 
@@ -27,3 +151,18 @@ func Test_mapNamespaceArrayFromDomain(t *testing.T) {
 	assert.EqualValues(t, "myns1", result[0].Name)
 	assert.EqualValues(t, "myns2", result[1].Name)
 }
+
+func Test_mapNamespaceConfigFromDomain(t *testing.T) {
+	domain := core.NamespaceConfig{
+		Namespace:       "myns",
+		EnvironmentName: "dev",
+		Doc: core.NamespaceConfigDoc{
+			Environment: map[string]intstr.IntOrString{"LOG_LEVEL": intstr.FromString("debug")},
+		},
+	}
+
+	result := mapNamespaceConfigFromDomain(domain)
+
+	assert.Equal(t, "dev", result.Environment)
+	assert.Equal(t, map[string]intstr.IntOrString{"LOG_LEVEL": intstr.FromString("debug")}, result.Env)
+}
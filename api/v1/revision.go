@@ -0,0 +1,31 @@
+package v1
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+// expectedRiserRevision returns the revision that the caller expects the deployment to be at, or zero if the caller did not specify one.
+// The revision may be passed in the If-Match header (e.g. If-Match: "3") or in the request. If both are specified they must match.
+func expectedRiserRevision(c echo.Context, fromRequest int64) (int64, error) {
+	ifMatch := strings.TrimSpace(c.Request().Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return fromRequest, nil
+	}
+
+	fromHeader, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`), 10, 64)
+	if err != nil || fromHeader < 1 {
+		return 0, core.NewValidationErrorMessage(fmt.Sprintf("invalid If-Match header %q: must be a riser revision", ifMatch))
+	}
+
+	if fromRequest > 0 && fromRequest != fromHeader {
+		return 0, core.NewValidationErrorMessage(
+			fmt.Sprintf("the If-Match header (%d) does not match expectedRiserRevision (%d)", fromHeader, fromRequest))
+	}
+
+	return fromHeader, nil
+}
@@ -0,0 +1,60 @@
+package v1
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_expectedRiserRevision(t *testing.T) {
+	tt := []struct {
+		ifMatch     string
+		fromRequest int64
+		expected    int64
+	}{
+		{"", 0, 0},
+		{"", 2, 2},
+		{"*", 2, 2},
+		{`"3"`, 0, 3},
+		{`W/"3"`, 0, 3},
+		{"3", 3, 3},
+	}
+
+	for _, test := range tt {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set("If-Match", test.ifMatch)
+		ctx, _ := newContextWithRecorder(req)
+
+		result, err := expectedRiserRevision(ctx, test.fromRequest)
+
+		assert.NoError(t, err, test.ifMatch)
+		assert.Equal(t, test.expected, result, test.ifMatch)
+	}
+}
+
+func Test_expectedRiserRevision_InvalidHeader(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	req.Header.Set("If-Match", `"abc"`)
+	ctx, _ := newContextWithRecorder(req)
+
+	result, err := expectedRiserRevision(ctx, 0)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `invalid If-Match header "\"abc\"": must be a riser revision`, err.Error())
+}
+
+func Test_expectedRiserRevision_HeaderAndRequestMismatch(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPut, "/", nil)
+	req.Header.Set("If-Match", `"3"`)
+	ctx, _ := newContextWithRecorder(req)
+
+	result, err := expectedRiserRevision(ctx, 2)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, "the If-Match header (3) does not match expectedRiserRevision (2)", err.Error())
+}
@@ -39,6 +39,11 @@ func PutRollout(c echo.Context, rolloutService rollout.Service, environmentServi
 		return core.NewValidationError("Invalid rollout request", err)
 	}
 
+	expectedRevision, err := expectedRiserRevision(c, rolloutRequest.ExpectedRiserRevision)
+	if err != nil {
+		return err
+	}
+
 	stateRepo, err := repoCache.GetRepo(envName)
 	if err != nil {
 		return err
@@ -46,6 +51,8 @@ func PutRollout(c echo.Context, rolloutService rollout.Service, environmentServi
 
 	err = rolloutService.UpdateTraffic(core.NewNamespacedName(deploymentName, namespace), envName,
 		mapTrafficRulesToDomain(deploymentName, rolloutRequest.Traffic),
+		expectedRevision,
+		rolloutRequest.OverrideLock,
 		state.NewGitCommitter(stateRepo))
 	if err != nil {
 		if err == git.ErrNoChanges {
@@ -18,28 +18,34 @@ import (
 	"github.com/riser-platform/riser-server/pkg/login"
 	"github.com/riser-platform/riser-server/pkg/postgres"
 	"github.com/riser-platform/riser-server/pkg/secret"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 
 	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
 )
 
-func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB) {
+func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB, logger *logrus.Logger) {
 	v1 := e.Group("/api/v1")
 
 	// TODO: Refactor dependency management
 	environmentRepository := postgres.NewEnvironmentRepository(db)
 	environmentService := environment.NewService(environmentRepository)
+	webhookRepository := postgres.NewWebhookRepository(db)
+	webhookDeliveryRepository := postgres.NewWebhookDeliveryRepository(db)
+	webhookService := webhook.NewService(webhookRepository, webhookDeliveryRepository, logger)
+	deploymentRepository := postgres.NewDeploymentRepository(db)
 	namespaceRepository := postgres.NewNamespaceRepository(db)
-	namespaceService := namespace.NewService(namespaceRepository, environmentRepository)
+	namespaceConfigRepository := postgres.NewNamespaceConfigRepository(db)
+	namespaceService := namespace.NewService(namespaceRepository, namespaceConfigRepository, environmentRepository, deploymentRepository, webhookService)
 	deploymentReservationRepository := postgres.NewDeploymentReservationRepository(db)
 	appRepository := postgres.NewAppRepository(db)
 	appService := app.NewService(appRepository, namespaceService)
 	secretMetaRepository := postgres.NewSecretMetaRepository(db)
-	secretService := secret.NewService(secretMetaRepository, environmentRepository)
+	secretService := secret.NewService(secretMetaRepository, environmentRepository, webhookService)
 	deploymentReservationService := deploymentreservation.NewService(deploymentReservationRepository)
-	deploymentRepository := postgres.NewDeploymentRepository(db)
-	deploymentService := deployment.NewService(appRepository, namespaceService, secretMetaRepository, environmentRepository, deploymentRepository, deploymentReservationService)
+	deploymentService := deployment.NewService(appRepository, namespaceService, namespaceConfigRepository, secretMetaRepository, environmentRepository, deploymentRepository, deploymentReservationService, webhookService)
 	deploymentStatusService := deploymentstatus.NewService(deploymentRepository, environmentService)
-	rolloutService := rollout.NewService(appRepository, deploymentRepository)
+	rolloutService := rollout.NewService(appRepository, deploymentRepository, webhookService)
 	userRepository := postgres.NewUserRepository(db)
 	apiKeyRepository := postgres.NewApiKeyRepository(db)
 	loginService := login.NewService(userRepository, apiKeyRepository)
@@ -79,8 +85,16 @@ func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB)
 		return DeleteDeployment(c, repoCache, deploymentService)
 	})
 
+	v1.PUT("/deployments/:envName/:namespace/:deploymentName/lock", func(c echo.Context) error {
+		return PutDeploymentLock(c, deploymentService)
+	})
+
+	v1.DELETE("/deployments/:envName/:namespace/:deploymentName/lock", func(c echo.Context) error {
+		return DeleteDeploymentLock(c, deploymentService)
+	})
+
 	v1.PUT("/deployments/:envName/:namespace/:deploymentName/status", func(c echo.Context) error {
-		return PutDeploymentStatus(c, deploymentRepository)
+		return PutDeploymentStatus(c, deploymentRepository, webhookService)
 	})
 
 	v1.PUT("/rollout/:envName/:namespace/:deploymentName", func(c echo.Context) error {
@@ -103,6 +117,14 @@ func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB)
 		return PostNamespace(c, namespaceService)
 	})
 
+	v1.GET("/namespaces/:namespace/config", func(c echo.Context) error {
+		return GetNamespaceConfigs(c, namespaceConfigRepository)
+	})
+
+	v1.PUT("/namespaces/:namespace/config", func(c echo.Context) error {
+		return PutNamespaceConfig(c, namespaceService, environmentService)
+	})
+
 	v1.GET("/environments/:envName/config", func(c echo.Context) error {
 		return GetEnvironmentConfig(c, environmentService)
 	})
@@ -119,7 +141,31 @@ func RegisterRoutes(e *echo.Echo, repoCache *environment.RepoCache, db *sql.DB)
 		return ListEnvironments(c, environmentRepository)
 	})
 
+	v1.GET("/schemas/appconfig", func(c echo.Context) error {
+		return GetAppConfigSchema(c)
+	})
+
 	v1.POST("/validate/appconfig", func(c echo.Context) error {
-		return PostValidateAppConfig(c, appService, environmentService)
+		return PostValidateAppConfig(c, appService, environmentService, deploymentService)
+	})
+
+	v1.GET("/webhooks", func(c echo.Context) error {
+		return ListWebhooks(c, webhookRepository)
+	})
+
+	v1.POST("/webhooks", func(c echo.Context) error {
+		return PostWebhook(c, webhookService)
+	})
+
+	v1.GET("/webhooks/:id", func(c echo.Context) error {
+		return GetWebhook(c, webhookRepository)
+	})
+
+	v1.DELETE("/webhooks/:id", func(c echo.Context) error {
+		return DeleteWebhook(c, webhookService)
+	})
+
+	v1.GET("/webhooks/:id/deliveries", func(c echo.Context) error {
+		return ListWebhookDeliveries(c, webhookRepository, webhookDeliveryRepository)
 	})
 }
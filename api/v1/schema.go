@@ -0,0 +1,13 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+func GetAppConfigSchema(c echo.Context) error {
+	return c.JSON(http.StatusOK, model.AppConfigSchema())
+}
@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetAppConfigSchema(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, rec := newContextWithRecorder(req)
+
+	err := GetAppConfigSchema(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	result := &model.JSONSchema{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), result))
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", result.Schema)
+	assert.Contains(t, result.Properties, "expose")
+	assert.Contains(t, result.Definitions, "AppConfigExpose")
+}
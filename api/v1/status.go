@@ -63,6 +63,13 @@ func mapDeploymentToStatusModel(domain *core.Deployment) *model.DeploymentStatus
 		Namespace:       domain.Namespace,
 		EnvironmentName: domain.EnvironmentName,
 		RiserRevision:   domain.RiserRevision,
+		Worker:          domain.Doc.Worker,
+	}
+	if domain.Doc.Lock.IsActive(time.Now()) {
+		status.Lock = mapDeploymentLockFromDomain(domain.Doc.Lock)
+	}
+	for _, deploymentDomain := range domain.Doc.Domains {
+		status.MappedUrls = append(status.MappedUrls, deploymentDomain.URL())
 	}
 	if domain.Doc.Status == nil {
 		status.DeploymentStatusMutable = model.DeploymentStatusMutable{}
@@ -84,6 +91,11 @@ func mapDeploymentToStatusModel(domain *core.Deployment) *model.DeploymentStatus
 			}
 		}
 
+		// Workers do not have a Route so any reported traffic is stale
+		if domain.Doc.Worker {
+			return status
+		}
+
 		status.Traffic = make([]model.DeploymentTrafficStatus, len(domain.Doc.Status.Traffic))
 		for idx, traffic := range domain.Doc.Status.Traffic {
 			status.Traffic[idx] = model.DeploymentTrafficStatus{
@@ -10,6 +10,7 @@ import (
 	"github.com/riser-platform/riser-server/pkg/core"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
 )
@@ -96,6 +97,69 @@ func Test_mapDeploymentToStatusModel(t *testing.T) {
 	assert.Equal(t, int64(4), result.Revisions[1].RiserRevision)
 }
 
+func Test_mapDeploymentToStatusModel_Lock(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	deployment := &core.Deployment{
+		DeploymentRecord: core.DeploymentRecord{
+			Doc: core.DeploymentDoc{
+				Lock: &core.DeploymentLock{Holder: "alice", Reason: "incident"},
+			},
+		},
+	}
+
+	result := mapDeploymentToStatusModel(deployment)
+
+	require.NotNil(t, result.Lock)
+	assert.Equal(t, "alice", result.Lock.Holder)
+	assert.Equal(t, "incident", result.Lock.Reason)
+
+	deployment.Doc.Lock.ExpiresAt = &expired
+
+	result = mapDeploymentToStatusModel(deployment)
+
+	assert.Nil(t, result.Lock)
+}
+
+func Test_mapDeploymentToStatusModel_MappedUrls(t *testing.T) {
+	deployment := &core.Deployment{
+		DeploymentRecord: core.DeploymentRecord{
+			Doc: core.DeploymentDoc{
+				Domains: core.DeploymentDomains{
+					{Name: "myapp.example.com", TLS: true},
+					{Name: "myapp.example.org"},
+				},
+			},
+		},
+	}
+
+	result := mapDeploymentToStatusModel(deployment)
+
+	assert.Equal(t, []string{"https://myapp.example.com", "http://myapp.example.org"}, result.MappedUrls)
+}
+
+func Test_mapDeploymentToStatusModel_Worker(t *testing.T) {
+	percent := int64(100)
+	deployment := &core.Deployment{
+		DeploymentRecord: core.DeploymentRecord{
+			Doc: core.DeploymentDoc{
+				Worker: true,
+				Status: &core.DeploymentStatus{
+					LatestReadyRevisionName: "myapp-1",
+					Revisions:               []core.DeploymentRevisionStatus{{Name: "myapp-1", RevisionStatus: model.RevisionStatusReady}},
+					Traffic:                 []core.DeploymentTrafficStatus{{Percent: &percent, RevisionName: "myapp-1"}},
+				},
+			},
+		},
+	}
+
+	result := mapDeploymentToStatusModel(deployment)
+
+	assert.True(t, result.Worker)
+	assert.Equal(t, "myapp-1", result.LatestReadyRevisionName)
+	assert.Len(t, result.Revisions, 1)
+	assert.Empty(t, result.Traffic)
+}
+
 func Test_mapDeploymentToStatusModel_NilStatus(t *testing.T) {
 	deployment := &core.Deployment{
 		DeploymentReservation: core.DeploymentReservation{
@@ -1,7 +1,9 @@
 package v1
 
 import (
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/riser-platform/riser-server/pkg/environment"
 
@@ -10,9 +12,10 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/deployment"
 )
 
-func PostValidateAppConfig(c echo.Context, appService app.Service, environmentService environment.Service) error {
+func PostValidateAppConfig(c echo.Context, appService app.Service, environmentService environment.Service, deploymentService deployment.Service) error {
 	appConfig := &model.AppConfigWithOverrides{}
 	err := c.Bind(appConfig)
 	// if err == nil {
@@ -25,10 +28,22 @@ func PostValidateAppConfig(c echo.Context, appService app.Service, environmentSe
 	}
 
 	err = validateAppConfig(appConfig, appService, environmentService)
-	if err == nil {
-		return c.NoContent(http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+
+	warnings, err := appConfigWarnings(appConfig, appService)
+	if err != nil {
+		return err
 	}
-	return err
+
+	environmentWarnings, err := deploymentService.EnvironmentWarnings(appConfig)
+	if err != nil {
+		return err
+	}
+	warnings = append(warnings, environmentWarnings...)
+
+	return c.JSON(http.StatusOK, &model.AppConfigValidationResult{Warnings: warnings})
 }
 
 // validateAppConfig performs additional validation beyond type validation of the appConfig model (i.e. appConfig.Validate())
@@ -47,3 +62,38 @@ func validateAppConfig(appConfig *model.AppConfigWithOverrides, appService app.S
 	}
 	return nil
 }
+
+// appConfigWarnings returns potential problems with the appConfig that should not prevent it from being deployed
+func appConfigWarnings(appConfig *model.AppConfigWithOverrides, appService app.Service) ([]string, error) {
+	appConfigs := []*model.AppConfig{&appConfig.AppConfig}
+	for env := range appConfig.Overrides {
+		envAppConfig, err := appConfig.ApplyOverrides(env)
+		if err != nil {
+			return nil, err
+		}
+		appConfigs = append(appConfigs, envAppConfig)
+	}
+
+	warnings := []string{}
+	checked := map[string]bool{}
+	for _, envAppConfig := range appConfigs {
+		for _, source := range envAppConfig.AllowFromSources() {
+			name := core.NewNamespacedName(source.App, source.Namespace)
+			if source.App == "" || checked[name.String()] {
+				continue
+			}
+			checked[name.String()] = true
+			_, err := appService.GetByName(name)
+			if err == app.ErrAppNotFound {
+				warnings = append(warnings, fmt.Sprintf("The app %q in expose.allowFrom does not exist", name))
+			} else if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Strings(warnings)
+	if deprecationWarning := appConfig.DeprecationWarning(); deprecationWarning != "" {
+		warnings = append([]string{deprecationWarning}, warnings...)
+	}
+	return warnings, nil
+}
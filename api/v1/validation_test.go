@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,18 +13,23 @@ import (
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/app"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/deployment"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var validAppConfig = &model.AppConfigWithOverrides{
 	AppConfig: model.AppConfig{
-		Name:      "myapp",
-		Namespace: "myns",
-		Id:        uuid.New(),
-		Image:     "myimage",
-		Expose: &model.AppConfigExpose{
-			ContainerPort: 80,
+		ApiVersion: model.AppConfigApiVersion,
+		Name:       "myapp",
+		Namespace:  "myns",
+		Id:         uuid.New(),
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Image: "myimage",
+			Expose: &model.AppConfigExpose{
+				ContainerPort: 80,
+			},
 		},
 	},
 }
@@ -31,7 +37,7 @@ var validAppConfig = &model.AppConfigWithOverrides{
 func Test_PostValidateAppConfig(t *testing.T) {
 	req := httptest.NewRequest(http.MethodPost, "/validate/appconfig", safeMarshal(validAppConfig))
 	req.Header.Add("CONTENT-TYPE", "application/json")
-	ctx, _ := newContextWithRecorder(req)
+	ctx, rec := newContextWithRecorder(req)
 
 	appService := &app.FakeService{
 		CheckIDFn: func(id uuid.UUID, name *core.NamespacedName) error {
@@ -42,9 +48,19 @@ func Test_PostValidateAppConfig(t *testing.T) {
 		},
 	}
 
-	err := PostValidateAppConfig(ctx, appService, &environment.FakeService{})
+	deploymentService := &deployment.FakeService{
+		EnvironmentWarningsFn: func(appConfig *model.AppConfigWithOverrides) ([]string, error) {
+			assert.EqualValues(t, "myapp", appConfig.Name)
+			return []string{`The secret "creds" does not exist in environment "prod"`}, nil
+		},
+	}
+
+	err := PostValidateAppConfig(ctx, appService, &environment.FakeService{}, deploymentService)
 
 	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"warnings":["The secret \"creds\" does not exist in environment \"prod\""]}`, rec.Body.String())
+	assert.Equal(t, 1, deploymentService.EnvironmentWarningsCallCount)
 }
 
 func Test_PostValidateAppConfig_InvalidAppName(t *testing.T) {
@@ -58,7 +74,7 @@ func Test_PostValidateAppConfig_InvalidAppName(t *testing.T) {
 		},
 	}
 
-	err := PostValidateAppConfig(ctx, appService, &environment.FakeService{})
+	err := PostValidateAppConfig(ctx, appService, &environment.FakeService{}, &deployment.FakeService{})
 
 	assert.Equal(t, app.ErrInvalidAppName, err)
 }
@@ -121,3 +137,81 @@ func Test_validateAppConfig_InvalidEnvOverride(t *testing.T) {
 	assert.IsType(t, &core.ValidationError{}, result)
 	assert.EqualError(t, result, "Invalid environmentOverride: Invalid env")
 }
+
+func Test_appConfigWarnings(t *testing.T) {
+	appConfig := &model.AppConfigWithOverrides{
+		AppConfig: model.AppConfig{
+			Name:      "myapp",
+			Namespace: "myns",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Expose: &model.AppConfigExpose{
+					AllowFrom: []string{"checkout", "missing.otherns", "ns:billing"},
+				},
+			},
+		},
+		Overrides: map[string]model.OverrideableAppConfig{
+			"prod": {
+				Expose: &model.AppConfigExpose{
+					AllowFrom: []string{"checkout", "missing.prodns"},
+				},
+			},
+		},
+	}
+
+	appService := &app.FakeService{
+		GetByNameFn: func(name *core.NamespacedName) (*core.App, error) {
+			if name.Name == "missing" {
+				return nil, app.ErrAppNotFound
+			}
+			assert.Equal(t, core.NewNamespacedName("checkout", "myns"), name)
+			return &core.App{}, nil
+		},
+	}
+
+	result, err := appConfigWarnings(appConfig, appService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`The app "missing.otherns" in expose.allowFrom does not exist`,
+		`The app "missing.prodns" in expose.allowFrom does not exist`,
+	}, result)
+}
+
+func Test_appConfigWarnings_GetByNameError(t *testing.T) {
+	appConfig := &model.AppConfigWithOverrides{
+		AppConfig: model.AppConfig{
+			Name:      "myapp",
+			Namespace: "myns",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Expose: &model.AppConfigExpose{
+					AllowFrom: []string{"checkout"},
+				},
+			},
+		},
+	}
+
+	appService := &app.FakeService{
+		GetByNameFn: func(name *core.NamespacedName) (*core.App, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	result, err := appConfigWarnings(appConfig, appService)
+
+	assert.Nil(t, result)
+	assert.Equal(t, "test", err.Error())
+}
+
+func Test_appConfigWarnings_DeprecatedApiVersion(t *testing.T) {
+	appConfig := &model.AppConfigWithOverrides{}
+	err := json.Unmarshal([]byte(`{"apiVersion": "riser.dev/v1beta1", "name": "myapp"}`), appConfig)
+	require.NoError(t, err)
+	require.NoError(t, appConfig.Convert())
+
+	result, err := appConfigWarnings(appConfig, &app.FakeService{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`The apiVersion "riser.dev/v1beta1" is deprecated and was converted to "riser.dev/v1". Update your app config to use "apiVersion: riser.dev/v1"`,
+	}, result)
+}
@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/webhook"
+)
+
+// webhookDeliveryLimit limits the number of deliveries returned in the delivery log
+const webhookDeliveryLimit = 100
+
+func PostWebhook(c echo.Context, webhookService webhook.Service) error {
+	newWebhook := &model.NewWebhook{}
+	err := c.Bind(newWebhook)
+	if err != nil {
+		return err
+	}
+
+	domain := mapNewWebhookToDomain(newWebhook)
+	err = webhookService.Create(domain)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, mapWebhookFromDomain(*domain))
+}
+
+func ListWebhooks(c echo.Context, webhooks core.WebhookRepository) error {
+	domainArray, err := webhooks.List()
+	if err != nil {
+		return err
+	}
+
+	out := []model.Webhook{}
+	for _, domain := range domainArray {
+		out = append(out, mapWebhookFromDomain(domain))
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func GetWebhook(c echo.Context, webhooks core.WebhookRepository) error {
+	id, err := parseWebhookId(c)
+	if err != nil {
+		return err
+	}
+
+	domain, err := webhooks.Get(id)
+	if err != nil {
+		return handleWebhookErr(err)
+	}
+
+	return c.JSON(http.StatusOK, mapWebhookFromDomain(*domain))
+}
+
+func DeleteWebhook(c echo.Context, webhookService webhook.Service) error {
+	id, err := parseWebhookId(c)
+	if err != nil {
+		return err
+	}
+
+	err = webhookService.Delete(id)
+	if err != nil {
+		return handleWebhookErr(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func ListWebhookDeliveries(c echo.Context, webhooks core.WebhookRepository, deliveries core.WebhookDeliveryRepository) error {
+	id, err := parseWebhookId(c)
+	if err != nil {
+		return err
+	}
+
+	_, err = webhooks.Get(id)
+	if err != nil {
+		return handleWebhookErr(err)
+	}
+
+	domainArray, err := deliveries.ListByWebhook(id, webhookDeliveryLimit)
+	if err != nil {
+		return err
+	}
+
+	out := []model.WebhookDelivery{}
+	for _, domain := range domainArray {
+		out = append(out, mapWebhookDeliveryFromDomain(domain))
+	}
+	return c.JSON(http.StatusOK, out)
+}
+
+func parseWebhookId(c echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, core.NewValidationErrorMessage("invalid webhook id")
+	}
+	return id, nil
+}
+
+func handleWebhookErr(err error) error {
+	if err == core.ErrNotFound {
+		return echo.NewHTTPError(http.StatusNotFound, "Webhook not found")
+	}
+	return err
+}
+
+func mapNewWebhookToDomain(in *model.NewWebhook) *core.Webhook {
+	return &core.Webhook{
+		Doc: core.WebhookDoc{
+			Url:             in.Url,
+			Secret:          in.Secret,
+			Events:          in.Events,
+			Namespace:       string(in.Namespace),
+			EnvironmentName: in.Environment,
+		},
+	}
+}
+
+func mapWebhookFromDomain(domain core.Webhook) model.Webhook {
+	return model.Webhook{
+		Id:          domain.Id,
+		Url:         domain.Doc.Url,
+		Events:      domain.Doc.Events,
+		Namespace:   model.NamespaceName(domain.Doc.Namespace),
+		Environment: domain.Doc.EnvironmentName,
+		Created:     domain.Created,
+	}
+}
+
+func mapWebhookDeliveryFromDomain(domain core.WebhookDelivery) model.WebhookDelivery {
+	return model.WebhookDelivery{
+		Id:            domain.Id,
+		WebhookId:     domain.WebhookId,
+		EventType:     domain.EventType,
+		Status:        domain.Status,
+		Attempts:      domain.Attempts,
+		NextAttemptAt: domain.NextAttemptAt,
+		Created:       domain.Created,
+		ResponseCode:  domain.Doc.ResponseCode,
+		LastError:     domain.Doc.LastError,
+	}
+}
@@ -0,0 +1,155 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PostWebhook(t *testing.T) {
+	newWebhook := &model.NewWebhook{
+		Url:         "https://acme.org",
+		Secret:      "0123456789abcdef",
+		Events:      []string{model.WebhookEvent_RevisionFailed},
+		Namespace:   "myns",
+		Environment: "dev",
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", safeMarshal(newWebhook))
+	req.Header.Add("Content-Type", "application/json")
+	ctx, rec := newContextWithRecorder(req)
+
+	webhookService := &webhook.FakeService{
+		CreateFn: func(webhook *core.Webhook) error {
+			assert.Equal(t, "https://acme.org", webhook.Doc.Url)
+			assert.Equal(t, "0123456789abcdef", webhook.Doc.Secret)
+			assert.Equal(t, []string{model.WebhookEvent_RevisionFailed}, webhook.Doc.Events)
+			assert.Equal(t, "myns", webhook.Doc.Namespace)
+			assert.Equal(t, "dev", webhook.Doc.EnvironmentName)
+			webhook.Id = uuid.New()
+			return nil
+		},
+	}
+
+	err := PostWebhook(ctx, webhookService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 1, webhookService.CreateCallCount)
+	assert.NotContains(t, rec.Body.String(), "0123456789abcdef")
+}
+
+func Test_GetWebhook_NotFound(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(uuid.New().String())
+
+	webhooks := &core.FakeWebhookRepository{
+		GetFn: func(uuid.UUID) (*core.Webhook, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	err := GetWebhook(ctx, webhooks)
+
+	require.IsType(t, &echo.HTTPError{}, err)
+	assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
+}
+
+func Test_GetWebhook_InvalidId(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, _ := newContextWithRecorder(req)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues("bad")
+
+	err := GetWebhook(ctx, &core.FakeWebhookRepository{})
+
+	require.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, "invalid webhook id", err.Error())
+}
+
+func Test_DeleteWebhook(t *testing.T) {
+	id := uuid.New()
+	req := httptest.NewRequest(http.MethodDelete, "/", nil)
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	webhookService := &webhook.FakeService{
+		DeleteFn: func(webhookId uuid.UUID) error {
+			assert.Equal(t, id, webhookId)
+			return nil
+		},
+	}
+
+	err := DeleteWebhook(ctx, webhookService)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func Test_ListWebhookDeliveries(t *testing.T) {
+	id := uuid.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, rec := newContextWithRecorder(req)
+	ctx.SetParamNames("id")
+	ctx.SetParamValues(id.String())
+
+	webhooks := &core.FakeWebhookRepository{
+		GetFn: func(uuid.UUID) (*core.Webhook, error) {
+			return &core.Webhook{Id: id}, nil
+		},
+	}
+	deliveries := &core.FakeWebhookDeliveryRepository{
+		ListByWebhookFn: func(webhookId uuid.UUID, limit int) ([]core.WebhookDelivery, error) {
+			assert.Equal(t, id, webhookId)
+			assert.Equal(t, webhookDeliveryLimit, limit)
+			return []core.WebhookDelivery{
+				{WebhookId: id, EventType: model.WebhookEvent_RevisionReady, Status: model.WebhookDeliveryStatusFailed, Doc: core.WebhookDeliveryDoc{LastError: "timeout"}},
+			}, nil
+		},
+	}
+
+	err := ListWebhookDeliveries(ctx, webhooks, deliveries)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	result := []model.WebhookDelivery{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &result))
+	require.Len(t, result, 1)
+	assert.Equal(t, "timeout", result[0].LastError)
+}
+
+func Test_mapWebhookFromDomain(t *testing.T) {
+	created := time.Now()
+	domain := core.Webhook{
+		Id:      uuid.New(),
+		Created: created,
+		Doc: core.WebhookDoc{
+			Url:             "https://acme.org",
+			Secret:          "mysecret",
+			Events:          []string{model.WebhookEvent_RolloutUpdated},
+			Namespace:       "myns",
+			EnvironmentName: "dev",
+		},
+	}
+
+	result := mapWebhookFromDomain(domain)
+
+	assert.Equal(t, domain.Id, result.Id)
+	assert.Equal(t, "https://acme.org", result.Url)
+	assert.Equal(t, []string{model.WebhookEvent_RolloutUpdated}, result.Events)
+	assert.EqualValues(t, "myns", result.Namespace)
+	assert.Equal(t, "dev", result.Environment)
+	assert.Equal(t, created, result.Created)
+}
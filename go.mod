@@ -26,6 +26,7 @@ require (
 	k8s.io/api v0.21.4
 	k8s.io/apimachinery v0.21.4
 	k8s.io/client-go v0.21.4
+	knative.dev/pkg v0.0.0-20211101212339-96c0204a70dc
 	knative.dev/serving v0.27.0
 	sigs.k8s.io/yaml v1.3.0
 )
@@ -74,6 +75,5 @@ require (
 	k8s.io/klog/v2 v2.8.0 // indirect
 	k8s.io/utils v0.0.0-20210111153108-fddb29f9d009 // indirect
 	knative.dev/networking v0.0.0-20211101215640-8c71a2708e7d // indirect
-	knative.dev/pkg v0.0.0-20211101212339-96c0204a70dc // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.1.2 // indirect
 )
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 
+	"github.com/riser-platform/riser-server/pkg/webhook"
+
 	"github.com/riser-platform/riser-server/pkg/environment"
 
 	"github.com/riser-platform/riser-server/pkg/namespace"
@@ -84,13 +87,22 @@ func main() {
 	e.HTTPErrorHandler = api.ErrorHandler
 	e.Binder = &api.DataBinder{}
 
-	apiv1.RegisterRoutes(e, repoCache, postgresDb)
+	apiv1.RegisterRoutes(e, repoCache, postgresDb, logger)
+
+	webhookDispatcher := webhook.NewDispatcher(postgres.NewWebhookRepository(postgresDb), postgres.NewWebhookDeliveryRepository(postgresDb), logger)
+	go webhookDispatcher.Run(context.Background(), rc.WebhookPollInterval)
+
 	err = e.Start(rc.BindAddress)
 	exitIfError(err, "Error starting server")
 }
 
 func bootstrapDefaultNamespace(db *sql.DB) {
-	namespaceService := namespace.NewService(postgres.NewNamespaceRepository(db), postgres.NewEnvironmentRepository(db))
+	namespaceService := namespace.NewService(
+		postgres.NewNamespaceRepository(db),
+		postgres.NewNamespaceConfigRepository(db),
+		postgres.NewEnvironmentRepository(db),
+		postgres.NewDeploymentRepository(db),
+		webhook.NewService(postgres.NewWebhookRepository(db), postgres.NewWebhookDeliveryRepository(db), logger))
 	err := namespaceService.EnsureDefaultNamespace()
 	exitIfError(err, "Error ensuring default namespace")
 }
@@ -0,0 +1,24 @@
+CREATE TABLE webhook
+(
+  id uuid NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT(now()),
+  doc jsonb NOT NULL,
+  PRIMARY KEY(id)
+);
+
+CREATE TABLE webhook_delivery
+(
+  id uuid NOT NULL,
+  webhook_id uuid NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+  event_type character varying(63) NOT NULL,
+  status character varying(16) NOT NULL,
+  attempts integer NOT NULL DEFAULT(0),
+  next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT(now()),
+  doc jsonb NOT NULL,
+  PRIMARY KEY(id)
+);
+
+CREATE INDEX ix_webhook_delivery_webhook_id ON webhook_delivery(webhook_id, created_at);
+-- Used for polling deliveries that are due
+CREATE INDEX ix_webhook_delivery_status_next_attempt_at ON webhook_delivery(status, next_attempt_at);
@@ -0,0 +1,7 @@
+CREATE TABLE namespace_config
+(
+  namespace character varying(63) NOT NULL REFERENCES namespace(name),
+  environment_name character varying(63) NOT NULL REFERENCES environment(name),
+  doc jsonb NOT NULL,
+  PRIMARY KEY (namespace, environment_name)
+);
@@ -6,7 +6,8 @@ import (
 )
 
 type FakeService struct {
-	CheckIDFn func(id uuid.UUID, name *core.NamespacedName) error
+	CheckIDFn   func(id uuid.UUID, name *core.NamespacedName) error
+	GetByNameFn func(name *core.NamespacedName) (*core.App, error)
 }
 
 func (f *FakeService) CheckID(id uuid.UUID, name *core.NamespacedName) error {
@@ -18,5 +19,5 @@ func (f *FakeService) Create(name *core.NamespacedName) (*core.App, error) {
 }
 
 func (f *FakeService) GetByName(name *core.NamespacedName) (*core.App, error) {
-	panic("NI!")
+	return f.GetByNameFn(name)
 }
@@ -4,31 +4,52 @@ import "github.com/google/uuid"
 
 type DeploymentRepository interface {
 	Create(newDeployment *DeploymentRecord) error
-	Delete(name *NamespacedName, envName string) error
+	// Delete soft deletes a deployment. ErrConflictNewerVersion is returned when expectedRiserRevision is greater than zero and does not
+	// match the current revision, or when the deployment has an active lock that is not overridden.
+	Delete(name *NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error
 	GetByReservation(reservationId uuid.UUID, envName string) (*Deployment, error)
 	GetByName(name *NamespacedName, envName string) (*Deployment, error)
 	FindByApp(appId uuid.UUID) ([]Deployment, error)
+	// FindByDomains returns all active deployments in an environment that have any of the domains mapped
+	FindByDomains(envName string, domains []string) ([]Deployment, error)
+	// FindByNamespace returns all active deployments in a namespace in an environment
+	FindByNamespace(namespace string, envName string) ([]Deployment, error)
 	UpdateStatus(name *NamespacedName, envName string, status *DeploymentStatus) error
-	UpdateTraffic(name *NamespacedName, envName string, riserRevision int64, traffic TrafficConfig) error
-	IncrementRevision(name *NamespacedName, envName string) (int64, error)
-	RollbackRevision(name *NamespacedName, envName string, failedRevision int64) (int64, error)
+	// UpdateLock sets the lock on a deployment. A nil lock removes the lock.
+	UpdateLock(name *NamespacedName, envName string, lock *DeploymentLock) error
+	// UpdateTraffic updates the traffic and increments the traffic version only if riserRevision and trafficVersion are current and the
+	// deployment does not have an active lock that is not overridden, otherwise ErrConflictNewerVersion is returned.
+	UpdateTraffic(name *NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, traffic TrafficConfig) error
+	// IncrementRevision increments the revision from riserRevision, increments the traffic version, and sets the fields of the revision doc
+	// in a single update. ErrConflictNewerVersion is returned when riserRevision or trafficVersion is not current, or when the deployment
+	// has an active lock that is not overridden. A deployment that was previously deleted is no longer marked as deleted.
+	IncrementRevision(name *NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *DeploymentRevisionDoc) error
+	// RollbackRevision restores the revision doc, traffic version, and deletion time of the previous record when the failed revision is still
+	// current so that a revision that was never deployed does not hold on to its domains, service account, or ConfigMaps.
+	RollbackRevision(name *NamespacedName, envName string, failedRevision int64, previous *DeploymentRecord) (int64, error)
 }
 
 type FakeDeploymentRepository struct {
 	CreateFn                   func(newDeployment *DeploymentRecord) error
 	CreateCallCount            int
-	DeleteFn                   func(name *NamespacedName, envName string) error
+	DeleteFn                   func(name *NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error
 	DeleteCallCount            int
 	GetByNameFn                func(name *NamespacedName, envName string) (*Deployment, error)
 	GetByReservationFn         func(reservationId uuid.UUID, envName string) (*Deployment, error)
 	GetByReservationCallCount  int
 	FindByAppFn                func(uuid.UUID) ([]Deployment, error)
-	IncrementRevisionFn        func(name *NamespacedName, envName string) (int64, error)
+	FindByDomainsFn            func(envName string, domains []string) ([]Deployment, error)
+	FindByDomainsCallCount     int
+	FindByNamespaceFn          func(namespace string, envName string) ([]Deployment, error)
+	IncrementRevisionFn        func(name *NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *DeploymentRevisionDoc) error
 	IncrementRevisionCallCount int
-	RollbackRevisionFn         func(name *NamespacedName, envName string, failedRevision int64) (int64, error)
+	RollbackRevisionFn         func(name *NamespacedName, envName string, failedRevision int64, previous *DeploymentRecord) (int64, error)
+	RollbackRevisionCallCount  int
 	UpdateStatusFn             func(name *NamespacedName, envName string, status *DeploymentStatus) error
 	UpdateStatusCallCount      int
-	UpdateTrafficFn            func(name *NamespacedName, envName string, riserRevision int64, traffic TrafficConfig) error
+	UpdateLockFn               func(name *NamespacedName, envName string, lock *DeploymentLock) error
+	UpdateLockCallCount        int
+	UpdateTrafficFn            func(name *NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, traffic TrafficConfig) error
 	UpdateTrafficCallCount     int
 }
 
@@ -37,9 +58,9 @@ func (f *FakeDeploymentRepository) Create(newDeployment *DeploymentRecord) error
 	return f.CreateFn(newDeployment)
 }
 
-func (f *FakeDeploymentRepository) Delete(name *NamespacedName, envName string) error {
+func (f *FakeDeploymentRepository) Delete(name *NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
 	f.DeleteCallCount++
-	return f.DeleteFn(name, envName)
+	return f.DeleteFn(name, envName, expectedRiserRevision, overrideLock)
 }
 
 func (f *FakeDeploymentRepository) GetByName(name *NamespacedName, envName string) (*Deployment, error) {
@@ -55,13 +76,23 @@ func (fake *FakeDeploymentRepository) FindByApp(appId uuid.UUID) ([]Deployment,
 	return fake.FindByAppFn(appId)
 }
 
-func (fake *FakeDeploymentRepository) IncrementRevision(name *NamespacedName, envName string) (int64, error) {
+func (fake *FakeDeploymentRepository) FindByDomains(envName string, domains []string) ([]Deployment, error) {
+	fake.FindByDomainsCallCount++
+	return fake.FindByDomainsFn(envName, domains)
+}
+
+func (fake *FakeDeploymentRepository) FindByNamespace(namespace string, envName string) ([]Deployment, error) {
+	return fake.FindByNamespaceFn(namespace, envName)
+}
+
+func (fake *FakeDeploymentRepository) IncrementRevision(name *NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *DeploymentRevisionDoc) error {
 	fake.IncrementRevisionCallCount++
-	return fake.IncrementRevisionFn(name, envName)
+	return fake.IncrementRevisionFn(name, envName, riserRevision, trafficVersion, overrideLock, revision)
 }
 
-func (fake *FakeDeploymentRepository) RollbackRevision(name *NamespacedName, envName string, failedRevision int64) (int64, error) {
-	return fake.RollbackRevisionFn(name, envName, failedRevision)
+func (fake *FakeDeploymentRepository) RollbackRevision(name *NamespacedName, envName string, failedRevision int64, previous *DeploymentRecord) (int64, error) {
+	fake.RollbackRevisionCallCount++
+	return fake.RollbackRevisionFn(name, envName, failedRevision, previous)
 }
 
 func (fake *FakeDeploymentRepository) UpdateStatus(name *NamespacedName, envName string, status *DeploymentStatus) error {
@@ -69,7 +100,12 @@ func (fake *FakeDeploymentRepository) UpdateStatus(name *NamespacedName, envName
 	return fake.UpdateStatusFn(name, envName, status)
 }
 
-func (fake *FakeDeploymentRepository) UpdateTraffic(name *NamespacedName, envName string, riserRevision int64, traffic TrafficConfig) error {
+func (fake *FakeDeploymentRepository) UpdateLock(name *NamespacedName, envName string, lock *DeploymentLock) error {
+	fake.UpdateLockCallCount++
+	return fake.UpdateLockFn(name, envName, lock)
+}
+
+func (fake *FakeDeploymentRepository) UpdateTraffic(name *NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, traffic TrafficConfig) error {
 	fake.UpdateTrafficCallCount++
-	return fake.UpdateTrafficFn(name, envName, riserRevision, traffic)
+	return fake.UpdateTrafficFn(name, envName, riserRevision, trafficVersion, overrideLock, traffic)
 }
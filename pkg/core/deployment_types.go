@@ -3,6 +3,7 @@ package core
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -35,6 +36,15 @@ type DeploymentConfig struct {
 	App           *model.AppConfig
 	Traffic       TrafficConfig
 	ManualRollout bool
+	// ExpectedRiserRevision optionally requires the current revision to match before updating. Zero skips the check.
+	ExpectedRiserRevision int64
+	// OverrideLock allows the deployment to be updated even if it's locked
+	OverrideLock bool
+	// Domains are the app's custom domains in the environment. See NewDeploymentDomains.
+	Domains DeploymentDomains
+	// FilesConfigMaps and RemovedFilesConfigMaps are computed along with the traffic. See DeploymentDoc.FilesConfigMaps.
+	FilesConfigMaps        []DeploymentFilesConfigMap
+	RemovedFilesConfigMaps []string
 }
 
 type DeploymentDocker struct {
@@ -53,6 +63,131 @@ type TrafficConfigRule struct {
 type DeploymentDoc struct {
 	Status  *DeploymentStatus   `json:"status,omitempty"`
 	Traffic []TrafficConfigRule `json:"traffic"`
+	// TrafficVersion is incremented by every rollout so that concurrent rollouts of the same revision conflict
+	TrafficVersion int64             `json:"trafficVersion,omitempty"`
+	Lock           *DeploymentLock   `json:"lock,omitempty"`
+	Domains        DeploymentDomains `json:"domains,omitempty"`
+	// Workload is the app's workload as of the last deployment. Deployments from before the workload was recorded are knative.
+	Workload string `json:"workload,omitempty"`
+	// Worker is true when the app did not expose a port as of the last deployment
+	Worker bool `json:"worker,omitempty"`
+	// FilesConfigMaps are the ConfigMaps containing the app's files that are kept for the deployment's revisions. A ConfigMap is removed
+	// by the next deployment once its revision no longer receives traffic and is no longer reported in the status (i.e. the revision was
+	// garbage collected) so that a rollout to any revision in the status still has its files.
+	FilesConfigMaps []DeploymentFilesConfigMap `json:"filesConfigMaps,omitempty"`
+	// ServiceAccount is the service account that the deployment runs under as of the last deployment. The service account is shared by
+	// all of the app's deployments in the environment and is removed along with the last of them.
+	ServiceAccount *DeploymentServiceAccount `json:"serviceAccount,omitempty"`
+}
+
+// DeploymentRevisionDoc contains the fields of a DeploymentDoc that are set with each revision
+type DeploymentRevisionDoc struct {
+	Traffic         TrafficConfig              `json:"traffic"`
+	Domains         DeploymentDomains          `json:"domains"`
+	FilesConfigMaps []DeploymentFilesConfigMap `json:"filesConfigMaps"`
+	Workload        string                     `json:"workload"`
+	Worker          bool                       `json:"worker"`
+	ServiceAccount  *DeploymentServiceAccount  `json:"serviceAccount"`
+}
+
+// RevisionDoc returns the fields of the doc that are set with each revision
+func (doc *DeploymentDoc) RevisionDoc() *DeploymentRevisionDoc {
+	return &DeploymentRevisionDoc{
+		Traffic:         doc.Traffic,
+		Domains:         doc.Domains,
+		FilesConfigMaps: doc.FilesConfigMaps,
+		Workload:        doc.Workload,
+		Worker:          doc.Worker,
+		ServiceAccount:  doc.ServiceAccount,
+	}
+}
+
+type DeploymentServiceAccount struct {
+	Name        string            `json:"name"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// HasAnnotations returns true if the service account has the same annotations. Nil and empty annotations are the same.
+func (serviceAccount *DeploymentServiceAccount) HasAnnotations(annotations map[string]string) bool {
+	if len(serviceAccount.Annotations) != len(annotations) {
+		return false
+	}
+	for key, value := range annotations {
+		if existing, ok := serviceAccount.Annotations[key]; !ok || existing != value {
+			return false
+		}
+	}
+	return true
+}
+
+type DeploymentFilesConfigMap struct {
+	RiserRevision int64  `json:"riserRevision"`
+	Name          string `json:"name"`
+}
+
+type DeploymentDomains []DeploymentDomain
+
+// DeploymentDomain is a custom domain that is mapped to a deployment
+type DeploymentDomain struct {
+	Name string `json:"name"`
+	TLS  bool   `json:"tls"`
+}
+
+// NewDeploymentDomains returns the app's custom domains for the environment
+func NewDeploymentDomains(app *model.AppConfig, environmentConfig *EnvironmentConfig) DeploymentDomains {
+	domains := DeploymentDomains{}
+	if app.Expose == nil {
+		return domains
+	}
+	for _, domain := range app.Expose.Domains {
+		domains = append(domains, DeploymentDomain{Name: domain, TLS: environmentConfig.TLSEnabled()})
+	}
+	return domains
+}
+
+// Names returns the domain names
+func (d DeploymentDomains) Names() []string {
+	names := []string{}
+	for _, domain := range d {
+		names = append(names, domain.Name)
+	}
+	return names
+}
+
+// URL returns the URL that the domain is served from
+func (d DeploymentDomain) URL() string {
+	if d.TLS {
+		return fmt.Sprintf("https://%s", d.Name)
+	}
+	return fmt.Sprintf("http://%s", d.Name)
+}
+
+// DeploymentLock pins a deployment in an environment so that it may not be changed without an explicit override
+type DeploymentLock struct {
+	Holder    string     `json:"holder"`
+	Reason    string     `json:"reason"`
+	Created   time.Time  `json:"created"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// IsActive returns true if the lock exists and has not expired
+func (l *DeploymentLock) IsActive(now time.Time) bool {
+	return l != nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
+
+// CheckLock returns a ValidationError naming the lock holder if the deployment has an active lock and the lock is not overridden.
+// Locks on a deleted deployment are ignored.
+func (d *Deployment) CheckLock(now time.Time, overrideLock bool) error {
+	if overrideLock || d.DeletedAt != nil || !d.Doc.Lock.IsActive(now) {
+		return nil
+	}
+
+	message := fmt.Sprintf("deployment %q is locked in environment %q by %q: %s",
+		NewNamespacedName(d.Name, d.Namespace), d.EnvironmentName, d.Doc.Lock.Holder, d.Doc.Lock.Reason)
+	if d.Doc.Lock.ExpiresAt != nil {
+		message = fmt.Sprintf("%s (expires %s)", message, d.Doc.Lock.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return NewValidationErrorMessage(message + ". Override the lock to proceed.")
 }
 
 type DeploymentStatus struct {
@@ -83,7 +218,9 @@ type DeploymentContext struct {
 	EnvironmentConfig *EnvironmentConfig
 	RiserRevision     int64
 	Secrets           []SecretMeta
-	ManualRollout     bool
+	// NamespaceConfig is nil when the namespace does not have config in the environment
+	NamespaceConfig *NamespaceConfig
+	ManualRollout   bool
 }
 
 // Needed for sql.Scanner interface
@@ -96,11 +233,21 @@ func (a *DeploymentDoc) Scan(value interface{}) error {
 	return jsonbSqlUnmarshal(value, &a)
 }
 
+// Needed for sql.Scanner interface. Normally this is only needed on the "Doc" object but we need this here since we do revision only updates.
+func (a *DeploymentRevisionDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
 // Needed for sql.Scanner interface. Normally this is only needed on the "Doc" object but we need this here since we do status only updates.
 func (a *DeploymentStatus) Value() (driver.Value, error) {
 	return json.Marshal(a)
 }
 
+// Needed for sql.Scanner interface. Normally this is only needed on the "Doc" object but we need this here since we do lock only updates.
+func (a *DeploymentLock) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
 // Needed for sql.Scanner interface. Normally this is only needed on the "Doc" object but we need this here since we do traffic only updates.
 func (a TrafficConfig) Value() (driver.Value, error) {
 	return json.Marshal(a)
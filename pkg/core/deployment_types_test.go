@@ -0,0 +1,97 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DeploymentLock_IsActive(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+
+	var nilLock *DeploymentLock
+	assert.False(t, nilLock.IsActive(now))
+	assert.True(t, (&DeploymentLock{}).IsActive(now))
+	assert.True(t, (&DeploymentLock{ExpiresAt: &future}).IsActive(now))
+	assert.False(t, (&DeploymentLock{ExpiresAt: &past}).IsActive(now))
+}
+
+func Test_Deployment_CheckLock(t *testing.T) {
+	expiresAt := time.Date(2021, 11, 2, 10, 0, 0, 0, time.UTC)
+	deployment := &Deployment{
+		DeploymentReservation: DeploymentReservation{Name: "mydep", Namespace: "myns"},
+		DeploymentRecord: DeploymentRecord{
+			EnvironmentName: "prod",
+			Doc: DeploymentDoc{
+				Lock: &DeploymentLock{Holder: "alice", Reason: "incident 42", ExpiresAt: &expiresAt},
+			},
+		},
+	}
+
+	result := deployment.CheckLock(expiresAt.Add(-time.Hour), false)
+
+	assert.IsType(t, &ValidationError{}, result)
+	assert.Equal(t,
+		`deployment "mydep.myns" is locked in environment "prod" by "alice": incident 42 (expires 2021-11-02T10:00:00Z). Override the lock to proceed.`,
+		result.Error())
+}
+
+func Test_Deployment_CheckLock_NoError(t *testing.T) {
+	now := time.Now()
+	deletedAt := now
+	tt := []struct {
+		deployment   *Deployment
+		overrideLock bool
+	}{
+		{&Deployment{}, false},
+		{&Deployment{DeploymentRecord: DeploymentRecord{Doc: DeploymentDoc{Lock: &DeploymentLock{}}}}, true},
+		{&Deployment{DeploymentRecord: DeploymentRecord{DeletedAt: &deletedAt, Doc: DeploymentDoc{Lock: &DeploymentLock{}}}}, false},
+	}
+
+	for idx, test := range tt {
+		assert.NoError(t, test.deployment.CheckLock(now, test.overrideLock), "test %d", idx)
+	}
+}
+
+func Test_NewDeploymentDomains(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Expose: &model.AppConfigExpose{
+				Domains: []string{"myapp.example.com", "www.myapp.example.com"},
+			},
+		},
+	}
+
+	result := NewDeploymentDomains(app, &EnvironmentConfig{TLSClusterIssuer: "letsencrypt"})
+
+	assert.Equal(t, DeploymentDomains{
+		{Name: "myapp.example.com", TLS: true},
+		{Name: "www.myapp.example.com", TLS: true},
+	}, result)
+	assert.Equal(t, []string{"myapp.example.com", "www.myapp.example.com"}, result.Names())
+}
+
+func Test_NewDeploymentDomains_NoExpose(t *testing.T) {
+	result := NewDeploymentDomains(&model.AppConfig{}, &EnvironmentConfig{})
+
+	assert.Empty(t, result)
+}
+
+func Test_DeploymentDomain_URL(t *testing.T) {
+	assert.Equal(t, "https://myapp.example.com", DeploymentDomain{Name: "myapp.example.com", TLS: true}.URL())
+	assert.Equal(t, "http://myapp.example.com", DeploymentDomain{Name: "myapp.example.com"}.URL())
+}
+
+func Test_DeploymentServiceAccount_HasAnnotations(t *testing.T) {
+	serviceAccount := &DeploymentServiceAccount{Name: "myapp", Annotations: map[string]string{"a": "1"}}
+
+	assert.True(t, serviceAccount.HasAnnotations(map[string]string{"a": "1"}))
+	assert.False(t, serviceAccount.HasAnnotations(map[string]string{"a": "2"}))
+	assert.False(t, serviceAccount.HasAnnotations(map[string]string{"b": "1"}))
+	assert.False(t, serviceAccount.HasAnnotations(nil))
+	assert.True(t, (&DeploymentServiceAccount{Name: "myapp"}).HasAnnotations(map[string]string{}))
+}
@@ -0,0 +1,188 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
+// EnvironmentPolicy is a rule that every deployment to the environment must satisfy. See model.EnvironmentPolicy for the fields.
+type EnvironmentPolicy struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Field  string   `json:"field"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+	Values []string `json:"values,omitempty"`
+}
+
+type EnvironmentPolicies []EnvironmentPolicy
+
+// PolicyInput is what environment policies are evaluated against. Policies for a field whose root is nil are skipped
+// (e.g. docker.tag when validating an app config before it's deployed).
+type PolicyInput struct {
+	App    *model.AppConfig  `json:"app,omitempty"`
+	Docker *DeploymentDocker `json:"docker,omitempty"`
+}
+
+// policyRule returns an error describing why the value violates the policy. The value is nil when the field is not set.
+type policyRule func(policy EnvironmentPolicy, value interface{}) error
+
+// policyRules are the built-in policy types. New policy types only need to be added here and to model.EnvironmentPolicy.
+var policyRules = map[string]policyRule{
+	model.EnvironmentPolicyType_Range:            policyRange,
+	model.EnvironmentPolicyType_AllowedPrefixes:  policyAllowedPrefixes,
+	model.EnvironmentPolicyType_DisallowedValues: policyDisallowedValues,
+	model.EnvironmentPolicyType_Required:         policyRequired,
+}
+
+// Violations returns the policy violations keyed by the policy's field. Fields that are not set violate required and range policies.
+// The input should include the values that the environment sets when the app is deployed (see EnvironmentConfig.EffectiveAppConfig).
+func (p EnvironmentPolicies) Violations(input PolicyInput) (validation.Errors, error) {
+	if len(p) == 0 {
+		return validation.Errors{}, nil
+	}
+
+	doc, err := input.doc()
+	if err != nil {
+		return nil, err
+	}
+
+	violations := map[string][]string{}
+	for _, policy := range p {
+		rule, ok := policyRules[policy.Type]
+		if !ok {
+			return nil, fmt.Errorf("the policy %q has an unknown type %q", policy.Name, policy.Type)
+		}
+		path := strings.Split(policy.Field, ".")
+		if doc[path[0]] == nil {
+			continue
+		}
+		if err := rule(policy, lookupPolicyField(doc, path)); err != nil {
+			violations[policy.Field] = append(violations[policy.Field], fmt.Sprintf("violates policy %q: %s", policy.Name, err))
+		}
+	}
+
+	validationErrors := validation.Errors{}
+	for field, messages := range violations {
+		validationErrors[field] = errors.New(strings.Join(messages, "; "))
+	}
+	return validationErrors, nil
+}
+
+// Validate returns a ValidationError if the input violates any of the policies
+func (p EnvironmentPolicies) Validate(envName string, input PolicyInput) error {
+	violations, err := p.Violations(input)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return NewValidationError(fmt.Sprintf("The deployment violates the policies of environment %q", envName), violations)
+	}
+	return nil
+}
+
+// Warnings returns a sorted message for each policy violation. This is used when validating an app config that is not yet deployed.
+func (p EnvironmentPolicies) Warnings(envName string, input PolicyInput) ([]string, error) {
+	violations, err := p.Violations(input)
+	if err != nil {
+		return nil, err
+	}
+
+	warnings := []string{}
+	for field, violation := range violations {
+		warnings = append(warnings, fmt.Sprintf("The app violates a policy in environment %q: %s %s", envName, field, violation))
+	}
+	sort.Strings(warnings)
+	return warnings, nil
+}
+
+// doc returns the input as generic JSON so that policy fields use the same names as the app config
+func (input PolicyInput) doc() (map[string]interface{}, error) {
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return nil, errors.Wrap(err, "error marshaling policy input")
+	}
+
+	doc := map[string]interface{}{}
+	err = json.Unmarshal(raw, &doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling policy input")
+	}
+	return doc, nil
+}
+
+func lookupPolicyField(doc map[string]interface{}, path []string) interface{} {
+	var value interface{} = doc
+	for _, segment := range path {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = obj[segment]
+	}
+	return value
+}
+
+func policyRange(policy EnvironmentPolicy, value interface{}) error {
+	// An unset field violates the range so that the policy can't be bypassed by omitting the field
+	if value == nil {
+		return errors.New("is required")
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return errors.New("must be a number")
+	}
+	if policy.Min != nil && number < *policy.Min {
+		return fmt.Errorf("must be no less than %v", *policy.Min)
+	}
+	if policy.Max != nil && number > *policy.Max {
+		return fmt.Errorf("must be no greater than %v", *policy.Max)
+	}
+	return nil
+}
+
+func policyAllowedPrefixes(policy EnvironmentPolicy, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return errors.New("must be a string")
+	}
+	for _, prefix := range policy.Values {
+		if strings.HasPrefix(str, prefix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("must start with one of: %s", strings.Join(policy.Values, ", "))
+}
+
+func policyDisallowedValues(policy EnvironmentPolicy, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return errors.New("must be a string")
+	}
+	for _, disallowed := range policy.Values {
+		if str == disallowed {
+			return fmt.Errorf("must not be one of: %s", strings.Join(policy.Values, ", "))
+		}
+	}
+	return nil
+}
+
+func policyRequired(policy EnvironmentPolicy, value interface{}) error {
+	if validation.IsEmpty(value) {
+		return errors.New("is required")
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvironmentPolicies_Violations(t *testing.T) {
+	memoryMB := int32(8192)
+	policies := EnvironmentPolicies{
+		{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: util.PtrFloat64(2)},
+		{Name: "max-memory", Type: model.EnvironmentPolicyType_Range, Field: "app.resources.memoryMB", Max: util.PtrFloat64(4096)},
+		{Name: "registry", Type: model.EnvironmentPolicyType_AllowedPrefixes, Field: "app.image", Values: []string{"registry.example.com/"}},
+		{Name: "no-latest", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "docker.tag", Values: []string{"latest"}},
+		{Name: "health", Type: model.EnvironmentPolicyType_Required, Field: "app.healthcheck"},
+	}
+	input := PolicyInput{
+		App: &model.AppConfig{
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image:     "docker.io/myimage",
+				Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(0)},
+				Resources: &model.AppConfigResources{MemoryMB: &memoryMB},
+			},
+		},
+		Docker: &DeploymentDocker{Tag: "latest"},
+	}
+
+	result, err := policies.Violations(input)
+
+	require.NoError(t, err)
+	assert.Len(t, result, 5)
+	assert.Equal(t, `violates policy "min-scale": must be no less than 2`, result["app.autoscale.min"].Error())
+	assert.Equal(t, `violates policy "max-memory": must be no greater than 4096`, result["app.resources.memoryMB"].Error())
+	assert.Equal(t, `violates policy "registry": must start with one of: registry.example.com/`, result["app.image"].Error())
+	assert.Equal(t, `violates policy "no-latest": must not be one of: latest`, result["docker.tag"].Error())
+	assert.Equal(t, `violates policy "health": is required`, result["app.healthcheck"].Error())
+}
+
+func Test_EnvironmentPolicies_Violations_Satisfied(t *testing.T) {
+	policies := EnvironmentPolicies{
+		{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: util.PtrFloat64(2)},
+		{Name: "registry", Type: model.EnvironmentPolicyType_AllowedPrefixes, Field: "app.image", Values: []string{"registry.example.com/"}},
+		{Name: "no-latest", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "docker.tag", Values: []string{"latest"}},
+		// Unset fields do not violate allowedPrefixes or disallowedValues policies
+		{Name: "no-root-healthcheck", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "app.healthcheck.path", Values: []string{"/"}},
+	}
+	input := PolicyInput{
+		App: &model.AppConfig{
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image:     "registry.example.com/myimage",
+				Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(2)},
+			},
+		},
+		Docker: &DeploymentDocker{Tag: "1.0.0"},
+	}
+
+	result, err := policies.Violations(input)
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func Test_EnvironmentPolicies_Violations_RangeRequiresField(t *testing.T) {
+	policies := EnvironmentPolicies{
+		{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: util.PtrFloat64(2)},
+	}
+
+	result, err := policies.Violations(PolicyInput{App: &model.AppConfig{}})
+
+	require.NoError(t, err)
+	assert.Equal(t, `violates policy "min-scale": is required`, result["app.autoscale.min"].Error())
+}
+
+func Test_EnvironmentPolicies_Violations_SkipsNilRoot(t *testing.T) {
+	policies := EnvironmentPolicies{
+		{Name: "tag", Type: model.EnvironmentPolicyType_Required, Field: "docker.tag"},
+	}
+
+	result, err := policies.Violations(PolicyInput{App: &model.AppConfig{}})
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func Test_EnvironmentPolicies_Violations_MultiplePoliciesForField(t *testing.T) {
+	policies := EnvironmentPolicies{
+		{Name: "registry", Type: model.EnvironmentPolicyType_AllowedPrefixes, Field: "app.image", Values: []string{"registry.example.com/"}},
+		{Name: "no-busybox", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "app.image", Values: []string{"busybox"}},
+	}
+	input := PolicyInput{App: &model.AppConfig{OverrideableAppConfig: model.OverrideableAppConfig{Image: "busybox"}}}
+
+	result, err := policies.Violations(input)
+
+	require.NoError(t, err)
+	assert.Equal(t, `violates policy "registry": must start with one of: registry.example.com/; violates policy "no-busybox": must not be one of: busybox`,
+		result["app.image"].Error())
+}
+
+func Test_EnvironmentPolicies_Violations_WrongValueType(t *testing.T) {
+	policies := EnvironmentPolicies{
+		{Name: "scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale", Min: util.PtrFloat64(1)},
+		{Name: "name", Type: model.EnvironmentPolicyType_AllowedPrefixes, Field: "app.autoscale.min", Values: []string{"a"}},
+	}
+	input := PolicyInput{App: &model.AppConfig{OverrideableAppConfig: model.OverrideableAppConfig{Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(1)}}}}
+
+	result, err := policies.Violations(input)
+
+	require.NoError(t, err)
+	assert.Equal(t, `violates policy "scale": must be a number`, result["app.autoscale"].Error())
+	assert.Equal(t, `violates policy "name": must be a string`, result["app.autoscale.min"].Error())
+}
+
+func Test_EnvironmentPolicies_Violations_UnknownType(t *testing.T) {
+	policies := EnvironmentPolicies{{Name: "bad", Type: "cel", Field: "app.image"}}
+
+	result, err := policies.Violations(PolicyInput{App: &model.AppConfig{}})
+
+	assert.Nil(t, result)
+	assert.Equal(t, `the policy "bad" has an unknown type "cel"`, err.Error())
+}
+
+func Test_EnvironmentPolicies_Validate(t *testing.T) {
+	policies := EnvironmentPolicies{
+		{Name: "no-latest", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "docker.tag", Values: []string{"latest"}},
+	}
+
+	err := policies.Validate("prod", PolicyInput{Docker: &DeploymentDocker{Tag: "latest"}})
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErr := err.(*ValidationError)
+	assert.Equal(t, `The deployment violates the policies of environment "prod"`, validationErr.Message)
+	assert.Equal(t, `violates policy "no-latest": must not be one of: latest`, validationErr.ValidationError.(validation.Errors)["docker.tag"].Error())
+
+	assert.NoError(t, policies.Validate("prod", PolicyInput{Docker: &DeploymentDocker{Tag: "1.0.0"}}))
+	assert.NoError(t, EnvironmentPolicies{}.Validate("prod", PolicyInput{}))
+}
+
+func Test_EnvironmentPolicies_Warnings(t *testing.T) {
+	policies := EnvironmentPolicies{
+		{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: util.PtrFloat64(2)},
+		{Name: "health", Type: model.EnvironmentPolicyType_Required, Field: "app.healthcheck"},
+	}
+	input := PolicyInput{App: &model.AppConfig{OverrideableAppConfig: model.OverrideableAppConfig{Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(1)}}}}
+
+	result, err := policies.Warnings("prod", input)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`The app violates a policy in environment "prod": app.autoscale.min violates policy "min-scale": must be no less than 2`,
+		`The app violates a policy in environment "prod": app.healthcheck violates policy "health": is required`,
+	}, result)
+}
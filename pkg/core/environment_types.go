@@ -3,7 +3,13 @@ package core
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
 )
 
 type Environment struct {
@@ -17,8 +23,306 @@ type EnvironmentDoc struct {
 }
 
 type EnvironmentConfig struct {
-	SealedSecretCert  []byte `json:"sealedSecretCert"`
-	PublicGatewayHost string `json:"publicGatewayHost"`
+	SealedSecretCert  []byte               `json:"sealedSecretCert"`
+	PublicGatewayHost string               `json:"publicGatewayHost"`
+	Resources         EnvironmentResources `json:"resources"`
+	Requests          EnvironmentRequests  `json:"requests"`
+	Security          EnvironmentSecurity  `json:"security"`
+	// TLSClusterIssuer is the name of the cert-manager ClusterIssuer used to issue certificates for custom domains. TLS is disabled when empty.
+	TLSClusterIssuer string `json:"tlsClusterIssuer,omitempty"`
+	// DefaultDenyNamespaces are namespaces where requests between apps are denied unless allowed by an app's expose.allowFrom
+	DefaultDenyNamespaces []string `json:"defaultDenyNamespaces,omitempty"`
+	// AllowedServiceAccountAnnotations are the annotation keys that apps may set with serviceAccount.annotations
+	AllowedServiceAccountAnnotations []string `json:"allowedServiceAccountAnnotations,omitempty"`
+	// AppDefaults are merged into the config of every app deployed to the environment. See model.AppConfigWithOverrides.ApplyEnvironment.
+	AppDefaults *model.OverrideableAppConfig `json:"appDefaults,omitempty"`
+	// Policies are rules that every deployment to the environment must satisfy
+	Policies EnvironmentPolicies `json:"policies,omitempty"`
+}
+
+// IsDefaultDeny returns true if requests between apps in the namespace are denied by default
+func (cfg EnvironmentConfig) IsDefaultDeny(namespace string) bool {
+	for _, defaultDenyNamespace := range cfg.DefaultDenyNamespaces {
+		if defaultDenyNamespace == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateServiceAccountAnnotations returns a ValidationError if the app sets a service account annotation that is not allowed
+func (cfg EnvironmentConfig) ValidateServiceAccountAnnotations(envName string, app *model.AppConfig) error {
+	if app.ServiceAccount == nil {
+		return nil
+	}
+	allowed := map[string]bool{}
+	for _, key := range cfg.AllowedServiceAccountAnnotations {
+		allowed[key] = true
+	}
+
+	validationErrors := validation.Errors{}
+	for key := range app.ServiceAccount.Annotations {
+		if !allowed[key] {
+			validationErrors[fmt.Sprintf("serviceAccount.annotations.%s", key)] = errors.New("is not an allowed annotation")
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return NewValidationError(fmt.Sprintf("The app's service account annotations are not allowed in environment %q", envName), validationErrors)
+	}
+	return nil
+}
+
+// TLSEnabled returns true if certificates should be issued for custom domains
+func (cfg EnvironmentConfig) TLSEnabled() bool {
+	return cfg.TLSClusterIssuer != ""
+}
+
+// EffectiveAppConfig returns a copy of the app with the values that the environment sets when the app is deployed (e.g. resource
+// defaults). Environment policies are evaluated against the effective app config.
+func (cfg EnvironmentConfig) EffectiveAppConfig(app *model.AppConfig) *model.AppConfig {
+	if app == nil {
+		return nil
+	}
+	out := *app
+	out.Resources = cfg.Resources.ApplyDefaults(app.Resources)
+	return &out
+}
+
+// EnvironmentResources contains container resource defaults and maximums for all apps deployed to the environment. The defaults only fill
+// in values that are still unset after the app config is merged with the environment's appDefaults (see ApplyDefaults), so
+// appDefaults.resources takes precedence over them.
+type EnvironmentResources struct {
+	DefaultRequests ResourceQuantities `json:"defaultRequests"`
+	DefaultLimits   ResourceQuantities `json:"defaultLimits"`
+	// Max applies to both requests and limits
+	Max ResourceQuantities `json:"max"`
+}
+
+type ResourceQuantities struct {
+	CpuCores *float32 `json:"cpuCores,omitempty"`
+	MemoryMB *int32   `json:"memoryMB,omitempty"`
+}
+
+// ApplyDefaults returns a copy of the app's resources with any unset request or limit set to the environment default.
+// A defaulted request is never greater than the limit and a defaulted limit is never less than the request.
+func (r EnvironmentResources) ApplyDefaults(appResources *model.AppConfigResources) *model.AppConfigResources {
+	out := &model.AppConfigResources{Requests: &model.ResourceQuantities{}}
+	if appResources != nil {
+		out.CpuCores = appResources.CpuCores
+		out.MemoryMB = appResources.MemoryMB
+		if appResources.Requests != nil {
+			out.Requests.CpuCores = appResources.Requests.CpuCores
+			out.Requests.MemoryMB = appResources.Requests.MemoryMB
+		}
+	}
+
+	if out.CpuCores == nil {
+		out.CpuCores = maxFloat32(r.DefaultLimits.CpuCores, out.Requests.CpuCores)
+	}
+	if out.MemoryMB == nil {
+		out.MemoryMB = maxInt32(r.DefaultLimits.MemoryMB, out.Requests.MemoryMB)
+	}
+	if out.Requests.CpuCores == nil {
+		out.Requests.CpuCores = minFloat32(r.DefaultRequests.CpuCores, out.CpuCores)
+	}
+	if out.Requests.MemoryMB == nil {
+		out.Requests.MemoryMB = minInt32(r.DefaultRequests.MemoryMB, out.MemoryMB)
+	}
+
+	return out
+}
+
+// ValidateMax returns a ValidationError if any of the app's requests or limits are greater than the environment maximum. The environment
+// defaults are applied first, and a limit that is still unset is not allowed when there is a maximum since the container would be unlimited.
+func (r EnvironmentResources) ValidateMax(envName string, appResources *model.AppConfigResources) error {
+	resources := r.ApplyDefaults(appResources)
+
+	validationErrors := validation.Errors{}
+	checkMaxFloat32(validationErrors, "resources.cpuCores", resources.CpuCores, r.Max.CpuCores)
+	checkMaxInt32(validationErrors, "resources.memoryMB", resources.MemoryMB, r.Max.MemoryMB)
+	checkMaxFloat32(validationErrors, "resources.requests.cpuCores", resources.Requests.CpuCores, r.Max.CpuCores)
+	checkMaxInt32(validationErrors, "resources.requests.memoryMB", resources.Requests.MemoryMB, r.Max.MemoryMB)
+	if resources.CpuCores == nil && r.Max.CpuCores != nil {
+		validationErrors["resources.cpuCores"] = fmt.Errorf("is required since the environment has a maximum of %v", *r.Max.CpuCores)
+	}
+	if resources.MemoryMB == nil && r.Max.MemoryMB != nil {
+		validationErrors["resources.memoryMB"] = fmt.Errorf("is required since the environment has a maximum of %d", *r.Max.MemoryMB)
+	}
+
+	if len(validationErrors) > 0 {
+		return NewValidationError(fmt.Sprintf("The app's resources exceed the maximum allowed in environment %q", envName), validationErrors)
+	}
+	return nil
+}
+
+// ValidateDefaults returns a ValidationError if a default is greater than the maximum, or if there is a maximum without a default limit since
+// apps without resources would be unlimited. The environment config is set partially, so this is validated after merging with the existing config.
+func (r EnvironmentResources) ValidateDefaults(envName string) error {
+	validationErrors := validation.Errors{}
+	for fieldName, quantities := range map[string]ResourceQuantities{"defaultRequests": r.DefaultRequests, "defaultLimits": r.DefaultLimits} {
+		if r.Max.CpuCores != nil {
+			if quantities.CpuCores == nil && fieldName == "defaultLimits" {
+				validationErrors["resources.defaultLimits.cpuCores"] = errors.New("is required when resources.max.cpuCores is set")
+			} else if quantities.CpuCores != nil && *quantities.CpuCores > *r.Max.CpuCores {
+				validationErrors[fmt.Sprintf("resources.%s.cpuCores", fieldName)] = errors.New("must be less than or equal to resources.max.cpuCores")
+			}
+		}
+		if r.Max.MemoryMB != nil {
+			if quantities.MemoryMB == nil && fieldName == "defaultLimits" {
+				validationErrors["resources.defaultLimits.memoryMB"] = errors.New("is required when resources.max.memoryMB is set")
+			} else if quantities.MemoryMB != nil && *quantities.MemoryMB > *r.Max.MemoryMB {
+				validationErrors[fmt.Sprintf("resources.%s.memoryMB", fieldName)] = errors.New("must be less than or equal to resources.max.memoryMB")
+			}
+		}
+	}
+
+	if len(validationErrors) > 0 {
+		return NewValidationError(fmt.Sprintf("The resource defaults are not valid in environment %q", envName), validationErrors)
+	}
+	return nil
+}
+
+// EnvironmentRequests contains maximums for how apps deployed to the environment handle requests
+type EnvironmentRequests struct {
+	// MaxContainerConcurrency is the max expose.containerConcurrency. Apps may not allow unlimited concurrent requests when set.
+	MaxContainerConcurrency *int64 `json:"maxContainerConcurrency,omitempty"`
+	// MaxTimeoutSeconds is the max expose.timeoutSeconds. It should not exceed KNative's max-revision-timeout-seconds.
+	MaxTimeoutSeconds *int64 `json:"maxTimeoutSeconds,omitempty"`
+}
+
+// ValidateMax returns a ValidationError if any of the app's request settings are greater than the environment maximum
+func (r EnvironmentRequests) ValidateMax(envName string, expose *model.AppConfigExpose) error {
+	if expose == nil {
+		return nil
+	}
+
+	validationErrors := validation.Errors{}
+	if expose.ContainerConcurrency != nil && r.MaxContainerConcurrency != nil && *expose.ContainerConcurrency == 0 {
+		validationErrors["expose.containerConcurrency"] = fmt.Errorf("must be between 1 and %d", *r.MaxContainerConcurrency)
+	} else {
+		checkMaxInt64(validationErrors, "expose.containerConcurrency", expose.ContainerConcurrency, r.MaxContainerConcurrency)
+	}
+	checkMaxInt64(validationErrors, "expose.timeoutSeconds", expose.TimeoutSeconds, r.MaxTimeoutSeconds)
+
+	if len(validationErrors) > 0 {
+		return NewValidationError(fmt.Sprintf("The app's request settings exceed the maximum allowed in environment %q", envName), validationErrors)
+	}
+	return nil
+}
+
+// EnvironmentSecurity contains the security settings that are applied to the containers of all apps deployed to the environment
+type EnvironmentSecurity struct {
+	RunAsNonRoot           bool `json:"runAsNonRoot,omitempty"`
+	ReadOnlyRootFilesystem bool `json:"readOnlyRootFilesystem,omitempty"`
+	// DropCapabilities are the Linux capabilities that are dropped from containers (e.g. ALL)
+	DropCapabilities []string `json:"dropCapabilities,omitempty"`
+	// SeccompProfile is the seccomp profile type of containers. Only RuntimeDefault is currently supported.
+	SeccompProfile string `json:"seccompProfile,omitempty"`
+	// AllowedOptOuts are the settings that apps may opt out of with security.optOut
+	AllowedOptOuts []string `json:"allowedOptOuts,omitempty"`
+}
+
+// Enforces returns true if the environment applies the security setting (e.g. readOnlyRootFilesystem) to containers
+func (s EnvironmentSecurity) Enforces(securitySetting string) bool {
+	switch securitySetting {
+	case model.SecuritySetting_RunAsNonRoot:
+		return s.RunAsNonRoot
+	case model.SecuritySetting_ReadOnlyRootFilesystem:
+		return s.ReadOnlyRootFilesystem
+	case model.SecuritySetting_DropCapabilities:
+		return len(s.DropCapabilities) > 0
+	case model.SecuritySetting_SeccompProfile:
+		return s.SeccompProfile != ""
+	}
+	return false
+}
+
+// Applies returns true if the security setting is enforced by the environment and the app has not opted out of it
+func (s EnvironmentSecurity) Applies(securitySetting string, app *model.AppConfig) bool {
+	return s.Enforces(securitySetting) && !app.OptsOutOf(securitySetting)
+}
+
+// DisallowedOptOuts returns the app's security opt-outs for settings that the environment enforces without allowing an opt-out.
+// Opting out of a setting that the environment does not enforce is allowed since it has no effect.
+func (s EnvironmentSecurity) DisallowedOptOuts(app *model.AppConfig) []string {
+	if app.Security == nil {
+		return nil
+	}
+	disallowed := []string{}
+	for _, optOut := range app.Security.OptOut {
+		if s.Enforces(optOut) && !s.allowsOptOut(optOut) {
+			disallowed = append(disallowed, optOut)
+		}
+	}
+	return disallowed
+}
+
+// ValidateOptOuts returns a ValidationError if the app opts out of a security setting that the environment does not allow an opt-out for
+func (s EnvironmentSecurity) ValidateOptOuts(envName string, app *model.AppConfig) error {
+	disallowed := s.DisallowedOptOuts(app)
+	if len(disallowed) == 0 {
+		return nil
+	}
+	return NewValidationError(fmt.Sprintf("The app's security opt-outs are not allowed in environment %q", envName),
+		validation.Errors{"security.optOut": fmt.Errorf("the environment does not allow opting out of: %s", strings.Join(disallowed, ", "))})
+}
+
+func (s EnvironmentSecurity) allowsOptOut(securitySetting string) bool {
+	for _, allowed := range s.AllowedOptOuts {
+		if allowed == securitySetting {
+			return true
+		}
+	}
+	return false
+}
+
+func checkMaxFloat32(validationErrors validation.Errors, fieldName string, value *float32, max *float32) {
+	if value != nil && max != nil && *value > *max {
+		validationErrors[fieldName] = fmt.Errorf("must be no greater than %v", *max)
+	}
+}
+
+func checkMaxInt32(validationErrors validation.Errors, fieldName string, value *int32, max *int32) {
+	if value != nil && max != nil && *value > *max {
+		validationErrors[fieldName] = fmt.Errorf("must be no greater than %d", *max)
+	}
+}
+
+func checkMaxInt64(validationErrors validation.Errors, fieldName string, value *int64, max *int64) {
+	if value != nil && max != nil && *value > *max {
+		validationErrors[fieldName] = fmt.Errorf("must be no greater than %d", *max)
+	}
+}
+
+// minFloat32 returns the default capped at the limit. A nil default returns nil.
+func minFloat32(defaultValue *float32, limit *float32) *float32 {
+	if defaultValue == nil || limit == nil || *defaultValue <= *limit {
+		return defaultValue
+	}
+	return limit
+}
+
+// maxFloat32 returns the default raised to the request. A nil default returns nil.
+func maxFloat32(defaultValue *float32, request *float32) *float32 {
+	if defaultValue == nil || request == nil || *defaultValue >= *request {
+		return defaultValue
+	}
+	return request
+}
+
+func minInt32(defaultValue *int32, limit *int32) *int32 {
+	if defaultValue == nil || limit == nil || *defaultValue <= *limit {
+		return defaultValue
+	}
+	return limit
+}
+
+func maxInt32(defaultValue *int32, request *int32) *int32 {
+	if defaultValue == nil || request == nil || *defaultValue >= *request {
+		return defaultValue
+	}
+	return request
 }
 
 // Needed for sql.Scanner interface
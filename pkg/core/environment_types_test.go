@@ -0,0 +1,366 @@
+package core
+
+import (
+	"testing"
+
+	validation "github.com/go-ozzo/ozzo-validation/v3"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EnvironmentResources_ApplyDefaults(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultRequests: ResourceQuantities{CpuCores: util.PtrFloat32(0.25), MemoryMB: util.PtrInt32(128)},
+		DefaultLimits:   ResourceQuantities{CpuCores: util.PtrFloat32(1), MemoryMB: util.PtrInt32(512)},
+	}
+
+	result := envResources.ApplyDefaults(nil)
+
+	assert.EqualValues(t, 1, *result.CpuCores)
+	assert.EqualValues(t, 512, *result.MemoryMB)
+	assert.EqualValues(t, 0.25, *result.Requests.CpuCores)
+	assert.EqualValues(t, 128, *result.Requests.MemoryMB)
+}
+
+func Test_EnvironmentResources_ApplyDefaults_AppValuesTakePrecedence(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultRequests: ResourceQuantities{CpuCores: util.PtrFloat32(0.25), MemoryMB: util.PtrInt32(128)},
+		DefaultLimits:   ResourceQuantities{CpuCores: util.PtrFloat32(1), MemoryMB: util.PtrInt32(512)},
+	}
+	appResources := &model.AppConfigResources{
+		CpuCores: util.PtrFloat32(2),
+		Requests: &model.ResourceQuantities{MemoryMB: util.PtrInt32(256)},
+	}
+
+	result := envResources.ApplyDefaults(appResources)
+
+	assert.EqualValues(t, 2, *result.CpuCores)
+	assert.EqualValues(t, 512, *result.MemoryMB)
+	assert.EqualValues(t, 0.25, *result.Requests.CpuCores)
+	assert.EqualValues(t, 256, *result.Requests.MemoryMB)
+	// Ensure that we don't mutate the app's resources
+	assert.Nil(t, appResources.MemoryMB)
+	assert.Nil(t, appResources.Requests.CpuCores)
+}
+
+func Test_EnvironmentResources_ApplyDefaults_RequestsNeverExceedLimits(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultRequests: ResourceQuantities{CpuCores: util.PtrFloat32(0.5), MemoryMB: util.PtrInt32(256)},
+		DefaultLimits:   ResourceQuantities{CpuCores: util.PtrFloat32(1), MemoryMB: util.PtrInt32(512)},
+	}
+	appResources := &model.AppConfigResources{
+		CpuCores: util.PtrFloat32(0.1),
+		Requests: &model.ResourceQuantities{MemoryMB: util.PtrInt32(1024)},
+	}
+
+	result := envResources.ApplyDefaults(appResources)
+
+	assert.EqualValues(t, 0.1, *result.CpuCores)
+	assert.EqualValues(t, 0.1, *result.Requests.CpuCores)
+	assert.EqualValues(t, 1024, *result.MemoryMB)
+	assert.EqualValues(t, 1024, *result.Requests.MemoryMB)
+}
+
+func Test_EnvironmentResources_ApplyDefaults_NoDefaults(t *testing.T) {
+	result := EnvironmentResources{}.ApplyDefaults(nil)
+
+	assert.Nil(t, result.CpuCores)
+	assert.Nil(t, result.MemoryMB)
+	assert.Nil(t, result.Requests.CpuCores)
+	assert.Nil(t, result.Requests.MemoryMB)
+}
+
+func Test_EnvironmentConfig_EffectiveAppConfig(t *testing.T) {
+	cfg := EnvironmentConfig{
+		Resources: EnvironmentResources{DefaultLimits: ResourceQuantities{MemoryMB: util.PtrInt32(512)}},
+	}
+	app := &model.AppConfig{Name: "myapp"}
+
+	result := cfg.EffectiveAppConfig(app)
+
+	assert.Equal(t, model.AppName("myapp"), result.Name)
+	assert.EqualValues(t, 512, *result.Resources.MemoryMB)
+	assert.Nil(t, app.Resources, "the app should not be modified")
+	assert.Nil(t, cfg.EffectiveAppConfig(nil))
+}
+
+func Test_EnvironmentResources_ValidateMax(t *testing.T) {
+	envResources := EnvironmentResources{
+		Max: ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(1024)},
+	}
+	appResources := &model.AppConfigResources{
+		CpuCores: util.PtrFloat32(4),
+		MemoryMB: util.PtrInt32(1024),
+		Requests: &model.ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(2048)},
+	}
+
+	err := envResources.ValidateMax("prod", appResources)
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErr := err.(*ValidationError)
+	assert.Equal(t, `The app's resources exceed the maximum allowed in environment "prod"`, validationErr.Message)
+	validationErrors := validationErr.ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must be no greater than 2", validationErrors["resources.cpuCores"].Error())
+	assert.Equal(t, "must be no greater than 1024", validationErrors["resources.requests.memoryMB"].Error())
+}
+
+func Test_EnvironmentResources_ValidateMax_WithinMax(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultLimits: ResourceQuantities{CpuCores: util.PtrFloat32(1)},
+		Max:           ResourceQuantities{CpuCores: util.PtrFloat32(2)},
+	}
+	appResources := &model.AppConfigResources{
+		CpuCores: util.PtrFloat32(2),
+		MemoryMB: util.PtrInt32(8192),
+	}
+
+	assert.NoError(t, envResources.ValidateMax("prod", appResources))
+	assert.NoError(t, envResources.ValidateMax("prod", nil))
+}
+
+func Test_EnvironmentResources_ValidateMax_DefaultsExceedMax(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultRequests: ResourceQuantities{MemoryMB: util.PtrInt32(2048)},
+		Max:             ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(1024)},
+	}
+
+	err := envResources.ValidateMax("prod", nil)
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErrors := err.(*ValidationError).ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 3)
+	assert.Equal(t, "is required since the environment has a maximum of 2", validationErrors["resources.cpuCores"].Error())
+	assert.Equal(t, "is required since the environment has a maximum of 1024", validationErrors["resources.memoryMB"].Error())
+	assert.Equal(t, "must be no greater than 1024", validationErrors["resources.requests.memoryMB"].Error())
+}
+
+func Test_EnvironmentResources_AppDefaultsTakePrecedence(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultRequests: ResourceQuantities{CpuCores: util.PtrFloat32(0.25), MemoryMB: util.PtrInt32(128)},
+		DefaultLimits:   ResourceQuantities{CpuCores: util.PtrFloat32(1), MemoryMB: util.PtrInt32(512)},
+	}
+	envAppDefaults := &model.OverrideableAppConfig{
+		Resources: &model.AppConfigResources{MemoryMB: util.PtrInt32(1024)},
+	}
+	app := &model.AppConfigWithOverrides{
+		AppConfig: model.AppConfig{
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Resources: &model.AppConfigResources{CpuCores: util.PtrFloat32(2)},
+			},
+		},
+	}
+
+	appConfig, err := app.ApplyEnvironment("prod", envAppDefaults)
+	require.NoError(t, err)
+	result := envResources.ApplyDefaults(appConfig.Resources)
+
+	assert.EqualValues(t, 2, *result.CpuCores)
+	assert.EqualValues(t, 1024, *result.MemoryMB)
+	assert.EqualValues(t, 0.25, *result.Requests.CpuCores)
+	assert.EqualValues(t, 128, *result.Requests.MemoryMB)
+}
+
+func Test_EnvironmentResources_ValidateDefaults(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultRequests: ResourceQuantities{CpuCores: util.PtrFloat32(0.5), MemoryMB: util.PtrInt32(128)},
+		DefaultLimits:   ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(512)},
+		Max:             ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(1024)},
+	}
+
+	assert.NoError(t, envResources.ValidateDefaults("prod"))
+	assert.NoError(t, EnvironmentResources{}.ValidateDefaults("prod"))
+}
+
+func Test_EnvironmentResources_ValidateDefaults_DefaultsExceedMax(t *testing.T) {
+	envResources := EnvironmentResources{
+		DefaultRequests: ResourceQuantities{MemoryMB: util.PtrInt32(2048)},
+		DefaultLimits:   ResourceQuantities{CpuCores: util.PtrFloat32(4), MemoryMB: util.PtrInt32(2048)},
+		Max:             ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(1024)},
+	}
+
+	err := envResources.ValidateDefaults("prod")
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErrors := err.(*ValidationError).ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 3)
+	assert.Equal(t, "must be less than or equal to resources.max.memoryMB", validationErrors["resources.defaultRequests.memoryMB"].Error())
+	assert.Equal(t, "must be less than or equal to resources.max.cpuCores", validationErrors["resources.defaultLimits.cpuCores"].Error())
+	assert.Equal(t, "must be less than or equal to resources.max.memoryMB", validationErrors["resources.defaultLimits.memoryMB"].Error())
+}
+
+func Test_EnvironmentResources_ValidateDefaults_MaxRequiresDefaultLimits(t *testing.T) {
+	envResources := EnvironmentResources{
+		Max: ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(1024)},
+	}
+
+	err := envResources.ValidateDefaults("prod")
+
+	require.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, `The resource defaults are not valid in environment "prod": `+
+		`resources.defaultLimits.cpuCores: is required when resources.max.cpuCores is set; `+
+		`resources.defaultLimits.memoryMB: is required when resources.max.memoryMB is set.`, err.Error())
+}
+
+func Test_EnvironmentRequests_ValidateMax(t *testing.T) {
+	envRequests := EnvironmentRequests{
+		MaxContainerConcurrency: util.PtrInt64(100),
+		MaxTimeoutSeconds:       util.PtrInt64(300),
+	}
+	expose := &model.AppConfigExpose{
+		ContainerConcurrency: util.PtrInt64(0),
+		TimeoutSeconds:       util.PtrInt64(3600),
+	}
+
+	err := envRequests.ValidateMax("prod", expose)
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErr := err.(*ValidationError)
+	assert.Equal(t, `The app's request settings exceed the maximum allowed in environment "prod"`, validationErr.Message)
+	validationErrors := validationErr.ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 2)
+	assert.Equal(t, "must be between 1 and 100", validationErrors["expose.containerConcurrency"].Error())
+	assert.Equal(t, "must be no greater than 300", validationErrors["expose.timeoutSeconds"].Error())
+}
+
+func Test_EnvironmentRequests_ValidateMax_ContainerConcurrency(t *testing.T) {
+	envRequests := EnvironmentRequests{MaxContainerConcurrency: util.PtrInt64(100)}
+
+	err := envRequests.ValidateMax("prod", &model.AppConfigExpose{ContainerConcurrency: util.PtrInt64(101)})
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErrors := err.(*ValidationError).ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "must be no greater than 100", validationErrors["expose.containerConcurrency"].Error())
+}
+
+func Test_EnvironmentRequests_ValidateMax_WithinMax(t *testing.T) {
+	envRequests := EnvironmentRequests{MaxTimeoutSeconds: util.PtrInt64(300)}
+	expose := &model.AppConfigExpose{
+		ContainerConcurrency: util.PtrInt64(0),
+		TimeoutSeconds:       util.PtrInt64(300),
+	}
+
+	assert.NoError(t, envRequests.ValidateMax("prod", expose))
+	assert.NoError(t, envRequests.ValidateMax("prod", &model.AppConfigExpose{}))
+	assert.NoError(t, envRequests.ValidateMax("prod", nil))
+}
+
+func Test_EnvironmentSecurity_Enforces(t *testing.T) {
+	security := EnvironmentSecurity{
+		RunAsNonRoot:     true,
+		DropCapabilities: []string{"ALL"},
+	}
+
+	assert.True(t, security.Enforces(model.SecuritySetting_RunAsNonRoot))
+	assert.True(t, security.Enforces(model.SecuritySetting_DropCapabilities))
+	assert.False(t, security.Enforces(model.SecuritySetting_ReadOnlyRootFilesystem))
+	assert.False(t, security.Enforces(model.SecuritySetting_SeccompProfile))
+	assert.False(t, security.Enforces("unknown"))
+}
+
+func Test_EnvironmentSecurity_Applies(t *testing.T) {
+	security := EnvironmentSecurity{RunAsNonRoot: true, ReadOnlyRootFilesystem: true}
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Security: &model.AppConfigSecurity{OptOut: []string{model.SecuritySetting_ReadOnlyRootFilesystem}},
+		},
+	}
+
+	assert.True(t, security.Applies(model.SecuritySetting_RunAsNonRoot, app))
+	assert.False(t, security.Applies(model.SecuritySetting_ReadOnlyRootFilesystem, app))
+	assert.False(t, security.Applies(model.SecuritySetting_SeccompProfile, app))
+}
+
+func Test_EnvironmentSecurity_ValidateOptOuts(t *testing.T) {
+	security := EnvironmentSecurity{
+		RunAsNonRoot:           true,
+		ReadOnlyRootFilesystem: true,
+		DropCapabilities:       []string{"ALL"},
+		AllowedOptOuts:         []string{model.SecuritySetting_ReadOnlyRootFilesystem},
+	}
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Security: &model.AppConfigSecurity{
+				OptOut: []string{
+					model.SecuritySetting_RunAsNonRoot,
+					model.SecuritySetting_ReadOnlyRootFilesystem,
+					model.SecuritySetting_DropCapabilities,
+					// Not enforced by the environment so the opt-out has no effect
+					model.SecuritySetting_SeccompProfile,
+				},
+			},
+		},
+	}
+
+	err := security.ValidateOptOuts("prod", app)
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErr := err.(*ValidationError)
+	assert.Equal(t, `The app's security opt-outs are not allowed in environment "prod"`, validationErr.Message)
+	validationErrors := validationErr.ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "the environment does not allow opting out of: runAsNonRoot, dropCapabilities", validationErrors["security.optOut"].Error())
+}
+
+func Test_EnvironmentSecurity_ValidateOptOuts_Allowed(t *testing.T) {
+	security := EnvironmentSecurity{
+		ReadOnlyRootFilesystem: true,
+		AllowedOptOuts:         []string{model.SecuritySetting_ReadOnlyRootFilesystem},
+	}
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Security: &model.AppConfigSecurity{OptOut: []string{model.SecuritySetting_ReadOnlyRootFilesystem}},
+		},
+	}
+
+	assert.NoError(t, security.ValidateOptOuts("prod", app))
+	assert.NoError(t, security.ValidateOptOuts("prod", &model.AppConfig{}))
+}
+
+func Test_EnvironmentConfig_ValidateServiceAccountAnnotations(t *testing.T) {
+	config := EnvironmentConfig{AllowedServiceAccountAnnotations: []string{"iam.gke.io/gcp-service-account"}}
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			ServiceAccount: &model.AppConfigServiceAccount{
+				Annotations: map[string]string{
+					"iam.gke.io/gcp-service-account": "myapp@myproject.iam.gserviceaccount.com",
+					"eks.amazonaws.com/role-arn":     "arn:aws:iam::111122223333:role/myapp",
+				},
+			},
+		},
+	}
+
+	err := config.ValidateServiceAccountAnnotations("prod", app)
+
+	require.IsType(t, &ValidationError{}, err)
+	validationErr := err.(*ValidationError)
+	assert.Equal(t, `The app's service account annotations are not allowed in environment "prod"`, validationErr.Message)
+	validationErrors := validationErr.ValidationError.(validation.Errors)
+	assert.Len(t, validationErrors, 1)
+	assert.Equal(t, "is not an allowed annotation", validationErrors["serviceAccount.annotations.eks.amazonaws.com/role-arn"].Error())
+}
+
+func Test_EnvironmentConfig_ValidateServiceAccountAnnotations_Allowed(t *testing.T) {
+	config := EnvironmentConfig{AllowedServiceAccountAnnotations: []string{"iam.gke.io/gcp-service-account"}}
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			ServiceAccount: &model.AppConfigServiceAccount{
+				Annotations: map[string]string{"iam.gke.io/gcp-service-account": "myapp@myproject.iam.gserviceaccount.com"},
+			},
+		},
+	}
+
+	assert.NoError(t, config.ValidateServiceAccountAnnotations("prod", app))
+	assert.NoError(t, EnvironmentConfig{}.ValidateServiceAccountAnnotations("prod", &model.AppConfig{}))
+}
+
+func Test_EnvironmentConfig_IsDefaultDeny(t *testing.T) {
+	config := EnvironmentConfig{DefaultDenyNamespaces: []string{"myns"}}
+
+	assert.True(t, config.IsDefaultDeny("myns"))
+	assert.False(t, config.IsDefaultDeny("otherns"))
+	assert.False(t, EnvironmentConfig{}.IsDefaultDeny("myns"))
+}
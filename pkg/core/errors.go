@@ -36,3 +36,17 @@ func (e *ValidationError) Error() string {
 
 	return e.Message
 }
+
+// RevisionConflictError is returned when a caller expects a riser revision that does not match the current revision. This is safe
+// to return to the API as the errorHandler is aware of this error.
+type RevisionConflictError struct {
+	CurrentRiserRevision int64
+}
+
+func NewRevisionConflictError(currentRiserRevision int64) error {
+	return &RevisionConflictError{CurrentRiserRevision: currentRiserRevision}
+}
+
+func (e *RevisionConflictError) Error() string {
+	return fmt.Sprintf("the deployment has been updated by another process (current revision: %d)", e.CurrentRiserRevision)
+}
@@ -17,3 +17,11 @@ func Test_NewValidationError(t *testing.T) {
 	assert.Equal(t, "msg", validationError.Message)
 	assert.Equal(t, err, validationError.ValidationError)
 }
+
+func Test_RevisionConflictError(t *testing.T) {
+	result := NewRevisionConflictError(3)
+
+	assert.IsType(t, &RevisionConflictError{}, result)
+	assert.EqualValues(t, 3, result.(*RevisionConflictError).CurrentRiserRevision)
+	assert.Equal(t, "the deployment has been updated by another process (current revision: 3)", result.Error())
+}
@@ -0,0 +1,30 @@
+package core
+
+type NamespaceConfigRepository interface {
+	// Get returns ErrNotFound when the namespace does not have config in the environment
+	Get(namespace string, envName string) (*NamespaceConfig, error)
+	ListByNamespace(namespace string) ([]NamespaceConfig, error)
+	Save(config *NamespaceConfig) error
+}
+
+type FakeNamespaceConfigRepository struct {
+	GetFn             func(namespace string, envName string) (*NamespaceConfig, error)
+	GetCallCount      int
+	ListByNamespaceFn func(namespace string) ([]NamespaceConfig, error)
+	SaveFn            func(config *NamespaceConfig) error
+	SaveCallCount     int
+}
+
+func (fake *FakeNamespaceConfigRepository) Get(namespace string, envName string) (*NamespaceConfig, error) {
+	fake.GetCallCount++
+	return fake.GetFn(namespace, envName)
+}
+
+func (fake *FakeNamespaceConfigRepository) ListByNamespace(namespace string) ([]NamespaceConfig, error) {
+	return fake.ListByNamespaceFn(namespace)
+}
+
+func (fake *FakeNamespaceConfigRepository) Save(config *NamespaceConfig) error {
+	fake.SaveCallCount++
+	return fake.SaveFn(config)
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// NamespaceConfig is config that is shared by all deployments in a namespace in an environment
+type NamespaceConfig struct {
+	Namespace       string
+	EnvironmentName string
+	Doc             NamespaceConfigDoc
+}
+
+type NamespaceConfigDoc struct {
+	// Environment contains env vars that are merged into the env of every deployment in the namespace. An app's own env vars and
+	// secrets take precedence.
+	Environment map[string]intstr.IntOrString `json:"env,omitempty"`
+}
+
+// Needed for sql.Scanner interface
+func (a *NamespaceConfigDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Needed for sql.Scanner interface
+func (a *NamespaceConfigDoc) Scan(value interface{}) error {
+	return jsonbSqlUnmarshal(value, &a)
+}
@@ -1,5 +1,7 @@
 package core
 
+import "time"
+
 // RuntimeConfig provides config for the server.
 type RuntimeConfig struct {
 	BootstrapApikey string `split_words:"true"`
@@ -13,4 +15,6 @@ type RuntimeConfig struct {
 	PostgresUsername         string `split_words:"true" required:"true"`
 	PostgresPassword         string `split_words:"true" required:"true"`
 	PostgresMigrateOnStartup bool   `split_words:"true" default:"true"`
+	// WebhookPollInterval is how often the server checks for webhook deliveries that are due
+	WebhookPollInterval time.Duration `split_words:"true" default:"5s"`
 }
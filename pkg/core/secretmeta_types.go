@@ -1,8 +1,32 @@
 package core
 
+import (
+	"sort"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
 type SecretMeta struct {
 	Name            string
 	App             *NamespacedName
 	EnvironmentName string
 	Revision        int64
 }
+
+// MissingSecrets returns the sorted names of the secrets configured in the app config that do not have a committed revision
+func MissingSecrets(app *model.AppConfig, secrets []SecretMeta) []string {
+	committed := map[string]bool{}
+	for _, secret := range secrets {
+		if secret.Revision > 0 {
+			committed[secret.Name] = true
+		}
+	}
+	missing := []string{}
+	for name := range app.Secrets {
+		if !committed[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
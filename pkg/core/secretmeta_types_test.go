@@ -0,0 +1,37 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MissingSecrets(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Secrets: map[string]model.AppConfigSecret{
+				"tls-key":     {Path: "/etc/tls.key"},
+				"creds":       {},
+				"uncommitted": {},
+				"missing":     {},
+			},
+		},
+	}
+	secrets := []SecretMeta{
+		{Name: "creds", Revision: 1},
+		{Name: "tls-key", Revision: 3},
+		{Name: "uncommitted", Revision: 0},
+		{Name: "unconfigured", Revision: 1},
+	}
+
+	result := MissingSecrets(app, secrets)
+
+	assert.Equal(t, []string{"missing", "uncommitted"}, result)
+}
+
+func Test_MissingSecrets_NoSecrets(t *testing.T) {
+	result := MissingSecrets(&model.AppConfig{}, nil)
+
+	assert.Empty(t, result)
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookRepository interface {
+	Create(webhook *Webhook) error
+	Get(id uuid.UUID) (*Webhook, error)
+	List() ([]Webhook, error)
+	Delete(id uuid.UUID) error
+}
+
+type WebhookDeliveryRepository interface {
+	Create(delivery *WebhookDelivery) error
+	// ClaimDue returns pending deliveries that are due for an attempt and pushes their next attempt out to leaseUntil so that
+	// other server instances do not attempt the same delivery concurrently.
+	ClaimDue(now time.Time, leaseUntil time.Time, limit int) ([]WebhookDelivery, error)
+	// UpdateAttempt records the result of a delivery attempt
+	UpdateAttempt(delivery *WebhookDelivery) error
+	ListByWebhook(webhookId uuid.UUID, limit int) ([]WebhookDelivery, error)
+}
+
+type FakeWebhookRepository struct {
+	CreateFn        func(webhook *Webhook) error
+	CreateCallCount int
+	GetFn           func(id uuid.UUID) (*Webhook, error)
+	ListFn          func() ([]Webhook, error)
+	ListCallCount   int
+	DeleteFn        func(id uuid.UUID) error
+	DeleteCallCount int
+}
+
+func (fake *FakeWebhookRepository) Create(webhook *Webhook) error {
+	fake.CreateCallCount++
+	return fake.CreateFn(webhook)
+}
+
+func (fake *FakeWebhookRepository) Get(id uuid.UUID) (*Webhook, error) {
+	return fake.GetFn(id)
+}
+
+func (fake *FakeWebhookRepository) List() ([]Webhook, error) {
+	fake.ListCallCount++
+	return fake.ListFn()
+}
+
+func (fake *FakeWebhookRepository) Delete(id uuid.UUID) error {
+	fake.DeleteCallCount++
+	return fake.DeleteFn(id)
+}
+
+type FakeWebhookDeliveryRepository struct {
+	CreateFn               func(delivery *WebhookDelivery) error
+	CreateCallCount        int
+	ClaimDueFn             func(now time.Time, leaseUntil time.Time, limit int) ([]WebhookDelivery, error)
+	UpdateAttemptFn        func(delivery *WebhookDelivery) error
+	UpdateAttemptCallCount int
+	ListByWebhookFn        func(webhookId uuid.UUID, limit int) ([]WebhookDelivery, error)
+}
+
+func (fake *FakeWebhookDeliveryRepository) Create(delivery *WebhookDelivery) error {
+	fake.CreateCallCount++
+	return fake.CreateFn(delivery)
+}
+
+func (fake *FakeWebhookDeliveryRepository) ClaimDue(now time.Time, leaseUntil time.Time, limit int) ([]WebhookDelivery, error) {
+	return fake.ClaimDueFn(now, leaseUntil, limit)
+}
+
+func (fake *FakeWebhookDeliveryRepository) UpdateAttempt(delivery *WebhookDelivery) error {
+	fake.UpdateAttemptCallCount++
+	return fake.UpdateAttemptFn(delivery)
+}
+
+func (fake *FakeWebhookDeliveryRepository) ListByWebhook(webhookId uuid.UUID, limit int) ([]WebhookDelivery, error) {
+	return fake.ListByWebhookFn(webhookId, limit)
+}
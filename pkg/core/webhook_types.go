@@ -0,0 +1,97 @@
+package core
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is a subscription to platform events
+type Webhook struct {
+	Id      uuid.UUID
+	Created time.Time
+	Doc     WebhookDoc
+}
+
+type WebhookDoc struct {
+	Url string `json:"url"`
+	// Secret is the shared HMAC key used to sign payloads. It is never returned to API clients.
+	Secret string `json:"secret"`
+	// Events filters by event type. An empty list matches all events.
+	Events []string `json:"events,omitempty"`
+	// Namespace and EnvironmentName are optional filters. An empty value matches any namespace or environment.
+	Namespace       string `json:"namespace,omitempty"`
+	EnvironmentName string `json:"environment,omitempty"`
+}
+
+// WebhookEvent is a platform event that may be delivered to zero or more webhooks
+type WebhookEvent struct {
+	Type            string
+	Namespace       string
+	EnvironmentName string
+	// Name is the name of the object that the event relates to (e.g. the deployment or secret name)
+	Name          string
+	RiserRevision int64
+	// Data contains event specific data. It must be JSON serializable.
+	Data interface{}
+}
+
+// WebhookDelivery represents a single event to be delivered to a single webhook
+type WebhookDelivery struct {
+	Id            uuid.UUID
+	WebhookId     uuid.UUID
+	EventType     string
+	Status        string
+	Attempts      int
+	NextAttemptAt time.Time
+	Created       time.Time
+	Doc           WebhookDeliveryDoc
+}
+
+type WebhookDeliveryDoc struct {
+	// Payload is the exact JSON body that is signed and sent to the webhook
+	Payload      json.RawMessage `json:"payload"`
+	ResponseCode int             `json:"responseCode,omitempty"`
+	LastError    string          `json:"lastError,omitempty"`
+}
+
+// Matches determines if the webhook is subscribed to the event
+func (w *Webhook) Matches(event *WebhookEvent) bool {
+	if w.Doc.Namespace != "" && w.Doc.Namespace != event.Namespace {
+		return false
+	}
+	if w.Doc.EnvironmentName != "" && w.Doc.EnvironmentName != event.EnvironmentName {
+		return false
+	}
+	if len(w.Doc.Events) == 0 {
+		return true
+	}
+	for _, eventType := range w.Doc.Events {
+		if eventType == event.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Needed for sql.Scanner interface
+func (a *WebhookDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Needed for sql.Scanner interface
+func (a *WebhookDoc) Scan(value interface{}) error {
+	return jsonbSqlUnmarshal(value, &a)
+}
+
+// Needed for sql.Scanner interface
+func (a *WebhookDeliveryDoc) Value() (driver.Value, error) {
+	return json.Marshal(a)
+}
+
+// Needed for sql.Scanner interface
+func (a *WebhookDeliveryDoc) Scan(value interface{}) error {
+	return jsonbSqlUnmarshal(value, &a)
+}
@@ -0,0 +1,30 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Webhook_Matches(t *testing.T) {
+	event := &WebhookEvent{Type: "deployment.updated", Namespace: "myns", EnvironmentName: "prod"}
+
+	tests := []struct {
+		doc      WebhookDoc
+		expected bool
+	}{
+		{WebhookDoc{}, true},
+		{WebhookDoc{Namespace: "myns"}, true},
+		{WebhookDoc{Namespace: "otherns"}, false},
+		{WebhookDoc{EnvironmentName: "prod"}, true},
+		{WebhookDoc{EnvironmentName: "dev"}, false},
+		{WebhookDoc{Events: []string{"rollout.updated", "deployment.updated"}}, true},
+		{WebhookDoc{Events: []string{"rollout.updated"}}, false},
+		{WebhookDoc{Namespace: "myns", EnvironmentName: "prod", Events: []string{"deployment.updated"}}, true},
+	}
+
+	for idx, test := range tests {
+		webhook := &Webhook{Doc: test.doc}
+		assert.Equal(t, test.expected, webhook.Matches(event), "test %d", idx)
+	}
+}
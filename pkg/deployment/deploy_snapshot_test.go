@@ -35,15 +35,15 @@ func Test_update_snapshot_simple(t *testing.T) {
 			Name:      "myapp",
 			Namespace: "apps",
 			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
-			Expose: &model.AppConfigExpose{
-				ContainerPort: 8080,
-				Protocol:      "http",
-				Scope:         model.AppExposeScope_External,
-			},
-			HealthCheck: &model.AppConfigHealthCheck{
-				Path: "/health",
-			},
 			OverrideableAppConfig: model.OverrideableAppConfig{
+				HealthCheck: &model.AppConfigHealthCheck{
+					Path: "/health",
+				},
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_External,
+				},
 				Autoscale: &model.AppConfigAutoscale{
 					Min: util.PtrInt(0),
 					Max: util.PtrInt(1),
@@ -62,9 +62,491 @@ func Test_update_snapshot_simple(t *testing.T) {
 		},
 	}
 
+	assertDeploySnapshot(t, "simple", newDeployment)
+}
+
+func Test_update_snapshot_files(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_External,
+				},
+				Files: map[string]string{
+					"/etc/myapp/config.yaml": "key: val\n",
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	assertDeploySnapshot(t, "files", newDeployment)
+}
+
+func Test_update_snapshot_command(t *testing.T) {
+	appConfig := &model.AppConfigWithOverrides{
+		AppConfig: model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_External,
+				},
+				Command:    []string{"/bin/myapp"},
+				Args:       []string{"serve", "--log-level=info"},
+				WorkingDir: "/opt/myapp",
+			},
+		},
+		Overrides: map[string]model.OverrideableAppConfig{
+			"dev": {
+				Args: []string{"serve", "--log-level=debug"},
+			},
+		},
+	}
+	app, err := appConfig.ApplyOverrides("dev")
+	require.NoError(t, err)
+
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: app,
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	assertDeploySnapshot(t, "command", newDeployment)
+}
+
+func Test_update_snapshot_domains(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_External,
+					Domains:       []string{"myapp.example.com"},
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	environmentConfig := &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org", TLSClusterIssuer: "letsencrypt"}
+
+	assertDeploySnapshotWithEnvironment(t, "domains", environmentConfig, newDeployment)
+}
+
+func Test_update_snapshot_requestsettings(t *testing.T) {
+	containerConcurrency := int64(1)
+	timeoutSeconds := int64(900)
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort:        8080,
+					Protocol:             "http",
+					Scope:                model.AppExposeScope_External,
+					ContainerConcurrency: &containerConcurrency,
+					TimeoutSeconds:       &timeoutSeconds,
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	environmentConfig := &core.EnvironmentConfig{
+		PublicGatewayHost: "dev.riser.org",
+		Requests:          core.EnvironmentRequests{MaxTimeoutSeconds: &timeoutSeconds},
+	}
+
+	assertDeploySnapshotWithEnvironment(t, "requestsettings", environmentConfig, newDeployment)
+}
+
+func Test_update_snapshot_security(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_External,
+				},
+				Security: &model.AppConfigSecurity{OptOut: []string{model.SecuritySetting_ReadOnlyRootFilesystem}},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	environmentConfig := &core.EnvironmentConfig{
+		PublicGatewayHost: "dev.riser.org",
+		Security: core.EnvironmentSecurity{
+			RunAsNonRoot:           true,
+			ReadOnlyRootFilesystem: true,
+			DropCapabilities:       []string{"ALL"},
+			SeccompProfile:         "RuntimeDefault",
+			AllowedOptOuts:         []string{model.SecuritySetting_ReadOnlyRootFilesystem},
+		},
+	}
+
+	assertDeploySnapshotWithEnvironment(t, "security", environmentConfig, newDeployment)
+}
+
+func Test_update_snapshot_serviceaccount(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_External,
+				},
+				ServiceAccount: &model.AppConfigServiceAccount{
+					Annotations: map[string]string{"iam.gke.io/gcp-service-account": "myapp@myproject.iam.gserviceaccount.com"},
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	environmentConfig := &core.EnvironmentConfig{
+		PublicGatewayHost:                "dev.riser.org",
+		AllowedServiceAccountAnnotations: []string{"iam.gke.io/gcp-service-account"},
+	}
+
+	assertDeploySnapshotWithEnvironment(t, "serviceaccount", environmentConfig, newDeployment)
+}
+
+func Test_update_snapshot_ports(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "grpc",
+					Scope:         model.AppExposeScope_External,
+					Ports: map[string]model.AppConfigPort{
+						"metrics": {ContainerPort: 9100},
+					},
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	environmentConfig := &core.EnvironmentConfig{
+		PublicGatewayHost: "dev.riser.org",
+	}
+
+	assertDeploySnapshotWithEnvironment(t, "ports", environmentConfig, newDeployment)
+}
+
+func Test_update_snapshot_allowfrom(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_Cluster,
+					AllowFrom:     []string{"checkout", "ns:billing"},
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	environmentConfig := &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org", DefaultDenyNamespaces: []string{"apps"}}
+
+	assertDeploySnapshotWithEnvironment(t, "allowfrom", environmentConfig, newDeployment)
+}
+
+func Test_update_snapshot_secrets(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_External,
+				},
+				Secrets: map[string]model.AppConfigSecret{
+					"mysecret": {Path: "/etc/myapp/mysecret", Mode: "0400"},
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	assertDeploySnapshot(t, "secrets", newDeployment)
+}
+
+func Test_update_snapshot_deploymentworkload(t *testing.T) {
+	target := float64(70)
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("2516D5E4-1EC3-46B8-B3CD-C3D72AE38DC0"),
+			Workload:  model.AppWorkload_Deployment,
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8080,
+					Protocol:      "http",
+					Scope:         model.AppExposeScope_Cluster,
+				},
+				HealthCheck: &model.AppConfigHealthCheck{Path: "/health"},
+				Autoscale: &model.AppConfigAutoscale{
+					Min:    util.PtrInt(2),
+					Max:    util.PtrInt(4),
+					Metric: model.AppAutoscaleMetric_CPU,
+					Target: &target,
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	assertDeploySnapshot(t, "deploymentworkload", newDeployment)
+}
+
+func Test_update_snapshot_worker(t *testing.T) {
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("6A2B9C1E-4F3D-4E8A-9B7C-2D1E0F3A4B5C"),
+			Workload:  model.AppWorkload_Deployment,
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image:       "myorg/myapp",
+				HealthCheck: &model.AppConfigHealthCheck{Mode: model.AppHealthCheckMode_Exec, Command: []string{"/bin/healthcheck"}},
+				Autoscale:   &model.AppConfigAutoscale{Min: util.PtrInt(3)},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	assertDeploySnapshot(t, "worker", newDeployment)
+}
+
+func Test_update_snapshot_jobs(t *testing.T) {
+	historyLimit := int32(1)
+	newDeployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "apps",
+		EnvironmentName: "dev",
+		Docker: core.DeploymentDocker{
+			Tag: "0.0.1",
+		},
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "apps",
+			Id:        uuid.MustParse("8E3C2A1B-5D4F-4A6B-8C7D-9E0F1A2B3C4D"),
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image: "myorg/myapp",
+				Expose: &model.AppConfigExpose{
+					ContainerPort: 8000,
+					Protocol:      "http",
+				},
+				Environment: map[string]intstr.IntOrString{"LOG_LEVEL": intstr.FromString("debug")},
+				Jobs: map[string]model.AppConfigJob{
+					"cleanup": {
+						Schedule:          "*/30 * * * *",
+						Command:           []string{"/app/cleanup", "--older-than=7d"},
+						ConcurrencyPolicy: model.AppJobConcurrencyPolicy_Forbid,
+						HistoryLimit:      &historyLimit,
+					},
+				},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			core.TrafficConfigRule{
+				RiserRevision: 1,
+				RevisionName:  "myapp-1",
+				Percent:       100,
+			},
+		},
+	}
+
+	assertDeploySnapshot(t, "jobs", newDeployment)
+}
+
+func assertDeploySnapshot(t *testing.T, fixtureName string, newDeployment *core.DeploymentConfig) {
+	assertDeploySnapshotWithEnvironment(t, fixtureName, &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org"}, newDeployment)
+}
+
+func assertDeploySnapshotWithEnvironment(t *testing.T, fixtureName string, environmentConfig *core.EnvironmentConfig, newDeployment *core.DeploymentConfig) {
 	secrets := []core.SecretMeta{{Name: "mysecret", Revision: 1}}
 
-	snapshotPath, err := filepath.Abs("testdata/snapshots/simple")
+	snapshotPath, err := filepath.Abs(filepath.Join("testdata/snapshots", fixtureName))
 	require.NoError(t, err)
 
 	committer, err := snapshot.CreateCommitter(snapshotPath)
@@ -72,7 +554,7 @@ func Test_update_snapshot_simple(t *testing.T) {
 
 	ctx := &core.DeploymentContext{
 		DeploymentConfig:  newDeployment,
-		EnvironmentConfig: &core.EnvironmentConfig{PublicGatewayHost: "dev.riser.org"},
+		EnvironmentConfig: environmentConfig,
 		RiserRevision:     3,
 		Secrets:           secrets,
 	}
@@ -1,20 +1,49 @@
 package deployment
 
 import (
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/state"
 )
 
 type FakeService struct {
-	DeleteFn        func(name *core.NamespacedName, envName string, committer state.Committer) error
-	DeleteCallCount int
+	DeleteFn                         func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error
+	DeleteCallCount                  int
+	LockFn                           func(name *core.NamespacedName, envName string, lock *core.DeploymentLock) error
+	LockCallCount                    int
+	UnlockFn                         func(name *core.NamespacedName, envName string) error
+	UnlockCallCount                  int
+	UnresolvedAppReferencesFn        func(app *model.AppConfig, deploymentName string, environment *core.Environment) ([]string, error)
+	UnresolvedAppReferencesCallCount int
+	EnvironmentWarningsFn            func(app *model.AppConfigWithOverrides) ([]string, error)
+	EnvironmentWarningsCallCount     int
 }
 
 func (f *FakeService) Update(deployment *core.DeploymentConfig, committer state.Committer, dryRun bool) (int64, error) {
 	panic("NI!")
 }
 
-func (f *FakeService) Delete(name *core.NamespacedName, envName string, committer state.Committer) error {
+func (f *FakeService) Delete(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
 	f.DeleteCallCount++
-	return f.DeleteFn(name, envName, committer)
+	return f.DeleteFn(name, envName, expectedRiserRevision, overrideLock, committer)
+}
+
+func (f *FakeService) Lock(name *core.NamespacedName, envName string, lock *core.DeploymentLock) error {
+	f.LockCallCount++
+	return f.LockFn(name, envName, lock)
+}
+
+func (f *FakeService) Unlock(name *core.NamespacedName, envName string) error {
+	f.UnlockCallCount++
+	return f.UnlockFn(name, envName)
+}
+
+func (f *FakeService) UnresolvedAppReferences(app *model.AppConfig, deploymentName string, environment *core.Environment) ([]string, error) {
+	f.UnresolvedAppReferencesCallCount++
+	return f.UnresolvedAppReferencesFn(app, deploymentName, environment)
+}
+
+func (f *FakeService) EnvironmentWarnings(app *model.AppConfigWithOverrides) ([]string, error) {
+	f.EnvironmentWarningsCallCount++
+	return f.EnvironmentWarningsFn(app)
 }
@@ -3,10 +3,14 @@ package deployment
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/deploymentreservation"
 	"github.com/riser-platform/riser-server/pkg/namespace"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 
 	validation "github.com/go-ozzo/ozzo-validation/v3"
 
@@ -21,31 +25,63 @@ import (
 
 type Service interface {
 	Update(deployment *core.DeploymentConfig, committer state.Committer, dryRun bool) (riserRevision int64, err error)
-	Delete(name *core.NamespacedName, envName string, committer state.Committer) error
+	// Delete deletes a deployment. When expectedRiserRevision is greater than zero the current revision must match.
+	Delete(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error
+	// Lock prevents changes to a deployment in an environment until it's unlocked, the lock expires, or a change overrides the lock.
+	Lock(name *core.NamespacedName, envName string, lock *core.DeploymentLock) error
+	Unlock(name *core.NamespacedName, envName string) error
+	// UnresolvedAppReferences returns a message for each app reference in the app's env (e.g. "${app:checkout.apps.url}") that can't be
+	// resolved in the environment. References to the deployment itself are always resolved. These are warnings since references are
+	// rendered by name, which allows apps that reference each other to be deployed in any order.
+	UnresolvedAppReferences(app *model.AppConfig, deploymentName string, environment *core.Environment) ([]string, error)
+	// EnvironmentWarnings returns a message for each problem that would prevent the app from deploying to an environment, or that may cause
+	// it to not work as expected, e.g. missing secrets or unresolved app references. These are warnings since the app config is shared by
+	// all environments and secrets and referenced apps may be deployed before the app is.
+	EnvironmentWarnings(app *model.AppConfigWithOverrides) ([]string, error)
 }
 
 type service struct {
 	namespaceService   namespace.Service
+	namespaceConfigs   core.NamespaceConfigRepository
 	secrets            core.SecretMetaRepository
 	environments       core.EnvironmentRepository
 	deployments        core.DeploymentRepository
 	reservationService deploymentreservation.Service
+	webhooks           webhook.Service
 }
 
 func NewService(
 	apps core.AppRepository,
 	namespaceService namespace.Service,
+	namespaceConfigs core.NamespaceConfigRepository,
 	secrets core.SecretMetaRepository,
 	environments core.EnvironmentRepository,
 	deployments core.DeploymentRepository,
-	reservationService deploymentreservation.Service) Service {
-	return &service{namespaceService, secrets, environments, deployments, reservationService}
+	reservationService deploymentreservation.Service,
+	webhooks webhook.Service) Service {
+	return &service{namespaceService, namespaceConfigs, secrets, environments, deployments, reservationService, webhooks}
 }
 
-func (s *service) Delete(name *core.NamespacedName, envName string, committer state.Committer) error {
+func (s *service) Delete(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
+	existingDeployment, err := s.deployments.GetByName(name, envName)
+	if err != nil {
+		if err == core.ErrNotFound {
+			return core.NewValidationErrorMessage(fmt.Sprintf("There is no deployment by the name %q in environment %q", name, envName))
+		}
+		return errors.Wrap(err, "error getting deployment")
+	}
+
+	err = existingDeployment.CheckLock(time.Now(), overrideLock)
+	if err != nil {
+		return err
+	}
+
 	// Deleting the deployment is safe to do before we perform the commit since it's a soft delete and therefore idempotent
-	err := s.deployments.Delete(name, envName)
+	err = s.deployments.Delete(name, envName, expectedRiserRevision, overrideLock)
 	if err != nil {
+		if err == core.ErrConflictNewerVersion {
+			return s.revisionConflict(name, envName, overrideLock)
+		}
 		if err == core.ErrNotFound {
 			return core.NewValidationErrorMessage(fmt.Sprintf("There is no deployment by the name %q in environment %q", name, envName))
 		}
@@ -53,15 +89,95 @@ func (s *service) Delete(name *core.NamespacedName, envName string, committer st
 	}
 
 	files := state.RenderDeleteDeployment(name.Name, name.Namespace)
-	return committer.Commit(fmt.Sprintf("Deleting deployment %q", name), files)
+	removeServiceAccount, err := s.isServiceAccountUnused(existingDeployment)
+	if err != nil {
+		return err
+	}
+	if removeServiceAccount {
+		files = append(files, state.RenderDeleteGeneric(resources.ServiceAccountMeta(existingDeployment.Doc.ServiceAccount.Name, name.Namespace))...)
+	}
+	err = committer.Commit(fmt.Sprintf("Deleting deployment %q", name), files)
+	if err != nil {
+		return err
+	}
+
+	s.webhooks.Publish(&core.WebhookEvent{
+		Type:            model.WebhookEvent_DeploymentDeleted,
+		Namespace:       name.Namespace,
+		EnvironmentName: envName,
+		Name:            name.Name,
+	})
+
+	return nil
+}
+
+func (s *service) Lock(name *core.NamespacedName, envName string, lock *core.DeploymentLock) error {
+	lock.Created = time.Now()
+	if lock.ExpiresAt != nil && !lock.ExpiresAt.After(lock.Created) {
+		return core.NewValidationErrorMessage("The lock expiration must be in the future")
+	}
+
+	err := s.deployments.UpdateLock(name, envName, lock)
+	if err == core.ErrNotFound {
+		return core.NewValidationErrorMessage(fmt.Sprintf("There is no deployment by the name %q in environment %q", name, envName))
+	}
+	return err
+}
+
+func (s *service) Unlock(name *core.NamespacedName, envName string) error {
+	err := s.deployments.UpdateLock(name, envName, nil)
+	if err == core.ErrNotFound {
+		return core.NewValidationErrorMessage(fmt.Sprintf("There is no deployment by the name %q in environment %q", name, envName))
+	}
+	return err
 }
 
 func (s *service) Update(deploymentConfig *core.DeploymentConfig, committer state.Committer, dryRun bool) (riserRevision int64, err error) {
-	riserRevision, err = s.prepareForDeployment(deploymentConfig, dryRun)
+	environment, err := s.environments.Get(deploymentConfig.EnvironmentName)
 	if err != nil {
 		return 0, err
 	}
-	environment, err := s.environments.Get(deploymentConfig.EnvironmentName)
+
+	err = environment.Doc.Config.Resources.ValidateMax(deploymentConfig.EnvironmentName, deploymentConfig.App.Resources)
+	if err != nil {
+		return 0, err
+	}
+
+	err = environment.Doc.Config.Requests.ValidateMax(deploymentConfig.EnvironmentName, deploymentConfig.App.Expose)
+	if err != nil {
+		return 0, err
+	}
+
+	err = environment.Doc.Config.Security.ValidateOptOuts(deploymentConfig.EnvironmentName, deploymentConfig.App)
+	if err != nil {
+		return 0, err
+	}
+
+	err = environment.Doc.Config.ValidateServiceAccountAnnotations(deploymentConfig.EnvironmentName, deploymentConfig.App)
+	if err != nil {
+		return 0, err
+	}
+
+	err = environment.Doc.Config.Policies.Validate(deploymentConfig.EnvironmentName,
+		core.PolicyInput{App: environment.Doc.Config.EffectiveAppConfig(deploymentConfig.App), Docker: &deploymentConfig.Docker})
+	if err != nil {
+		return 0, err
+	}
+
+	if deploymentConfig.ManualRollout && deploymentConfig.App.Workload == model.AppWorkload_Deployment {
+		return 0, core.NewValidationErrorMessage("Manual rollouts are not supported by the deployment workload")
+	}
+
+	for jobName := range deploymentConfig.App.Jobs {
+		if cronJobName := resources.CronJobName(deploymentConfig.Name, jobName); len(cronJobName) > resources.CronJobNameMaxLength {
+			return 0, core.NewValidationErrorMessage(
+				fmt.Sprintf("The job %q is too long for the deployment %q: the combined name %q must be no more than %d characters",
+					jobName, deploymentConfig.Name, cronJobName, resources.CronJobNameMaxLength))
+		}
+	}
+
+	deploymentConfig.Domains = core.NewDeploymentDomains(deploymentConfig.App, &environment.Doc.Config)
+	err = s.validateDomainsAvailable(deploymentConfig, deploymentConfig.Domains)
 	if err != nil {
 		return 0, err
 	}
@@ -70,85 +186,353 @@ func (s *service) Update(deploymentConfig *core.DeploymentConfig, committer stat
 	if err != nil {
 		return 0, err
 	}
+	missingSecrets := core.MissingSecrets(deploymentConfig.App, secrets)
+	if len(missingSecrets) > 0 {
+		return 0, core.NewValidationErrorMessage(
+			fmt.Sprintf("The following secrets do not exist in environment %q: %s",
+				deploymentConfig.EnvironmentName, strings.Join(missingSecrets, ", ")))
+	}
+
+	namespaceConfig, err := s.getNamespaceConfig(deploymentConfig.Namespace, deploymentConfig.EnvironmentName)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.validateServiceAccountShared(deploymentConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	riserRevision, previous, err := s.prepareForDeployment(deploymentConfig, dryRun)
+	if err != nil {
+		return 0, err
+	}
 	ctx := &core.DeploymentContext{
 		DeploymentConfig:  deploymentConfig,
 		EnvironmentConfig: &environment.Doc.Config,
 		RiserRevision:     riserRevision,
 		Secrets:           secrets,
+		NamespaceConfig:   namespaceConfig,
 	}
 	err = deploy(ctx, committer)
 	if err != nil {
-		// TODO: Log rollback error but don't return since we want the original deployment error to flow to caller
-		_, _ = s.deployments.RollbackRevision(
-			core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName, riserRevision)
+		if previous != nil {
+			// TODO: Log rollback error but don't return since we want the original deployment error to flow to caller
+			_, _ = s.deployments.RollbackRevision(
+				core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName, riserRevision, previous)
+		}
 		return 0, err
 	}
 
+	if !dryRun {
+		s.webhooks.Publish(&core.WebhookEvent{
+			Type:            model.WebhookEvent_DeploymentUpdated,
+			Namespace:       deploymentConfig.Namespace,
+			EnvironmentName: deploymentConfig.EnvironmentName,
+			Name:            deploymentConfig.Name,
+			RiserRevision:   riserRevision,
+			Data: map[string]interface{}{
+				"app":         deploymentConfig.App.Name,
+				"dockerImage": fmt.Sprintf("%s:%s", deploymentConfig.App.Image, deploymentConfig.Docker.Tag),
+				"traffic":     deploymentConfig.Traffic,
+			},
+		})
+	}
+
 	return riserRevision, nil
 }
 
-func (s *service) prepareForDeployment(deploymentConfig *core.DeploymentConfig, dryRun bool) (riserRevision int64, err error) {
+// prepareForDeployment saves the new revision of the deployment. The previous record is returned so that the revision can be rolled back
+// if it fails to deploy. It's nil when nothing was saved (i.e. a dry run of an existing deployment).
+func (s *service) prepareForDeployment(deploymentConfig *core.DeploymentConfig, dryRun bool) (riserRevision int64, previous *core.DeploymentRecord, err error) {
 	if err := validateDeploymentConfig(deploymentConfig); err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	reservation, err := s.reservationService.EnsureReservation(
 		deploymentConfig.App.Id,
 		core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace))
 	if err != nil {
-		return 0, errors.Wrap(err, "Error ensuring deployment reservation")
+		return 0, nil, errors.Wrap(err, "Error ensuring deployment reservation")
 	}
 
 	existingDeployment, err := s.deployments.GetByReservation(reservation.Id, deploymentConfig.EnvironmentName)
 	if err != nil && err != core.ErrNotFound {
-		return 0, errors.Wrap(err, fmt.Sprintf("Error retrieving deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
+		return 0, nil, errors.Wrap(err, fmt.Sprintf("Error retrieving deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
 	}
 	if err == core.ErrNotFound {
+		if deploymentConfig.ExpectedRiserRevision > 0 {
+			return 0, nil, core.NewRevisionConflictError(0)
+		}
 		riserRevision = 1
 		deploymentConfig.Traffic = computeTraffic(riserRevision, deploymentConfig, nil)
+		computeFilesConfigMaps(riserRevision, deploymentConfig, nil)
 		err = s.deployments.Create(&core.DeploymentRecord{
 			Id:              uuid.New(),
 			ReservationId:   reservation.Id,
 			EnvironmentName: deploymentConfig.EnvironmentName,
 			RiserRevision:   riserRevision,
 			Doc: core.DeploymentDoc{
-				Traffic: deploymentConfig.Traffic,
+				Traffic:         deploymentConfig.Traffic,
+				Domains:         deploymentConfig.Domains,
+				Workload:        deploymentConfig.App.Workload,
+				Worker:          deploymentConfig.App.IsWorker(),
+				FilesConfigMaps: deploymentConfig.FilesConfigMaps,
+				ServiceAccount:  deploymentServiceAccount(deploymentConfig.App),
 			},
 		})
 		if err != nil {
-			return 0, errors.Wrap(err, fmt.Sprintf("Error creating deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
+			return 0, nil, errors.Wrap(err, fmt.Sprintf("Error creating deployment %q in environment %q", deploymentConfig.Name, deploymentConfig.EnvironmentName))
 		}
+		// A new deployment that fails to deploy is marked as deleted since none of its resources exist
+		deletedAt := time.Now()
+		previous = &core.DeploymentRecord{DeletedAt: &deletedAt}
 	} else if existingDeployment.AppId != deploymentConfig.App.Id {
-		return 0, &core.ValidationError{Message: fmt.Sprintf("A deployment with the name %q is owned by app %q", deploymentConfig.Name, existingDeployment.AppId)}
+		return 0, nil, &core.ValidationError{Message: fmt.Sprintf("A deployment with the name %q is owned by app %q", deploymentConfig.Name, existingDeployment.AppId)}
+	} else if deploymentConfig.ExpectedRiserRevision > 0 && existingDeployment.RiserRevision != deploymentConfig.ExpectedRiserRevision {
+		return 0, nil, core.NewRevisionConflictError(existingDeployment.RiserRevision)
+	} else if err = existingDeployment.CheckLock(time.Now(), deploymentConfig.OverrideLock); err != nil {
+		return 0, nil, err
 	} else {
 		if !dryRun {
-			riserRevision, err = s.deployments.IncrementRevision(
-				core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace), deploymentConfig.EnvironmentName)
-			if err != nil {
-				return 0, errors.Wrap(err, "Error incrementing deployment revision")
-			}
+			riserRevision = existingDeployment.RiserRevision + 1
 		}
 
-		// When a deployment was previously deleted, we don't want to compute traffic with the old traffic rules
+		// When a deployment was previously deleted, we don't want to compute traffic with the old traffic rules. Its ConfigMaps were
+		// deleted along with the rest of its resources.
 		if existingDeployment.DeletedAt == nil {
 			deploymentConfig.Traffic = computeTraffic(riserRevision, deploymentConfig, &existingDeployment.DeploymentRecord)
+			computeFilesConfigMaps(riserRevision, deploymentConfig, &existingDeployment.DeploymentRecord)
 		} else {
 			deploymentConfig.Traffic = computeTraffic(riserRevision, deploymentConfig, nil)
+			computeFilesConfigMaps(riserRevision, deploymentConfig, nil)
 		}
 
 		if !dryRun {
-			err = s.deployments.UpdateTraffic(
-				core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace),
+			name := core.NewNamespacedName(deploymentConfig.Name, deploymentConfig.Namespace)
+			// Conditional on the revision and traffic version that the traffic was computed from so that a concurrent deployment or rollout
+			// is not overwritten
+			err = s.deployments.IncrementRevision(
+				name,
 				deploymentConfig.EnvironmentName,
-				riserRevision,
-				deploymentConfig.Traffic)
+				existingDeployment.RiserRevision,
+				existingDeployment.Doc.TrafficVersion,
+				deploymentConfig.OverrideLock,
+				&core.DeploymentRevisionDoc{
+					Traffic:         deploymentConfig.Traffic,
+					Domains:         deploymentConfig.Domains,
+					FilesConfigMaps: deploymentConfig.FilesConfigMaps,
+					Workload:        deploymentConfig.App.Workload,
+					Worker:          deploymentConfig.App.IsWorker(),
+					ServiceAccount:  deploymentServiceAccount(deploymentConfig.App),
+				})
 			if err != nil {
-				return 0, errors.Wrap(err, "Error updating traffic")
+				if err == core.ErrConflictNewerVersion {
+					return 0, nil, s.revisionConflict(name, deploymentConfig.EnvironmentName, deploymentConfig.OverrideLock)
+				}
+				return 0, nil, errors.Wrap(err, "Error incrementing deployment revision")
 			}
+			previous = &existingDeployment.DeploymentRecord
 		}
 	}
 
-	return riserRevision, nil
+	return riserRevision, previous, nil
+}
+
+// validateServiceAccountShared ensures that the app's deployments in the environment agree on the annotations of the service account that
+// they share so that one deployment does not overwrite the annotations of another. The deployment named after the app may always change
+// the annotations, which the app's other deployments must then match.
+func (s *service) validateServiceAccountShared(deploymentConfig *core.DeploymentConfig) error {
+	if deploymentConfig.Name == string(deploymentConfig.App.Name) {
+		return nil
+	}
+
+	deployments, err := s.deployments.FindByApp(deploymentConfig.App.Id)
+	if err != nil {
+		return errors.Wrap(err, "Error retrieving the app's deployments")
+	}
+
+	serviceAccount := deploymentServiceAccount(deploymentConfig.App)
+	for _, deployment := range deployments {
+		if !usesServiceAccount(&deployment, deploymentConfig.EnvironmentName, deploymentConfig.Namespace, serviceAccount.Name) ||
+			deployment.Name == deploymentConfig.Name {
+			continue
+		}
+		if !deployment.Doc.ServiceAccount.HasAnnotations(serviceAccount.Annotations) {
+			return core.NewValidationErrorMessage(fmt.Sprintf(
+				"The serviceAccount.annotations must match the deployment %q since the app's deployments in environment %q share the service account %q. "+
+					"Deploy %q to change the annotations.",
+				deployment.Name, deploymentConfig.EnvironmentName, serviceAccount.Name, deploymentConfig.App.Name))
+		}
+	}
+	return nil
+}
+
+// isServiceAccountUnused returns true when no other active deployment uses the service account of a deleted deployment
+func (s *service) isServiceAccountUnused(deleted *core.Deployment) (bool, error) {
+	if deleted.Doc.ServiceAccount == nil {
+		return false, nil
+	}
+
+	deployments, err := s.deployments.FindByApp(deleted.AppId)
+	if err != nil {
+		return false, errors.Wrap(err, "Error retrieving the app's deployments")
+	}
+
+	for _, deployment := range deployments {
+		if deployment.Name != deleted.Name &&
+			usesServiceAccount(&deployment, deleted.EnvironmentName, deleted.Namespace, deleted.Doc.ServiceAccount.Name) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func usesServiceAccount(deployment *core.Deployment, envName, namespace, serviceAccountName string) bool {
+	return deployment.EnvironmentName == envName && deployment.Namespace == namespace &&
+		deployment.Doc.ServiceAccount != nil && deployment.Doc.ServiceAccount.Name == serviceAccountName
+}
+
+func deploymentServiceAccount(app *model.AppConfig) *core.DeploymentServiceAccount {
+	serviceAccount := &core.DeploymentServiceAccount{Name: resources.AppServiceAccountName(string(app.Name))}
+	if app.ServiceAccount != nil {
+		serviceAccount.Annotations = app.ServiceAccount.Annotations
+	}
+	return serviceAccount
+}
+
+// getNamespaceConfig returns nil when the namespace does not have config in the environment
+func (s *service) getNamespaceConfig(namespace string, envName string) (*core.NamespaceConfig, error) {
+	namespaceConfig, err := s.namespaceConfigs.Get(namespace, envName)
+	if err == core.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Error retrieving namespace config")
+	}
+	return namespaceConfig, nil
+}
+
+// validateDomainsAvailable returns a ValidationError if any of the domains are mapped to another deployment in the environment
+func (s *service) validateDomainsAvailable(deploymentConfig *core.DeploymentConfig, domains core.DeploymentDomains) error {
+	if len(domains) == 0 {
+		return nil
+	}
+
+	existingDeployments, err := s.deployments.FindByDomains(deploymentConfig.EnvironmentName, domains.Names())
+	if err != nil {
+		return errors.Wrap(err, "Error retrieving deployments by domain")
+	}
+
+	for _, existingDeployment := range existingDeployments {
+		if existingDeployment.Name == deploymentConfig.Name && existingDeployment.Namespace == deploymentConfig.Namespace {
+			continue
+		}
+		for _, existingDomain := range existingDeployment.Doc.Domains {
+			for _, domain := range domains {
+				if existingDomain.Name == domain.Name {
+					return core.NewValidationErrorMessage(fmt.Sprintf("The domain %q is already mapped to deployment %q in environment %q",
+						domain.Name, core.NewNamespacedName(existingDeployment.Name, existingDeployment.Namespace), deploymentConfig.EnvironmentName))
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) UnresolvedAppReferences(app *model.AppConfig, deploymentName string, environment *core.Environment) ([]string, error) {
+	unresolved := []string{}
+	references := app.AppReferences()
+	for _, key := range model.AppReferenceKeys(references) {
+		for _, reference := range references[key] {
+			if (reference.Field == model.AppReferenceField_ExternalURL || reference.Field == model.AppReferenceField_ExternalHost) &&
+				environment.Doc.Config.PublicGatewayHost == "" {
+				unresolved = append(unresolved, fmt.Sprintf("The env var %q references %q but environment %q does not have a publicGatewayHost",
+					key, reference.Expression, environment.Name))
+				continue
+			}
+
+			if reference.Name == deploymentName && reference.Namespace == string(app.Namespace) {
+				continue
+			}
+			name := core.NewNamespacedName(reference.Name, reference.Namespace)
+			deployment, err := s.deployments.GetByName(name, environment.Name)
+			if err == core.ErrNotFound || (err == nil && deployment.DeletedAt != nil) {
+				unresolved = append(unresolved, fmt.Sprintf("The env var %q references the deployment %q which does not exist in environment %q",
+					key, name, environment.Name))
+			} else if err == nil && deployment.Doc.Worker {
+				unresolved = append(unresolved, fmt.Sprintf("The env var %q references the deployment %q which is a worker without an address in environment %q",
+					key, name, environment.Name))
+			} else if err != nil {
+				return nil, errors.Wrap(err, "Error retrieving referenced deployment")
+			}
+		}
+	}
+	return unresolved, nil
+}
+
+func (s *service) EnvironmentWarnings(app *model.AppConfigWithOverrides) ([]string, error) {
+	environments, err := s.environments.List()
+	if err != nil {
+		return nil, err
+	}
+
+	appName := core.NewNamespacedName(string(app.Name), string(app.Namespace))
+	warnings := []string{}
+	for idx := range environments {
+		environment := &environments[idx]
+		envAppConfig, err := app.ApplyEnvironment(environment.Name, environment.Doc.Config.AppDefaults)
+		if err != nil {
+			return nil, err
+		}
+
+		unresolved, err := s.UnresolvedAppReferences(envAppConfig, appName.Name, environment)
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, unresolved...)
+
+		// Docker tag policies are not evaluated since the tag is not known until the app is deployed
+		policyWarnings, err := environment.Doc.Config.Policies.Warnings(environment.Name,
+			core.PolicyInput{App: environment.Doc.Config.EffectiveAppConfig(envAppConfig)})
+		if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, policyWarnings...)
+
+		for _, optOut := range environment.Doc.Config.Security.DisallowedOptOuts(envAppConfig) {
+			warnings = append(warnings, fmt.Sprintf("The security setting %q may not be opted out of in environment %q", optOut, environment.Name))
+		}
+
+		if len(envAppConfig.Secrets) == 0 {
+			continue
+		}
+		secrets, err := s.secrets.ListByAppInEnvironment(appName, environment.Name)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range core.MissingSecrets(envAppConfig, secrets) {
+			warnings = append(warnings, fmt.Sprintf("The secret %q does not exist in environment %q", name, environment.Name))
+		}
+	}
+	return warnings, nil
+}
+
+// revisionConflict returns the reason that a conditional change was rejected: either a lock that was acquired after the deployment was
+// read or a RevisionConflictError with the revision that won the race
+func (s *service) revisionConflict(name *core.NamespacedName, envName string, overrideLock bool) error {
+	deployment, err := s.deployments.GetByName(name, envName)
+	if err == core.ErrNotFound {
+		return core.NewRevisionConflictError(0)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Error retrieving current deployment revision")
+	}
+	if err = deployment.CheckLock(time.Now(), overrideLock); err != nil {
+		return err
+	}
+	return core.NewRevisionConflictError(deployment.RiserRevision)
 }
 
 func computeTraffic(riserRevision int64, deploymentConfig *core.DeploymentConfig, existingDeployment *core.DeploymentRecord) core.TrafficConfig {
@@ -172,6 +556,51 @@ func computeTraffic(riserRevision int64, deploymentConfig *core.DeploymentConfig
 	return core.TrafficConfig{newRule}
 }
 
+// computeFilesConfigMaps sets the files ConfigMaps that are kept and removed with the new revision. The ConfigMaps of previous revisions are
+// kept while the revision receives traffic or is reported in the status so that they may still be rolled out to.
+func computeFilesConfigMaps(riserRevision int64, deploymentConfig *core.DeploymentConfig, existingDeployment *core.DeploymentRecord) {
+	kept := []core.DeploymentFilesConfigMap{}
+	keptNames := map[string]bool{}
+	if name := resources.FilesConfigMapName(deploymentConfig); name != "" {
+		kept = append(kept, core.DeploymentFilesConfigMap{RiserRevision: riserRevision, Name: name})
+		keptNames[name] = true
+	}
+	if existingDeployment == nil {
+		deploymentConfig.FilesConfigMaps = kept
+		deploymentConfig.RemovedFilesConfigMaps = []string{}
+		return
+	}
+
+	revisions := map[int64]bool{}
+	for _, rule := range deploymentConfig.Traffic {
+		revisions[rule.RiserRevision] = rule.Percent > 0
+	}
+	if existingDeployment.Doc.Status != nil {
+		for _, revision := range existingDeployment.Doc.Status.Revisions {
+			revisions[revision.RiserRevision] = true
+		}
+	}
+
+	for _, configMap := range existingDeployment.Doc.FilesConfigMaps {
+		if revisions[configMap.RiserRevision] {
+			kept = append(kept, configMap)
+			keptNames[configMap.Name] = true
+		}
+	}
+
+	removed := []string{}
+	for _, configMap := range existingDeployment.Doc.FilesConfigMaps {
+		if !keptNames[configMap.Name] {
+			removed = append(removed, configMap.Name)
+			// The same ConfigMap may be used by more than one revision
+			keptNames[configMap.Name] = true
+		}
+	}
+
+	deploymentConfig.FilesConfigMaps = kept
+	deploymentConfig.RemovedFilesConfigMaps = removed
+}
+
 // This is a one-off validation until we rationalize our validation strategy (API layer or service layer).
 func validateDeploymentConfig(deployment *core.DeploymentConfig) error {
 	// TODO: Once rules are factored out of api/v1/model use RulesNamingIdentifier (creates a circular dep)
@@ -193,10 +622,22 @@ func deploy(ctx *core.DeploymentContext, committer state.Committer) error {
 	if err != nil {
 		return err
 	}
+	removedResources := removedWorkloadResources(ctx)
+	for _, configMapName := range ctx.DeploymentConfig.RemovedFilesConfigMaps {
+		removedResources = append(removedResources, resources.FilesConfigMapMeta(ctx, configMapName))
+	}
+	resourceFiles = append(state.RenderDeleteDeploymentResources(ctx.DeploymentConfig, removedResources...), resourceFiles...)
 
-	// Create the namespace resource whether we need to or not to ensure that it exists and that it's up-to-date
-	clusterResourceFiles, err := state.RenderGeneric(ctx.DeploymentConfig.EnvironmentName,
-		resources.CreateNamespace(ctx.DeploymentConfig.Namespace, ctx.DeploymentConfig.EnvironmentName))
+	// Create the namespace resources whether we need to or not to ensure that they exist and that they're up-to-date. The service account is
+	// shared by all of the app's deployments so it's rendered alongside the namespace instead of in the deployment's folder.
+	clusterResources := []state.KubeResource{
+		resources.CreateNamespace(ctx.DeploymentConfig.Namespace, ctx.DeploymentConfig.EnvironmentName),
+		resources.CreateServiceAccount(ctx),
+	}
+	if ctx.EnvironmentConfig != nil && ctx.EnvironmentConfig.IsDefaultDeny(ctx.DeploymentConfig.Namespace) {
+		clusterResources = append(clusterResources, resources.CreateDefaultDenyPolicy(ctx.DeploymentConfig.Namespace))
+	}
+	clusterResourceFiles, err := state.RenderGeneric(ctx.DeploymentConfig.EnvironmentName, clusterResources...)
 	if err != nil {
 		return nil
 	}
@@ -207,9 +648,58 @@ func deploy(ctx *core.DeploymentContext, committer state.Committer) error {
 }
 
 func createDeployResources(ctx *core.DeploymentContext) []state.KubeResource {
-	return []state.KubeResource{
+	deployResources := []state.KubeResource{
 		resources.CreateHealthcheckDenyPolicy(ctx),
+		resources.CreateAllowFromPolicy(ctx),
+		resources.CreateFilesConfigMap(ctx),
+	}
+	for _, cronJob := range resources.CreateCronJobs(ctx) {
+		deployResources = append(deployResources, cronJob)
+	}
+	if ctx.DeploymentConfig.App.Workload == model.AppWorkload_Deployment {
+		return append(deployResources, createDeploymentWorkloadResources(ctx)...)
+	}
+
+	deployResources = append(deployResources, createKNativeWorkloadResources(ctx)...)
+	deployResources = append(deployResources, resources.CreateKNativePortsService(ctx))
+	for _, domainMapping := range resources.CreateDomainMappings(ctx) {
+		deployResources = append(deployResources, domainMapping)
+	}
+	for _, certificate := range resources.CreateDomainCertificates(ctx) {
+		deployResources = append(deployResources, certificate)
+	}
+	return deployResources
+}
+
+func createKNativeWorkloadResources(ctx *core.DeploymentContext) []state.KubeResource {
+	return []state.KubeResource{
 		resources.CreateKNativeConfiguration(ctx),
 		resources.CreateKNativeRoute(ctx),
 	}
 }
+
+func createDeploymentWorkloadResources(ctx *core.DeploymentContext) []state.KubeResource {
+	return []state.KubeResource{
+		resources.CreateDeployment(ctx),
+		resources.CreateService(ctx),
+		resources.CreateHorizontalPodAutoscaler(ctx),
+		resources.CreatePodDisruptionBudget(ctx),
+	}
+}
+
+// removedWorkloadResources returns the resources of the workload that the app is not using so that they are removed when an app changes
+// its workload, becomes a worker, or no longer has additional ports
+func removedWorkloadResources(ctx *core.DeploymentContext) []state.KubeResource {
+	if ctx.DeploymentConfig.App.Workload == model.AppWorkload_Deployment {
+		removed := append(createKNativeWorkloadResources(ctx), resources.KNativePortsServiceMeta(ctx))
+		if ctx.DeploymentConfig.App.IsWorker() {
+			removed = append(removed, resources.ServiceMeta(ctx))
+		}
+		return removed
+	}
+	removed := createDeploymentWorkloadResources(ctx)
+	if resources.CreateKNativePortsService(ctx) == nil {
+		removed = append(removed, resources.KNativePortsServiceMeta(ctx))
+	}
+	return removed
+}
@@ -4,7 +4,11 @@ import (
 	"time"
 
 	"github.com/riser-platform/riser-server/pkg/deploymentreservation"
+	"github.com/riser-platform/riser-server/pkg/git"
 	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/state/resources"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -12,9 +16,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Note: See snapshot_test for state based testing of deployment artifacts
@@ -22,59 +28,1192 @@ import (
 func Test_Delete(t *testing.T) {
 	name := core.NewNamespacedName("mydep", "apps")
 	deploymentRepository := &core.FakeDeploymentRepository{
-		DeleteFn: func(nameArg *core.NamespacedName, envName string) error {
+		GetByNameFn: func(nameArg *core.NamespacedName, envName string) (*core.Deployment, error) {
 			assert.Equal(t, name, nameArg)
 			assert.Equal(t, "myenv", envName)
+			return &core.Deployment{}, nil
+		},
+		DeleteFn: func(nameArg *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "myenv", envName)
+			return nil
+		},
+	}
+
+	webhookService := &webhook.FakeService{
+		PublishFn: func(event *core.WebhookEvent) {
+			assert.Equal(t, model.WebhookEvent_DeploymentDeleted, event.Type)
+			assert.Equal(t, "mydep", event.Name)
+			assert.Equal(t, "apps", event.Namespace)
+			assert.Equal(t, "myenv", event.EnvironmentName)
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository, webhooks: webhookService}
+
+	err := service.Delete(name, "myenv", 0, false, committer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentRepository.DeleteCallCount)
+	assert.Equal(t, 1, webhookService.PublishCallCount)
+	assert.Len(t, committer.Commits, 1)
+	assert.Equal(t, `Deleting deployment "mydep.apps"`, committer.Commits[0].Message)
+	assert.Len(t, committer.Commits[0].Files, 2)
+	assert.Equal(t, "state/riser-managed/apps/deployments/mydep", committer.Commits[0].Files[0].Name)
+	assert.True(t, committer.Commits[0].Files[0].Delete)
+	assert.Equal(t, "riser-config/apps/mydep.yaml", committer.Commits[0].Files[1].Name)
+	assert.True(t, committer.Commits[0].Files[1].Delete)
+}
+
+func Test_Delete_RemovesUnusedServiceAccount(t *testing.T) {
+	name := core.NewNamespacedName("myapp-canary", "apps")
+	appId := uuid.New()
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(nameArg *core.NamespacedName, envName string) (*core.Deployment, error) {
+			deployment := serviceAccountDeployment("myapp-canary", "myenv", nil)
+			deployment.AppId = appId
+			deployment.Namespace = "apps"
+			return &deployment, nil
+		},
+		DeleteFn: func(nameArg *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
+			return nil
+		},
+		FindByAppFn: func(appIdArg uuid.UUID) ([]core.Deployment, error) {
+			assert.Equal(t, appId, appIdArg)
+			// The app's deployment in another environment does not use the environment's service account
+			deployment := serviceAccountDeployment("myapp", "otherenv", nil)
+			deployment.Namespace = "apps"
+			return []core.Deployment{deployment}, nil
+		},
+	}
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository, webhooks: &webhook.FakeService{PublishFn: func(*core.WebhookEvent) {}}}
+
+	err := service.Delete(name, "myenv", 0, false, committer)
+
+	assert.NoError(t, err)
+	require.Len(t, committer.Commits, 1)
+	require.Len(t, committer.Commits[0].Files, 3)
+	assert.Equal(t, "state/riser-managed/apps/serviceaccount.riser-myapp.yaml", committer.Commits[0].Files[2].Name)
+	assert.True(t, committer.Commits[0].Files[2].Delete)
+}
+
+func Test_Delete_KeepsServiceAccountInUse(t *testing.T) {
+	name := core.NewNamespacedName("myapp-canary", "apps")
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(nameArg *core.NamespacedName, envName string) (*core.Deployment, error) {
+			deployment := serviceAccountDeployment("myapp-canary", "myenv", nil)
+			deployment.Namespace = "apps"
+			return &deployment, nil
+		},
+		DeleteFn: func(nameArg *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
+			return nil
+		},
+		FindByAppFn: func(appIdArg uuid.UUID) ([]core.Deployment, error) {
+			deployment := serviceAccountDeployment("myapp", "myenv", nil)
+			deployment.Namespace = "apps"
+			return []core.Deployment{deployment}, nil
+		},
+	}
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository, webhooks: &webhook.FakeService{PublishFn: func(*core.WebhookEvent) {}}}
+
+	err := service.Delete(name, "myenv", 0, false, committer)
+
+	assert.NoError(t, err)
+	require.Len(t, committer.Commits, 1)
+	assert.Len(t, committer.Commits[0].Files, 2)
+}
+
+func Test_Delete_SoftDeleteFails(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{}, nil
+		},
+		DeleteFn: func(*core.NamespacedName, string, int64, bool) error {
+			return errors.New("test")
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", 0, false, committer)
+
+	assert.Equal(t, "error deleting deployment: test", err.Error())
+}
+
+func Test_Delete_DeploymentNotFound(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", 0, false, nil)
+
+	assert.Equal(t, `There is no deployment by the name "mydep.myns" in environment "myenv"`, err.Error())
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, 0, deploymentRepository.DeleteCallCount)
+}
+
+func Test_Delete_WhenLocked(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return lockedDeployment(), nil
+		},
+	}
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", 0, false, nil)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Contains(t, err.Error(), `is locked in environment "myenv" by "alice": incident`)
+	assert.Equal(t, 0, deploymentRepository.DeleteCallCount)
+}
+
+func Test_Delete_WhenLockOverridden(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return lockedDeployment(), nil
+		},
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
+			assert.True(t, overrideLock)
+			return nil
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository, webhooks: &webhook.FakeService{}}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", 0, true, committer)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentRepository.DeleteCallCount)
+	assert.Len(t, committer.Commits, 1)
+}
+
+func Test_Delete_WhenLockedConcurrently(t *testing.T) {
+	getCallCount := 0
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			getCallCount++
+			// The lock is acquired after the deployment is read but before it's deleted
+			if getCallCount == 1 {
+				return &core.Deployment{}, nil
+			}
+			return lockedDeployment(), nil
+		},
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
+			assert.False(t, overrideLock)
+			return core.ErrConflictNewerVersion
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", 0, false, committer)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Contains(t, err.Error(), `is locked in environment "myenv" by "alice": incident`)
+	assert.Empty(t, committer.Commits)
+}
+
+func Test_Lock(t *testing.T) {
+	name := core.NewNamespacedName("mydep", "myns")
+	expiresAt := time.Now().Add(time.Hour)
+	deploymentRepository := &core.FakeDeploymentRepository{
+		UpdateLockFn: func(nameArg *core.NamespacedName, envName string, lock *core.DeploymentLock) error {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "myenv", envName)
+			assert.Equal(t, "alice", lock.Holder)
+			assert.Equal(t, "incident", lock.Reason)
+			assert.Equal(t, &expiresAt, lock.ExpiresAt)
+			assert.False(t, lock.Created.IsZero())
+			return nil
+		},
+	}
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Lock(name, "myenv", &core.DeploymentLock{Holder: "alice", Reason: "incident", ExpiresAt: &expiresAt})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentRepository.UpdateLockCallCount)
+}
+
+func Test_Lock_ExpiresInPast(t *testing.T) {
+	expiresAt := time.Now().Add(-time.Hour)
+	deploymentRepository := &core.FakeDeploymentRepository{}
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Lock(core.NewNamespacedName("mydep", "myns"), "myenv", &core.DeploymentLock{ExpiresAt: &expiresAt})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, "The lock expiration must be in the future", err.Error())
+	assert.Equal(t, 0, deploymentRepository.UpdateLockCallCount)
+}
+
+func Test_Lock_DeploymentNotFound(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		UpdateLockFn: func(*core.NamespacedName, string, *core.DeploymentLock) error {
+			return core.ErrNotFound
+		},
+	}
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Lock(core.NewNamespacedName("mydep", "myns"), "myenv", &core.DeploymentLock{})
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `There is no deployment by the name "mydep.myns" in environment "myenv"`, err.Error())
+}
+
+func Test_Unlock(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		UpdateLockFn: func(name *core.NamespacedName, envName string, lock *core.DeploymentLock) error {
+			assert.Nil(t, lock)
 			return nil
 		},
 	}
 
-	committer := state.NewDryRunCommitter()
-
-	service := service{deployments: deploymentRepository}
+	service := service{deployments: deploymentRepository}
+
+	err := service.Unlock(core.NewNamespacedName("mydep", "myns"), "myenv")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deploymentRepository.UpdateLockCallCount)
+}
+
+func Test_Delete_WhenExpectedRevisionDoesNotMatch(t *testing.T) {
+	deploymentRepository := &core.FakeDeploymentRepository{
+		DeleteFn: func(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
+			assert.EqualValues(t, 2, expectedRiserRevision)
+			return core.ErrConflictNewerVersion
+		},
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentRecord: core.DeploymentRecord{RiserRevision: 3}}, nil
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	service := service{deployments: deploymentRepository}
+
+	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", 2, false, committer)
+
+	assert.IsType(t, &core.RevisionConflictError{}, err)
+	assert.EqualValues(t, 3, err.(*core.RevisionConflictError).CurrentRiserRevision)
+	assert.Empty(t, committer.Commits)
+}
+
+func Test_Update_WhenResourcesExceedEnvironmentMax(t *testing.T) {
+	maxMemoryMB := int32(1024)
+	memoryMB := int32(2048)
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			assert.Equal(t, "prod", envName)
+			return &core.Environment{
+				Name: "prod",
+				Doc: core.EnvironmentDoc{
+					Config: core.EnvironmentConfig{
+						Resources: core.EnvironmentResources{
+							Max: core.ResourceQuantities{MemoryMB: &maxMemoryMB},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Resources: &model.AppConfigResources{
+					MemoryMB: &memoryMB,
+				},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.Equal(t, `The app's resources exceed the maximum allowed in environment "prod": resources.memoryMB: must be no greater than 1024.`, err.Error())
+}
+
+func Test_Update_WhenRequestSettingsExceedEnvironmentMax(t *testing.T) {
+	maxTimeoutSeconds := int64(300)
+	timeoutSeconds := int64(3600)
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "prod",
+				Doc: core.EnvironmentDoc{
+					Config: core.EnvironmentConfig{
+						Requests: core.EnvironmentRequests{MaxTimeoutSeconds: &maxTimeoutSeconds},
+					},
+				},
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Expose: &model.AppConfigExpose{TimeoutSeconds: &timeoutSeconds},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.Equal(t, `The app's request settings exceed the maximum allowed in environment "prod": expose.timeoutSeconds: must be no greater than 300.`, err.Error())
+}
+
+func Test_Update_WhenSecurityOptOutNotAllowed(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "prod",
+				Doc: core.EnvironmentDoc{
+					Config: core.EnvironmentConfig{
+						Security: core.EnvironmentSecurity{RunAsNonRoot: true},
+					},
+				},
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Security: &model.AppConfigSecurity{OptOut: []string{model.SecuritySetting_RunAsNonRoot}},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.Equal(t, `The app's security opt-outs are not allowed in environment "prod": security.optOut: the environment does not allow opting out of: runAsNonRoot.`, err.Error())
+}
+
+func Test_Update_WhenServiceAccountAnnotationNotAllowed(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "prod",
+				Doc: core.EnvironmentDoc{
+					Config: core.EnvironmentConfig{
+						AllowedServiceAccountAnnotations: []string{"iam.gke.io/gcp-service-account"},
+					},
+				},
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				ServiceAccount: &model.AppConfigServiceAccount{
+					Annotations: map[string]string{"eks.amazonaws.com/role-arn": "arn:aws:iam::111122223333:role/myapp"},
+				},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.Equal(t, `The app's service account annotations are not allowed in environment "prod": serviceAccount.annotations.eks.amazonaws.com/role-arn: is not an allowed annotation.`, err.Error())
+}
+
+func Test_Update_WhenPolicyViolated(t *testing.T) {
+	minScale := float64(2)
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "prod",
+				Doc: core.EnvironmentDoc{
+					Config: core.EnvironmentConfig{
+						// The environment's resource defaults are evaluated as if the app set them
+						Resources: core.EnvironmentResources{DefaultLimits: core.ResourceQuantities{MemoryMB: util.PtrInt32(2048)}},
+						Policies: core.EnvironmentPolicies{
+							{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: &minScale},
+							{Name: "max-memory", Type: model.EnvironmentPolicyType_Range, Field: "app.resources.memoryMB", Max: util.PtrFloat64(1024)},
+							{Name: "no-latest", Type: model.EnvironmentPolicyType_DisallowedValues, Field: "docker.tag", Values: []string{"latest"}},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		Docker:          core.DeploymentDocker{Tag: "latest"},
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(1)},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), true)
+
+	assert.Zero(t, result)
+	require.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The deployment violates the policies of environment "prod": `+
+		`app.autoscale.min: violates policy "min-scale": must be no less than 2; `+
+		`app.resources.memoryMB: violates policy "max-memory": must be no greater than 1024; docker.tag: violates policy "no-latest": must not be one of: latest.`, err.Error())
+}
+
+func Test_Update_WhenManualRolloutWithDeploymentWorkload(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{Name: "prod"}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		ManualRollout:   true,
+		App: &model.AppConfig{
+			Name:     "myapp",
+			Workload: model.AppWorkload_Deployment,
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, "Manual rollouts are not supported by the deployment workload", err.Error())
+}
+
+func Test_Update_WhenJobNameTooLong(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{Name: "prod"}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "my-very-long-deployment-name",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Jobs: map[string]model.AppConfigJob{
+					"a-very-long-cleanup-job-name": {Schedule: "@daily", Command: []string{"job"}},
+				},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The job "a-very-long-cleanup-job-name" is too long for the deployment "my-very-long-deployment-name": `+
+		`the combined name "my-very-long-deployment-name-a-very-long-cleanup-job-name" must be no more than 52 characters`, err.Error())
+}
+
+func Test_deploy_RemovesOtherWorkloadResources(t *testing.T) {
+	expose := &model.AppConfigExpose{ContainerPort: 8080, Protocol: "http"}
+	exposeWithPorts := &model.AppConfigExpose{ContainerPort: 8080, Protocol: "http", Ports: map[string]model.AppConfigPort{"metrics": {ContainerPort: 9100}}}
+	tt := []struct {
+		workload string
+		expose   *model.AppConfigExpose
+		deleted  []string
+	}{
+		{
+			workload: model.AppWorkload_Deployment,
+			expose:   expose,
+			deleted: []string{
+				"state/riser-managed/apps/deployments/myapp/serving.knative.dev.configuration.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/serving.knative.dev.route.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/service.myapp-ports.yaml",
+			},
+		},
+		// Workers do not have a Service
+		{
+			workload: model.AppWorkload_Deployment,
+			deleted: []string{
+				"state/riser-managed/apps/deployments/myapp/serving.knative.dev.configuration.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/serving.knative.dev.route.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/service.myapp-ports.yaml",
+				"state/riser-managed/apps/deployments/myapp/service.myapp.yaml",
+			},
+		},
+		// The ports Service is removed when there are no additional ports
+		{
+			workload: model.AppWorkload_Knative,
+			expose:   expose,
+			deleted: []string{
+				"state/riser-managed/apps/deployments/myapp/apps.deployment.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/service.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/autoscaling.horizontalpodautoscaler.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/policy.poddisruptionbudget.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/service.myapp-ports.yaml",
+			},
+		},
+		{
+			workload: model.AppWorkload_Knative,
+			expose:   exposeWithPorts,
+			deleted: []string{
+				"state/riser-managed/apps/deployments/myapp/apps.deployment.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/service.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/autoscaling.horizontalpodautoscaler.myapp.yaml",
+				"state/riser-managed/apps/deployments/myapp/policy.poddisruptionbudget.myapp.yaml",
+			},
+		},
+	}
+
+	for _, test := range tt {
+		ctx := &core.DeploymentContext{
+			DeploymentConfig: &core.DeploymentConfig{
+				Name:            "myapp",
+				Namespace:       "apps",
+				EnvironmentName: "dev",
+				App: &model.AppConfig{
+					Name:     "myapp",
+					Workload: test.workload,
+					OverrideableAppConfig: model.OverrideableAppConfig{
+						Expose: test.expose,
+					},
+				},
+			},
+			RiserRevision: 1,
+		}
+		committer := state.NewDryRunCommitter()
+
+		err := deploy(ctx, committer)
+
+		require.NoError(t, err)
+		require.Len(t, committer.Commits, 1)
+		deleted := []string{}
+		for _, file := range committer.Commits[0].Files {
+			if file.Delete {
+				deleted = append(deleted, file.Name)
+			}
+		}
+		// The jobs and domains folders are always replaced
+		assert.Equal(t, append(test.deleted,
+			"state/riser-managed/apps/deployments/myapp/jobs",
+			"state/riser-managed/apps/deployments/myapp/domains"), deleted, test.workload)
+	}
+}
+
+func Test_deploy_RemovesFilesConfigMaps(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:                   "myapp",
+			Namespace:              "apps",
+			EnvironmentName:        "dev",
+			App:                    &model.AppConfig{Name: "myapp"},
+			RemovedFilesConfigMaps: []string{"myapp-files-0123456789"},
+		},
+		RiserRevision: 1,
+	}
+	committer := state.NewDryRunCommitter()
+
+	err := deploy(ctx, committer)
+
+	require.NoError(t, err)
+	require.Len(t, committer.Commits, 1)
+	deleted := []string{}
+	for _, file := range committer.Commits[0].Files {
+		if file.Delete {
+			deleted = append(deleted, file.Name)
+		}
+	}
+	assert.Contains(t, deleted, "state/riser-managed/apps/deployments/myapp/configmap.myapp-files-0123456789.yaml")
+}
+
+func Test_computeFilesConfigMaps(t *testing.T) {
+	deploymentConfig := &core.DeploymentConfig{
+		Name: "myapp",
+		App: &model.AppConfig{
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Files: map[string]string{"/etc/config.yaml": "a"},
+			},
+		},
+		Traffic: core.TrafficConfig{
+			{RiserRevision: 5, Percent: 0},
+			{RiserRevision: 4, Percent: 100},
+			{RiserRevision: 3, Percent: 0},
+		},
+	}
+	existingDeployment := &core.DeploymentRecord{
+		Doc: core.DeploymentDoc{
+			Status: &core.DeploymentStatus{
+				Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 2}, {RiserRevision: 4}},
+			},
+			FilesConfigMaps: []core.DeploymentFilesConfigMap{
+				{RiserRevision: 1, Name: "myapp-files-1"},
+				{RiserRevision: 2, Name: "myapp-files-2"},
+				// Does not receive traffic and was garbage collected
+				{RiserRevision: 3, Name: "myapp-files-3"},
+				{RiserRevision: 4, Name: "myapp-files-4"},
+				// The same files as a revision that is kept
+				{RiserRevision: 0, Name: "myapp-files-4"},
+			},
+		},
+	}
+
+	computeFilesConfigMaps(5, deploymentConfig, existingDeployment)
+
+	require.Len(t, deploymentConfig.FilesConfigMaps, 3)
+	assert.EqualValues(t, 5, deploymentConfig.FilesConfigMaps[0].RiserRevision)
+	assert.Equal(t, resources.FilesConfigMapName(deploymentConfig), deploymentConfig.FilesConfigMaps[0].Name)
+	assert.Equal(t, core.DeploymentFilesConfigMap{RiserRevision: 2, Name: "myapp-files-2"}, deploymentConfig.FilesConfigMaps[1])
+	assert.Equal(t, core.DeploymentFilesConfigMap{RiserRevision: 4, Name: "myapp-files-4"}, deploymentConfig.FilesConfigMaps[2])
+	assert.Equal(t, []string{"myapp-files-1", "myapp-files-3"}, deploymentConfig.RemovedFilesConfigMaps)
+}
+
+func Test_computeFilesConfigMaps_NewDeploymentWithoutFiles(t *testing.T) {
+	deploymentConfig := &core.DeploymentConfig{Name: "myapp", App: &model.AppConfig{}}
+
+	computeFilesConfigMaps(1, deploymentConfig, nil)
+
+	assert.Empty(t, deploymentConfig.FilesConfigMaps)
+	assert.Empty(t, deploymentConfig.RemovedFilesConfigMaps)
+}
+
+func Test_Update_WhenDomainMappedToAnotherDeployment(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{Name: "prod"}, nil
+		},
+	}
+	deployments := &core.FakeDeploymentRepository{
+		FindByDomainsFn: func(envName string, domains []string) ([]core.Deployment, error) {
+			assert.Equal(t, "prod", envName)
+			assert.Equal(t, []string{"myapp.example.com"}, domains)
+			return []core.Deployment{
+				{
+					DeploymentReservation: core.DeploymentReservation{Name: "otherapp", Namespace: "otherns"},
+					DeploymentRecord: core.DeploymentRecord{
+						Doc: core.DeploymentDoc{
+							Domains: core.DeploymentDomains{{Name: "myapp.example.com"}},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Expose: &model.AppConfigExpose{
+					Domains: []string{"myapp.example.com"},
+				},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments, deployments: deployments}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The domain "myapp.example.com" is already mapped to deployment "otherapp.otherns" in environment "prod"`, err.Error())
+}
+
+func Test_validateDomainsAvailable_SameDeployment(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{
+		FindByDomainsFn: func(envName string, domains []string) ([]core.Deployment, error) {
+			return []core.Deployment{
+				{
+					DeploymentReservation: core.DeploymentReservation{Name: "myapp", Namespace: "myns"},
+					DeploymentRecord: core.DeploymentRecord{
+						Doc: core.DeploymentDoc{
+							Domains: core.DeploymentDomains{{Name: "myapp.example.com"}},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{Name: "myapp", Namespace: "myns", EnvironmentName: "prod"}
+
+	s := service{deployments: deployments}
+
+	err := s.validateDomainsAvailable(deploymentConfig, core.DeploymentDomains{{Name: "myapp.example.com"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deployments.FindByDomainsCallCount)
+}
+
+func Test_validateDomainsAvailable_NoDomains(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{}
+
+	s := service{deployments: deployments}
+
+	err := s.validateDomainsAvailable(&core.DeploymentConfig{}, core.DeploymentDomains{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, deployments.FindByDomainsCallCount)
+}
+
+func Test_validateServiceAccountShared(t *testing.T) {
+	appId := uuid.New()
+	deployments := &core.FakeDeploymentRepository{
+		FindByAppFn: func(appIdArg uuid.UUID) ([]core.Deployment, error) {
+			assert.Equal(t, appId, appIdArg)
+			return []core.Deployment{
+				serviceAccountDeployment("myapp-canary", "prod", map[string]string{"iam.gke.io/gcp-service-account": "other"}),
+				serviceAccountDeployment("myapp", "dev", map[string]string{"iam.gke.io/gcp-service-account": "dev"}),
+				serviceAccountDeployment("myapp", "prod", map[string]string{"iam.gke.io/gcp-service-account": "prod"}),
+			}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp-canary",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Id:   appId,
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				ServiceAccount: &model.AppConfigServiceAccount{
+					Annotations: map[string]string{"iam.gke.io/gcp-service-account": "canary"},
+				},
+			},
+		},
+	}
+
+	s := service{deployments: deployments}
+
+	err := s.validateServiceAccountShared(deploymentConfig)
+
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The serviceAccount.annotations must match the deployment "myapp" since the app's deployments in environment "prod" share the `+
+		`service account "riser-myapp". Deploy "myapp" to change the annotations.`, err.Error())
+
+	deploymentConfig.App.ServiceAccount.Annotations["iam.gke.io/gcp-service-account"] = "prod"
+	assert.NoError(t, s.validateServiceAccountShared(deploymentConfig))
+}
+
+func Test_validateServiceAccountShared_DeploymentNamedAfterApp(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App:             &model.AppConfig{Name: "myapp"},
+	}
+
+	s := service{deployments: deployments}
+
+	// The deployment named after the app may change the annotations without a lookup
+	err := s.validateServiceAccountShared(deploymentConfig)
+
+	assert.NoError(t, err)
+}
+
+func serviceAccountDeployment(name, envName string, annotations map[string]string) core.Deployment {
+	return core.Deployment{
+		DeploymentReservation: core.DeploymentReservation{Name: name, Namespace: "myns"},
+		DeploymentRecord: core.DeploymentRecord{
+			EnvironmentName: envName,
+			Doc: core.DeploymentDoc{
+				ServiceAccount: &core.DeploymentServiceAccount{Name: "riser-myapp", Annotations: annotations},
+			},
+		},
+	}
+}
+
+func Test_Update_DoesNotRequireReferencedDeployments(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{Name: "prod"}, nil
+		},
+	}
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(name *core.NamespacedName, envName string) (*core.Deployment, error) {
+			assert.Fail(t, "the referenced deployment should not be retrieved")
+			return nil, core.ErrNotFound
+		},
+	}
+	secrets := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(appName *core.NamespacedName, envName string) ([]core.SecretMeta, error) {
+			return nil, errors.New("test")
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "myns",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Environment: map[string]intstr.IntOrString{
+					"CHECKOUT_URL": intstr.FromString("${app:checkout.apps.url}"),
+				},
+			},
+		},
+	}
+
+	s := service{environments: environments, deployments: deployments, secrets: secrets}
+
+	_, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	// References are resolved by name so that apps that reference each other may be deployed in any order
+	assert.Equal(t, "test", err.Error())
+}
+
+func Test_Update_WhenSecretMissing(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{Name: "prod"}, nil
+		},
+	}
+	secrets := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(appName *core.NamespacedName, envName string) ([]core.SecretMeta, error) {
+			assert.Equal(t, core.NewNamespacedName("myapp", "myns"), appName)
+			assert.Equal(t, "prod", envName)
+			return []core.SecretMeta{{Name: "creds", Revision: 1}}, nil
+		},
+	}
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Name:      "myapp",
+			Namespace: "myns",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Secrets: map[string]model.AppConfigSecret{
+					"creds":   {},
+					"tls-key": {Path: "/etc/tls.key"},
+					"api-key": {},
+				},
+			},
+		},
+	}
+
+	// The reservation service is intentionally not set since validation must occur before any changes are made
+	s := service{environments: environments, secrets: secrets}
+
+	result, err := s.Update(deploymentConfig, state.NewDryRunCommitter(), false)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The following secrets do not exist in environment "prod": api-key, tls-key`, err.Error())
+}
+
+func Test_Update_RollsBackRevisionWhenCommitFails(t *testing.T) {
+	appId := uuid.New()
+	previous := core.DeploymentRecord{
+		RiserRevision: 2,
+		Doc: core.DeploymentDoc{
+			Traffic:        core.TrafficConfig{{RiserRevision: 2, RevisionName: "myapp-2", Percent: 100}},
+			TrafficVersion: 4,
+			Domains:        core.DeploymentDomains{{Name: "old.example.com"}},
+		},
+	}
+	environments := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{Name: "prod"}, nil
+		},
+	}
+	secrets := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(appName *core.NamespacedName, envName string) ([]core.SecretMeta, error) {
+			return []core.SecretMeta{}, nil
+		},
+	}
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{
+		GetFn: func(namespace string, envName string) (*core.NamespaceConfig, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(appIdArg uuid.UUID, nameArg *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New(), AppId: appId}, nil
+		},
+	}
+	deployments := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(reservationId uuid.UUID, envName string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentReservation: core.DeploymentReservation{AppId: appId}, DeploymentRecord: previous}, nil
+		},
+		FindByDomainsFn: func(envName string, domains []string) ([]core.Deployment, error) {
+			return []core.Deployment{}, nil
+		},
+		IncrementRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *core.DeploymentRevisionDoc) error {
+			return nil
+		},
+		RollbackRevisionFn: func(name *core.NamespacedName, envName string, failedRevision int64, previousArg *core.DeploymentRecord) (int64, error) {
+			assert.Equal(t, core.NewNamespacedName("myapp", "myns"), name)
+			assert.Equal(t, "prod", envName)
+			assert.EqualValues(t, 3, failedRevision)
+			// The domains, traffic, and traffic version of the revision that was never deployed are restored
+			assert.Equal(t, &previous, previousArg)
+			return 2, nil
+		},
+	}
+	committer := state.NewGitCommitter(&git.FakeRepo{
+		ResetHardRemoteFn: func() error {
+			return errors.New("broke")
+		},
+	})
+	deploymentConfig := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "prod",
+		App: &model.AppConfig{
+			Id:        appId,
+			Name:      "myapp",
+			Namespace: "myns",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image:  "hashicorp/http-echo",
+				Expose: &model.AppConfigExpose{ContainerPort: 8080, Domains: []string{"new.example.com"}},
+			},
+		},
+	}
+
+	s := service{
+		environments:       environments,
+		secrets:            secrets,
+		namespaceConfigs:   namespaceConfigs,
+		deployments:        deployments,
+		reservationService: reservationService,
+	}
+
+	result, err := s.Update(deploymentConfig, committer, false)
+
+	assert.Zero(t, result)
+	assert.Equal(t, "error resetting repo: broke", err.Error())
+	assert.Equal(t, 1, deployments.IncrementRevisionCallCount)
+	assert.Equal(t, 1, deployments.RollbackRevisionCallCount)
+}
+
+func Test_UnresolvedAppReferences(t *testing.T) {
+	deletedAt := time.Now()
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(name *core.NamespacedName, envName string) (*core.Deployment, error) {
+			assert.Equal(t, "prod", envName)
+			switch name.Name {
+			case "checkout":
+				assert.Equal(t, "apps", name.Namespace)
+				return &core.Deployment{}, nil
+			case "billing":
+				assert.Equal(t, "myns", name.Namespace)
+				return &core.Deployment{DeploymentRecord: core.DeploymentRecord{DeletedAt: &deletedAt}}, nil
+			case "queue":
+				return &core.Deployment{DeploymentRecord: core.DeploymentRecord{Doc: core.DeploymentDoc{Worker: true}}}, nil
+			}
+			return nil, core.ErrNotFound
+		},
+	}
+	app := &model.AppConfig{
+		Name:      "myapp",
+		Namespace: "myns",
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Environment: map[string]intstr.IntOrString{
+				"CHECKOUT_URL": intstr.FromString("${app:checkout.apps.url}"),
+				"BILLING_HOST": intstr.FromString("${app:billing.host}"),
+				"MISSING_URL":  intstr.FromString("${app:missing.apps.url}"),
+				"SELF_URL":     intstr.FromString("${app:myapp-canary.url}"),
+				"PUBLIC_URL":   intstr.FromString("${app:myapp-canary.externalUrl}"),
+				"QUEUE_URL":    intstr.FromString("${app:queue.url}"),
+			},
+		},
+	}
+
+	s := service{deployments: deployments}
 
-	err := service.Delete(name, "myenv", committer)
+	result, err := s.UnresolvedAppReferences(app, "myapp-canary", &core.Environment{Name: "prod"})
 
 	assert.NoError(t, err)
-	assert.Equal(t, 1, deploymentRepository.DeleteCallCount)
-	assert.Len(t, committer.Commits, 1)
-	assert.Equal(t, `Deleting deployment "mydep.apps"`, committer.Commits[0].Message)
-	assert.Len(t, committer.Commits[0].Files, 2)
-	assert.Equal(t, "state/riser-managed/apps/deployments/mydep", committer.Commits[0].Files[0].Name)
-	assert.True(t, committer.Commits[0].Files[0].Delete)
-	assert.Equal(t, "riser-config/apps/mydep.yaml", committer.Commits[0].Files[1].Name)
-	assert.True(t, committer.Commits[0].Files[1].Delete)
+	assert.Equal(t, []string{
+		`The env var "BILLING_HOST" references the deployment "billing.myns" which does not exist in environment "prod"`,
+		`The env var "MISSING_URL" references the deployment "missing.apps" which does not exist in environment "prod"`,
+		`The env var "PUBLIC_URL" references "${app:myapp-canary.externalUrl}" but environment "prod" does not have a publicGatewayHost`,
+		`The env var "QUEUE_URL" references the deployment "queue.myns" which is a worker without an address in environment "prod"`,
+	}, result)
 }
 
-func Test_Delete_SoftDeleteFails(t *testing.T) {
-	deploymentRepository := &core.FakeDeploymentRepository{
-		DeleteFn: func(*core.NamespacedName, string) error {
-			return errors.New("test")
+func Test_UnresolvedAppReferences_GetByNameError(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(name *core.NamespacedName, envName string) (*core.Deployment, error) {
+			return nil, errors.New("test")
+		},
+	}
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Environment: map[string]intstr.IntOrString{"CHECKOUT_URL": intstr.FromString("${app:checkout.apps.url}")},
 		},
 	}
 
-	committer := state.NewDryRunCommitter()
+	s := service{deployments: deployments}
 
-	service := service{deployments: deploymentRepository}
+	result, err := s.UnresolvedAppReferences(app, "myapp", &core.Environment{Name: "prod"})
 
-	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", committer)
+	assert.Nil(t, result)
+	assert.Equal(t, "Error retrieving referenced deployment: test", err.Error())
+}
 
-	assert.Equal(t, "error deleting deployment: test", err.Error())
+func Test_EnvironmentWarnings(t *testing.T) {
+	app := &model.AppConfigWithOverrides{
+		AppConfig: model.AppConfig{
+			Name:      "myapp",
+			Namespace: "myns",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Image:       "registry.example.com/myapp",
+				Environment: map[string]intstr.IntOrString{"CHECKOUT_URL": intstr.FromString("${app:checkout.url}")},
+				Secrets:     map[string]model.AppConfigSecret{"creds": {}},
+				Security: &model.AppConfigSecurity{
+					OptOut: []string{model.SecuritySetting_ReadOnlyRootFilesystem, model.SecuritySetting_RunAsNonRoot},
+				},
+			},
+		},
+		Overrides: map[string]model.OverrideableAppConfig{
+			"prod": {
+				Environment: map[string]intstr.IntOrString{"CHECKOUT_URL": intstr.FromString("${app:checkout.prodns.url}")},
+				Secrets:     map[string]model.AppConfigSecret{"tls-key": {Path: "/etc/tls.key"}},
+			},
+		},
+	}
+	prodConfig := core.EnvironmentConfig{
+		// The environment's app defaults are applied before evaluating policies
+		AppDefaults: &model.OverrideableAppConfig{Autoscale: &model.AppConfigAutoscale{Min: util.PtrInt(1)}},
+		Policies: core.EnvironmentPolicies{
+			{Name: "min-scale", Type: model.EnvironmentPolicyType_Range, Field: "app.autoscale.min", Min: util.PtrFloat64(2)},
+			{Name: "registry", Type: model.EnvironmentPolicyType_AllowedPrefixes, Field: "app.image", Values: []string{"registry.example.com/"}},
+			// The docker tag is not known until the app is deployed
+			{Name: "tag", Type: model.EnvironmentPolicyType_Required, Field: "docker.tag"},
+		},
+		Security: core.EnvironmentSecurity{
+			RunAsNonRoot:           true,
+			ReadOnlyRootFilesystem: true,
+			AllowedOptOuts:         []string{model.SecuritySetting_ReadOnlyRootFilesystem},
+		},
+	}
+	environments := &core.FakeEnvironmentRepository{
+		ListFn: func() ([]core.Environment, error) {
+			return []core.Environment{{Name: "dev"}, {Name: "prod", Doc: core.EnvironmentDoc{Config: prodConfig}}}, nil
+		},
+	}
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(name *core.NamespacedName, envName string) (*core.Deployment, error) {
+			if envName == "prod" {
+				assert.Equal(t, core.NewNamespacedName("checkout", "prodns"), name)
+				return nil, core.ErrNotFound
+			}
+			assert.Equal(t, core.NewNamespacedName("checkout", "myns"), name)
+			return &core.Deployment{}, nil
+		},
+	}
+	secretMetas := &core.FakeSecretMetaRepository{
+		ListByAppInEnvironmentFn: func(appName *core.NamespacedName, envName string) ([]core.SecretMeta, error) {
+			assert.Equal(t, core.NewNamespacedName("myapp", "myns"), appName)
+			return []core.SecretMeta{{Name: "creds", Revision: 1}}, nil
+		},
+	}
+
+	s := service{environments: environments, deployments: deployments, secrets: secretMetas}
+
+	result, err := s.EnvironmentWarnings(app)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		`The env var "CHECKOUT_URL" references the deployment "checkout.prodns" which does not exist in environment "prod"`,
+		`The app violates a policy in environment "prod": app.autoscale.min violates policy "min-scale": must be no less than 2`,
+		`The security setting "runAsNonRoot" may not be opted out of in environment "prod"`,
+		`The secret "tls-key" does not exist in environment "prod"`,
+	}, result)
+	assert.Equal(t, 1, environments.ListCallCount)
 }
 
-func Test_Delete_DeploymentNotFound(t *testing.T) {
-	deploymentRepository := &core.FakeDeploymentRepository{
-		DeleteFn: func(*core.NamespacedName, string) error {
-			return core.ErrNotFound
+func Test_EnvironmentWarnings_NoSecrets(t *testing.T) {
+	app := &model.AppConfigWithOverrides{AppConfig: model.AppConfig{Name: "myapp", Namespace: "myns"}}
+	environments := &core.FakeEnvironmentRepository{
+		ListFn: func() ([]core.Environment, error) {
+			return []core.Environment{{Name: "dev"}}, nil
 		},
 	}
 
-	service := service{deployments: deploymentRepository}
+	// The secret meta repository must not be called when the app has no secrets
+	s := service{environments: environments, secrets: &core.FakeSecretMetaRepository{}}
 
-	err := service.Delete(core.NewNamespacedName("mydep", "myns"), "myenv", nil)
+	result, err := s.EnvironmentWarnings(app)
 
-	assert.Equal(t, `There is no deployment by the name "mydep.myns" in environment "myenv"`, err.Error())
-	assert.IsType(t, &core.ValidationError{}, err)
+	assert.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func Test_EnvironmentWarnings_ListError(t *testing.T) {
+	environments := &core.FakeEnvironmentRepository{
+		ListFn: func() ([]core.Environment, error) {
+			return nil, errors.New("broke")
+		},
+	}
+
+	s := service{environments: environments}
+
+	result, err := s.EnvironmentWarnings(&model.AppConfigWithOverrides{})
+
+	assert.Nil(t, result)
+	assert.Equal(t, "broke", err.Error())
 }
 
 func Test_prepareForDeployment_whenNewDeploymentCreates(t *testing.T) {
@@ -116,11 +1255,13 @@ func Test_prepareForDeployment_whenNewDeploymentCreates(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, previous, err := service.prepareForDeployment(deployment, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, "myns", deployment.Namespace)
 	assert.Equal(t, int64(1), result)
+	// A new deployment that fails to deploy is rolled back to deleted
+	assert.NotNil(t, previous.DeletedAt)
 	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
 	assert.Equal(t, 1, deploymentRepository.CreateCallCount)
 }
@@ -131,9 +1272,11 @@ func Test_prepareForDeployment_whenExistingDeployment(t *testing.T) {
 		Namespace:       "myns",
 		EnvironmentName: "myenv",
 		App: &model.AppConfig{
-			Id:   uuid.New(),
-			Name: "myapp",
+			Id:       uuid.New(),
+			Name:     "myapp",
+			Workload: model.AppWorkload_Deployment,
 		},
+		Domains: core.DeploymentDomains{{Name: "myapp.example.com", TLS: true}},
 	}
 
 	deploymentId := uuid.New()
@@ -157,34 +1300,39 @@ func Test_prepareForDeployment_whenExistingDeployment(t *testing.T) {
 				DeploymentRecord: core.DeploymentRecord{
 					Id:              deploymentId,
 					ReservationId:   reservation.Id,
-					EnvironmentName: "myenv"}}, nil
-		},
-		IncrementRevisionFn: func(name *core.NamespacedName, envName string) (int64, error) {
-			assert.Equal(t, "myapp-mydep", name.Name)
-			assert.Equal(t, "myns", name.Namespace)
-			assert.Equal(t, "myenv", envName)
-			return 3, nil
+					EnvironmentName: "myenv",
+					RiserRevision:   2,
+					Doc:             core.DeploymentDoc{TrafficVersion: 4}}}, nil
 		},
-		UpdateTrafficFn: func(name *core.NamespacedName, envName string, riserRevision int64, traffic core.TrafficConfig) error {
+		IncrementRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *core.DeploymentRevisionDoc) error {
 			assert.Equal(t, "myapp-mydep", name.Name)
 			assert.Equal(t, "myns", name.Namespace)
 			assert.Equal(t, "myenv", envName)
-			assert.Len(t, traffic, 1)
-			assert.Equal(t, int64(3), traffic[0].RiserRevision)
-			assert.Equal(t, "myapp-mydep-3", traffic[0].RevisionName)
-			assert.Equal(t, 100, traffic[0].Percent)
+			assert.EqualValues(t, 2, riserRevision)
+			assert.EqualValues(t, 4, trafficVersion)
+			assert.Len(t, revision.Traffic, 1)
+			assert.Equal(t, int64(3), revision.Traffic[0].RiserRevision)
+			assert.Equal(t, "myapp-mydep-3", revision.Traffic[0].RevisionName)
+			assert.Equal(t, 100, revision.Traffic[0].Percent)
+			assert.Equal(t, deployment.Domains, revision.Domains)
+			assert.Empty(t, revision.FilesConfigMaps)
+			assert.Equal(t, model.AppWorkload_Deployment, revision.Workload)
+			assert.True(t, revision.Worker)
+			assert.Equal(t, &core.DeploymentServiceAccount{Name: "riser-myapp"}, revision.ServiceAccount)
 			return nil
 		},
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, previous, err := service.prepareForDeployment(deployment, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(3), result)
+	assert.Equal(t, deploymentId, previous.Id)
+	assert.EqualValues(t, 4, previous.Doc.TrafficVersion)
 	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
 	assert.Equal(t, 1, deploymentRepository.IncrementRevisionCallCount)
-	assert.Equal(t, 1, deploymentRepository.UpdateTrafficCallCount)
+	assert.Equal(t, 0, deploymentRepository.UpdateTrafficCallCount)
 	assert.Equal(t, 0, deploymentRepository.CreateCallCount)
 }
 
@@ -227,6 +1375,7 @@ func Test_prepareForDeployment_manualRollout_previouslyDeletedDeployment(t *test
 					Id:              deploymentId,
 					ReservationId:   reservation.Id,
 					EnvironmentName: "myenv",
+					RiserRevision:   2,
 					DeletedAt:       &deletedAt,
 					Doc: core.DeploymentDoc{
 						// This rule should be ignored since the deployment was previously deleted
@@ -241,34 +1390,28 @@ func Test_prepareForDeployment_manualRollout_previouslyDeletedDeployment(t *test
 				},
 			}, nil
 		},
-		IncrementRevisionFn: func(name *core.NamespacedName, envName string) (int64, error) {
-			assert.Equal(t, "myapp-mydep", name.Name)
-			assert.Equal(t, "myns", name.Namespace)
-			assert.Equal(t, "myenv", envName)
-			return 3, nil
-		},
-		UpdateTrafficFn: func(name *core.NamespacedName, envName string, riserRevision int64, traffic core.TrafficConfig) error {
+		IncrementRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *core.DeploymentRevisionDoc) error {
 			assert.Equal(t, "myapp-mydep", name.Name)
 			assert.Equal(t, "myns", name.Namespace)
 			assert.Equal(t, "myenv", envName)
 			// Even though a manual rollout is requested, a previously deleted deployment is treated as if there are no previous traffic rules
 			// Therefore we route all traffic to the new revision.
-			assert.Len(t, traffic, 1)
-			assert.Equal(t, int64(3), traffic[0].RiserRevision)
-			assert.Equal(t, "myapp-mydep-3", traffic[0].RevisionName)
-			assert.Equal(t, 100, traffic[0].Percent)
+			assert.Len(t, revision.Traffic, 1)
+			assert.Equal(t, int64(3), revision.Traffic[0].RiserRevision)
+			assert.Equal(t, "myapp-mydep-3", revision.Traffic[0].RevisionName)
+			assert.Equal(t, 100, revision.Traffic[0].Percent)
 			return nil
 		},
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(3), result)
 	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
 	assert.Equal(t, 1, deploymentRepository.IncrementRevisionCallCount)
-	assert.Equal(t, 1, deploymentRepository.UpdateTrafficCallCount)
+	assert.Equal(t, 0, deploymentRepository.UpdateTrafficCallCount)
 	assert.Equal(t, 0, deploymentRepository.CreateCallCount)
 }
 
@@ -305,19 +1448,19 @@ func Test_prepareForDeployment_whenIncrementRevisionFails(t *testing.T) {
 					ReservationId:   reservation.Id,
 					EnvironmentName: "myenv"}}, nil
 		},
-		IncrementRevisionFn: func(name *core.NamespacedName, envName string) (int64, error) {
-			return 0, errors.New("test")
+		IncrementRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *core.DeploymentRevisionDoc) error {
+			return errors.New("test")
 		},
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, "Error incrementing deployment revision: test", err.Error())
 }
 
-func Test_prepareForDeployment_doesNotUpdateWhenDryRun(t *testing.T) {
+func Test_prepareForDeployment_whenExpectedRevisionDoesNotMatch(t *testing.T) {
 	deployment := &core.DeploymentConfig{
 		Name:            "myapp-mydep",
 		EnvironmentName: "myenv",
@@ -325,14 +1468,12 @@ func Test_prepareForDeployment_doesNotUpdateWhenDryRun(t *testing.T) {
 			Id:   uuid.New(),
 			Name: "myapp",
 		},
+		ExpectedRiserRevision: 2,
 	}
 
-	deploymentId := uuid.New()
 	reservation := core.DeploymentReservation{
-		Id:        uuid.New(),
-		AppId:     deployment.App.Id,
-		Name:      deployment.Name,
-		Namespace: deployment.Namespace,
+		Id:    uuid.New(),
+		AppId: deployment.App.Id,
 	}
 
 	reservationService := &deploymentreservation.FakeService{
@@ -345,31 +1486,174 @@ func Test_prepareForDeployment_doesNotUpdateWhenDryRun(t *testing.T) {
 		GetByReservationFn: func(reservationId uuid.UUID, envNameArg string) (*core.Deployment, error) {
 			return &core.Deployment{
 				DeploymentReservation: reservation,
-				DeploymentRecord: core.DeploymentRecord{
-					Id:              deploymentId,
-					ReservationId:   reservation.Id,
-					EnvironmentName: "myenv"}}, nil
+				DeploymentRecord:      core.DeploymentRecord{RiserRevision: 3}}, nil
 		},
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, true)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
-	assert.NoError(t, err)
-	// The RiserRevision is always "0" for a dry-run
-	assert.Equal(t, int64(0), result)
-	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
+	assert.Zero(t, result)
+	assert.IsType(t, &core.RevisionConflictError{}, err)
+	assert.EqualValues(t, 3, err.(*core.RevisionConflictError).CurrentRiserRevision)
 	assert.Equal(t, 0, deploymentRepository.IncrementRevisionCallCount)
-	assert.Equal(t, 0, deploymentRepository.UpdateTrafficCallCount)
+}
+
+func Test_prepareForDeployment_whenExpectedRevisionAndNewDeployment(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Id:   uuid.New(),
+			Name: "myapp",
+		},
+		ExpectedRiserRevision: 1,
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(appIdArg uuid.UUID, nameArg *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New()}, nil
+		},
+	}
+
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(reservationId uuid.UUID, envNameArg string) (*core.Deployment, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	service := service{deployments: deploymentRepository, reservationService: reservationService}
+	result, _, err := service.prepareForDeployment(deployment, false)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.RevisionConflictError{}, err)
+	assert.EqualValues(t, 0, err.(*core.RevisionConflictError).CurrentRiserRevision)
 	assert.Equal(t, 0, deploymentRepository.CreateCallCount)
-	// Traffic should still be computed in a dry-run, just not persisted
-	assert.Len(t, deployment.Traffic, 1)
-	assert.Equal(t, int64(0), deployment.Traffic[0].RiserRevision)
-	assert.Equal(t, "myapp-mydep-0", deployment.Traffic[0].RevisionName)
-	assert.Equal(t, 100, deployment.Traffic[0].Percent)
 }
 
-func Test_prepareForDeployment_whenUpdateTrafficFails(t *testing.T) {
+func Test_prepareForDeployment_whenConcurrentIncrement(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Id:   uuid.New(),
+			Name: "myapp",
+		},
+		ExpectedRiserRevision: 2,
+	}
+
+	reservation := core.DeploymentReservation{
+		Id:    uuid.New(),
+		AppId: deployment.App.Id,
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(appIdArg uuid.UUID, nameArg *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &reservation, nil
+		},
+	}
+
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(reservationId uuid.UUID, envNameArg string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentReservation: reservation,
+				DeploymentRecord:      core.DeploymentRecord{RiserRevision: 2}}, nil
+		},
+		IncrementRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *core.DeploymentRevisionDoc) error {
+			assert.EqualValues(t, 2, riserRevision)
+			return core.ErrConflictNewerVersion
+		},
+		GetByNameFn: func(name *core.NamespacedName, envName string) (*core.Deployment, error) {
+			assert.Equal(t, core.NewNamespacedName("myapp-mydep", "myns"), name)
+			return &core.Deployment{DeploymentRecord: core.DeploymentRecord{RiserRevision: 3}}, nil
+		},
+	}
+
+	service := service{deployments: deploymentRepository, reservationService: reservationService}
+	result, _, err := service.prepareForDeployment(deployment, false)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.RevisionConflictError{}, err)
+	assert.EqualValues(t, 3, err.(*core.RevisionConflictError).CurrentRiserRevision)
+	assert.Equal(t, 0, deploymentRepository.UpdateTrafficCallCount)
+}
+
+func Test_prepareForDeployment_whenLocked(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Id:   uuid.New(),
+			Name: "myapp",
+		},
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(appIdArg uuid.UUID, nameArg *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New(), AppId: deployment.App.Id}, nil
+		},
+	}
+
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(reservationId uuid.UUID, envNameArg string) (*core.Deployment, error) {
+			existing := lockedDeployment()
+			existing.AppId = deployment.App.Id
+			return existing, nil
+		},
+	}
+
+	service := service{deployments: deploymentRepository, reservationService: reservationService}
+	result, _, err := service.prepareForDeployment(deployment, true)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Contains(t, err.Error(), `by "alice"`)
+	assert.Equal(t, 0, deploymentRepository.IncrementRevisionCallCount)
+}
+
+func Test_prepareForDeployment_whenLockedConcurrently(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp-mydep",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Id:   uuid.New(),
+			Name: "myapp",
+		},
+	}
+
+	reservationService := &deploymentreservation.FakeService{
+		EnsureReservationFn: func(appIdArg uuid.UUID, nameArg *core.NamespacedName) (*core.DeploymentReservation, error) {
+			return &core.DeploymentReservation{Id: uuid.New(), AppId: deployment.App.Id}, nil
+		},
+	}
+
+	deploymentRepository := &core.FakeDeploymentRepository{
+		GetByReservationFn: func(reservationId uuid.UUID, envNameArg string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentReservation: core.DeploymentReservation{AppId: deployment.App.Id},
+				DeploymentRecord:      core.DeploymentRecord{RiserRevision: 2}}, nil
+		},
+		// The lock is acquired after the deployment is read but before the revision is incremented
+		IncrementRevisionFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *core.DeploymentRevisionDoc) error {
+			assert.False(t, overrideLock)
+			return core.ErrConflictNewerVersion
+		},
+		GetByNameFn: func(name *core.NamespacedName, envName string) (*core.Deployment, error) {
+			return lockedDeployment(), nil
+		},
+	}
+
+	service := service{deployments: deploymentRepository, reservationService: reservationService}
+	result, _, err := service.prepareForDeployment(deployment, false)
+
+	assert.Zero(t, result)
+	assert.IsType(t, &core.ValidationError{}, err)
+	assert.Contains(t, err.Error(), `by "alice"`)
+	assert.Equal(t, 0, deploymentRepository.UpdateTrafficCallCount)
+}
+
+func Test_prepareForDeployment_doesNotUpdateWhenDryRun(t *testing.T) {
 	deployment := &core.DeploymentConfig{
 		Name:            "myapp-mydep",
 		EnvironmentName: "myenv",
@@ -402,19 +1686,23 @@ func Test_prepareForDeployment_whenUpdateTrafficFails(t *testing.T) {
 					ReservationId:   reservation.Id,
 					EnvironmentName: "myenv"}}, nil
 		},
-		IncrementRevisionFn: func(name *core.NamespacedName, envName string) (int64, error) {
-			return 1, nil
-		},
-		UpdateTrafficFn: func(*core.NamespacedName, string, int64, core.TrafficConfig) error {
-			return errors.New("broke")
-		},
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, true)
 
-	assert.Zero(t, result)
-	assert.Equal(t, "Error updating traffic: broke", err.Error())
+	assert.NoError(t, err)
+	// The RiserRevision is always "0" for a dry-run
+	assert.Equal(t, int64(0), result)
+	assert.Equal(t, 1, deploymentRepository.GetByReservationCallCount)
+	assert.Equal(t, 0, deploymentRepository.IncrementRevisionCallCount)
+	assert.Equal(t, 0, deploymentRepository.UpdateTrafficCallCount)
+	assert.Equal(t, 0, deploymentRepository.CreateCallCount)
+	// Traffic should still be computed in a dry-run, just not persisted
+	assert.Len(t, deployment.Traffic, 1)
+	assert.Equal(t, int64(0), deployment.Traffic[0].RiserRevision)
+	assert.Equal(t, "myapp-mydep-0", deployment.Traffic[0].RevisionName)
+	assert.Equal(t, 100, deployment.Traffic[0].Percent)
 }
 
 func Test_prepareForDeployment_whenEnsureReservationErr(t *testing.T) {
@@ -434,7 +1722,7 @@ func Test_prepareForDeployment_whenEnsureReservationErr(t *testing.T) {
 	}
 
 	service := service{reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, `Error ensuring deployment reservation: test`, err.Error())
@@ -469,7 +1757,7 @@ func Test_prepareForDeployment_whenGetFails(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, `Error retrieving deployment "myapp-mydep" in environment "myenv": test`, err.Error())
@@ -507,7 +1795,7 @@ func Test_prepareForDeployment_whenCreateFails(t *testing.T) {
 	}
 
 	service := service{deployments: deploymentRepository, reservationService: reservationService}
-	result, err := service.prepareForDeployment(deployment, false)
+	result, _, err := service.prepareForDeployment(deployment, false)
 
 	assert.Zero(t, result)
 	assert.Equal(t, `Error creating deployment "myapp-mydep" in environment "myenv": test`, err.Error())
@@ -636,3 +1924,63 @@ func Test_validateDeploymentConfig_ValidatesName(t *testing.T) {
 		}
 	}
 }
+
+func lockedDeployment() *core.Deployment {
+	return &core.Deployment{
+		DeploymentReservation: core.DeploymentReservation{Name: "mydep", Namespace: "myns"},
+		DeploymentRecord: core.DeploymentRecord{
+			EnvironmentName: "myenv",
+			Doc: core.DeploymentDoc{
+				Lock: &core.DeploymentLock{Holder: "alice", Reason: "incident"},
+			},
+		},
+	}
+}
+
+func Test_getNamespaceConfig(t *testing.T) {
+	namespaceConfig := &core.NamespaceConfig{Namespace: "myns", EnvironmentName: "dev"}
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{
+		GetFn: func(namespace string, envName string) (*core.NamespaceConfig, error) {
+			assert.Equal(t, "myns", namespace)
+			assert.Equal(t, "dev", envName)
+			return namespaceConfig, nil
+		},
+	}
+
+	s := service{namespaceConfigs: namespaceConfigs}
+
+	result, err := s.getNamespaceConfig("myns", "dev")
+
+	assert.NoError(t, err)
+	assert.Equal(t, namespaceConfig, result)
+}
+
+func Test_getNamespaceConfig_WhenNotFound(t *testing.T) {
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{
+		GetFn: func(namespace string, envName string) (*core.NamespaceConfig, error) {
+			return nil, core.ErrNotFound
+		},
+	}
+
+	s := service{namespaceConfigs: namespaceConfigs}
+
+	result, err := s.getNamespaceConfig("myns", "dev")
+
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func Test_getNamespaceConfig_WhenError(t *testing.T) {
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{
+		GetFn: func(namespace string, envName string) (*core.NamespaceConfig, error) {
+			return nil, errors.New("test")
+		},
+	}
+
+	s := service{namespaceConfigs: namespaceConfigs}
+
+	result, err := s.getNamespaceConfig("myns", "dev")
+
+	assert.Nil(t, result)
+	assert.Equal(t, "Error retrieving namespace config: test", err.Error())
+}
@@ -9,6 +9,8 @@ type FakeService struct {
 	PingCallCount        int
 	GetStatusFn          func(envName string) (*core.EnvironmentStatus, error)
 	GetStatusCallCount   int
+	GetConfigFn          func(envName string) (*core.EnvironmentConfig, error)
+	GetConfigCallCount   int
 	ValidateDeployableFn func(envName string) error
 }
 
@@ -22,8 +24,9 @@ func (fake *FakeService) GetStatus(envName string) (*core.EnvironmentStatus, err
 	return fake.GetStatusFn(envName)
 }
 
-func (fake *FakeService) GetConfig(string) (*core.EnvironmentConfig, error) {
-	panic("NI")
+func (fake *FakeService) GetConfig(envName string) (*core.EnvironmentConfig, error) {
+	fake.GetConfigCallCount++
+	return fake.GetConfigFn(envName)
 }
 
 func (fake *FakeService) SetConfig(string, *core.EnvironmentConfig) error {
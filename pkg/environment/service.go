@@ -66,6 +66,11 @@ func (s *service) SetConfig(envName string, environmentConfig *core.EnvironmentC
 		return errors.Wrap(err, fmt.Sprintf("Error merging environment configuration for environment %q", envName))
 	}
 
+	err = environment.Doc.Config.Resources.ValidateDefaults(envName)
+	if err != nil {
+		return err
+	}
+
 	err = s.environments.Save(environment)
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("Error saving environment %q", envName))
@@ -6,7 +6,9 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_Ping(t *testing.T) {
@@ -211,6 +213,70 @@ func Test_SetConfig_IgnoresEmptyValues(t *testing.T) {
 	assert.Equal(t, 1, environmentRepository.SaveCallCount)
 }
 
+func Test_SetConfig_ValidatesMergedResources(t *testing.T) {
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "myenv",
+				Doc: core.EnvironmentDoc{
+					Config: core.EnvironmentConfig{
+						Resources: core.EnvironmentResources{
+							DefaultLimits: core.ResourceQuantities{CpuCores: util.PtrFloat32(1), MemoryMB: util.PtrInt32(512)},
+						},
+					},
+				},
+			}, nil
+		},
+		SaveFn: func(environment *core.Environment) error {
+			assert.EqualValues(t, 1, *environment.Doc.Config.Resources.DefaultLimits.CpuCores)
+			assert.EqualValues(t, 2, *environment.Doc.Config.Resources.Max.CpuCores)
+			return nil
+		},
+	}
+
+	service := service{environmentRepository}
+
+	// Only the max is set since the default limits are already set
+	err := service.SetConfig("myenv", &core.EnvironmentConfig{
+		Resources: core.EnvironmentResources{
+			Max: core.ResourceQuantities{CpuCores: util.PtrFloat32(2), MemoryMB: util.PtrInt32(1024)},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, environmentRepository.SaveCallCount)
+}
+
+func Test_SetConfig_WhenMergedResourcesInvalid(t *testing.T) {
+	environmentRepository := &core.FakeEnvironmentRepository{
+		GetFn: func(envName string) (*core.Environment, error) {
+			return &core.Environment{
+				Name: "myenv",
+				Doc: core.EnvironmentDoc{
+					Config: core.EnvironmentConfig{
+						Resources: core.EnvironmentResources{
+							DefaultLimits: core.ResourceQuantities{CpuCores: util.PtrFloat32(4), MemoryMB: util.PtrInt32(512)},
+						},
+					},
+				},
+			}, nil
+		},
+	}
+
+	service := service{environmentRepository}
+
+	err := service.SetConfig("myenv", &core.EnvironmentConfig{
+		Resources: core.EnvironmentResources{
+			Max: core.ResourceQuantities{CpuCores: util.PtrFloat32(2)},
+		},
+	})
+
+	require.IsType(t, &core.ValidationError{}, err)
+	assert.Equal(t, `The resource defaults are not valid in environment "myenv": `+
+		`resources.defaultLimits.cpuCores: must be less than or equal to resources.max.cpuCores.`, err.Error())
+	assert.Equal(t, 0, environmentRepository.SaveCallCount)
+}
+
 func Test_ValidateDeployable(t *testing.T) {
 	environmentRepository := &core.FakeEnvironmentRepository{
 		ListFn: func() ([]core.Environment, error) {
@@ -1,7 +1,11 @@
 package namespace
 
+import "github.com/riser-platform/riser-server/pkg/core"
+
 type FakeService struct {
 	ValidateDeployableFn func(string) error
+	SaveConfigFn         func(config *core.NamespaceConfig) ([]core.Deployment, error)
+	SaveConfigCallCount  int
 }
 
 func (fake *FakeService) ValidateDeployable(namespaceName string) error {
@@ -13,3 +17,8 @@ func (fake *FakeService) EnsureDefaultNamespace() error {
 func (fake *FakeService) Create(namespaceName string) error {
 	panic("NI")
 }
+
+func (fake *FakeService) SaveConfig(config *core.NamespaceConfig) ([]core.Deployment, error) {
+	fake.SaveConfigCallCount++
+	return fake.SaveConfigFn(config)
+}
@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 )
 
 type Service interface {
@@ -14,15 +16,26 @@ type Service interface {
 	// EnsureDefaultNamespace ensures that the default namespace has been provisioned. Designed to be used only at server startup.
 	EnsureDefaultNamespace() error
 	Create(namespaceName string) error
+	// SaveConfig saves the namespace config for an environment. Returns the deployments in the namespace that must be redeployed for the
+	// config to take effect.
+	SaveConfig(config *core.NamespaceConfig) ([]core.Deployment, error)
 }
 
 type service struct {
-	namespaces   core.NamespaceRepository
-	environments core.EnvironmentRepository
+	namespaces       core.NamespaceRepository
+	namespaceConfigs core.NamespaceConfigRepository
+	environments     core.EnvironmentRepository
+	deployments      core.DeploymentRepository
+	webhooks         webhook.Service
 }
 
-func NewService(namespaces core.NamespaceRepository, environments core.EnvironmentRepository) Service {
-	return &service{namespaces, environments}
+func NewService(
+	namespaces core.NamespaceRepository,
+	namespaceConfigs core.NamespaceConfigRepository,
+	environments core.EnvironmentRepository,
+	deployments core.DeploymentRepository,
+	webhooks webhook.Service) Service {
+	return &service{namespaces, namespaceConfigs, environments, deployments, webhooks}
 }
 
 func (s *service) EnsureDefaultNamespace() error {
@@ -46,6 +59,40 @@ func (s *service) Create(namespaceName string) error {
 	return nil
 }
 
+func (s *service) SaveConfig(config *core.NamespaceConfig) ([]core.Deployment, error) {
+	err := s.ValidateDeployable(config.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.namespaceConfigs.Save(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "error saving namespace config")
+	}
+
+	deployments, err := s.deployments.FindByNamespace(config.Namespace, config.EnvironmentName)
+	if err != nil {
+		return nil, errors.Wrap(err, "error retrieving deployments in namespace")
+	}
+
+	deploymentNames := []string{}
+	for _, deployment := range deployments {
+		deploymentNames = append(deploymentNames, deployment.Name)
+	}
+
+	s.webhooks.Publish(&core.WebhookEvent{
+		Type:            model.WebhookEvent_NamespaceConfigUpdated,
+		Namespace:       config.Namespace,
+		EnvironmentName: config.EnvironmentName,
+		Name:            config.Namespace,
+		Data: map[string]interface{}{
+			"deployments": deploymentNames,
+		},
+	})
+
+	return deployments, nil
+}
+
 func (s *service) ValidateDeployable(namespaceName string) error {
 	_, err := s.namespaces.Get(namespaceName)
 
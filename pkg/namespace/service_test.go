@@ -4,9 +4,12 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func Test_Create(t *testing.T) {
@@ -26,7 +29,7 @@ func Test_Create(t *testing.T) {
 		},
 	}
 
-	svc := &service{namespaces, environments}
+	svc := &service{namespaces: namespaces, environments: environments}
 
 	err := svc.Create("myns")
 
@@ -143,3 +146,92 @@ func Test_ValidateDeployable_GetError(t *testing.T) {
 
 	assert.Equal(t, "test", err.Error())
 }
+
+func Test_SaveConfig(t *testing.T) {
+	config := &core.NamespaceConfig{
+		Namespace:       "myns",
+		EnvironmentName: "dev",
+		Doc: core.NamespaceConfigDoc{
+			Environment: map[string]intstr.IntOrString{"LOG_LEVEL": intstr.FromString("debug")},
+		},
+	}
+	namespaces := &core.FakeNamespaceRepository{
+		GetFn: func(namespaceArg string) (*core.Namespace, error) {
+			return &core.Namespace{Name: namespaceArg}, nil
+		},
+	}
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{
+		SaveFn: func(configArg *core.NamespaceConfig) error {
+			assert.Equal(t, config, configArg)
+			return nil
+		},
+	}
+	deployments := &core.FakeDeploymentRepository{
+		FindByNamespaceFn: func(namespaceArg string, envName string) ([]core.Deployment, error) {
+			assert.Equal(t, "myns", namespaceArg)
+			assert.Equal(t, "dev", envName)
+			return []core.Deployment{
+				{DeploymentReservation: core.DeploymentReservation{Name: "app1"}},
+				{DeploymentReservation: core.DeploymentReservation{Name: "app2"}},
+			}, nil
+		},
+	}
+	webhooks := &webhook.FakeService{
+		PublishFn: func(event *core.WebhookEvent) {
+			assert.Equal(t, model.WebhookEvent_NamespaceConfigUpdated, event.Type)
+			assert.Equal(t, "myns", event.Namespace)
+			assert.Equal(t, "dev", event.EnvironmentName)
+			assert.Equal(t, map[string]interface{}{"deployments": []string{"app1", "app2"}}, event.Data)
+		},
+	}
+
+	svc := &service{namespaces: namespaces, namespaceConfigs: namespaceConfigs, deployments: deployments, webhooks: webhooks}
+
+	result, err := svc.SaveConfig(config)
+
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, 1, namespaceConfigs.SaveCallCount)
+	assert.Equal(t, 1, webhooks.PublishCallCount)
+}
+
+func Test_SaveConfig_NamespaceMissing(t *testing.T) {
+	namespaces := &core.FakeNamespaceRepository{
+		GetFn: func(namespaceArg string) (*core.Namespace, error) {
+			return nil, core.ErrNotFound
+		},
+		ListFn: func() ([]core.Namespace, error) {
+			return []core.Namespace{{Name: "ns1"}}, nil
+		},
+	}
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{}
+
+	svc := &service{namespaces: namespaces, namespaceConfigs: namespaceConfigs}
+
+	result, err := svc.SaveConfig(&core.NamespaceConfig{Namespace: "myns", EnvironmentName: "dev"})
+
+	assert.Nil(t, result)
+	require.IsType(t, &core.ValidationError{}, err, err.Error())
+	assert.Equal(t, `Invalid namespace "myns". Must be one of: ns1`, err.Error())
+	assert.Equal(t, 0, namespaceConfigs.SaveCallCount)
+}
+
+func Test_SaveConfig_SaveError(t *testing.T) {
+	namespaces := &core.FakeNamespaceRepository{
+		GetFn: func(namespaceArg string) (*core.Namespace, error) {
+			return &core.Namespace{Name: namespaceArg}, nil
+		},
+	}
+	namespaceConfigs := &core.FakeNamespaceConfigRepository{
+		SaveFn: func(configArg *core.NamespaceConfig) error {
+			return errors.New("test")
+		},
+	}
+
+	svc := &service{namespaces: namespaces, namespaceConfigs: namespaceConfigs}
+
+	result, err := svc.SaveConfig(&core.NamespaceConfig{Namespace: "myns", EnvironmentName: "dev"})
+
+	assert.Nil(t, result)
+	assert.Equal(t, "error saving namespace config: test", err.Error())
+}
@@ -2,9 +2,10 @@ package postgres
 
 import (
 	"database/sql"
+	"fmt"
 
 	"github.com/google/uuid"
-	"github.com/pkg/errors"
+	"github.com/lib/pq"
 	"github.com/riser-platform/riser-server/pkg/core"
 )
 
@@ -24,8 +25,8 @@ func (r *deploymentRepository) Create(deployment *core.DeploymentRecord) error {
 	return err
 }
 
-func (r *deploymentRepository) Delete(name *core.NamespacedName, envName string) error {
-	_, err := r.db.Exec(`
+func (r *deploymentRepository) Delete(name *core.NamespacedName, envName string, expectedRiserRevision int64, overrideLock bool) error {
+	result, err := r.db.Exec(`
 	UPDATE deployment SET deleted_at=now()
 	FROM deployment_reservation
 	WHERE
@@ -33,9 +34,14 @@ func (r *deploymentRepository) Delete(name *core.NamespacedName, envName string)
 	 AND deployment_reservation.name = $1
 	 AND deployment_reservation.namespace = $2
 	 AND deployment.environment_name = $3
-	`, name.Name, name.Namespace, envName)
+	 AND ($4 = 0 OR deployment.riser_revision = $4)
+	 AND `+lockNotActiveCondition("$5"),
+		name.Name, name.Namespace, envName, expectedRiserRevision, overrideLock)
+	if err != nil {
+		return noRowsErrorHandler(err)
+	}
 
-	return noRowsErrorHandler(err)
+	return r.handleConditionalUpdateResult(result)
 }
 
 // GetByName returns a deployment by its name whether or not it's been deleted.
@@ -106,8 +112,7 @@ func (r *deploymentRepository) GetByReservation(reservationId uuid.UUID, envName
 
 // FindByApp returns all active deployments in all environments by a given app ID
 func (r *deploymentRepository) FindByApp(appId uuid.UUID) ([]core.Deployment, error) {
-	deployments := []core.Deployment{}
-	rows, err := r.db.Query(`
+	return r.queryDeployments(`
 	SELECT
 		deployment_reservation.id,
 		deployment_reservation.app_id,
@@ -124,7 +129,59 @@ func (r *deploymentRepository) FindByApp(appId uuid.UUID) ([]core.Deployment, er
 	WHERE deployment_reservation.app_id = $1 AND deployment.deleted_at IS NULL
 	ORDER BY deployment.environment_name, deployment_reservation.name
 	`, appId)
+}
+
+func (r *deploymentRepository) FindByDomains(envName string, domains []string) ([]core.Deployment, error) {
+	return r.queryDeployments(`
+	SELECT
+		deployment_reservation.id,
+		deployment_reservation.app_id,
+		deployment_reservation.name,
+		deployment_reservation.namespace,
+		deployment.id,
+		deployment.deleted_at,
+		deployment.deployment_reservation_id,
+		deployment.environment_name,
+		deployment.riser_revision,
+		deployment.doc
+	FROM deployment
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	WHERE deployment.environment_name = $1
+		AND deployment.deleted_at IS NULL
+		AND EXISTS (
+			SELECT 1 FROM jsonb_array_elements(
+				CASE WHEN jsonb_typeof(deployment.doc->'domains') = 'array' THEN deployment.doc->'domains' ELSE '[]'::jsonb END) AS domain
+			WHERE domain->>'name' = ANY($2)
+		)
+	ORDER BY deployment_reservation.namespace, deployment_reservation.name
+	`, envName, pq.Array(domains))
+}
 
+func (r *deploymentRepository) FindByNamespace(namespace string, envName string) ([]core.Deployment, error) {
+	return r.queryDeployments(`
+	SELECT
+		deployment_reservation.id,
+		deployment_reservation.app_id,
+		deployment_reservation.name,
+		deployment_reservation.namespace,
+		deployment.id,
+		deployment.deleted_at,
+		deployment.deployment_reservation_id,
+		deployment.environment_name,
+		deployment.riser_revision,
+		deployment.doc
+	FROM deployment
+	INNER JOIN deployment_reservation ON deployment.deployment_reservation_id = deployment_reservation.id
+	WHERE deployment_reservation.namespace = $1
+		AND deployment.environment_name = $2
+		AND deployment.deleted_at IS NULL
+	ORDER BY deployment_reservation.name
+	`, namespace, envName)
+}
+
+func (r *deploymentRepository) queryDeployments(query string, args ...interface{}) ([]core.Deployment, error) {
+	deployments := []core.Deployment{}
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -152,29 +209,47 @@ func (r *deploymentRepository) FindByApp(appId uuid.UUID) ([]core.Deployment, er
 	return deployments, nil
 }
 
-// IncrementeRevision increments the revision of a deployment. If the deployment was previously soft deleted, it will mark
-// the deployment as no longer being deleted
-func (r *deploymentRepository) IncrementRevision(name *core.NamespacedName, envName string) (revision int64, err error) {
-	err = r.db.QueryRow(`
-	UPDATE deployment SET riser_revision = riser_revision + 1, deleted_at = NULL
+// IncrementRevision increments the revision of a deployment along with the fields that are set with each revision. If the deployment was
+// previously soft deleted, it will mark the deployment as no longer being deleted
+func (r *deploymentRepository) IncrementRevision(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, revision *core.DeploymentRevisionDoc) error {
+	result, err := r.db.Exec(`
+	UPDATE deployment
+	SET riser_revision = riser_revision + 1,
+		deleted_at = NULL,
+		doc = doc || $7::jsonb || jsonb_build_object('trafficVersion', $5::bigint + 1)
 	FROM deployment_reservation
 	WHERE
 	deployment.deployment_reservation_id = deployment_reservation.id
 	AND deployment_reservation.name = $1
 	AND deployment_reservation.namespace = $2
 	AND environment_name = $3
-	RETURNING riser_revision
-	`, name.Name, name.Namespace, envName).Scan(&revision)
+	AND riser_revision = $4
+	AND COALESCE((doc->>'trafficVersion')::bigint, 0) = $5
+	AND `+lockNotActiveCondition("$6"),
+		name.Name, name.Namespace, envName, riserRevision, trafficVersion, overrideLock, revision)
 	if err != nil {
-		return 0, err
+		return err
 	}
 
-	return revision, nil
+	return r.handleConditionalUpdateResult(result)
 }
 
-func (r *deploymentRepository) RollbackRevision(name *core.NamespacedName, envName string, failedRevision int64) (revision int64, err error) {
+// lockNotActiveCondition returns a condition that matches deployments without an active lock so that the lock check is part of the
+// same statement as the change. The overrideLock parameter matches all deployments when true. Locks on a deleted deployment are ignored.
+// See core.Deployment.CheckLock.
+func lockNotActiveCondition(overrideLockParam string) string {
+	return fmt.Sprintf(`(%s::boolean
+		OR deployment.deleted_at IS NOT NULL
+		OR deployment.doc->'lock' IS NULL
+		OR (deployment.doc->'lock'->>'expiresAt' IS NOT NULL AND (deployment.doc->'lock'->>'expiresAt')::timestamptz <= now()))`, overrideLockParam)
+}
+
+func (r *deploymentRepository) RollbackRevision(name *core.NamespacedName, envName string, failedRevision int64, previous *core.DeploymentRecord) (revision int64, err error) {
 	err = r.db.QueryRow(`
-	UPDATE deployment SET riser_revision = riser_revision - 1
+	UPDATE deployment
+	SET riser_revision = riser_revision - 1,
+		deleted_at = $5,
+		doc = doc || $6::jsonb || jsonb_build_object('trafficVersion', $7::bigint)
 	FROM deployment_reservation
 	WHERE
 	deployment.deployment_reservation_id = deployment_reservation.id
@@ -183,7 +258,7 @@ func (r *deploymentRepository) RollbackRevision(name *core.NamespacedName, envNa
 	AND environment_name = $3
 	AND riser_revision = $4
 	RETURNING riser_revision
-	`, name.Name, name.Namespace, envName, failedRevision).Scan(&revision)
+	`, name.Name, name.Namespace, envName, failedRevision, previous.DeletedAt, previous.Doc.RevisionDoc(), previous.Doc.TrafficVersion).Scan(&revision)
 	if err != nil {
 		return 0, err
 	}
@@ -214,10 +289,10 @@ func (r *deploymentRepository) UpdateStatus(name *core.NamespacedName, envName s
 	if err != nil {
 		return err
 	}
-	return r.handleUpdateStatusResult(result)
+	return r.handleConditionalUpdateResult(result)
 }
 
-func (deploymentRepository) handleUpdateStatusResult(r sql.Result) error {
+func (deploymentRepository) handleConditionalUpdateResult(r sql.Result) error {
 	rows, err := r.RowsAffected()
 	if err != nil {
 		return err
@@ -234,28 +309,70 @@ func (deploymentRepository) handleUpdateStatusResult(r sql.Result) error {
 	return nil
 }
 
-func (r *deploymentRepository) UpdateTraffic(name *core.NamespacedName, envName string, riserRevision int64, traffic core.TrafficConfig) error {
-	result, err := r.db.Exec(`
+func (r *deploymentRepository) UpdateLock(name *core.NamespacedName, envName string, lock *core.DeploymentLock) error {
+	var result sql.Result
+	var err error
+	if lock == nil {
+		result, err = r.db.Exec(`
 		UPDATE deployment
-		SET doc = jsonb_set(doc, '{traffic}', $5)
+		SET doc = doc - 'lock'
 		FROM deployment_reservation
 		WHERE
 		deployment.deployment_reservation_id = deployment_reservation.id
 		AND deployment_reservation.name = $1
 		AND deployment_reservation.namespace = $2
 		AND deployment.environment_name = $3
-		AND riser_revision = $4
 		AND deleted_at IS NULL
-	`, name.Name, name.Namespace, envName, riserRevision, traffic)
+	`, name.Name, name.Namespace, envName)
+	} else {
+		result, err = r.db.Exec(`
+		UPDATE deployment
+		SET doc = jsonb_set(doc, '{lock}', $4)
+		FROM deployment_reservation
+		WHERE
+		deployment.deployment_reservation_id = deployment_reservation.id
+		AND deployment_reservation.name = $1
+		AND deployment_reservation.namespace = $2
+		AND deployment.environment_name = $3
+		AND deleted_at IS NULL
+	`, name.Name, name.Namespace, envName, lock)
+	}
 
 	if err != nil {
 		return err
 	}
 
-	rows, _ := result.RowsAffected()
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
 	if rows == 0 {
-		return errors.New("Deployment not found or has been updated by another process")
+		return core.ErrNotFound
 	}
 
 	return nil
 }
+
+func (r *deploymentRepository) UpdateTraffic(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, traffic core.TrafficConfig) error {
+	result, err := r.db.Exec(`
+		UPDATE deployment
+		SET doc = jsonb_set(jsonb_set(doc, '{traffic}', $7), '{trafficVersion}', to_jsonb($5::bigint + 1))
+		FROM deployment_reservation
+		WHERE
+		deployment.deployment_reservation_id = deployment_reservation.id
+		AND deployment_reservation.name = $1
+		AND deployment_reservation.namespace = $2
+		AND deployment.environment_name = $3
+		AND riser_revision = $4
+		AND COALESCE((doc->>'trafficVersion')::bigint, 0) = $5
+		AND deleted_at IS NULL
+		AND `+lockNotActiveCondition("$6"),
+		name.Name, name.Namespace, envName, riserRevision, trafficVersion, overrideLock, traffic)
+
+	if err != nil {
+		return err
+	}
+
+	return r.handleConditionalUpdateResult(result)
+}
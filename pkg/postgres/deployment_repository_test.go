@@ -8,7 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func Test_DeploymentRepository_handleUpdateStatusResult(t *testing.T) {
+func Test_DeploymentRepository_handleConditionalUpdateResult(t *testing.T) {
 	failedErr := errors.New("failed")
 	tt := []struct {
 		f        *fakeSqlResult
@@ -42,7 +42,7 @@ func Test_DeploymentRepository_handleUpdateStatusResult(t *testing.T) {
 
 	repository := deploymentRepository{}
 	for idx, test := range tt {
-		result := repository.handleUpdateStatusResult(test.f)
+		result := repository.handleConditionalUpdateResult(test.f)
 		assert.Equal(t, test.expected, result, "test %d", idx)
 	}
 }
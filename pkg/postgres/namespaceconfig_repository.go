@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type namespaceConfigRepository struct {
+	db *sql.DB
+}
+
+func NewNamespaceConfigRepository(db *sql.DB) core.NamespaceConfigRepository {
+	return &namespaceConfigRepository{db}
+}
+
+func (r *namespaceConfigRepository) Get(namespace string, envName string) (*core.NamespaceConfig, error) {
+	config := &core.NamespaceConfig{}
+	err := r.db.QueryRow("SELECT namespace, environment_name, doc FROM namespace_config WHERE namespace = $1 AND environment_name = $2",
+		namespace, envName).Scan(&config.Namespace, &config.EnvironmentName, &config.Doc)
+	return config, noRowsErrorHandler(err)
+}
+
+func (r *namespaceConfigRepository) ListByNamespace(namespace string) ([]core.NamespaceConfig, error) {
+	configs := []core.NamespaceConfig{}
+	rows, err := r.db.Query("SELECT namespace, environment_name, doc FROM namespace_config WHERE namespace = $1 ORDER BY environment_name", namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		config := core.NamespaceConfig{}
+		err := rows.Scan(&config.Namespace, &config.EnvironmentName, &config.Doc)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+func (r *namespaceConfigRepository) Save(config *core.NamespaceConfig) error {
+	_, err := r.db.Exec(`
+		INSERT INTO namespace_config(namespace, environment_name, doc) VALUES($1,$2,$3)
+		ON CONFLICT (namespace, environment_name) DO
+		UPDATE SET
+			doc = $3;`, config.Namespace, config.EnvironmentName, &config.Doc)
+
+	return err
+}
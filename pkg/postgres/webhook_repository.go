@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type webhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) core.WebhookRepository {
+	return &webhookRepository{db}
+}
+
+func (r *webhookRepository) Create(webhook *core.Webhook) error {
+	_, err := r.db.Exec("INSERT INTO webhook (id, created_at, doc) VALUES ($1, $2, $3)", webhook.Id, webhook.Created, &webhook.Doc)
+	return err
+}
+
+func (r *webhookRepository) Get(id uuid.UUID) (*core.Webhook, error) {
+	webhook := &core.Webhook{}
+	err := r.db.QueryRow("SELECT id, created_at, doc FROM webhook WHERE id = $1", id).Scan(&webhook.Id, &webhook.Created, &webhook.Doc)
+	return webhook, noRowsErrorHandler(err)
+}
+
+func (r *webhookRepository) List() ([]core.Webhook, error) {
+	webhooks := []core.Webhook{}
+	rows, err := r.db.Query("SELECT id, created_at, doc FROM webhook ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		webhook := core.Webhook{}
+		err := rows.Scan(&webhook.Id, &webhook.Created, &webhook.Doc)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Delete(id uuid.UUID) error {
+	result, err := r.db.Exec("DELETE FROM webhook WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if !resultHasRows(result) {
+		return core.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type webhookDeliveryRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookDeliveryRepository(db *sql.DB) core.WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{db}
+}
+
+func (r *webhookDeliveryRepository) Create(delivery *core.WebhookDelivery) error {
+	_, err := r.db.Exec(`
+	INSERT INTO webhook_delivery (id, webhook_id, event_type, status, attempts, next_attempt_at, created_at, doc)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8)`,
+		delivery.Id, delivery.WebhookId, delivery.EventType, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.Created, &delivery.Doc)
+	return err
+}
+
+func (r *webhookDeliveryRepository) ClaimDue(now time.Time, leaseUntil time.Time, limit int) ([]core.WebhookDelivery, error) {
+	return r.query(`
+	UPDATE webhook_delivery SET next_attempt_at = $3
+	WHERE id IN (
+		SELECT id FROM webhook_delivery
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at
+		LIMIT $4
+		-- Allows multiple server instances to poll concurrently without delivering the same event twice
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING id, webhook_id, event_type, status, attempts, next_attempt_at, created_at, doc
+	`, model.WebhookDeliveryStatusPending, now, leaseUntil, limit)
+}
+
+func (r *webhookDeliveryRepository) UpdateAttempt(delivery *core.WebhookDelivery) error {
+	_, err := r.db.Exec(`
+	UPDATE webhook_delivery SET status = $2, attempts = $3, next_attempt_at = $4, doc = $5
+	WHERE id = $1
+	`, delivery.Id, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, &delivery.Doc)
+	return err
+}
+
+func (r *webhookDeliveryRepository) ListByWebhook(webhookId uuid.UUID, limit int) ([]core.WebhookDelivery, error) {
+	return r.query(`
+	SELECT id, webhook_id, event_type, status, attempts, next_attempt_at, created_at, doc
+	FROM webhook_delivery
+	WHERE webhook_id = $1
+	ORDER BY created_at DESC
+	LIMIT $2
+	`, webhookId, limit)
+}
+
+func (r *webhookDeliveryRepository) query(query string, args ...interface{}) ([]core.WebhookDelivery, error) {
+	deliveries := []core.WebhookDelivery{}
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		delivery := core.WebhookDelivery{}
+		err := rows.Scan(
+			&delivery.Id,
+			&delivery.WebhookId,
+			&delivery.EventType,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.Created,
+			&delivery.Doc)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
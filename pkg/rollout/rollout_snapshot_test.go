@@ -5,9 +5,11 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/snapshot"
 	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -29,6 +31,7 @@ func Test_update_snapshot_rollout(t *testing.T) {
 					AppId: appId,
 				},
 				DeploymentRecord: core.DeploymentRecord{
+					RiserRevision: 1,
 					Doc: core.DeploymentDoc{
 						Status: &core.DeploymentStatus{
 							Revisions: []core.DeploymentRevisionStatus{
@@ -41,6 +44,13 @@ func Test_update_snapshot_rollout(t *testing.T) {
 				},
 			}, nil
 		},
+		UpdateTrafficFn: func(nameArg *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, trafficArg core.TrafficConfig) error {
+			assert.Equal(t, name, nameArg)
+			assert.Equal(t, "dev", envName)
+			assert.EqualValues(t, 1, riserRevision)
+			assert.Len(t, trafficArg, 1)
+			return nil
+		},
 	}
 
 	apps := &core.FakeAppRepository{
@@ -60,7 +70,14 @@ func Test_update_snapshot_rollout(t *testing.T) {
 		},
 	}
 
-	svc := service{apps, deployments}
+	webhookService := &webhook.FakeService{
+		PublishFn: func(event *core.WebhookEvent) {
+			assert.Equal(t, model.WebhookEvent_RolloutUpdated, event.Type)
+			assert.Equal(t, traffic, event.Data.(map[string]interface{})["traffic"])
+		},
+	}
+
+	svc := service{apps, deployments, webhookService}
 
 	snapshotPath, err := filepath.Abs("testdata/snapshots/rollout")
 	require.NoError(t, err)
@@ -68,9 +85,10 @@ func Test_update_snapshot_rollout(t *testing.T) {
 	committer, err := snapshot.CreateCommitter(snapshotPath)
 	require.NoError(t, err)
 
-	err = svc.UpdateTraffic(name, "dev", traffic, committer)
+	err = svc.UpdateTraffic(name, "dev", traffic, 1, false, committer)
 
 	assert.NoError(t, err)
+	assert.Equal(t, 1, deployments.UpdateTrafficCallCount)
 	if !snapshot.ShouldUpdate() {
 		dryRunCommitter := committer.(*state.DryRunCommitter)
 		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
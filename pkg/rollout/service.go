@@ -2,6 +2,7 @@ package rollout
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -9,22 +10,25 @@ import (
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/state"
 	"github.com/riser-platform/riser-server/pkg/state/resources"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 )
 
 type Service interface {
-	UpdateTraffic(name *core.NamespacedName, envName string, rollout core.TrafficConfig, committer state.Committer) error
+	// UpdateTraffic updates the traffic rules. When expectedRiserRevision is greater than zero the current revision must match.
+	UpdateTraffic(name *core.NamespacedName, envName string, rollout core.TrafficConfig, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error
 }
 
 type service struct {
 	apps        core.AppRepository
 	deployments core.DeploymentRepository
+	webhooks    webhook.Service
 }
 
-func NewService(apps core.AppRepository, deployments core.DeploymentRepository) Service {
-	return &service{apps, deployments}
+func NewService(apps core.AppRepository, deployments core.DeploymentRepository, webhooks webhook.Service) Service {
+	return &service{apps, deployments, webhooks}
 }
 
-func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traffic core.TrafficConfig, committer state.Committer) error {
+func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traffic core.TrafficConfig, expectedRiserRevision int64, overrideLock bool, committer state.Committer) error {
 	deployment, err := s.deployments.GetByName(name, envName)
 	if err != nil {
 		if err == core.ErrNotFound {
@@ -33,6 +37,23 @@ func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traff
 		return errors.Wrap(err, "error getting deployment")
 	}
 
+	if deployment.DeletedAt != nil {
+		return &core.ValidationError{Message: fmt.Sprintf("the deployment %q has been deleted from environment %q", name, envName)}
+	}
+
+	if deployment.Doc.Workload == model.AppWorkload_Deployment {
+		return &core.ValidationError{Message: fmt.Sprintf("the deployment %q in environment %q uses the deployment workload which does not support rollouts", name, envName)}
+	}
+
+	if expectedRiserRevision > 0 && deployment.RiserRevision != expectedRiserRevision {
+		return core.NewRevisionConflictError(deployment.RiserRevision)
+	}
+
+	err = deployment.CheckLock(time.Now(), overrideLock)
+	if err != nil {
+		return err
+	}
+
 	app, err := s.apps.Get(deployment.AppId)
 	if err != nil {
 		return errors.Wrap(err, "error getting app")
@@ -62,7 +83,50 @@ func (s *service) UpdateTraffic(name *core.NamespacedName, envName string, traff
 		return err
 	}
 
-	return committer.Commit(fmt.Sprintf("Updating resources for %q in environment %q", name, ctx.DeploymentConfig.EnvironmentName), resourceFiles)
+	// Conditional on the revision and traffic version that we validated against so that a concurrent deployment or rollout is not overwritten.
+	// A rollout does not change the revision, so the traffic version is what makes two rollouts of the same revision conflict.
+	err = s.deployments.UpdateTraffic(name, envName, deployment.RiserRevision, deployment.Doc.TrafficVersion, overrideLock, traffic)
+	if err != nil {
+		if err == core.ErrConflictNewerVersion {
+			return s.revisionConflict(name, envName, overrideLock)
+		}
+		return errors.Wrap(err, "error updating traffic")
+	}
+
+	err = committer.Commit(fmt.Sprintf("Updating resources for %q in environment %q", name, ctx.DeploymentConfig.EnvironmentName), resourceFiles)
+	if err != nil {
+		// Restore the traffic so that the next deployment or rollout computes traffic from what's in the state repo. This is conditional on
+		// the traffic version from our update so that a concurrent change is not overwritten. The lock only applies to new changes.
+		// TODO: Log revert error but don't return since we want the original commit error to flow to caller
+		_ = s.deployments.UpdateTraffic(name, envName, deployment.RiserRevision, deployment.Doc.TrafficVersion+1, true, deployment.Doc.Traffic)
+		return err
+	}
+
+	s.webhooks.Publish(&core.WebhookEvent{
+		Type:            model.WebhookEvent_RolloutUpdated,
+		Namespace:       name.Namespace,
+		EnvironmentName: envName,
+		Name:            name.Name,
+		RiserRevision:   deployment.RiserRevision,
+		Data: map[string]interface{}{
+			"traffic": traffic,
+		},
+	})
+
+	return nil
+}
+
+// revisionConflict returns the reason that the traffic update was rejected: either a lock that was acquired after the deployment was
+// read or a RevisionConflictError with the current revision
+func (s *service) revisionConflict(name *core.NamespacedName, envName string, overrideLock bool) error {
+	deployment, err := s.deployments.GetByName(name, envName)
+	if err != nil {
+		return errors.Wrap(err, "error getting deployment")
+	}
+	if err = deployment.CheckLock(time.Now(), overrideLock); err != nil {
+		return err
+	}
+	return core.NewRevisionConflictError(deployment.RiserRevision)
 }
 
 func validateTrafficRules(traffic core.TrafficConfig, deployment *core.Deployment) error {
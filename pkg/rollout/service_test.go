@@ -3,11 +3,17 @@ package rollout
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/git"
+	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // See snapshot test for happy path
@@ -21,7 +27,7 @@ func Test_UpdateTraffic_ReturnsGetDeploymentError(t *testing.T) {
 
 	svc := service{deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, 0, false, nil)
 
 	assert.Equal(t, "error getting deployment: test", result.Error())
 }
@@ -35,7 +41,7 @@ func Test_UpdateTraffic_WhenDeploymentDoesNotExist(t *testing.T) {
 
 	svc := service{deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, 0, false, nil)
 
 	assert.IsType(t, &core.ValidationError{}, result)
 	vErr := result.(*core.ValidationError)
@@ -81,9 +87,9 @@ func Test_UpdateTraffic_ValidatesRevisionStatus(t *testing.T) {
 		},
 	}
 
-	svc := service{apps, deployments}
+	svc := service{apps: apps, deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, 0, false, nil)
 
 	assert.Equal(t, `revision "2" either does not exist or has not reported its status yet`, result.Error())
 }
@@ -112,9 +118,216 @@ func Test_UpdateTraffic_ValidatesRevisionStatus_NoStatus(t *testing.T) {
 		},
 	}
 
-	svc := service{apps, deployments}
+	svc := service{apps: apps, deployments: deployments}
 
-	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, nil)
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, 0, false, nil)
 
 	assert.Equal(t, `revision "1" either does not exist or has not reported its status yet`, result.Error())
 }
+
+func Test_UpdateTraffic_WhenDeploymentDeleted(t *testing.T) {
+	deletedAt := time.Now()
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentRecord: core.DeploymentRecord{DeletedAt: &deletedAt}}, nil
+		},
+	}
+
+	svc := service{deployments: deployments}
+
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, 0, false, nil)
+
+	assert.IsType(t, &core.ValidationError{}, result)
+	assert.Equal(t, `the deployment "myapp.myns" has been deleted from environment "dev"`, result.Error())
+}
+
+func Test_UpdateTraffic_WhenDeploymentWorkload(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentRecord: core.DeploymentRecord{Doc: core.DeploymentDoc{Workload: model.AppWorkload_Deployment}}}, nil
+		},
+	}
+
+	svc := service{deployments: deployments}
+
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, 0, false, nil)
+
+	assert.IsType(t, &core.ValidationError{}, result)
+	assert.Equal(t, `the deployment "myapp.myns" in environment "dev" uses the deployment workload which does not support rollouts`, result.Error())
+}
+
+func Test_UpdateTraffic_WhenLocked(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{
+				DeploymentReservation: core.DeploymentReservation{Name: "myapp", Namespace: "myns"},
+				DeploymentRecord: core.DeploymentRecord{
+					EnvironmentName: "dev",
+					Doc: core.DeploymentDoc{
+						Lock: &core.DeploymentLock{Holder: "alice", Reason: "incident"},
+					},
+				},
+			}, nil
+		},
+	}
+
+	svc := service{deployments: deployments}
+
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, 0, false, nil)
+
+	assert.IsType(t, &core.ValidationError{}, result)
+	assert.Equal(t, `deployment "myapp.myns" is locked in environment "dev" by "alice": incident. Override the lock to proceed.`, result.Error())
+	assert.Equal(t, 0, deployments.UpdateTrafficCallCount)
+}
+
+func Test_UpdateTraffic_WhenExpectedRevisionDoesNotMatch(t *testing.T) {
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentRecord: core.DeploymentRecord{RiserRevision: 3}}, nil
+		},
+	}
+
+	svc := service{deployments: deployments}
+
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{}, 2, false, nil)
+
+	require.IsType(t, &core.RevisionConflictError{}, result)
+	assert.EqualValues(t, 3, result.(*core.RevisionConflictError).CurrentRiserRevision)
+	assert.Equal(t, 0, deployments.UpdateTrafficCallCount)
+}
+
+func Test_UpdateTraffic_WhenConcurrentUpdate(t *testing.T) {
+	getCallCount := 0
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			getCallCount++
+			return &core.Deployment{
+				DeploymentRecord: core.DeploymentRecord{
+					RiserRevision: int64(getCallCount),
+					Doc: core.DeploymentDoc{
+						Status: &core.DeploymentStatus{
+							Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 1}},
+						},
+					},
+				},
+			}, nil
+		},
+		UpdateTrafficFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, traffic core.TrafficConfig) error {
+			assert.EqualValues(t, 1, riserRevision)
+			return core.ErrConflictNewerVersion
+		},
+	}
+
+	apps := &core.FakeAppRepository{
+		GetFn: func(id uuid.UUID) (*core.App, error) {
+			return &core.App{Id: id, Name: "myapp"}, nil
+		},
+	}
+
+	traffic := core.TrafficConfig{
+		core.TrafficConfigRule{
+			RiserRevision: 1,
+			Percent:       100,
+		},
+	}
+
+	committer := state.NewDryRunCommitter()
+
+	svc := service{apps: apps, deployments: deployments}
+
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", traffic, 1, false, committer)
+
+	require.IsType(t, &core.RevisionConflictError{}, result)
+	assert.EqualValues(t, 2, result.(*core.RevisionConflictError).CurrentRiserRevision)
+	assert.Empty(t, committer.Commits)
+}
+
+func Test_UpdateTraffic_ConcurrentRolloutsOfSameRevision(t *testing.T) {
+	current := core.DeploymentRecord{
+		RiserRevision: 2,
+		Doc: core.DeploymentDoc{
+			Status: &core.DeploymentStatus{
+				Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 1}, {RiserRevision: 2}},
+			},
+			TrafficVersion: 4,
+		},
+	}
+	// Both rollouts read the deployment before either one updates the traffic
+	read := current
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentRecord: read}, nil
+		},
+		UpdateTrafficFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, traffic core.TrafficConfig) error {
+			if riserRevision != current.RiserRevision || trafficVersion != current.Doc.TrafficVersion {
+				return core.ErrConflictNewerVersion
+			}
+			current.Doc.TrafficVersion++
+			current.Doc.Traffic = traffic
+			return nil
+		},
+	}
+	apps := &core.FakeAppRepository{
+		GetFn: func(id uuid.UUID) (*core.App, error) {
+			return &core.App{Id: id, Name: "myapp"}, nil
+		},
+	}
+	committer := state.NewDryRunCommitter()
+	svc := service{apps: apps, deployments: deployments, webhooks: &webhook.FakeService{}}
+	name := core.NewNamespacedName("myapp", "myns")
+
+	first := svc.UpdateTraffic(name, "dev", core.TrafficConfig{{RiserRevision: 2, Percent: 100}}, 2, false, committer)
+	second := svc.UpdateTraffic(name, "dev", core.TrafficConfig{{RiserRevision: 1, Percent: 100}}, 2, false, committer)
+
+	assert.NoError(t, first)
+	require.IsType(t, &core.RevisionConflictError{}, second)
+	assert.EqualValues(t, 2, second.(*core.RevisionConflictError).CurrentRiserRevision)
+	assert.Len(t, committer.Commits, 1)
+	assert.EqualValues(t, 5, current.Doc.TrafficVersion)
+	assert.Equal(t, core.TrafficConfig{{RiserRevision: 2, Percent: 100}}, core.TrafficConfig(current.Doc.Traffic))
+}
+
+func Test_UpdateTraffic_RevertsTrafficWhenCommitFails(t *testing.T) {
+	previousTraffic := core.TrafficConfig{{RiserRevision: 1, Percent: 100}}
+	current := core.DeploymentRecord{
+		RiserRevision: 2,
+		Doc: core.DeploymentDoc{
+			Status: &core.DeploymentStatus{
+				Revisions: []core.DeploymentRevisionStatus{{RiserRevision: 1}, {RiserRevision: 2}},
+			},
+			Traffic:        previousTraffic,
+			TrafficVersion: 4,
+		},
+	}
+	deployments := &core.FakeDeploymentRepository{
+		GetByNameFn: func(*core.NamespacedName, string) (*core.Deployment, error) {
+			return &core.Deployment{DeploymentRecord: current}, nil
+		},
+		UpdateTrafficFn: func(name *core.NamespacedName, envName string, riserRevision int64, trafficVersion int64, overrideLock bool, traffic core.TrafficConfig) error {
+			if riserRevision != current.RiserRevision || trafficVersion != current.Doc.TrafficVersion {
+				return core.ErrConflictNewerVersion
+			}
+			current.Doc.TrafficVersion++
+			current.Doc.Traffic = traffic
+			return nil
+		},
+	}
+	apps := &core.FakeAppRepository{
+		GetFn: func(id uuid.UUID) (*core.App, error) {
+			return &core.App{Id: id, Name: "myapp"}, nil
+		},
+	}
+	committer := state.NewGitCommitter(&git.FakeRepo{
+		ResetHardRemoteFn: func() error {
+			return errors.New("broke")
+		},
+	})
+	svc := service{apps: apps, deployments: deployments}
+
+	result := svc.UpdateTraffic(core.NewNamespacedName("myapp", "myns"), "dev", core.TrafficConfig{{RiserRevision: 2, Percent: 100}}, 2, false, committer)
+
+	assert.Equal(t, "error resetting repo: broke", result.Error())
+	assert.Equal(t, 2, deployments.UpdateTrafficCallCount)
+	assert.Equal(t, previousTraffic, core.TrafficConfig(current.Doc.Traffic))
+	assert.EqualValues(t, 6, current.Doc.TrafficVersion)
+}
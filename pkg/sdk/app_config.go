@@ -7,9 +7,11 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
 )
 
-const appConfigTemplate = `name: {{.AppName}}
+const appConfigTemplate = `apiVersion: {{.ApiVersion}}
+name: {{.AppName}}
 namespace: {{.AppNamespace}}
 id: {{.AppId}}
 # TODO: Update to use your docker image registry/repo (without tag) here
@@ -20,6 +22,7 @@ expose:
 `
 
 type AppConfigTemplateData struct {
+	ApiVersion   string
 	AppName      string
 	AppNamespace string
 	AppId        string
@@ -33,6 +36,7 @@ func DefaultAppConfig(writer io.Writer, appId uuid.UUID, appName, appNamespace s
 	}
 
 	err = parsedTemplate.Execute(writer, AppConfigTemplateData{
+		ApiVersion:   model.AppConfigApiVersion,
 		AppName:      appName,
 		AppNamespace: appNamespace,
 		AppId:        appId.String(),
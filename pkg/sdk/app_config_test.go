@@ -8,7 +8,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-const expectedAppConfig = `name: myapp
+const expectedAppConfig = `apiVersion: riser.dev/v1
+name: myapp
 namespace: myns
 id: e29bf621-4da7-4df1-8c04-6609b9eb2447
 # TODO: Update to use your docker image registry/repo (without tag) here
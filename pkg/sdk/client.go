@@ -30,9 +30,11 @@ type Client struct {
 	Deployments  DeploymentsClient
 	Namespaces   NamespacesClient
 	Rollouts     RolloutsClient
+	Schemas      SchemasClient
 	Secrets      SecretsClient
 	Environments EnvironmentsClient
 	Validate     ValidateClient
+	Webhooks     WebhooksClient
 }
 
 func NewClient(baseURI string, apikey string) (*Client, error) {
@@ -48,9 +50,11 @@ func NewClient(baseURI string, apikey string) (*Client, error) {
 	client.Deployments = &deploymentsClient{client}
 	client.Namespaces = &namespacesClient{client}
 	client.Rollouts = &rolloutsClient{client}
+	client.Schemas = &schemasClient{client}
 	client.Secrets = &secretsClient{client}
 	client.Environments = &environmentsClient{client}
 	client.Validate = &validateClient{client}
+	client.Webhooks = &webhooksClient{client}
 
 	return client, nil
 }
@@ -102,7 +106,8 @@ func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
 		return response, err
 	}
 
-	if v != nil {
+	// Older servers may respond with no content where newer servers return a body
+	if v != nil && response.StatusCode != http.StatusNoContent {
 		// TODO: Try to use same error handling logic from validateResponse and return a ClientError instead
 		responseBytes, err := ioutil.ReadAll(response.Body)
 		if err != nil {
@@ -137,6 +142,15 @@ func validateResponse(response *http.Response) error {
 		clientErr.Message = fmt.Sprintf("Unable to parse response: %s", responseBytes)
 	}
 
+	if response.StatusCode == http.StatusConflict {
+		conflict := &struct {
+			CurrentRiserRevision *int64 `json:"currentRiserRevision"`
+		}{}
+		if json.Unmarshal(responseBytes, conflict) == nil && conflict.CurrentRiserRevision != nil {
+			return &RevisionConflictError{ClientError: *clientErr, CurrentRiserRevision: *conflict.CurrentRiserRevision}
+		}
+	}
+
 	return clientErr
 }
 
@@ -25,3 +25,13 @@ func (e *ClientError) Error() string {
 		return builder.String()
 	}
 }
+
+// RevisionConflictError is returned when an expected riser revision does not match the deployment's current revision.
+type RevisionConflictError struct {
+	ClientError
+	CurrentRiserRevision int64 `json:"currentRiserRevision"`
+}
+
+func (e *RevisionConflictError) Error() string {
+	return e.ClientError.Error()
+}
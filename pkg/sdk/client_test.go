@@ -85,6 +85,23 @@ func Test_Do(t *testing.T) {
 	assert.Equal(t, http.StatusOK, response.StatusCode)
 }
 
+func Test_Do_NoContent(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	request, _ := client.NewGetRequest("/")
+	responseBody := testResponse{}
+	response, err := client.Do(request, &responseBody)
+
+	assert.NoError(t, err)
+	assert.Empty(t, responseBody.Field)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+}
+
 func Test_Do_ErrorMessage(t *testing.T) {
 	setup()
 	defer teardown()
@@ -134,6 +151,42 @@ func Test_Do_ClientError(t *testing.T) {
 	assert.Equal(t, "fieldVal", clientError.ValidationErrors["field"])
 }
 
+func Test_Do_RevisionConflictError(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"message": "conflict", "currentRiserRevision": 3}`)
+	})
+
+	request, _ := client.NewGetRequest("/")
+	_, err := client.Do(request, nil)
+
+	assert.IsType(t, &RevisionConflictError{}, err)
+	conflictError := err.(*RevisionConflictError)
+	assert.Equal(t, http.StatusConflict, conflictError.StatusCode)
+	assert.Equal(t, "conflict", conflictError.Message)
+	assert.EqualValues(t, 3, conflictError.CurrentRiserRevision)
+	assert.Equal(t, "Error: conflict", conflictError.Error())
+}
+
+// Other conflicts (e.g. a status update from an older revision) do not include the current revision
+func Test_Do_ConflictWithoutRevision(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"message": "conflict"}`)
+	})
+
+	request, _ := client.NewGetRequest("/")
+	_, err := client.Do(request, nil)
+
+	assert.IsType(t, &ClientError{}, err)
+}
+
 func mustReadAll(r io.Reader) []byte {
 	bytes, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -7,8 +7,19 @@ import (
 	"github.com/riser-platform/riser-server/api/v1/model"
 )
 
+// DeploymentChangeOptions are optional preconditions for changing a deployment
+type DeploymentChangeOptions struct {
+	// ExpectedRiserRevision returns a RevisionConflictError if the deployment's current revision does not match. Zero skips the check.
+	ExpectedRiserRevision int64
+	// OverrideLock allows a locked deployment to be changed
+	OverrideLock bool
+}
+
 type DeploymentsClient interface {
 	Delete(deploymentName, namespace, envName string) (*model.SaveDeploymentResponse, error)
+	DeleteWithOptions(deploymentName, namespace, envName string, options DeploymentChangeOptions) (*model.SaveDeploymentResponse, error)
+	Lock(deploymentName, namespace, envName string, lockRequest *model.DeploymentLockRequest) (*model.DeploymentLock, error)
+	Unlock(deploymentName, namespace, envName string) error
 	Save(deployment *model.SaveDeploymentRequest, dryRun bool) (*model.SaveDeploymentResponse, error)
 	SaveStatus(deploymentName, namespace, envName string, status *model.DeploymentStatusMutable) (statusCode int, err error)
 }
@@ -18,11 +29,25 @@ type deploymentsClient struct {
 }
 
 func (c *deploymentsClient) Delete(deploymentName, namespace, envName string) (*model.SaveDeploymentResponse, error) {
+	return c.DeleteWithOptions(deploymentName, namespace, envName, DeploymentChangeOptions{})
+}
+
+func (c *deploymentsClient) DeleteWithOptions(deploymentName, namespace, envName string, options DeploymentChangeOptions) (*model.SaveDeploymentResponse, error) {
 	request, err := c.client.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/deployments/%s/%s/%s", envName, namespace, deploymentName), nil)
 	if err != nil {
 		return nil, err
 	}
 
+	if options.ExpectedRiserRevision > 0 {
+		request.Header.Set("If-Match", fmt.Sprintf(`"%d"`, options.ExpectedRiserRevision))
+	}
+
+	if options.OverrideLock {
+		q := request.URL.Query()
+		q.Add("overrideLock", "true")
+		request.URL.RawQuery = q.Encode()
+	}
+
 	responseModel := &model.SaveDeploymentResponse{}
 	_, err = c.client.Do(request, responseModel)
 	if err != nil {
@@ -32,6 +57,31 @@ func (c *deploymentsClient) Delete(deploymentName, namespace, envName string) (*
 	return responseModel, nil
 }
 
+func (c *deploymentsClient) Lock(deploymentName, namespace, envName string, lockRequest *model.DeploymentLockRequest) (*model.DeploymentLock, error) {
+	request, err := c.client.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/deployments/%s/%s/%s/lock", envName, namespace, deploymentName), lockRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &model.DeploymentLock{}
+	_, err = c.client.Do(request, lock)
+	if err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+func (c *deploymentsClient) Unlock(deploymentName, namespace, envName string) error {
+	request, err := c.client.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/deployments/%s/%s/%s/lock", envName, namespace, deploymentName), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Do(request, nil)
+	return err
+}
+
 func (c *deploymentsClient) Save(deployment *model.SaveDeploymentRequest, dryRun bool) (*model.SaveDeploymentResponse, error) {
 	request, err := c.client.NewRequest(http.MethodPut, "/api/v1/deployments", deployment)
 	if err != nil {
@@ -24,6 +24,75 @@ func Test_Deployments_Delete(t *testing.T) {
 	assert.Equal(t, "deleted", result.Message)
 }
 
+func Test_Deployments_DeleteWithOptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/deployments/myenv/myns/mydep", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, `"3"`, r.Header.Get("If-Match"))
+		assert.Equal(t, "true", r.URL.Query().Get("overrideLock"))
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"message": "conflict", "currentRiserRevision": 4}`)
+	})
+
+	result, err := client.Deployments.DeleteWithOptions("mydep", "myns", "myenv", DeploymentChangeOptions{ExpectedRiserRevision: 3, OverrideLock: true})
+
+	assert.Nil(t, result)
+	assert.IsType(t, &RevisionConflictError{}, err)
+	assert.EqualValues(t, 4, err.(*RevisionConflictError).CurrentRiserRevision)
+}
+
+func Test_Deployments_DeleteWithOptions_ExpectedRevisionOnly(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/deployments/myenv/myns/mydep", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		assert.Equal(t, `"3"`, r.Header.Get("If-Match"))
+		assert.Empty(t, r.URL.Query().Get("overrideLock"))
+		fmt.Fprint(w, `{"message": "deleted"}`)
+	})
+
+	result, err := client.Deployments.DeleteWithOptions("mydep", "myns", "myenv", DeploymentChangeOptions{ExpectedRiserRevision: 3})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "deleted", result.Message)
+}
+
+func Test_Deployments_Lock(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/deployments/myenv/myns/mydep/lock", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		lockRequest := &model.DeploymentLockRequest{}
+		mustUnmarshalR(r.Body, lockRequest)
+		assert.Equal(t, "incident", lockRequest.Reason)
+		fmt.Fprint(w, `{"holder": "alice", "reason": "incident"}`)
+	})
+
+	result, err := client.Deployments.Lock("mydep", "myns", "myenv", &model.DeploymentLockRequest{Reason: "incident"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", result.Holder)
+	assert.Equal(t, "incident", result.Reason)
+}
+
+func Test_Deployments_Unlock(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/deployments/myenv/myns/mydep/lock", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := client.Deployments.Unlock("mydep", "myns", "myenv")
+
+	assert.NoError(t, err)
+}
+
 func Test_Deployments_Save(t *testing.T) {
 	setup()
 	defer teardown()
@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
@@ -9,6 +10,9 @@ import (
 type NamespacesClient interface {
 	List() ([]model.Namespace, error)
 	Create(namespaceName string) error
+	GetConfigs(namespaceName string) ([]model.NamespaceConfig, error)
+	// SaveConfig replaces the namespace config in an environment. The response lists the deployments that must be redeployed.
+	SaveConfig(namespaceName string, config *model.NamespaceConfig) (*model.SaveNamespaceConfigResponse, error)
 }
 
 type namespacesClient struct {
@@ -41,3 +45,31 @@ func (c *namespacesClient) Create(namespaceName string) error {
 
 	return nil
 }
+
+func (c *namespacesClient) GetConfigs(namespaceName string) ([]model.NamespaceConfig, error) {
+	configs := []model.NamespaceConfig{}
+	request, err := c.client.NewGetRequest(fmt.Sprintf("/api/v1/namespaces/%s/config", namespaceName))
+	if err != nil {
+		return nil, err
+	}
+	_, err = c.client.Do(request, &configs)
+	if err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+func (c *namespacesClient) SaveConfig(namespaceName string, config *model.NamespaceConfig) (*model.SaveNamespaceConfigResponse, error) {
+	request, err := c.client.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/namespaces/%s/config", namespaceName), config)
+	if err != nil {
+		return nil, err
+	}
+
+	responseModel := &model.SaveNamespaceConfigResponse{}
+	_, err = c.client.Do(request, responseModel)
+	if err != nil {
+		return nil, err
+	}
+
+	return responseModel, nil
+}
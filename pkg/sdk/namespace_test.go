@@ -48,3 +48,44 @@ func Test_Namespaces_Create(t *testing.T) {
 
 	assert.NoError(t, err)
 }
+
+func Test_Namespaces_GetConfigs(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/namespaces/myns/config", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		response := `
+		[
+			{"environment": "dev", "env": {"LOG_LEVEL": "debug"}}
+		]`
+
+		fmt.Fprint(w, response)
+	})
+
+	configs, err := client.Namespaces.GetConfigs("myns")
+
+	assert.NoError(t, err)
+	assert.Len(t, configs, 1)
+	assert.Equal(t, "dev", configs[0].Environment)
+	assert.Equal(t, "debug", configs[0].Env["LOG_LEVEL"].StrVal)
+}
+
+func Test_Namespaces_SaveConfig(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/namespaces/myns/config", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		actualModel := &model.NamespaceConfig{}
+		mustUnmarshalR(r.Body, actualModel)
+		assert.Equal(t, "dev", actualModel.Environment)
+		fmt.Fprint(w, `{"message": "saved", "deployments": ["app1"]}`)
+	})
+
+	result, err := client.Namespaces.SaveConfig("myns", &model.NamespaceConfig{Environment: "dev"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "saved", result.Message)
+	assert.Equal(t, []string{"app1"}, result.Deployments)
+}
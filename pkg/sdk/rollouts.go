@@ -18,6 +18,7 @@ var trafficRuleExp = regexp.MustCompile(`r([0-9]+):(\*|[0-9]+)`)
 
 type RolloutsClient interface {
 	Save(deploymentName, namespace, envName string, trafficRule ...string) error
+	SaveWithOptions(deploymentName, namespace, envName string, options DeploymentChangeOptions, trafficRule ...string) error
 }
 
 type rolloutsClient struct {
@@ -25,13 +26,19 @@ type rolloutsClient struct {
 }
 
 func (c *rolloutsClient) Save(deploymentName, namespace, envName string, trafficRules ...string) error {
+	return c.SaveWithOptions(deploymentName, namespace, envName, DeploymentChangeOptions{}, trafficRules...)
+}
+
+func (c *rolloutsClient) SaveWithOptions(deploymentName, namespace, envName string, options DeploymentChangeOptions, trafficRules ...string) error {
 	parsedRules, err := parseTrafficRules(trafficRules...)
 	if err != nil {
 		return err
 	}
 
 	rolloutRequest := model.RolloutRequest{
-		Traffic: parsedRules,
+		Traffic:               parsedRules,
+		ExpectedRiserRevision: options.ExpectedRiserRevision,
+		OverrideLock:          options.OverrideLock,
 	}
 
 	request, err := c.client.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/rollout/%s/%s/%s", envName, namespace, deploymentName), rolloutRequest)
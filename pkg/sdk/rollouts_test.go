@@ -30,6 +30,40 @@ func Test_Rollouts_Save(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_Rollouts_SaveWithOptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/rollout/dev/myns/myapp", func(w http.ResponseWriter, r *http.Request) {
+		rollout := &model.RolloutRequest{}
+		mustUnmarshalR(r.Body, rollout)
+		assert.EqualValues(t, 2, rollout.ExpectedRiserRevision)
+		assert.True(t, rollout.OverrideLock)
+		assert.Len(t, rollout.Traffic, 1)
+	})
+
+	err := client.Rollouts.SaveWithOptions("myapp", "myns", "dev", DeploymentChangeOptions{ExpectedRiserRevision: 2, OverrideLock: true}, "r2:100")
+
+	assert.NoError(t, err)
+}
+
+func Test_Rollouts_SaveWithOptions_ExpectedRevisionOnly(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/rollout/dev/myns/myapp", func(w http.ResponseWriter, r *http.Request) {
+		rollout := &model.RolloutRequest{}
+		mustUnmarshalR(r.Body, rollout)
+		assert.EqualValues(t, 2, rollout.ExpectedRiserRevision)
+		assert.False(t, rollout.OverrideLock)
+		assert.Len(t, rollout.Traffic, 1)
+	})
+
+	err := client.Rollouts.SaveWithOptions("myapp", "myns", "dev", DeploymentChangeOptions{ExpectedRiserRevision: 2}, "r2:100")
+
+	assert.NoError(t, err)
+}
+
 func Test_parseTrafficRules(t *testing.T) {
 	tests := []struct {
 		trafficRules  []string
@@ -0,0 +1,26 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Schemas_AppConfig(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/schemas/appconfig", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		fmt.Fprint(w, `{"$schema": "http://json-schema.org/draft-07/schema#", "type": "object", "required": ["name"]}`)
+	})
+
+	result, err := client.Schemas.AppConfig()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "http://json-schema.org/draft-07/schema#", result.Schema)
+	assert.Equal(t, "object", result.Type)
+	assert.Equal(t, []string{"name"}, result.Required)
+}
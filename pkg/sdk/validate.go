@@ -7,23 +7,25 @@ import (
 )
 
 type ValidateClient interface {
-	AppConfig(appConfig *model.AppConfigWithOverrides) error
+	// AppConfig validates the app config. Warnings are returned for potential problems that do not prevent the app from being deployed.
+	AppConfig(appConfig *model.AppConfigWithOverrides) (*model.AppConfigValidationResult, error)
 }
 
 type validateClient struct {
 	client *Client
 }
 
-func (c *validateClient) AppConfig(appConfig *model.AppConfigWithOverrides) error {
+func (c *validateClient) AppConfig(appConfig *model.AppConfigWithOverrides) (*model.AppConfigValidationResult, error) {
 	request, err := c.client.NewRequest(http.MethodPost, "/api/v1/validate/appconfig", appConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = c.client.Do(request, nil)
+	result := &model.AppConfigValidationResult{}
+	_, err = c.client.Do(request, result)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return result, nil
 }
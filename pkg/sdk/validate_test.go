@@ -1,6 +1,7 @@
 package sdk
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -12,7 +13,7 @@ func Test_Validate_AppConfig(t *testing.T) {
 	setup()
 	defer teardown()
 
-	requestModel := &model.AppConfigWithOverrides{}
+	requestModel := &model.AppConfigWithOverrides{AppConfig: model.AppConfig{ApiVersion: model.AppConfigApiVersion}}
 
 	mux.HandleFunc("/api/v1/validate/appconfig", func(w http.ResponseWriter, r *http.Request) {
 		assert.Equal(t, http.MethodPost, r.Method)
@@ -20,9 +21,11 @@ func Test_Validate_AppConfig(t *testing.T) {
 		mustUnmarshalR(r.Body, actualModel)
 		assert.Equal(t, requestModel, actualModel)
 		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `{"warnings":["mywarning"]}`)
 	})
 
-	err := client.Validate.AppConfig(requestModel)
+	result, err := client.Validate.AppConfig(requestModel)
 
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"mywarning"}, result.Warnings)
 }
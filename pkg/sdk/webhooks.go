@@ -0,0 +1,87 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+)
+
+type WebhooksClient interface {
+	List() ([]model.Webhook, error)
+	Get(id uuid.UUID) (*model.Webhook, error)
+	Create(newWebhook *model.NewWebhook) (*model.Webhook, error)
+	Delete(id uuid.UUID) error
+	ListDeliveries(id uuid.UUID) ([]model.WebhookDelivery, error)
+}
+
+type webhooksClient struct {
+	client *Client
+}
+
+func (c *webhooksClient) List() ([]model.Webhook, error) {
+	request, err := c.client.NewGetRequest("/api/v1/webhooks")
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := []model.Webhook{}
+	_, err = c.client.Do(request, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (c *webhooksClient) Get(id uuid.UUID) (*model.Webhook, error) {
+	request, err := c.client.NewGetRequest(fmt.Sprintf("/api/v1/webhooks/%s", id))
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &model.Webhook{}
+	_, err = c.client.Do(request, webhook)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (c *webhooksClient) Create(newWebhook *model.NewWebhook) (*model.Webhook, error) {
+	request, err := c.client.NewRequest(http.MethodPost, "/api/v1/webhooks", newWebhook)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &model.Webhook{}
+	_, err = c.client.Do(request, webhook)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (c *webhooksClient) Delete(id uuid.UUID) error {
+	request, err := c.client.NewRequest(http.MethodDelete, fmt.Sprintf("/api/v1/webhooks/%s", id), nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Do(request, nil)
+	return err
+}
+
+func (c *webhooksClient) ListDeliveries(id uuid.UUID) ([]model.WebhookDelivery, error) {
+	request, err := c.client.NewGetRequest(fmt.Sprintf("/api/v1/webhooks/%s/deliveries", id))
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := []model.WebhookDelivery{}
+	_, err = c.client.Do(request, &deliveries)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
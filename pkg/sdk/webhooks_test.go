@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Webhooks_List(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `[{"url":"https://acme.org"}]`)
+	})
+
+	webhooks, err := client.Webhooks.List()
+
+	assert.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, "https://acme.org", webhooks[0].Url)
+}
+
+func Test_Webhooks_Get(t *testing.T) {
+	setup()
+	defer teardown()
+
+	id := uuid.New()
+	mux.HandleFunc(fmt.Sprintf("/api/v1/webhooks/%s", id), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, `{"id":"%s","url":"https://acme.org"}`, id)
+	})
+
+	webhook, err := client.Webhooks.Get(id)
+
+	assert.NoError(t, err)
+	assert.Equal(t, id, webhook.Id)
+	assert.Equal(t, "https://acme.org", webhook.Url)
+}
+
+func Test_Webhooks_Create(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/api/v1/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		newWebhook := &model.NewWebhook{}
+		mustUnmarshalR(r.Body, newWebhook)
+		assert.Equal(t, "https://acme.org", newWebhook.Url)
+		assert.Equal(t, "mysecret", newWebhook.Secret)
+		assert.Equal(t, []string{model.WebhookEvent_RevisionFailed}, newWebhook.Events)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, `{"url":"https://acme.org"}`)
+	})
+
+	webhook, err := client.Webhooks.Create(&model.NewWebhook{
+		Url:    "https://acme.org",
+		Secret: "mysecret",
+		Events: []string{model.WebhookEvent_RevisionFailed},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "https://acme.org", webhook.Url)
+}
+
+func Test_Webhooks_Delete(t *testing.T) {
+	setup()
+	defer teardown()
+
+	id := uuid.New()
+	mux.HandleFunc(fmt.Sprintf("/api/v1/webhooks/%s", id), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	err := client.Webhooks.Delete(id)
+
+	assert.NoError(t, err)
+}
+
+func Test_Webhooks_ListDeliveries(t *testing.T) {
+	setup()
+	defer teardown()
+
+	id := uuid.New()
+	mux.HandleFunc(fmt.Sprintf("/api/v1/webhooks/%s/deliveries", id), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, `[{"eventType":"revision.ready","status":"Succeeded","attempts":1}]`)
+	})
+
+	deliveries, err := client.Webhooks.ListDeliveries(id)
+
+	assert.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, model.WebhookEvent_RevisionReady, deliveries[0].EventType)
+	assert.Equal(t, model.WebhookDeliveryStatusSucceeded, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+}
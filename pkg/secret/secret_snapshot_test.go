@@ -5,9 +5,11 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/snapshot"
 	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -53,11 +55,20 @@ func Test_update_snapshot_sealedsecret(t *testing.T) {
 	committer, err := snapshot.CreateCommitter(snapshotPath)
 	require.NoError(t, err)
 
-	secretService := service{secretMetaRepository, environmentRepository, staticReader{}}
+	webhookService := &webhook.FakeService{
+		PublishFn: func(event *core.WebhookEvent) {
+			assert.Equal(t, model.WebhookEvent_SecretUpdated, event.Type)
+			assert.Equal(t, "mysecret", event.Name)
+			assert.Equal(t, "dev", event.EnvironmentName)
+		},
+	}
+
+	secretService := service{secretMetaRepository, environmentRepository, webhookService, staticReader{}}
 
 	err = secretService.SealAndSave("mysecretval", secretMeta, committer)
 
 	assert.NoError(t, err)
+	assert.Equal(t, 1, webhookService.PublishCallCount)
 	if !snapshot.ShouldUpdate() {
 		dryRunCommitter := committer.(*state.DryRunCommitter)
 		snapshot.AssertCommitter(t, snapshotPath, dryRunCommitter)
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/state/resources"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 
 	"github.com/pkg/errors"
 	"github.com/riser-platform/riser-server/pkg/core"
@@ -19,11 +21,12 @@ type Service interface {
 type service struct {
 	secretMetas  core.SecretMetaRepository
 	environments core.EnvironmentRepository
+	webhooks     webhook.Service
 	rand         io.Reader
 }
 
-func NewService(secretMetas core.SecretMetaRepository, environments core.EnvironmentRepository) Service {
-	return &service{secretMetas, environments, rand.Reader}
+func NewService(secretMetas core.SecretMetaRepository, environments core.EnvironmentRepository, webhooks webhook.Service) Service {
+	return &service{secretMetas, environments, webhooks, rand.Reader}
 }
 
 func (s *service) SealAndSave(plaintextSecret string, secretMeta *core.SecretMeta, committer state.Committer) error {
@@ -66,6 +69,18 @@ func (s *service) sealAndSave(plaintextSecret string, sealedSecretCert []byte, s
 		}
 		return errors.Wrap(err, "Error committing sealed secret metadata")
 	}
+
+	// The secret value is intentionally omitted from the event
+	s.webhooks.Publish(&core.WebhookEvent{
+		Type:            model.WebhookEvent_SecretUpdated,
+		Namespace:       secretMeta.App.Namespace,
+		EnvironmentName: secretMeta.EnvironmentName,
+		Name:            secretMeta.Name,
+		Data: map[string]interface{}{
+			"app":      secretMeta.App.Name,
+			"revision": secretMeta.Revision,
+		},
+	})
 	return nil
 }
 
@@ -3,9 +3,12 @@ package secret
 import (
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 	"testing"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/state"
+	"github.com/riser-platform/riser-server/pkg/webhook"
 
 	"github.com/pkg/errors"
 
@@ -98,13 +101,24 @@ func Test_sealAndSave(t *testing.T) {
 		Revision:        1,
 	}
 
+	webhookService := &webhook.FakeService{
+		PublishFn: func(event *core.WebhookEvent) {
+			assert.Equal(t, model.WebhookEvent_SecretUpdated, event.Type)
+			assert.Equal(t, "mysecret", event.Name)
+			assert.Equal(t, "myns", event.Namespace)
+			assert.Equal(t, "myenv", event.EnvironmentName)
+			assert.NotContains(t, fmt.Sprintf("%v", event.Data), "plain")
+		},
+	}
+
 	committer := state.NewDryRunCommitter()
 
-	service := service{secretMetas: secretMetaRepository, rand: rand.Reader}
+	service := service{secretMetas: secretMetaRepository, webhooks: webhookService, rand: rand.Reader}
 
 	result := service.sealAndSave("plain", testCertBytes, meta, committer)
 
 	assert.NoError(t, result)
+	assert.Equal(t, 1, webhookService.PublishCallCount)
 	assert.EqualValues(t, 1, meta.Revision)
 	assert.Equal(t, 1, secretMetaRepository.SaveCallCount)
 	assert.Equal(t, 1, secretMetaRepository.CommitCallCount)
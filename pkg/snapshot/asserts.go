@@ -19,6 +19,10 @@ func AssertEqual(t *testing.T, snapshotDir string, actualFiles []core.ResourceFi
 	snapshotFileMap := map[string][]byte{}
 
 	for _, file := range actualFiles {
+		// Deleted files are not part of the snapshot
+		if file.Delete {
+			continue
+		}
 		actualFileMap[file.Name] = file.Contents
 	}
 
@@ -3,6 +3,7 @@ package state
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/riser-platform/riser-server/pkg/util"
@@ -22,6 +23,13 @@ func NewFileCommitter(basePath string) *FileCommitter {
 func (committer *FileCommitter) Commit(message string, files []core.ResourceFile) error {
 	for _, file := range files {
 		fullpath := filepath.Join(committer.basePath, file.Name)
+		if file.Delete {
+			err := os.RemoveAll(fullpath)
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("error deleting %q", fullpath))
+			}
+			continue
+		}
 		err := util.EnsureDir(fullpath, 0755)
 		if err != nil {
 			return errors.Wrap(err, fmt.Sprintf("error creating directory for file %q", fullpath))
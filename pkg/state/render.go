@@ -9,11 +9,18 @@ import (
 	"github.com/riser-platform/riser-server/pkg/state/resources"
 
 	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/util"
 )
 
-const riserManagedStatePath = "state/riser-managed"
+const (
+	riserManagedStatePath = "state/riser-managed"
+	// deploymentJobsDir is the folder within a deployment's folder that contains the CronJobs of the app's jobs
+	deploymentJobsDir = "jobs"
+	// deploymentDomainsDir is the folder within a deployment's folder that contains the DomainMappings and Certificates of the app's domains
+	deploymentDomainsDir = "domains"
+)
 
 type getResourcePathFunc func(resource KubeResource) string
 
@@ -37,15 +44,28 @@ func RenderGeneric(environmentName string, resources ...KubeResource) ([]core.Re
 	}, resources...)
 }
 
+// RenderDeleteGeneric renders the removal of generic resources (e.g. the service account of an app that no longer has any deployments)
+func RenderDeleteGeneric(resources ...KubeResource) []core.ResourceFile {
+	files := []core.ResourceFile{}
+	for _, resource := range resources {
+		files = append(files, core.ResourceFile{
+			Name:   getGenericStatePath(resource),
+			Delete: true,
+		})
+	}
+	return files
+}
+
 func RenderSealedSecret(app, environmentName string, sealedSecret *resources.SealedSecret) ([]core.ResourceFile, error) {
 	return renderKubeResources(func(resource KubeResource) string {
 		return getSecretScmPath(app, environmentName, sealedSecret)
 	}, sealedSecret)
 }
 
-// RenderDeployment renders resources that target a deployment's git folder
+// RenderDeployment renders resources that target a deployment's git folder. CronJobs and the resources of custom domains are rendered to
+// the jobs and domains folders which are replaced each time so that the resources of removed jobs and domains are deleted.
 func RenderDeployment(deployment *core.DeploymentConfig, deploymentResources ...KubeResource) ([]core.ResourceFile, error) {
-	files, err := renderKubeResources(func(resource KubeResource) string {
+	resourceFiles, err := renderKubeResources(func(resource KubeResource) string {
 		return getDeploymentScmPath(deployment.Name, deployment.Namespace, deployment.EnvironmentName, resource)
 	}, filterNilResources(deploymentResources...)...)
 
@@ -53,6 +73,18 @@ func RenderDeployment(deployment *core.DeploymentConfig, deploymentResources ...
 		return nil, err
 	}
 
+	files := []core.ResourceFile{
+		{
+			Name:   getDeploymentJobsScmDir(deployment.Name, deployment.Namespace),
+			Delete: true,
+		},
+		{
+			Name:   getDeploymentDomainsScmDir(deployment.Name, deployment.Namespace),
+			Delete: true,
+		},
+	}
+	files = append(files, resourceFiles...)
+
 	appConfigFile, err := renderAppConfig(deployment)
 	if err != nil {
 		return nil, err
@@ -62,6 +94,19 @@ func RenderDeployment(deployment *core.DeploymentConfig, deploymentResources ...
 	return files, nil
 }
 
+// RenderDeleteDeploymentResources renders the removal of resources from a deployment's git folder (e.g. the resources of the previous
+// workload when an app changes its workload)
+func RenderDeleteDeploymentResources(deployment *core.DeploymentConfig, deploymentResources ...KubeResource) []core.ResourceFile {
+	files := []core.ResourceFile{}
+	for _, resource := range filterNilResources(deploymentResources...) {
+		files = append(files, core.ResourceFile{
+			Name:   getDeploymentScmPath(deployment.Name, deployment.Namespace, deployment.EnvironmentName, resource),
+			Delete: true,
+		})
+	}
+	return files
+}
+
 // RenderRoute renders just the route resource.
 func RenderRoute(deploymentName, namespace, environmentName string, resource KubeResource) ([]core.ResourceFile, error) {
 	files, err := renderKubeResources(func(resource KubeResource) string {
@@ -76,7 +121,10 @@ func RenderRoute(deploymentName, namespace, environmentName string, resource Kub
 }
 
 func renderAppConfig(deployment *core.DeploymentConfig) (*core.ResourceFile, error) {
-	serialized, err := util.ToYaml(deployment.App)
+	// Always record the version so that the app config may be converted if the schema changes
+	appConfig := *deployment.App
+	appConfig.ApiVersion = model.AppConfigApiVersion
+	serialized, err := util.ToYaml(appConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error serializing app config")
 	}
@@ -108,10 +156,23 @@ func getDeploymentScmDir(deploymentName, namespace string) string {
 		deploymentName))
 }
 
+func getDeploymentJobsScmDir(deploymentName, namespace string) string {
+	return filepath.Join(getDeploymentScmDir(deploymentName, namespace), deploymentJobsDir)
+}
+
+func getDeploymentDomainsScmDir(deploymentName, namespace string) string {
+	return filepath.Join(getDeploymentScmDir(deploymentName, namespace), deploymentDomainsDir)
+}
+
 func getDeploymentScmPath(deploymentName, namespace, environmentName string, resource KubeResource) string {
-	return strings.ToLower(filepath.Join(
-		getDeploymentScmDir(deploymentName, namespace),
-		getFileNameFromResource(resource)))
+	dir := getDeploymentScmDir(deploymentName, namespace)
+	switch resource.GetObjectKind().GroupVersionKind().Kind {
+	case "CronJob":
+		dir = getDeploymentJobsScmDir(deploymentName, namespace)
+	case "DomainMapping", "Certificate":
+		dir = getDeploymentDomainsScmDir(deploymentName, namespace)
+	}
+	return strings.ToLower(filepath.Join(dir, getFileNameFromResource(resource)))
 }
 
 func getSecretScmPath(app string, environmentName string, sealedSecret KubeResource) string {
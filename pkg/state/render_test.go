@@ -13,8 +13,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
+	servingv1beta1 "knative.dev/serving/pkg/apis/serving/v1beta1"
 )
 
 func Test_RenderDeleteDeployment(t *testing.T) {
@@ -27,6 +30,36 @@ func Test_RenderDeleteDeployment(t *testing.T) {
 	assert.True(t, result[1].Delete)
 }
 
+func Test_RenderDeleteDeploymentResources(t *testing.T) {
+	deployment := &core.DeploymentConfig{Name: "mydep", Namespace: "apps", EnvironmentName: "dev"}
+	route := &servingv1.Route{
+		ObjectMeta: metav1.ObjectMeta{Name: "mydep", Namespace: "apps"},
+		TypeMeta:   metav1.TypeMeta{Kind: "Route", APIVersion: "serving.knative.dev/v1"},
+	}
+	var nilRoute *servingv1.Route
+
+	result := RenderDeleteDeploymentResources(deployment, route, nilRoute)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, "state/riser-managed/apps/deployments/mydep/serving.knative.dev.route.mydep.yaml", result[0].Name)
+	assert.True(t, result[0].Delete)
+	assert.Empty(t, result[0].Contents)
+}
+
+func Test_RenderDeleteGeneric(t *testing.T) {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", Namespace: "apps"},
+		TypeMeta:   metav1.TypeMeta{Kind: "ServiceAccount", APIVersion: "v1"},
+	}
+
+	result := RenderDeleteGeneric(serviceAccount)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, "state/riser-managed/apps/serviceaccount.myapp.yaml", result[0].Name)
+	assert.True(t, result[0].Delete)
+	assert.Empty(t, result[0].Contents)
+}
+
 func Test_getDeploymentScmPath(t *testing.T) {
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -43,6 +76,40 @@ func Test_getDeploymentScmPath(t *testing.T) {
 	assert.Equal(t, "state/riser-managed/apps/deployments/myapp01/deployment.myapp01.yaml", result)
 }
 
+func Test_getDeploymentScmPath_CronJob(t *testing.T) {
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapp01-cleanup",
+			Namespace: "apps",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CronJob",
+			APIVersion: "batch/v1",
+		},
+	}
+
+	result := getDeploymentScmPath("myapp01", "apps", "dev", cronJob)
+
+	assert.Equal(t, "state/riser-managed/apps/deployments/myapp01/jobs/batch.cronjob.myapp01-cleanup.yaml", result)
+}
+
+func Test_getDeploymentScmPath_DomainMapping(t *testing.T) {
+	domainMapping := &servingv1beta1.DomainMapping{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "myapp.example.com",
+			Namespace: "apps",
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "DomainMapping",
+			APIVersion: "serving.knative.dev/v1beta1",
+		},
+	}
+
+	result := getDeploymentScmPath("myapp01", "apps", "dev", domainMapping)
+
+	assert.Equal(t, "state/riser-managed/apps/deployments/myapp01/domains/serving.knative.dev.domainmapping.myapp.example.com.yaml", result)
+}
+
 func Test_getAppConfigScmPath(t *testing.T) {
 	result := getAppConfigScmPath("myapp01-test", "apps")
 
@@ -90,11 +157,17 @@ func Test_RenderDeployment(t *testing.T) {
 
 	require.NoError(t, err)
 	// Sanity check output - we'll use snapshot testing for exhaustive serialization and file system tests
-	assert.Len(t, result, 2)
-	assert.Equal(t, "state/riser-managed/apps/deployments/mydeployment/service.mydeployment.yaml", result[0].Name)
-	assert.Contains(t, string(result[0].Contents), "name: mydeployment")
-	assert.Equal(t, "riser-config/apps/mydeployment.yaml", result[1].Name)
-	assert.Contains(t, string(result[1].Contents), "name: myapp01")
+	assert.Len(t, result, 4)
+	assert.Equal(t, "state/riser-managed/apps/deployments/mydeployment/jobs", result[0].Name)
+	assert.True(t, result[0].Delete)
+	assert.Equal(t, "state/riser-managed/apps/deployments/mydeployment/domains", result[1].Name)
+	assert.True(t, result[1].Delete)
+	assert.Equal(t, "state/riser-managed/apps/deployments/mydeployment/service.mydeployment.yaml", result[2].Name)
+	assert.Contains(t, string(result[2].Contents), "name: mydeployment")
+	assert.Equal(t, "riser-config/apps/mydeployment.yaml", result[3].Name)
+	assert.Contains(t, string(result[3].Contents), "name: myapp01")
+	assert.Contains(t, string(result[3].Contents), "apiVersion: riser.dev/v1\n")
+	assert.Empty(t, deployment.App.ApiVersion, "the deployment app config should not be modified")
 }
 
 func Test_getFileNameFromResource(t *testing.T) {
@@ -3,6 +3,7 @@ package resources
 import (
 	"fmt"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	securityv1beta1 "istio.io/api/security/v1beta1"
 	typev1beta1 "istio.io/api/type/v1beta1"
@@ -10,8 +11,106 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	// The Knative activator proxies requests to apps that are scaled to zero
+	knativeServingNamespace = "knative-serving"
+	// Istio's default trust domain
+	trustDomain = "cluster.local"
+	// The Istio ingress gateway routes external requests to apps. Only the external gateway is allowed since any app may send requests
+	// through other gateways in istio-system (e.g. the Knative local gateway).
+	istioIngressGatewayPrincipal = trustDomain + "/ns/istio-system/sa/istio-ingressgateway-service-account"
+)
+
+// CreateAllowFromPolicy allows requests to the app only from the apps and namespaces in expose.allowFrom. Once an ALLOW policy applies
+// to a workload Istio denies all other requests, so Knative and the ingress gateway for externally exposed apps are always allowed.
+//
+// Apps are matched by the principal of their service account, so only requests sent through the mesh from the calling app's sidecar are
+// allowed. Riser configures Knative with local-gateway.mesh so that requests to cluster local addresses are routed by the sidecar instead
+// of the Knative local gateway. Requests routed through a gateway carry the gateway's principal and are denied.
+func CreateAllowFromPolicy(dCtx *core.DeploymentContext) *v1beta1.AuthorizationPolicy {
+	sources := dCtx.DeploymentConfig.App.AllowFromSources()
+	if len(sources) == 0 {
+		return nil
+	}
+
+	principals := []string{}
+	namespaces := []string{knativeServingNamespace}
+	if dCtx.DeploymentConfig.App.Expose.Scope == model.AppExposeScope_External {
+		principals = append(principals, istioIngressGatewayPrincipal)
+	}
+	for _, source := range sources {
+		if source.App == "" {
+			namespaces = append(namespaces, source.Namespace)
+		} else {
+			principals = append(principals, appPrincipal(source.App, source.Namespace))
+		}
+	}
+
+	from := []*securityv1beta1.Rule_From{
+		{Source: &securityv1beta1.Source{Namespaces: namespaces}},
+	}
+	if len(principals) > 0 {
+		from = append(from, &securityv1beta1.Rule_From{Source: &securityv1beta1.Source{Principals: principals}})
+	}
+
+	return &v1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-allow-from", dCtx.DeploymentConfig.Name),
+			Namespace:   dCtx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(dCtx),
+			Annotations: deploymentAnnotations(dCtx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AuthorizationPolicy",
+			APIVersion: "security.istio.io/v1beta1",
+		},
+		Spec: securityv1beta1.AuthorizationPolicy{
+			Action: securityv1beta1.AuthorizationPolicy_ALLOW,
+			Selector: &typev1beta1.WorkloadSelector{
+				MatchLabels: map[string]string{
+					riserLabel("deployment"): dCtx.DeploymentConfig.Name,
+				},
+			},
+			Rules: []*securityv1beta1.Rule{{From: from}},
+		},
+	}
+}
+
+// CreateDefaultDenyPolicy denies requests to all apps in the namespace unless allowed by another policy. Knative and the ingress gateway
+// are still allowed so that apps without expose.allowFrom continue to serve external requests.
+func CreateDefaultDenyPolicy(namespace string) *v1beta1.AuthorizationPolicy {
+	return &v1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default-deny",
+			Namespace: namespace,
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AuthorizationPolicy",
+			APIVersion: "security.istio.io/v1beta1",
+		},
+		Spec: securityv1beta1.AuthorizationPolicy{
+			Action: securityv1beta1.AuthorizationPolicy_ALLOW,
+			Rules: []*securityv1beta1.Rule{
+				{
+					From: []*securityv1beta1.Rule_From{
+						{Source: &securityv1beta1.Source{Namespaces: []string{knativeServingNamespace}}},
+						{Source: &securityv1beta1.Source{Principals: []string{istioIngressGatewayPrincipal}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// appPrincipal returns the Istio principal of the service account that all deployments of an app run under
+func appPrincipal(app, namespace string) string {
+	return fmt.Sprintf("%s/ns/%s/sa/%s", trustDomain, namespace, AppServiceAccountName(app))
+}
+
+// CreateHealthcheckDenyPolicy denies external requests to http health check paths. Other health check modes do not expose a path.
 func CreateHealthcheckDenyPolicy(dCtx *core.DeploymentContext) *v1beta1.AuthorizationPolicy {
-	if dCtx.DeploymentConfig.App.HealthCheck == nil {
+	paths := healthCheckPaths(dCtx.DeploymentConfig.App)
+	if len(paths) == 0 {
 		return nil
 	}
 
@@ -38,7 +137,7 @@ func CreateHealthcheckDenyPolicy(dCtx *core.DeploymentContext) *v1beta1.Authoriz
 					To: []*securityv1beta1.Rule_To{
 						{
 							Operation: &securityv1beta1.Operation{
-								Paths: []string{dCtx.DeploymentConfig.App.HealthCheck.Path},
+								Paths: paths,
 							},
 						},
 					},
@@ -47,3 +146,14 @@ func CreateHealthcheckDenyPolicy(dCtx *core.DeploymentContext) *v1beta1.Authoriz
 		},
 	}
 }
+
+func healthCheckPaths(app *model.AppConfig) []string {
+	paths := []string{}
+	if app.HealthCheck.IsHTTP() {
+		paths = append(paths, app.HealthCheck.HTTPPath())
+	}
+	if app.Liveness.IsHTTP() && (len(paths) == 0 || paths[0] != app.Liveness.HTTPPath()) {
+		paths = append(paths, app.Liveness.HTTPPath())
+	}
+	return paths
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_createHealthcheckDenyPolicy(t *testing.T) {
@@ -15,8 +16,10 @@ func Test_createHealthcheckDenyPolicy(t *testing.T) {
 			EnvironmentName: "myenv",
 			App: &model.AppConfig{
 				Name: "myapp",
-				HealthCheck: &model.AppConfigHealthCheck{
-					Path: "/health",
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					HealthCheck: &model.AppConfigHealthCheck{
+						Path: "/health",
+					},
 				},
 			},
 		},
@@ -49,3 +52,116 @@ func Test_createHealthcheckDenyPolicy_NoHealthcheckReturnsNil(t *testing.T) {
 
 	assert.Nil(t, result)
 }
+
+func Test_createHealthcheckDenyPolicy_IncludesLivenessPath(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp-dep",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Liveness: &model.AppConfigHealthCheck{
+						Mode: model.AppHealthCheckMode_HTTP,
+						Path: "/live",
+					},
+					HealthCheck: &model.AppConfigHealthCheck{
+						Path: "/ready",
+					},
+				},
+			},
+		},
+	}
+
+	result := CreateHealthcheckDenyPolicy(ctx)
+
+	assert.Equal(t, []string{"/ready", "/live"}, result.Spec.Rules[0].To[0].Operation.Paths)
+}
+
+func Test_createHealthcheckDenyPolicy_NonHttpReturnsNil(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp-dep",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Liveness: &model.AppConfigHealthCheck{
+						Mode:    model.AppHealthCheckMode_Exec,
+						Command: []string{"true"},
+					},
+					HealthCheck: &model.AppConfigHealthCheck{
+						Mode: model.AppHealthCheckMode_TCP,
+					},
+				},
+			},
+		},
+	}
+
+	result := CreateHealthcheckDenyPolicy(ctx)
+
+	assert.Nil(t, result)
+}
+
+func Test_CreateAllowFromPolicy(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeployment("myapp-dep", "myns", "myenv"), withAllowFrom(model.AppExposeScope_External, "checkout", "billing.payments", "ns:ops"))
+
+	result := CreateAllowFromPolicy(ctx)
+
+	assert.Equal(t, "myapp-dep-allow-from", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Equal(t, "AuthorizationPolicy", result.TypeMeta.Kind)
+	assert.Equal(t, "security.istio.io/v1beta1", result.TypeMeta.APIVersion)
+	assert.Equal(t, "myapp-dep", result.Spec.Selector.MatchLabels["riser.dev/deployment"])
+	assert.Equal(t, "ALLOW", result.Spec.Action.String())
+	require.Len(t, result.Spec.Rules, 1)
+	require.Len(t, result.Spec.Rules[0].From, 2)
+	assert.Equal(t, []string{"knative-serving", "ops"}, result.Spec.Rules[0].From[0].Source.Namespaces)
+	assert.Equal(t, []string{
+		"cluster.local/ns/istio-system/sa/istio-ingressgateway-service-account",
+		"cluster.local/ns/myns/sa/riser-checkout",
+		"cluster.local/ns/payments/sa/riser-billing",
+	}, result.Spec.Rules[0].From[1].Source.Principals)
+}
+
+func Test_CreateAllowFromPolicy_ClusterScope(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeployment("myapp-dep", "myns", "myenv"), withAllowFrom(model.AppExposeScope_Cluster, "ns:ops"))
+
+	result := CreateAllowFromPolicy(ctx)
+
+	require.Len(t, result.Spec.Rules[0].From, 1)
+	assert.Equal(t, []string{"knative-serving", "ops"}, result.Spec.Rules[0].From[0].Source.Namespaces)
+}
+
+func Test_CreateAllowFromPolicy_NoAllowFromReturnsNil(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeployment("myapp-dep", "myns", "myenv"), withAllowFrom(model.AppExposeScope_External))
+
+	result := CreateAllowFromPolicy(ctx)
+
+	assert.Nil(t, result)
+}
+
+func Test_CreateDefaultDenyPolicy(t *testing.T) {
+	result := CreateDefaultDenyPolicy("myns")
+
+	assert.Equal(t, "default-deny", result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, "AuthorizationPolicy", result.TypeMeta.Kind)
+	assert.Nil(t, result.Spec.Selector)
+	assert.Equal(t, "ALLOW", result.Spec.Action.String())
+	require.Len(t, result.Spec.Rules, 1)
+	require.Len(t, result.Spec.Rules[0].From, 2)
+	assert.Equal(t, []string{"knative-serving"}, result.Spec.Rules[0].From[0].Source.Namespaces)
+	assert.Equal(t, []string{"cluster.local/ns/istio-system/sa/istio-ingressgateway-service-account"}, result.Spec.Rules[0].From[1].Source.Principals)
+}
+
+func withAllowFrom(scope string, allowFrom ...string) testDeploymentContextOption {
+	return withApp(func(app *model.AppConfig) {
+		app.Expose = &model.AppConfigExpose{
+			Scope:     scope,
+			AllowFrom: allowFrom,
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Certificate is a minimal cert-manager Certificate. We define our own type rather than depend on cert-manager for a handful of fields.
+type Certificate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CertificateSpec `json:"spec"`
+}
+
+type CertificateSpec struct {
+	SecretName string               `json:"secretName"`
+	DNSNames   []string             `json:"dnsNames"`
+	IssuerRef  CertificateIssuerRef `json:"issuerRef"`
+}
+
+type CertificateIssuerRef struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+// CreateDomainCertificates creates a Certificate for each of the app's custom domains when TLS is enabled in the environment
+func CreateDomainCertificates(ctx *core.DeploymentContext) []*Certificate {
+	if ctx.DeploymentConfig.App.Expose == nil || !tlsEnabled(ctx) {
+		return nil
+	}
+
+	certificates := []*Certificate{}
+	for _, domain := range ctx.DeploymentConfig.App.Expose.Domains {
+		certificates = append(certificates, &Certificate{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        domain,
+				Namespace:   ctx.DeploymentConfig.Namespace,
+				Labels:      deploymentLabels(ctx),
+				Annotations: deploymentAnnotations(ctx),
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Certificate",
+				APIVersion: "cert-manager.io/v1",
+			},
+			Spec: CertificateSpec{
+				SecretName: domainTLSSecretName(domain),
+				DNSNames:   []string{domain},
+				IssuerRef: CertificateIssuerRef{
+					Name: ctx.EnvironmentConfig.TLSClusterIssuer,
+					Kind: "ClusterIssuer",
+				},
+			},
+		})
+	}
+
+	return certificates
+}
@@ -0,0 +1,134 @@
+package resources
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const filesVolumeName = "riser-files"
+
+// CreateFilesConfigMap creates a ConfigMap containing the app's files. The name contains a hash of the files so that the ConfigMap is
+// immutable: a change in content results in a new ConfigMap while previous revisions continue to reference their own ConfigMap. See
+// core.DeploymentDoc.FilesConfigMaps for when previous ConfigMaps are removed.
+func CreateFilesConfigMap(ctx *core.DeploymentContext) *corev1.ConfigMap {
+	files := ctx.DeploymentConfig.App.Files
+	if len(files) == 0 {
+		return nil
+	}
+
+	data := map[string]string{}
+	for _, file := range sortedFiles(files) {
+		data[file.key] = files[file.path]
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        FilesConfigMapName(ctx.DeploymentConfig),
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+		Data: data,
+	}
+}
+
+type configMapFile struct {
+	path string
+	key  string
+}
+
+// sortedFiles returns the files sorted by path along with a unique ConfigMap key for each file
+func sortedFiles(files map[string]string) []configMapFile {
+	paths := []string{}
+	for filePath := range files {
+		paths = append(paths, filePath)
+	}
+	sort.Strings(paths)
+
+	out := []configMapFile{}
+	for idx, filePath := range paths {
+		out = append(out, configMapFile{
+			path: filePath,
+			// Prefix with the index since the same file name may be used in different folders
+			key: fmt.Sprintf("%d-%s", idx, path.Base(filePath)),
+		})
+	}
+	return out
+}
+
+// FilesConfigMapName returns the name of the ConfigMap containing the app's files or an empty string if the app does not have files
+func FilesConfigMapName(deployment *core.DeploymentConfig) string {
+	files := deployment.App.Files
+	if len(files) == 0 {
+		return ""
+	}
+	hash := sha256.New()
+	for _, file := range sortedFiles(files) {
+		// Include the separator so that a different split between path and content changes the hash
+		hash.Write([]byte(file.path))
+		hash.Write([]byte{0})
+		hash.Write([]byte(files[file.path]))
+		hash.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s-files-%x", deployment.Name, hash.Sum(nil)[:5])
+}
+
+// FilesConfigMapMeta returns a files ConfigMap without data. This is used to remove a ConfigMap that is no longer kept.
+func FilesConfigMapMeta(ctx *core.DeploymentContext, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ConfigMap",
+			APIVersion: "v1",
+		},
+	}
+}
+
+func filesVolumes(ctx *core.DeploymentContext) []corev1.Volume {
+	if len(ctx.DeploymentConfig.App.Files) == 0 {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: filesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: FilesConfigMapName(ctx.DeploymentConfig),
+					},
+				},
+			},
+		},
+	}
+}
+
+func filesVolumeMounts(ctx *core.DeploymentContext) []corev1.VolumeMount {
+	if len(ctx.DeploymentConfig.App.Files) == 0 {
+		return nil
+	}
+	mounts := []corev1.VolumeMount{}
+	for _, file := range sortedFiles(ctx.DeploymentConfig.App.Files) {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      filesVolumeName,
+			MountPath: file.path,
+			SubPath:   file.key,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
@@ -0,0 +1,95 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateFilesConfigMap(t *testing.T) {
+	ctx := newTestDeploymentContext(withFiles(map[string]string{
+		"/etc/myapp/config.yaml": "key: val",
+		"/etc/other/config.yaml": "other: val",
+	}))
+
+	result := CreateFilesConfigMap(ctx)
+
+	require.NotNil(t, result)
+	assert.Equal(t, FilesConfigMapName(ctx.DeploymentConfig), result.Name)
+	assert.Equal(t, "myns", result.Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Equal(t, "ConfigMap", result.Kind)
+	assert.Equal(t, "v1", result.APIVersion)
+	assert.Equal(t, map[string]string{"0-config.yaml": "key: val", "1-config.yaml": "other: val"}, result.Data)
+}
+
+func Test_CreateFilesConfigMap_NoFiles(t *testing.T) {
+	ctx := newTestDeploymentContext()
+
+	result := CreateFilesConfigMap(ctx)
+
+	assert.Nil(t, result)
+}
+
+func Test_FilesConfigMapName(t *testing.T) {
+	ctx := newTestDeploymentContext(withFiles(map[string]string{"/etc/config.yaml": "a"}))
+	sameCtx := newTestDeploymentContext(withFiles(map[string]string{"/etc/config.yaml": "a"}))
+	changedContentCtx := newTestDeploymentContext(withFiles(map[string]string{"/etc/config.yaml": "b"}))
+	changedPathCtx := newTestDeploymentContext(withFiles(map[string]string{"/etc/config.yml": "a"}))
+
+	result := FilesConfigMapName(ctx.DeploymentConfig)
+
+	assert.Regexp(t, "^myapp-files-[0-9a-f]{10}$", result)
+	assert.Equal(t, result, FilesConfigMapName(sameCtx.DeploymentConfig))
+	assert.NotEqual(t, result, FilesConfigMapName(changedContentCtx.DeploymentConfig))
+	assert.NotEqual(t, result, FilesConfigMapName(changedPathCtx.DeploymentConfig))
+}
+
+func Test_FilesConfigMapName_NoFiles(t *testing.T) {
+	ctx := newTestDeploymentContext()
+
+	assert.Empty(t, FilesConfigMapName(ctx.DeploymentConfig))
+}
+
+func Test_filesVolumes(t *testing.T) {
+	ctx := newTestDeploymentContext(withFiles(map[string]string{"/etc/config.yaml": "a"}))
+
+	result := filesVolumes(ctx)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, "riser-files", result[0].Name)
+	assert.Equal(t, FilesConfigMapName(ctx.DeploymentConfig), result[0].ConfigMap.Name)
+}
+
+func Test_filesVolumeMounts(t *testing.T) {
+	ctx := newTestDeploymentContext(withFiles(map[string]string{
+		"/etc/b/config.yaml": "b",
+		"/etc/a/config.yaml": "a",
+	}))
+
+	result := filesVolumeMounts(ctx)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "riser-files", result[0].Name)
+	assert.Equal(t, "/etc/a/config.yaml", result[0].MountPath)
+	assert.Equal(t, "0-config.yaml", result[0].SubPath)
+	assert.True(t, result[0].ReadOnly)
+	assert.Equal(t, "/etc/b/config.yaml", result[1].MountPath)
+	assert.Equal(t, "1-config.yaml", result[1].SubPath)
+}
+
+func Test_filesVolumes_NoFiles(t *testing.T) {
+	ctx := newTestDeploymentContext()
+
+	assert.Nil(t, filesVolumes(ctx))
+	assert.Nil(t, filesVolumeMounts(ctx))
+}
+
+func withFiles(files map[string]string) testDeploymentContextOption {
+	return withApp(func(app *model.AppConfig) {
+		app.Files = files
+	})
+}
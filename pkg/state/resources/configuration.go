@@ -2,8 +2,12 @@ package resources
 
 import (
 	"fmt"
+	"strconv"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
 
 	"github.com/riser-platform/riser-server/pkg/core"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 )
@@ -14,8 +18,33 @@ func CreateKNativeConfiguration(ctx *core.DeploymentContext) *servingv1.Configur
 	podSpec := createPodSpec(ctx)
 	// KNative does not allow setting this
 	podSpec.EnableServiceLinks = nil
+	// KNative only allows the primary port. Additional ports are reachable through the Service from CreateKNativePortsService.
+	for idx := range podSpec.Containers {
+		if len(podSpec.Containers[idx].Ports) > 1 {
+			podSpec.Containers[idx].Ports = podSpec.Containers[idx].Ports[:1]
+		}
+	}
 
 	revisionMeta := createRevisionMeta(ctx)
+	// KNative does not allow setting the seccomp profile so we use the deprecated pod annotation instead. Environments only support the
+	// RuntimeDefault profile.
+	for idx := range podSpec.Containers {
+		container := &podSpec.Containers[idx]
+		if container.SecurityContext != nil && container.SecurityContext.SeccompProfile != nil {
+			revisionMeta.Annotations[corev1.SeccompPodAnnotationKey] = corev1.SeccompProfileRuntimeDefault
+			container.SecurityContext.SeccompProfile = nil
+			if *container.SecurityContext == (corev1.SecurityContext{}) {
+				container.SecurityContext = nil
+			}
+		}
+	}
+	revisionSpec := servingv1.RevisionSpec{
+		PodSpec: podSpec,
+	}
+	if expose := ctx.DeploymentConfig.App.Expose; expose != nil {
+		revisionSpec.ContainerConcurrency = expose.ContainerConcurrency
+		revisionSpec.TimeoutSeconds = expose.TimeoutSeconds
+	}
 
 	// Not sure yet if we want this with KNative since KNative seems to handle readiness probes differently via the queue-proxy.
 
@@ -33,9 +62,7 @@ func CreateKNativeConfiguration(ctx *core.DeploymentContext) *servingv1.Configur
 		Spec: servingv1.ConfigurationSpec{
 			Template: servingv1.RevisionTemplateSpec{
 				ObjectMeta: revisionMeta,
-				Spec: servingv1.RevisionSpec{
-					PodSpec: podSpec,
-				},
+				Spec:       revisionSpec,
 			},
 		},
 	}
@@ -47,14 +74,44 @@ func createRevisionMeta(ctx *core.DeploymentContext) metav1.ObjectMeta {
 		Labels:      deploymentLabels(ctx),
 		Annotations: deploymentAnnotations(ctx),
 	}
-	if ctx.DeploymentConfig.App.Autoscale != nil {
-		if ctx.DeploymentConfig.App.Autoscale.Min != nil {
-			revisionMeta.Annotations["autoscaling.knative.dev/minScale"] = fmt.Sprintf("%d", *ctx.DeploymentConfig.App.Autoscale.Min)
+	autoscale := ctx.DeploymentConfig.App.Autoscale
+	if autoscale != nil {
+		if autoscale.Min != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/minScale"] = fmt.Sprintf("%d", *autoscale.Min)
+		}
+		if autoscale.Max != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/maxScale"] = fmt.Sprintf("%d", *autoscale.Max)
 		}
-		if ctx.DeploymentConfig.App.Autoscale.Max != nil {
-			revisionMeta.Annotations["autoscaling.knative.dev/maxScale"] = fmt.Sprintf("%d", *ctx.DeploymentConfig.App.Autoscale.Max)
+		if autoscale.Metric != "" {
+			revisionMeta.Annotations["autoscaling.knative.dev/metric"] = autoscale.Metric
+			// The cpu metric is only supported by the HPA autoscaler
+			if autoscale.Metric == model.AppAutoscaleMetric_CPU {
+				revisionMeta.Annotations["autoscaling.knative.dev/class"] = "hpa.autoscaling.knative.dev"
+			}
+		}
+		if autoscale.Target != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/target"] = formatFloat(*autoscale.Target)
+		}
+		if autoscale.TargetUtilizationPercentage != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/targetUtilizationPercentage"] = formatFloat(*autoscale.TargetUtilizationPercentage)
+		}
+		if autoscale.ScaleDownDelay != "" {
+			revisionMeta.Annotations["autoscaling.knative.dev/scaleDownDelay"] = autoscale.ScaleDownDelay
+		}
+		if autoscale.StableWindow != "" {
+			revisionMeta.Annotations["autoscaling.knative.dev/window"] = autoscale.StableWindow
+		}
+		if autoscale.PanicWindowPercentage != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/panicWindowPercentage"] = formatFloat(*autoscale.PanicWindowPercentage)
+		}
+		if autoscale.InitialScale != nil {
+			revisionMeta.Annotations["autoscaling.knative.dev/initialScale"] = fmt.Sprintf("%d", *autoscale.InitialScale)
 		}
 	}
 
 	return revisionMeta
 }
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -1,12 +1,14 @@
 package resources
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/util"
 	"github.com/stretchr/testify/assert"
+	servingv1 "knative.dev/serving/pkg/apis/serving/v1"
 )
 
 func Test_createRevisionMeta(t *testing.T) {
@@ -54,3 +56,130 @@ func Test_createRevisionMeta_Autoscale(t *testing.T) {
 	assert.Equal(t, "1", result.Annotations["riser.dev/revision"])
 	assert.Equal(t, util.VersionString, result.Annotations["riser.dev/server-version"])
 }
+
+func Test_createRevisionMeta_AutoscaleOptions(t *testing.T) {
+	target := float64(100)
+	targetUtilization := 70.5
+	panicWindow := float64(10)
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Autoscale: &model.AppConfigAutoscale{
+						Metric:                      model.AppAutoscaleMetric_RPS,
+						Target:                      &target,
+						TargetUtilizationPercentage: &targetUtilization,
+						ScaleDownDelay:              "15m",
+						StableWindow:                "90s",
+						PanicWindowPercentage:       &panicWindow,
+						InitialScale:                util.PtrInt(0),
+					},
+				},
+			},
+		},
+		RiserRevision: 1,
+	}
+
+	result := createRevisionMeta(ctx)
+
+	assert.Len(t, result.Annotations, 9)
+	assert.Equal(t, "rps", result.Annotations["autoscaling.knative.dev/metric"])
+	assert.Equal(t, "100", result.Annotations["autoscaling.knative.dev/target"])
+	assert.Equal(t, "70.5", result.Annotations["autoscaling.knative.dev/targetUtilizationPercentage"])
+	assert.Equal(t, "15m", result.Annotations["autoscaling.knative.dev/scaleDownDelay"])
+	assert.Equal(t, "90s", result.Annotations["autoscaling.knative.dev/window"])
+	assert.Equal(t, "10", result.Annotations["autoscaling.knative.dev/panicWindowPercentage"])
+	assert.Equal(t, "0", result.Annotations["autoscaling.knative.dev/initialScale"])
+	assert.NotContains(t, result.Annotations, "autoscaling.knative.dev/class")
+}
+
+func Test_createRevisionMeta_AutoscaleCpuUsesHPA(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name: "myapp",
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Autoscale: &model.AppConfigAutoscale{
+						Metric: model.AppAutoscaleMetric_CPU,
+					},
+				},
+			},
+		},
+		RiserRevision: 1,
+	}
+
+	result := createRevisionMeta(ctx)
+
+	assert.Equal(t, "cpu", result.Annotations["autoscaling.knative.dev/metric"])
+	assert.Equal(t, "hpa.autoscaling.knative.dev", result.Annotations["autoscaling.knative.dev/class"])
+}
+
+func Test_CreateKNativeConfiguration_RequestSettings(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose.ContainerConcurrency = util.PtrInt64(1)
+	ctx.DeploymentConfig.App.Expose.TimeoutSeconds = util.PtrInt64(900)
+
+	result := CreateKNativeConfiguration(ctx)
+
+	assert.EqualValues(t, 1, *result.Spec.Template.Spec.ContainerConcurrency)
+	assert.EqualValues(t, 900, *result.Spec.Template.Spec.TimeoutSeconds)
+}
+
+func Test_CreateKNativeConfiguration_DefaultRequestSettings(t *testing.T) {
+	result := CreateKNativeConfiguration(newTestDeploymentContext(withDeploymentWorkload()))
+
+	assert.Nil(t, result.Spec.Template.Spec.ContainerConcurrency)
+	assert.Nil(t, result.Spec.Template.Spec.TimeoutSeconds)
+}
+
+// The response start timeout is not rendered since the pinned knative serving API does not have it. This fails once knative serving is
+// upgraded so that expose.responseStartTimeoutSeconds and its environment maximum are added then.
+func Test_CreateKNativeConfiguration_ResponseStartTimeoutNotSupported(t *testing.T) {
+	_, found := reflect.TypeOf(servingv1.RevisionSpec{}).FieldByName("ResponseStartTimeoutSeconds")
+
+	assert.False(t, found, "knative serving supports responseStartTimeoutSeconds: add it to AppConfigExpose and render it in CreateKNativeConfiguration")
+}
+
+func Test_CreateKNativeConfiguration_SeccompProfile(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.EnvironmentConfig = &core.EnvironmentConfig{
+		Security: core.EnvironmentSecurity{RunAsNonRoot: true, SeccompProfile: "RuntimeDefault"},
+	}
+
+	result := CreateKNativeConfiguration(ctx)
+
+	assert.Equal(t, "runtime/default", result.Spec.Template.Annotations["seccomp.security.alpha.kubernetes.io/pod"])
+	securityContext := result.Spec.Template.Spec.Containers[0].SecurityContext
+	assert.Nil(t, securityContext.SeccompProfile)
+	assert.True(t, *securityContext.RunAsNonRoot)
+}
+
+func Test_CreateKNativeConfiguration_SeccompProfileOnly(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.EnvironmentConfig = &core.EnvironmentConfig{
+		Security: core.EnvironmentSecurity{SeccompProfile: "RuntimeDefault"},
+	}
+
+	result := CreateKNativeConfiguration(ctx)
+
+	assert.Equal(t, "runtime/default", result.Spec.Template.Annotations["seccomp.security.alpha.kubernetes.io/pod"])
+	assert.Nil(t, result.Spec.Template.Spec.Containers[0].SecurityContext)
+}
+
+func Test_CreateKNativeConfiguration_AdditionalPorts(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose.Ports = map[string]model.AppConfigPort{
+		"metrics": {ContainerPort: 9100},
+	}
+
+	result := CreateKNativeConfiguration(ctx)
+
+	ports := result.Spec.Template.Spec.Containers[0].Ports
+	assert.Len(t, ports, 1)
+	assert.EqualValues(t, 8080, ports[0].ContainerPort)
+}
@@ -0,0 +1,129 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CronJobNameMaxLength is the max length of a CronJob name. Kubernetes appends an 11 character suffix to the name of each Job.
+const CronJobNameMaxLength = 52
+
+// jobCommandScript runs the job's command and then asks the Istio sidecar to exit since a Job does not complete until all of its pod's
+// containers exit. The sidecar is kept so that jobs have the app's mesh identity (e.g. for expose.allowFrom and STRICT mTLS). The command's
+// exit code is preserved and the quit request fails harmlessly when the pod does not have a sidecar.
+const jobCommandScript = `"$@"
+code=$?
+curl -fsS -X POST http://127.0.0.1:15020/quitquitquit > /dev/null 2>&1 || wget -q -O /dev/null --post-data "" http://127.0.0.1:15020/quitquitquit > /dev/null 2>&1
+exit $code`
+
+var concurrencyPolicies = map[string]batchv1.ConcurrencyPolicy{
+	model.AppJobConcurrencyPolicy_Allow:   batchv1.AllowConcurrent,
+	model.AppJobConcurrencyPolicy_Forbid:  batchv1.ForbidConcurrent,
+	model.AppJobConcurrencyPolicy_Replace: batchv1.ReplaceConcurrent,
+}
+
+// CronJobName returns the name of the CronJob for a deployment's job
+func CronJobName(deploymentName, jobName string) string {
+	return fmt.Sprintf("%s-%s", deploymentName, jobName)
+}
+
+// CreateCronJobs creates a CronJob for each of the app's jobs sorted by name. Jobs run the deployment's image with the app's env vars,
+// secrets, files, and resources but without ports or probes.
+func CreateCronJobs(ctx *core.DeploymentContext) []*batchv1.CronJob {
+	jobs := ctx.DeploymentConfig.App.Jobs
+	jobNames := make([]string, 0, len(jobs))
+	for jobName := range jobs {
+		jobNames = append(jobNames, jobName)
+	}
+	sort.Strings(jobNames)
+
+	cronJobs := []*batchv1.CronJob{}
+	for _, jobName := range jobNames {
+		cronJobs = append(cronJobs, createCronJob(ctx, jobName, jobs[jobName]))
+	}
+	return cronJobs
+}
+
+// jobCommand wraps the job's command with jobCommandScript. The image must include /bin/sh and either curl or wget.
+func jobCommand(command []string) []string {
+	return append([]string{"/bin/sh", "-c", jobCommandScript, "riser-job"}, command...)
+}
+
+func createCronJob(ctx *core.DeploymentContext, jobName string, job model.AppConfigJob) *batchv1.CronJob {
+	labels := cronJobLabels(ctx, jobName)
+
+	podSpec := createPodSpec(ctx)
+	podSpec.RestartPolicy = corev1.RestartPolicyNever
+	for idx := range podSpec.Containers {
+		container := &podSpec.Containers[idx]
+		container.Command = jobCommand(job.Command)
+		container.Args = nil
+		container.Ports = nil
+		container.ReadinessProbe = nil
+		container.LivenessProbe = nil
+	}
+
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        CronJobName(ctx.DeploymentConfig.Name, jobName),
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      labels,
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "CronJob",
+			APIVersion: "batch/v1",
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   job.Schedule,
+			ConcurrencyPolicy:          concurrencyPolicies[job.ConcurrencyPolicy],
+			SuccessfulJobsHistoryLimit: job.HistoryLimit,
+			FailedJobsHistoryLimit:     job.HistoryLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels:      cronJobPodLabels(ctx, jobName),
+							Annotations: cronJobPodAnnotations(ctx),
+						},
+						Spec: podSpec,
+					},
+				},
+			},
+		},
+	}
+}
+
+// cronJobLabels include the revision so that the pods of each run can be traced back to the deployment revision that scheduled them
+func cronJobLabels(ctx *core.DeploymentContext, jobName string) map[string]string {
+	labels := deploymentLabels(ctx)
+	labels[riserLabel("job")] = jobName
+	labels[riserLabel("revision")] = strconv.FormatInt(ctx.RiserRevision, 10)
+	return labels
+}
+
+// cronJobPodLabels replace the deployment label with the CronJob's name since the deployment's Services and policies select pods by the
+// deployment label and must not route requests to the job's pods
+func cronJobPodLabels(ctx *core.DeploymentContext, jobName string) map[string]string {
+	labels := cronJobLabels(ctx, jobName)
+	delete(labels, riserLabel("deployment"))
+	labels[riserLabel("cronjob")] = CronJobName(ctx.DeploymentConfig.Name, jobName)
+	return labels
+}
+
+// cronJobPodAnnotations hold the job's container until the Istio sidecar is ready so that the job can reach the mesh as soon as it starts
+func cronJobPodAnnotations(ctx *core.DeploymentContext) map[string]string {
+	annotations := deploymentAnnotations(ctx)
+	annotations["proxy.istio.io/config"] = `{"holdApplicationUntilProxyStarts": true}`
+	return annotations
+}
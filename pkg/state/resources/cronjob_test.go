@@ -0,0 +1,119 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func Test_CronJobName(t *testing.T) {
+	assert.Equal(t, "myapp-cleanup", CronJobName("myapp", "cleanup"))
+}
+
+func Test_CreateCronJobs(t *testing.T) {
+	historyLimit := int32(2)
+	ctx := newTestDeploymentContext(withJobs(map[string]model.AppConfigJob{
+		"report": {Schedule: "@daily", Command: []string{"report"}},
+		"cleanup": {
+			Schedule:          "*/15 * * * *",
+			Command:           []string{"cleanup", "--all"},
+			ConcurrencyPolicy: model.AppJobConcurrencyPolicy_Forbid,
+			HistoryLimit:      &historyLimit,
+		},
+	}))
+
+	result := CreateCronJobs(ctx)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "myapp-report", result[1].Name)
+	cronJob := result[0]
+	expectedLabels := map[string]string{
+		"riser.dev/app":         "myapp",
+		"riser.dev/deployment":  "myapp",
+		"riser.dev/environment": "dev",
+		"riser.dev/job":         "cleanup",
+		"riser.dev/revision":    "3",
+	}
+	assert.Equal(t, "myapp-cleanup", cronJob.Name)
+	assert.Equal(t, "apps", cronJob.Namespace)
+	assert.Equal(t, "CronJob", cronJob.Kind)
+	assert.Equal(t, "batch/v1", cronJob.APIVersion)
+	assert.Equal(t, expectedLabels, cronJob.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), cronJob.Annotations)
+	assert.Equal(t, "*/15 * * * *", cronJob.Spec.Schedule)
+	assert.Equal(t, batchv1.ForbidConcurrent, cronJob.Spec.ConcurrencyPolicy)
+	assert.Equal(t, &historyLimit, cronJob.Spec.SuccessfulJobsHistoryLimit)
+	assert.Equal(t, &historyLimit, cronJob.Spec.FailedJobsHistoryLimit)
+	assert.Equal(t, expectedLabels, cronJob.Spec.JobTemplate.Labels)
+	podTemplate := cronJob.Spec.JobTemplate.Spec.Template
+	assert.Equal(t, map[string]string{
+		"riser.dev/app":         "myapp",
+		"riser.dev/cronjob":     "myapp-cleanup",
+		"riser.dev/environment": "dev",
+		"riser.dev/job":         "cleanup",
+		"riser.dev/revision":    "3",
+	}, podTemplate.Labels)
+	assert.NotContains(t, podTemplate.Annotations, "sidecar.istio.io/inject")
+	assert.Equal(t, `{"holdApplicationUntilProxyStarts": true}`, podTemplate.Annotations["proxy.istio.io/config"])
+	assert.Equal(t, "3", podTemplate.Annotations["riser.dev/revision"])
+	assert.Equal(t, corev1.RestartPolicyNever, podTemplate.Spec.RestartPolicy)
+	require.Len(t, podTemplate.Spec.Containers, 1)
+	container := podTemplate.Spec.Containers[0]
+	assert.Equal(t, "myorg/myapp:0.0.1", container.Image)
+	assert.Equal(t, []string{"/bin/sh", "-c", jobCommandScript, "riser-job", "cleanup", "--all"}, container.Command)
+	assert.Nil(t, container.Args)
+	assert.Nil(t, container.Ports)
+	assert.Nil(t, container.ReadinessProbe)
+	assert.Nil(t, container.LivenessProbe)
+	assert.Equal(t, k8sEnvVars(ctx), container.Env)
+}
+
+func Test_CreateCronJobs_PodsNotSelectedByDeployment(t *testing.T) {
+	ctx := newTestDeploymentContext(withJobs(map[string]model.AppConfigJob{
+		"report": {Schedule: "@daily", Command: []string{"report"}},
+	}))
+
+	result := CreateCronJobs(ctx)
+
+	require.Len(t, result, 1)
+	selector := labels.SelectorFromSet(deploymentSelector(ctx))
+	assert.False(t, selector.Matches(labels.Set(result[0].Spec.JobTemplate.Spec.Template.Labels)))
+}
+
+func Test_CreateCronJobs_Defaults(t *testing.T) {
+	ctx := newTestDeploymentContext(withJobs(map[string]model.AppConfigJob{
+		"report": {Schedule: "@daily", Command: []string{"report"}},
+	}))
+
+	result := CreateCronJobs(ctx)
+
+	require.Len(t, result, 1)
+	assert.Empty(t, result[0].Spec.ConcurrencyPolicy)
+	assert.Nil(t, result[0].Spec.SuccessfulJobsHistoryLimit)
+	assert.Nil(t, result[0].Spec.FailedJobsHistoryLimit)
+}
+
+func Test_CreateCronJobs_NoJobs(t *testing.T) {
+	assert.Empty(t, CreateCronJobs(newTestDeploymentContext(withJobs(nil))))
+}
+
+// withJobs sets the jobs of an app that has args, a health check and ports, which jobs do not use
+func withJobs(jobs map[string]model.AppConfigJob) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		withDeployment("myapp", "apps", "dev")(ctx)
+		withDockerTag("0.0.1")(ctx)
+		withRevision(3)(ctx)
+		app := ctx.DeploymentConfig.App
+		app.Image = "myorg/myapp"
+		app.Args = []string{"serve"}
+		app.HealthCheck = &model.AppConfigHealthCheck{Path: "/health"}
+		app.Expose = &model.AppConfigExpose{ContainerPort: 8080, Protocol: "http"}
+		app.Jobs = jobs
+	}
+}
@@ -0,0 +1,196 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// The port of the Service matches the port used by KNative so that the cluster-local url is the same for either workload
+	servicePort = 80
+	// The cpu utilization targeted by the HPA when autoscale.target is not set
+	defaultCPUUtilizationPercentage = 80
+)
+
+// CreateDeployment creates a Deployment for apps that use the deployment workload. The number of replicas is left to the
+// HorizontalPodAutoscaler.
+func CreateDeployment(ctx *core.DeploymentContext) *appsv1.Deployment {
+	podSpec := createPodSpec(ctx)
+	// Unlike KNative, Kubernetes requires the port on http and tcp probes. Workers may only use exec probes.
+	if expose := ctx.DeploymentConfig.App.Expose; expose != nil {
+		for idx := range podSpec.Containers {
+			setProbePort(podSpec.Containers[idx].ReadinessProbe, expose.ContainerPort)
+			setProbePort(podSpec.Containers[idx].LivenessProbe, expose.ContainerPort)
+		}
+	}
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ctx.DeploymentConfig.Name,
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: deploymentSelector(ctx),
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      deploymentLabels(ctx),
+					Annotations: deploymentAnnotations(ctx),
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+}
+
+// CreateService creates a Service for apps that use the deployment workload. KNative creates its own Service for each Route. Workers do
+// not have a Service.
+func CreateService(ctx *core.DeploymentContext) *corev1.Service {
+	expose := ctx.DeploymentConfig.App.Expose
+	if expose == nil {
+		return nil
+	}
+
+	service := ServiceMeta(ctx)
+	service.Spec = corev1.ServiceSpec{
+		Selector: deploymentSelector(ctx),
+		Ports: append([]corev1.ServicePort{
+			{
+				// Istio uses the port name to select the protocol
+				Name:       expose.Protocol,
+				Protocol:   corev1.ProtocolTCP,
+				Port:       servicePort,
+				TargetPort: intstr.FromInt(int(expose.ContainerPort)),
+			},
+		}, additionalServicePorts(expose)...),
+	}
+	return service
+}
+
+// ServiceMeta returns the Service without a spec. This identifies the Service of an app that no longer exposes a port so that it can
+// be removed.
+func ServiceMeta(ctx *core.DeploymentContext) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ctx.DeploymentConfig.Name,
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+	}
+}
+
+// CreateHorizontalPodAutoscaler scales the Deployment on cpu utilization. The replicas are fixed at autoscale.min when autoscale.max
+// is not set.
+func CreateHorizontalPodAutoscaler(ctx *core.DeploymentContext) *autoscalingv2beta2.HorizontalPodAutoscaler {
+	minReplicas := int32(1)
+	maxReplicas := int32(0)
+	targetUtilization := int32(defaultCPUUtilizationPercentage)
+	if autoscale := ctx.DeploymentConfig.App.Autoscale; autoscale != nil {
+		if autoscale.Min != nil {
+			minReplicas = int32(*autoscale.Min)
+		}
+		if autoscale.Max != nil {
+			maxReplicas = int32(*autoscale.Max)
+		}
+		if autoscale.Target != nil {
+			targetUtilization = int32(*autoscale.Target)
+		}
+	}
+	if maxReplicas < minReplicas {
+		maxReplicas = minReplicas
+	}
+
+	return &autoscalingv2beta2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ctx.DeploymentConfig.Name,
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "HorizontalPodAutoscaler",
+			APIVersion: "autoscaling/v2beta2",
+		},
+		Spec: autoscalingv2beta2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2beta2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       ctx.DeploymentConfig.Name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: maxReplicas,
+			Metrics: []autoscalingv2beta2.MetricSpec{
+				{
+					Type: autoscalingv2beta2.ResourceMetricSourceType,
+					Resource: &autoscalingv2beta2.ResourceMetricSource{
+						Name: corev1.ResourceCPU,
+						Target: autoscalingv2beta2.MetricTarget{
+							Type:               autoscalingv2beta2.UtilizationMetricType,
+							AverageUtilization: &targetUtilization,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// CreatePodDisruptionBudget allows one pod at a time to be evicted (e.g. when draining a node) so that the app stays available when it
+// has more than one replica.
+func CreatePodDisruptionBudget(ctx *core.DeploymentContext) *policyv1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ctx.DeploymentConfig.Name,
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1",
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: deploymentSelector(ctx),
+			},
+		},
+	}
+}
+
+// deploymentSelector selects the pods of a deployment. The selector of a Deployment is immutable so it must never change.
+func deploymentSelector(ctx *core.DeploymentContext) map[string]string {
+	return map[string]string{
+		riserLabel("deployment"): ctx.DeploymentConfig.Name,
+	}
+}
+
+func setProbePort(probe *corev1.Probe, port int32) {
+	if probe == nil {
+		return
+	}
+	if probe.HTTPGet != nil {
+		probe.HTTPGet.Port = intstr.FromInt(int(port))
+	}
+	if probe.TCPSocket != nil {
+		probe.TCPSocket.Port = intstr.FromInt(int(port))
+	}
+}
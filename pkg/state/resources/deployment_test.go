@@ -0,0 +1,180 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2beta2 "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_CreateDeployment(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.HealthCheck = &model.AppConfigHealthCheck{Path: "/health"}
+	ctx.DeploymentConfig.App.Liveness = &model.AppConfigHealthCheck{Mode: model.AppHealthCheckMode_TCP}
+
+	result := CreateDeployment(ctx)
+
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Equal(t, "Deployment", result.Kind)
+	assert.Equal(t, "apps/v1", result.APIVersion)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result.Annotations)
+	assert.Nil(t, result.Spec.Replicas)
+	assert.Equal(t, map[string]string{"riser.dev/deployment": "myapp"}, result.Spec.Selector.MatchLabels)
+	assert.Equal(t, deploymentLabels(ctx), result.Spec.Template.Labels)
+	assert.Equal(t, "3", result.Spec.Template.Annotations["riser.dev/revision"])
+	assert.Equal(t, util.PtrBool(false), result.Spec.Template.Spec.EnableServiceLinks)
+	require.Len(t, result.Spec.Template.Spec.Containers, 1)
+	container := result.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "myorg/myapp:0.0.1", container.Image)
+	assert.Equal(t, intstr.FromInt(8080), container.ReadinessProbe.HTTPGet.Port)
+	assert.Equal(t, intstr.FromInt(8080), container.LivenessProbe.TCPSocket.Port)
+}
+
+func Test_CreateDeployment_Worker(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose = nil
+	ctx.DeploymentConfig.App.HealthCheck = &model.AppConfigHealthCheck{Mode: model.AppHealthCheckMode_Exec, Command: []string{"healthcheck"}}
+
+	result := CreateDeployment(ctx)
+
+	require.Len(t, result.Spec.Template.Spec.Containers, 1)
+	container := result.Spec.Template.Spec.Containers[0]
+	assert.Empty(t, container.Ports)
+	assert.Equal(t, []string{"healthcheck"}, container.ReadinessProbe.Exec.Command)
+}
+
+func Test_CreateService(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+
+	result := CreateService(ctx)
+
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Equal(t, "Service", result.Kind)
+	assert.Equal(t, "v1", result.APIVersion)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, map[string]string{"riser.dev/deployment": "myapp"}, result.Spec.Selector)
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(8080)},
+	}, result.Spec.Ports)
+}
+
+func Test_CreateService_AdditionalPorts(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose.Ports = map[string]model.AppConfigPort{
+		"metrics": {ContainerPort: 9100},
+	}
+
+	result := CreateService(ctx)
+
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "http", Protocol: corev1.ProtocolTCP, Port: 80, TargetPort: intstr.FromInt(8080)},
+		{Name: "http-metrics", Protocol: corev1.ProtocolTCP, Port: 9100, TargetPort: intstr.FromInt(9100)},
+	}, result.Spec.Ports)
+}
+
+func Test_CreateService_Worker(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose = nil
+
+	assert.Nil(t, CreateService(ctx))
+}
+
+func Test_ServiceMeta(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose = nil
+
+	result := ServiceMeta(ctx)
+
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Equal(t, "Service", result.Kind)
+	assert.Equal(t, "v1", result.APIVersion)
+	assert.Empty(t, result.Spec.Ports)
+}
+
+func Test_CreateHorizontalPodAutoscaler(t *testing.T) {
+	target := float64(60)
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{
+		Min:    util.PtrInt(2),
+		Max:    util.PtrInt(5),
+		Target: &target,
+	}
+
+	result := CreateHorizontalPodAutoscaler(ctx)
+
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Equal(t, "HorizontalPodAutoscaler", result.Kind)
+	assert.Equal(t, "autoscaling/v2beta2", result.APIVersion)
+	assert.Equal(t, autoscalingv2beta2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "myapp"}, result.Spec.ScaleTargetRef)
+	assert.Equal(t, util.PtrInt32(2), result.Spec.MinReplicas)
+	assert.EqualValues(t, 5, result.Spec.MaxReplicas)
+	require.Len(t, result.Spec.Metrics, 1)
+	assert.Equal(t, corev1.ResourceCPU, result.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, util.PtrInt32(60), result.Spec.Metrics[0].Resource.Target.AverageUtilization)
+}
+
+func Test_CreateHorizontalPodAutoscaler_Defaults(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+
+	result := CreateHorizontalPodAutoscaler(ctx)
+
+	assert.Equal(t, util.PtrInt32(1), result.Spec.MinReplicas)
+	assert.EqualValues(t, 1, result.Spec.MaxReplicas)
+	assert.Equal(t, util.PtrInt32(80), result.Spec.Metrics[0].Resource.Target.AverageUtilization)
+}
+
+func Test_CreateHorizontalPodAutoscaler_MinOnly(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Autoscale = &model.AppConfigAutoscale{Min: util.PtrInt(3)}
+
+	result := CreateHorizontalPodAutoscaler(ctx)
+
+	assert.Equal(t, util.PtrInt32(3), result.Spec.MinReplicas)
+	assert.EqualValues(t, 3, result.Spec.MaxReplicas)
+}
+
+func Test_CreatePodDisruptionBudget(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+
+	result := CreatePodDisruptionBudget(ctx)
+
+	maxUnavailable := intstr.FromInt(1)
+	assert.Equal(t, "myapp", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Equal(t, "PodDisruptionBudget", result.Kind)
+	assert.Equal(t, "policy/v1", result.APIVersion)
+	assert.Equal(t, &maxUnavailable, result.Spec.MaxUnavailable)
+	assert.Equal(t, map[string]string{"riser.dev/deployment": "myapp"}, result.Spec.Selector.MatchLabels)
+}
+
+func Test_setProbePort_nil(t *testing.T) {
+	assert.NotPanics(t, func() { setProbePort(nil, 8080) })
+}
+
+// withDeploymentWorkload sets up an app that uses the deployment workload in the "apps" namespace and "dev" environment
+func withDeploymentWorkload() testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		withDeployment("myapp", "apps", "dev")(ctx)
+		withDockerTag("0.0.1")(ctx)
+		withRevision(3)(ctx)
+		app := ctx.DeploymentConfig.App
+		app.Workload = model.AppWorkload_Deployment
+		app.Image = "myorg/myapp"
+		app.Expose = &model.AppConfigExpose{
+			ContainerPort: 8080,
+			Protocol:      "http",
+			Scope:         model.AppExposeScope_Cluster,
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type testDeploymentContextOption func(ctx *core.DeploymentContext)
+
+// newTestDeploymentContext returns a context for deploying the app "myapp" in the namespace "myns" to the environment "myenv". Options
+// modify the context for each test.
+func newTestDeploymentContext(opts ...testDeploymentContextOption) *core.DeploymentContext {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name:            "myapp",
+			Namespace:       "myns",
+			EnvironmentName: "myenv",
+			App: &model.AppConfig{
+				Name:      "myapp",
+				Namespace: "myns",
+			},
+		},
+		EnvironmentConfig: &core.EnvironmentConfig{},
+		RiserRevision:     1,
+	}
+	for _, opt := range opts {
+		opt(ctx)
+	}
+	return ctx
+}
+
+// withDeployment sets the deployment's name, namespace and environment
+func withDeployment(name, namespace, envName string) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		ctx.DeploymentConfig.Name = name
+		ctx.DeploymentConfig.Namespace = namespace
+		ctx.DeploymentConfig.EnvironmentName = envName
+		ctx.DeploymentConfig.App.Namespace = model.NamespaceName(namespace)
+	}
+}
+
+func withRevision(riserRevision int64) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		ctx.RiserRevision = riserRevision
+	}
+}
+
+func withDockerTag(tag string) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		ctx.DeploymentConfig.Docker.Tag = tag
+	}
+}
+
+func withApp(fn func(app *model.AppConfig)) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		fn(ctx.DeploymentConfig.App)
+	}
+}
+
+func withEnvironmentConfig(fn func(config *core.EnvironmentConfig)) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		fn(ctx.EnvironmentConfig)
+	}
+}
@@ -0,0 +1,56 @@
+package resources
+
+import (
+	"github.com/riser-platform/riser-server/pkg/core"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	duckv1 "knative.dev/pkg/apis/duck/v1"
+	servingv1beta1 "knative.dev/serving/pkg/apis/serving/v1beta1"
+)
+
+// CreateDomainMappings creates a DomainMapping for each of the app's custom domains. The mapping targets the deployment's route
+// so that custom domains follow the same traffic rules as the default domain.
+func CreateDomainMappings(ctx *core.DeploymentContext) []*servingv1beta1.DomainMapping {
+	if ctx.DeploymentConfig.App.Expose == nil {
+		return nil
+	}
+
+	mappings := []*servingv1beta1.DomainMapping{}
+	for _, domain := range ctx.DeploymentConfig.App.Expose.Domains {
+		mapping := &servingv1beta1.DomainMapping{
+			ObjectMeta: metav1.ObjectMeta{
+				// DomainMappings must be named after the domain
+				Name:        domain,
+				Namespace:   ctx.DeploymentConfig.Namespace,
+				Labels:      deploymentLabels(ctx),
+				Annotations: deploymentAnnotations(ctx),
+			},
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "DomainMapping",
+				APIVersion: "serving.knative.dev/v1beta1",
+			},
+			Spec: servingv1beta1.DomainMappingSpec{
+				Ref: duckv1.KReference{
+					Kind:       "Route",
+					Name:       ctx.DeploymentConfig.Name,
+					APIVersion: "serving.knative.dev/v1",
+				},
+			},
+		}
+		if tlsEnabled(ctx) {
+			mapping.Spec.TLS = &servingv1beta1.SecretTLS{
+				SecretName: domainTLSSecretName(domain),
+			}
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings
+}
+
+func tlsEnabled(ctx *core.DeploymentContext) bool {
+	return ctx.EnvironmentConfig != nil && ctx.EnvironmentConfig.TLSEnabled()
+}
+
+func domainTLSSecretName(domain string) string {
+	return domain + "-tls"
+}
@@ -0,0 +1,85 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CreateDomainMappings(t *testing.T) {
+	ctx := newTestDeploymentContext(withDomains("myapp.example.com", "www.myapp.example.com"))
+
+	result := CreateDomainMappings(ctx)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "myapp.example.com", result[0].Name)
+	assert.Equal(t, "myns", result[0].Namespace)
+	assert.Equal(t, deploymentLabels(ctx), result[0].Labels)
+	assert.Equal(t, deploymentAnnotations(ctx), result[0].Annotations)
+	assert.Equal(t, "DomainMapping", result[0].Kind)
+	assert.Equal(t, "serving.knative.dev/v1beta1", result[0].APIVersion)
+	assert.Equal(t, "Route", result[0].Spec.Ref.Kind)
+	assert.Equal(t, "myapp", result[0].Spec.Ref.Name)
+	assert.Equal(t, "serving.knative.dev/v1", result[0].Spec.Ref.APIVersion)
+	assert.Nil(t, result[0].Spec.TLS)
+	assert.Equal(t, "www.myapp.example.com", result[1].Name)
+}
+
+func Test_CreateDomainMappings_TLS(t *testing.T) {
+	ctx := newTestDeploymentContext(withDomains("myapp.example.com"), withTLSClusterIssuer("letsencrypt"))
+
+	result := CreateDomainMappings(ctx)
+
+	require.Len(t, result, 1)
+	require.NotNil(t, result[0].Spec.TLS)
+	assert.Equal(t, "myapp.example.com-tls", result[0].Spec.TLS.SecretName)
+}
+
+func Test_CreateDomainMappings_NoDomains(t *testing.T) {
+	ctx := newTestDeploymentContext(withDomains(), withTLSClusterIssuer("letsencrypt"))
+
+	result := CreateDomainMappings(ctx)
+
+	assert.Empty(t, result)
+}
+
+func Test_CreateDomainCertificates(t *testing.T) {
+	ctx := newTestDeploymentContext(withDomains("myapp.example.com"), withTLSClusterIssuer("letsencrypt"))
+
+	result := CreateDomainCertificates(ctx)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, "myapp.example.com", result[0].Name)
+	assert.Equal(t, "myns", result[0].Namespace)
+	assert.Equal(t, "Certificate", result[0].Kind)
+	assert.Equal(t, "cert-manager.io/v1", result[0].APIVersion)
+	assert.Equal(t, "myapp.example.com-tls", result[0].Spec.SecretName)
+	assert.Equal(t, []string{"myapp.example.com"}, result[0].Spec.DNSNames)
+	assert.Equal(t, CertificateIssuerRef{Name: "letsencrypt", Kind: "ClusterIssuer"}, result[0].Spec.IssuerRef)
+}
+
+func Test_CreateDomainCertificates_TLSDisabled(t *testing.T) {
+	ctx := newTestDeploymentContext(withDomains("myapp.example.com"))
+
+	result := CreateDomainCertificates(ctx)
+
+	assert.Empty(t, result)
+}
+
+func withDomains(domains ...string) testDeploymentContextOption {
+	return withApp(func(app *model.AppConfig) {
+		app.Expose = &model.AppConfigExpose{
+			ContainerPort: 8080,
+			Domains:       domains,
+		}
+	})
+}
+
+func withTLSClusterIssuer(tlsClusterIssuer string) testDeploymentContextOption {
+	return withEnvironmentConfig(func(config *core.EnvironmentConfig) {
+		config.TLSClusterIssuer = tlsClusterIssuer
+	})
+}
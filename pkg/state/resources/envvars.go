@@ -5,19 +5,23 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/riser-platform/riser-server/api/v1/model"
 	"github.com/riser-platform/riser-server/pkg/util"
 
 	"github.com/riser-platform/riser-server/pkg/core"
 	corev1 "k8s.io/api/core/v1"
 )
 
+// clusterDomain is the DNS domain of the cluster used for cluster-local addresses
+const clusterDomain = "cluster.local"
+
 func k8sEnvVars(ctx *core.DeploymentContext) []corev1.EnvVar {
 	envVars := []corev1.EnvVar{}
 	// User defined  vars
 	for key, val := range ctx.DeploymentConfig.App.Environment {
 		envVars = append(envVars, corev1.EnvVar{
 			Name:  strings.ToUpper(key),
-			Value: val.String(),
+			Value: resolveAppReferences(ctx, val.String()),
 		})
 	}
 
@@ -27,10 +31,10 @@ func k8sEnvVars(ctx *core.DeploymentContext) []corev1.EnvVar {
 			Name: strings.ToUpper(secret.Name),
 			ValueFrom: &corev1.EnvVarSource{
 				SecretKeyRef: &corev1.SecretKeySelector{
-					Key:      "data",
+					Key:      secretDataKey,
 					Optional: util.PtrBool(false),
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: fmt.Sprintf("%s-%s-%d", ctx.DeploymentConfig.App.Name, secret.Name, secret.Revision),
+						Name: secretResourceName(string(ctx.DeploymentConfig.App.Name), secret.Name, secret.Revision),
 					},
 				},
 			},
@@ -39,6 +43,20 @@ func k8sEnvVars(ctx *core.DeploymentContext) []corev1.EnvVar {
 		envVars = append(envVars, secretEnv)
 	}
 
+	// Namespace vars. The app's own vars take precedence.
+	if ctx.NamespaceConfig != nil {
+		appEnvVarNames := map[string]bool{}
+		for _, envVar := range envVars {
+			appEnvVarNames[envVar.Name] = true
+		}
+		for key, val := range ctx.NamespaceConfig.Doc.Environment {
+			name := strings.ToUpper(key)
+			if !appEnvVarNames[name] {
+				envVars = append(envVars, corev1.EnvVar{Name: name, Value: val.String()})
+			}
+		}
+	}
+
 	// Platform vars
 	envVars = append(envVars,
 		corev1.EnvVar{Name: "RISER_APP", Value: string(ctx.DeploymentConfig.App.Name)},
@@ -50,6 +68,33 @@ func k8sEnvVars(ctx *core.DeploymentContext) []corev1.EnvVar {
 	return envVars
 }
 
+// resolveAppReferences replaces each app reference (e.g. "${app:checkout.apps.url}") with the address of the referenced deployment in the
+// same environment
+func resolveAppReferences(ctx *core.DeploymentContext, value string) string {
+	references, err := model.ParseAppReferences(value, ctx.DeploymentConfig.Namespace)
+	if err != nil {
+		// Invalid references are rejected when the app config is validated
+		return value
+	}
+	for _, reference := range references {
+		value = strings.Replace(value, reference.Expression, appReferenceValue(ctx.EnvironmentConfig, reference), -1)
+	}
+	return value
+}
+
+func appReferenceValue(environmentConfig *core.EnvironmentConfig, reference model.AppReference) string {
+	host := fmt.Sprintf("%s.%s.svc.%s", reference.Name, reference.Namespace, clusterDomain)
+	switch reference.Field {
+	case model.AppReferenceField_URL:
+		return fmt.Sprintf("http://%s", host)
+	case model.AppReferenceField_ExternalHost:
+		return fmt.Sprintf("%s.%s.%s", reference.Name, reference.Namespace, environmentConfig.PublicGatewayHost)
+	case model.AppReferenceField_ExternalURL:
+		return fmt.Sprintf("https://%s.%s.%s", reference.Name, reference.Namespace, environmentConfig.PublicGatewayHost)
+	}
+	return host
+}
+
 type envVarSorter struct {
 	items []corev1.EnvVar
 }
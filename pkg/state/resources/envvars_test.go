@@ -59,3 +59,79 @@ func Test_k8sEnvVars(t *testing.T) {
 	assert.Equal(t, "SECRET2", result[8].Name)
 	assert.Equal(t, "myapp-secret2-1", result[8].ValueFrom.SecretKeyRef.LocalObjectReference.Name)
 }
+
+func Test_k8sEnvVars_AppReferences(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:      "myapp",
+		Namespace: "myns",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Environment: map[string]intstr.IntOrString{
+					"CHECKOUT_URL":  intstr.Parse("${app:checkout.apps.url}/api"),
+					"CHECKOUT_HOST": intstr.Parse("${app:checkout.apps.host}"),
+					"PUBLIC_URL":    intstr.Parse("${app:myapp.externalUrl}"),
+					"PUBLIC_HOST":   intstr.Parse("${app:myapp.externalHost}"),
+					"INVALID":       intstr.Parse("${app:checkout}"),
+				},
+			},
+		},
+	}
+	deploymentCtx := &core.DeploymentContext{
+		DeploymentConfig:  deployment,
+		EnvironmentConfig: &core.EnvironmentConfig{PublicGatewayHost: "dev.example.com"},
+	}
+
+	result := k8sEnvVars(deploymentCtx)
+
+	assert.Equal(t, "CHECKOUT_HOST", result[0].Name)
+	assert.Equal(t, "checkout.apps.svc.cluster.local", result[0].Value)
+	assert.Equal(t, "CHECKOUT_URL", result[1].Name)
+	assert.Equal(t, "http://checkout.apps.svc.cluster.local/api", result[1].Value)
+	assert.Equal(t, "INVALID", result[2].Name)
+	assert.Equal(t, "${app:checkout}", result[2].Value)
+	assert.Equal(t, "PUBLIC_HOST", result[3].Name)
+	assert.Equal(t, "myapp.myns.dev.example.com", result[3].Value)
+	assert.Equal(t, "PUBLIC_URL", result[4].Name)
+	assert.Equal(t, "https://myapp.myns.dev.example.com", result[4].Value)
+}
+
+func Test_k8sEnvVars_NamespaceConfig(t *testing.T) {
+	deployment := &core.DeploymentConfig{
+		Name:            "myapp",
+		Namespace:       "myns",
+		EnvironmentName: "myenv",
+		App: &model.AppConfig{
+			Name: "myapp",
+			OverrideableAppConfig: model.OverrideableAppConfig{
+				Environment: map[string]intstr.IntOrString{
+					"LOG_LEVEL": intstr.Parse("debug"),
+				},
+			},
+		},
+	}
+	deploymentCtx := &core.DeploymentContext{
+		DeploymentConfig: deployment,
+		Secrets:          []core.SecretMeta{{Name: "tracing_token", Revision: 1}},
+		NamespaceConfig: &core.NamespaceConfig{
+			Doc: core.NamespaceConfigDoc{
+				Environment: map[string]intstr.IntOrString{
+					"LOG_LEVEL":     intstr.Parse("info"),
+					"TRACING_URL":   intstr.Parse("http://tracing"),
+					"TRACING_TOKEN": intstr.Parse("shouldNotBeUsed"),
+				},
+			},
+		},
+	}
+
+	result := k8sEnvVars(deploymentCtx)
+
+	assert.Len(t, result, 8)
+	assert.Equal(t, "LOG_LEVEL", result[0].Name)
+	assert.Equal(t, "debug", result[0].Value)
+	assert.Equal(t, "TRACING_TOKEN", result[6].Name)
+	assert.Empty(t, result[6].Value)
+	assert.NotNil(t, result[6].ValueFrom)
+	assert.Equal(t, "TRACING_URL", result[7].Name)
+	assert.Equal(t, "http://tracing", result[7].Value)
+}
@@ -15,23 +15,35 @@ import (
 func createPodSpec(ctx *core.DeploymentContext) corev1.PodSpec {
 	return corev1.PodSpec{
 		EnableServiceLinks: util.PtrBool(false),
+		ServiceAccountName: ServiceAccountName(ctx),
+		Volumes:            append(filesVolumes(ctx), secretVolumes(ctx)...),
 		Containers: []corev1.Container{
 			{
-				Name:           ctx.DeploymentConfig.Name,
-				Image:          fmt.Sprintf("%s:%s", ctx.DeploymentConfig.App.Image, ctx.DeploymentConfig.Docker.Tag),
-				Resources:      resources(ctx.DeploymentConfig.App),
-				ReadinessProbe: readinessProbe(ctx.DeploymentConfig.App),
-				Env:            k8sEnvVars(ctx),
-				Ports:          createPodPorts(ctx.DeploymentConfig.App.Expose),
+				Name:            ctx.DeploymentConfig.Name,
+				Image:           fmt.Sprintf("%s:%s", ctx.DeploymentConfig.App.Image, ctx.DeploymentConfig.Docker.Tag),
+				Command:         ctx.DeploymentConfig.App.Command,
+				Args:            ctx.DeploymentConfig.App.Args,
+				WorkingDir:      ctx.DeploymentConfig.App.WorkingDir,
+				Resources:       resources(ctx),
+				ReadinessProbe:  readinessProbe(ctx.DeploymentConfig.App),
+				LivenessProbe:   livenessProbe(ctx.DeploymentConfig.App),
+				Env:             k8sEnvVars(ctx),
+				Ports:           createPodPorts(ctx.DeploymentConfig.App.Expose),
+				VolumeMounts:    append(filesVolumeMounts(ctx), secretVolumeMounts(ctx)...),
+				SecurityContext: securityContext(ctx),
 			},
 		},
 	}
 }
 
+// createPodPorts returns the primary port followed by any additional ports. Returns nil for workers since they do not expose a port.
 func createPodPorts(expose *model.AppConfigExpose) []corev1.ContainerPort {
+	if expose == nil {
+		return nil
+	}
 	containerPortName := ""
 	// See https://github.com/knative/serving/blob/master/docs/runtime-contract.md#protocols-and-ports
-	if expose.Protocol == "http2" {
+	if expose.Protocol == model.AppExposeProtocol_HTTP2 || expose.Protocol == model.AppExposeProtocol_GRPC {
 		containerPortName = "h2c"
 	}
 	ports := []corev1.ContainerPort{
@@ -41,35 +53,132 @@ func createPodPorts(expose *model.AppConfigExpose) []corev1.ContainerPort {
 			Name:          containerPortName,
 		},
 	}
+	for _, name := range expose.PortNames() {
+		ports = append(ports, corev1.ContainerPort{
+			Protocol:      corev1.ProtocolTCP,
+			ContainerPort: expose.Ports[name].ContainerPort,
+			Name:          name,
+		})
+	}
 	return ports
 }
 
 func readinessProbe(appConfig *model.AppConfig) *corev1.Probe {
-	if appConfig.HealthCheck == nil {
+	return createProbe(appConfig.HealthCheck, appConfig.Expose)
+}
+
+func livenessProbe(appConfig *model.AppConfig) *corev1.Probe {
+	return createProbe(appConfig.Liveness, appConfig.Expose)
+}
+
+// createProbe does not set the port on http and tcp probes since KNative does not allow it and always uses the container port.
+func createProbe(healthCheck *model.AppConfigHealthCheck, expose *model.AppConfigExpose) *corev1.Probe {
+	if healthCheck == nil {
 		return nil
 	}
 
 	probe := &corev1.Probe{
-		Handler: corev1.Handler{
-			HTTPGet: &corev1.HTTPGetAction{
-				Path: appConfig.HealthCheck.Path,
-			},
-		},
+		InitialDelaySeconds: derefInt32(healthCheck.InitialDelaySeconds),
+		PeriodSeconds:       derefInt32(healthCheck.PeriodSeconds),
+		TimeoutSeconds:      derefInt32(healthCheck.TimeoutSeconds),
+		FailureThreshold:    derefInt32(healthCheck.FailureThreshold),
+	}
+
+	switch healthCheck.Mode {
+	case model.AppHealthCheckMode_TCP:
+		probe.TCPSocket = &corev1.TCPSocketAction{}
+	case model.AppHealthCheckMode_GRPC:
+		probe.Exec = &corev1.ExecAction{Command: grpcHealthProbeCommand(healthCheck, expose)}
+	case model.AppHealthCheckMode_Exec:
+		probe.Exec = &corev1.ExecAction{Command: healthCheck.Command}
+	default:
+		probe.HTTPGet = &corev1.HTTPGetAction{
+			Path: healthCheck.HTTPPath(),
+		}
 	}
 
 	return probe
 }
 
-func resources(appConfig *model.AppConfig) corev1.ResourceRequirements {
-	res := corev1.ResourceRequirements{}
-	if appConfig.Resources != nil {
-		res.Limits = corev1.ResourceList{}
-		if appConfig.Resources.CpuCores != nil {
-			res.Limits[corev1.ResourceCPU] = *resource.NewScaledQuantity(int64(*appConfig.Resources.CpuCores*float32(1000)), resource.Milli)
+// grpcHealthProbeCommand uses the grpc_health_probe binary (https://github.com/grpc-ecosystem/grpc-health-probe) since our
+// version of Kubernetes does not support native gRPC probes. The binary must be present in the app's image.
+func grpcHealthProbeCommand(healthCheck *model.AppConfigHealthCheck, expose *model.AppConfigExpose) []string {
+	command := []string{"grpc_health_probe"}
+	if expose != nil {
+		command = append(command, fmt.Sprintf("-addr=:%d", expose.ContainerPort))
+	}
+	if healthCheck.GRPCService != "" {
+		command = append(command, fmt.Sprintf("-service=%s", healthCheck.GRPCService))
+	}
+	return command
+}
+
+func derefInt32(value *int32) int32 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// securityContext applies the environment's security settings that the app has not opted out of. Opt-outs that the environment does not
+// allow are rejected before deploying. Returns nil when no settings apply.
+func securityContext(ctx *core.DeploymentContext) *corev1.SecurityContext {
+	if ctx.EnvironmentConfig == nil {
+		return nil
+	}
+	security := ctx.EnvironmentConfig.Security
+	app := ctx.DeploymentConfig.App
+
+	out := &corev1.SecurityContext{}
+	if security.Applies(model.SecuritySetting_RunAsNonRoot, app) {
+		out.RunAsNonRoot = util.PtrBool(true)
+	}
+	if security.Applies(model.SecuritySetting_ReadOnlyRootFilesystem, app) {
+		out.ReadOnlyRootFilesystem = util.PtrBool(true)
+	}
+	if security.Applies(model.SecuritySetting_DropCapabilities, app) {
+		out.Capabilities = &corev1.Capabilities{}
+		for _, capability := range security.DropCapabilities {
+			out.Capabilities.Drop = append(out.Capabilities.Drop, corev1.Capability(capability))
 		}
-		if appConfig.Resources.MemoryMB != nil {
-			res.Limits[corev1.ResourceMemory] = *resource.NewScaledQuantity(int64(*appConfig.Resources.MemoryMB), resource.Mega)
+	}
+	if security.Applies(model.SecuritySetting_SeccompProfile, app) {
+		out.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileType(security.SeccompProfile)}
+	}
+
+	if *out == (corev1.SecurityContext{}) {
+		return nil
+	}
+	return out
+}
+
+func resources(ctx *core.DeploymentContext) corev1.ResourceRequirements {
+	appResources := ctx.DeploymentConfig.App.Resources
+	if ctx.EnvironmentConfig != nil {
+		appResources = ctx.EnvironmentConfig.Resources.ApplyDefaults(appResources)
+	}
+
+	res := corev1.ResourceRequirements{}
+	if appResources != nil {
+		res.Limits = resourceList(appResources.CpuCores, appResources.MemoryMB)
+		if appResources.Requests != nil {
+			res.Requests = resourceList(appResources.Requests.CpuCores, appResources.Requests.MemoryMB)
 		}
 	}
 	return res
 }
+
+// resourceList returns nil when neither cpu nor memory are set so that we don't render empty resource lists
+func resourceList(cpuCores *float32, memoryMB *int32) corev1.ResourceList {
+	if cpuCores == nil && memoryMB == nil {
+		return nil
+	}
+	list := corev1.ResourceList{}
+	if cpuCores != nil {
+		list[corev1.ResourceCPU] = *resource.NewScaledQuantity(int64(*cpuCores*float32(1000)), resource.Milli)
+	}
+	if memoryMB != nil {
+		list[corev1.ResourceMemory] = *resource.NewScaledQuantity(int64(*memoryMB), resource.Mega)
+	}
+	return list
+}
@@ -3,17 +3,51 @@ package resources
 import (
 	"testing"
 
+	"github.com/riser-platform/riser-server/pkg/core"
 	"github.com/riser-platform/riser-server/pkg/util"
 
 	"github.com/riser-platform/riser-server/api/v1/model"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	corev1 "k8s.io/api/core/v1"
 )
 
 // Basic podspec tests are covered in knativeservice_test.go.
 
+func Test_createPodSpec_command(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			Name: "myapp",
+			App: &model.AppConfig{
+				Name: "myapp",
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Expose:     &model.AppConfigExpose{ContainerPort: 8080},
+					Command:    []string{"/bin/myapp"},
+					Args:       []string{"serve"},
+					WorkingDir: "/opt/myapp",
+				},
+			},
+		},
+	}
+
+	result := createPodSpec(ctx)
+
+	assert.Equal(t, []string{"/bin/myapp"}, result.Containers[0].Command)
+	assert.Equal(t, []string{"serve"}, result.Containers[0].Args)
+	assert.Equal(t, "/opt/myapp", result.Containers[0].WorkingDir)
+}
+
+func Test_createPodSpec_serviceAccountName(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.Name = "myapp-canary"
+
+	result := createPodSpec(ctx)
+
+	assert.Equal(t, "riser-myapp", result.ServiceAccountName)
+}
+
 func Test_readinessProbe_nilDeploy(t *testing.T) {
 	app := &model.AppConfig{}
 
@@ -32,8 +66,10 @@ func Test_readinessProbe_nilHealth(t *testing.T) {
 
 func Test_readinessProbe_httpGet(t *testing.T) {
 	app := &model.AppConfig{
-		HealthCheck: &model.AppConfigHealthCheck{
-			Path: "/health",
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			HealthCheck: &model.AppConfigHealthCheck{
+				Path: "/health",
+			},
 		},
 	}
 
@@ -44,20 +80,198 @@ func Test_readinessProbe_httpGet(t *testing.T) {
 	assert.Empty(t, result.HTTPGet.Port)
 }
 
-func Test_resources(t *testing.T) {
+func Test_readinessProbe_httpGetWithoutLeadingSlash(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			HealthCheck: &model.AppConfigHealthCheck{
+				Path: "health",
+			},
+		},
+	}
+
+	result := readinessProbe(app)
+
+	assert.Equal(t, "/health", result.HTTPGet.Path)
+}
+
+func Test_readinessProbe_tcp(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			HealthCheck: &model.AppConfigHealthCheck{
+				Mode: model.AppHealthCheckMode_TCP,
+			},
+		},
+	}
+
+	result := readinessProbe(app)
+
+	assert.Nil(t, result.HTTPGet)
+	require.NotNil(t, result.TCPSocket)
+	// KNative does not allow setting the port on a probe
+	assert.Empty(t, result.TCPSocket.Port)
+}
+
+func Test_readinessProbe_grpc(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			HealthCheck: &model.AppConfigHealthCheck{
+				Mode:        model.AppHealthCheckMode_GRPC,
+				GRPCService: "myservice",
+			},
+			Expose: &model.AppConfigExpose{
+				ContainerPort: 9000,
+			},
+		},
+	}
+
+	result := readinessProbe(app)
+
+	assert.Nil(t, result.HTTPGet)
+	require.NotNil(t, result.Exec)
+	assert.Equal(t, []string{"grpc_health_probe", "-addr=:9000", "-service=myservice"}, result.Exec.Command)
+}
+
+func Test_readinessProbe_exec(t *testing.T) {
 	app := &model.AppConfig{
 		OverrideableAppConfig: model.OverrideableAppConfig{
-			Resources: &model.AppConfigResources{
-				CpuCores: util.PtrFloat32(1.5),
-				MemoryMB: util.PtrInt32(4096),
+			HealthCheck: &model.AppConfigHealthCheck{
+				Mode:    model.AppHealthCheckMode_Exec,
+				Command: []string{"cat", "/tmp/healthy"},
 			},
 		},
 	}
 
-	result := resources(app)
+	result := readinessProbe(app)
+
+	assert.Nil(t, result.HTTPGet)
+	require.NotNil(t, result.Exec)
+	assert.Equal(t, []string{"cat", "/tmp/healthy"}, result.Exec.Command)
+}
+
+func Test_readinessProbe_thresholds(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			HealthCheck: &model.AppConfigHealthCheck{
+				Path:                "/health",
+				InitialDelaySeconds: util.PtrInt32(5),
+				PeriodSeconds:       util.PtrInt32(10),
+				TimeoutSeconds:      util.PtrInt32(2),
+				FailureThreshold:    util.PtrInt32(3),
+			},
+		},
+	}
+
+	result := readinessProbe(app)
+
+	assert.EqualValues(t, 5, result.InitialDelaySeconds)
+	assert.EqualValues(t, 10, result.PeriodSeconds)
+	assert.EqualValues(t, 2, result.TimeoutSeconds)
+	assert.EqualValues(t, 3, result.FailureThreshold)
+}
+
+func Test_livenessProbe(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			Liveness: &model.AppConfigHealthCheck{
+				Path:             "/live",
+				FailureThreshold: util.PtrInt32(5),
+			},
+			HealthCheck: &model.AppConfigHealthCheck{
+				Path: "/ready",
+			},
+		},
+	}
+
+	result := livenessProbe(app)
+
+	assert.Equal(t, "/live", result.HTTPGet.Path)
+	assert.EqualValues(t, 5, result.FailureThreshold)
+}
+
+func Test_livenessProbe_nilLiveness(t *testing.T) {
+	app := &model.AppConfig{
+		OverrideableAppConfig: model.OverrideableAppConfig{
+			HealthCheck: &model.AppConfigHealthCheck{
+				Path: "/ready",
+			},
+		},
+	}
+
+	result := livenessProbe(app)
+
+	assert.Nil(t, result)
+}
+
+func Test_resources(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			App: &model.AppConfig{
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Resources: &model.AppConfigResources{
+						CpuCores: util.PtrFloat32(1.5),
+						MemoryMB: util.PtrInt32(4096),
+						Requests: &model.ResourceQuantities{
+							CpuCores: util.PtrFloat32(0.5),
+							MemoryMB: util.PtrInt32(1024),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result := resources(ctx)
 
 	assert.EqualValues(t, 1500, result.Limits.Cpu().MilliValue(), "millicores")
 	assert.EqualValues(t, 4096000000, result.Limits.Memory().Value(), "bytes")
+	assert.EqualValues(t, 500, result.Requests.Cpu().MilliValue(), "millicores")
+	assert.EqualValues(t, 1024000000, result.Requests.Memory().Value(), "bytes")
+}
+
+func Test_resources_EnvironmentDefaults(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			App: &model.AppConfig{
+				OverrideableAppConfig: model.OverrideableAppConfig{
+					Resources: &model.AppConfigResources{
+						MemoryMB: util.PtrInt32(512),
+					},
+				},
+			},
+		},
+		EnvironmentConfig: &core.EnvironmentConfig{
+			Resources: core.EnvironmentResources{
+				DefaultRequests: core.ResourceQuantities{
+					CpuCores: util.PtrFloat32(0.25),
+					MemoryMB: util.PtrInt32(256),
+				},
+				DefaultLimits: core.ResourceQuantities{
+					CpuCores: util.PtrFloat32(1),
+				},
+			},
+		},
+	}
+
+	result := resources(ctx)
+
+	assert.EqualValues(t, 1000, result.Limits.Cpu().MilliValue(), "millicores")
+	assert.EqualValues(t, 512000000, result.Limits.Memory().Value(), "bytes")
+	assert.EqualValues(t, 250, result.Requests.Cpu().MilliValue(), "millicores")
+	assert.EqualValues(t, 256000000, result.Requests.Memory().Value(), "bytes")
+}
+
+func Test_resources_None(t *testing.T) {
+	ctx := &core.DeploymentContext{
+		DeploymentConfig: &core.DeploymentConfig{
+			App: &model.AppConfig{},
+		},
+		EnvironmentConfig: &core.EnvironmentConfig{},
+	}
+
+	result := resources(ctx)
+
+	assert.Nil(t, result.Limits)
+	assert.Nil(t, result.Requests)
 }
 
 func Test_createPodPorts_http(t *testing.T) {
@@ -74,6 +288,10 @@ func Test_createPodPorts_http(t *testing.T) {
 	assert.Empty(t, result[0].Name)
 }
 
+func Test_createPodPorts_worker(t *testing.T) {
+	assert.Nil(t, createPodPorts(nil))
+}
+
 func Test_createPodPorts_http2(t *testing.T) {
 	expose := &model.AppConfigExpose{
 		Protocol:      "http2",
@@ -87,3 +305,83 @@ func Test_createPodPorts_http2(t *testing.T) {
 	assert.Equal(t, corev1.ProtocolTCP, result[0].Protocol)
 	assert.Equal(t, "h2c", result[0].Name)
 }
+
+func Test_createPodPorts_grpc(t *testing.T) {
+	expose := &model.AppConfigExpose{
+		Protocol:      "grpc",
+		ContainerPort: 80,
+	}
+
+	result := createPodPorts(expose)
+
+	assert.Len(t, result, 1)
+	assert.Equal(t, "h2c", result[0].Name)
+}
+
+func Test_createPodPorts_additionalPorts(t *testing.T) {
+	expose := &model.AppConfigExpose{
+		Protocol:      "http",
+		ContainerPort: 80,
+		Ports: map[string]model.AppConfigPort{
+			"metrics": {ContainerPort: 9100},
+			"admin":   {ContainerPort: 9000, Protocol: "grpc"},
+		},
+	}
+
+	result := createPodPorts(expose)
+
+	assert.Equal(t, []corev1.ContainerPort{
+		{Protocol: corev1.ProtocolTCP, ContainerPort: 80},
+		{Protocol: corev1.ProtocolTCP, ContainerPort: 9000, Name: "admin"},
+		{Protocol: corev1.ProtocolTCP, ContainerPort: 9100, Name: "metrics"},
+	}, result)
+}
+
+func Test_securityContext(t *testing.T) {
+	ctx := newTestDeploymentContext(withSecurity())
+
+	result := securityContext(ctx)
+
+	assert.Equal(t, &corev1.SecurityContext{
+		RunAsNonRoot:           util.PtrBool(true),
+		ReadOnlyRootFilesystem: util.PtrBool(true),
+		Capabilities:           &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		SeccompProfile:         &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}, result)
+}
+
+func Test_securityContext_OptOut(t *testing.T) {
+	ctx := newTestDeploymentContext(withSecurity(model.SecuritySetting_ReadOnlyRootFilesystem, model.SecuritySetting_SeccompProfile))
+
+	result := securityContext(ctx)
+
+	assert.Equal(t, &corev1.SecurityContext{
+		RunAsNonRoot: util.PtrBool(true),
+		Capabilities: &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+	}, result)
+}
+
+func Test_securityContext_None(t *testing.T) {
+	ctx := newTestDeploymentContext(withSecurity())
+	ctx.EnvironmentConfig.Security = core.EnvironmentSecurity{}
+
+	assert.Nil(t, securityContext(ctx))
+
+	ctx.EnvironmentConfig = nil
+
+	assert.Nil(t, securityContext(ctx))
+}
+
+// withSecurity sets the app's security opt-outs in an environment that enables every security setting
+func withSecurity(optOut ...string) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		ctx.DeploymentConfig.App.Security = &model.AppConfigSecurity{OptOut: optOut}
+		ctx.EnvironmentConfig.Security = core.EnvironmentSecurity{
+			RunAsNonRoot:           true,
+			ReadOnlyRootFilesystem: true,
+			DropCapabilities:       []string{"ALL"},
+			SeccompProfile:         "RuntimeDefault",
+			AllowedOptOuts:         []string{model.SecuritySetting_ReadOnlyRootFilesystem, model.SecuritySetting_SeccompProfile},
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// KNativePortsServiceName returns the name of the Service for the additional ports of an app that uses the knative workload
+func KNativePortsServiceName(deploymentName string) string {
+	return fmt.Sprintf("%s-ports", deploymentName)
+}
+
+// CreateKNativePortsService creates a Service for the additional ports of an app that uses the knative workload. KNative only allows
+// the primary port so the additional ports are targeted by number instead of by name. Returns nil when there are no additional ports.
+func CreateKNativePortsService(ctx *core.DeploymentContext) *corev1.Service {
+	expose := ctx.DeploymentConfig.App.Expose
+	if expose == nil || len(expose.Ports) == 0 {
+		return nil
+	}
+
+	service := KNativePortsServiceMeta(ctx)
+	service.Spec = corev1.ServiceSpec{
+		Selector: deploymentSelector(ctx),
+		Ports:    additionalServicePorts(expose),
+	}
+	return service
+}
+
+// KNativePortsServiceMeta returns the Service for additional ports without a spec. This identifies the Service of an app that no longer
+// has additional ports so that it can be removed.
+func KNativePortsServiceMeta(ctx *core.DeploymentContext) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        KNativePortsServiceName(ctx.DeploymentConfig.Name),
+			Namespace:   ctx.DeploymentConfig.Namespace,
+			Labels:      deploymentLabels(ctx),
+			Annotations: deploymentAnnotations(ctx),
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+	}
+}
+
+// additionalServicePorts returns a Service port for each additional port. The Service port is the same as the container port.
+func additionalServicePorts(expose *model.AppConfigExpose) []corev1.ServicePort {
+	ports := []corev1.ServicePort{}
+	for _, name := range expose.PortNames() {
+		port := expose.Ports[name]
+		protocol := port.Protocol
+		if protocol == "" {
+			protocol = model.AppExposeProtocol_HTTP
+		}
+		ports = append(ports, corev1.ServicePort{
+			// Istio uses the port name prefix to select the protocol
+			Name:       fmt.Sprintf("%s-%s", protocol, name),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port.ContainerPort,
+			TargetPort: intstr.FromInt(int(port.ContainerPort)),
+		})
+	}
+	return ports
+}
@@ -0,0 +1,50 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func Test_CreateKNativePortsService(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose.Ports = map[string]model.AppConfigPort{
+		"metrics": {ContainerPort: 9100},
+		"admin":   {ContainerPort: 9000, Protocol: "grpc"},
+	}
+
+	result := CreateKNativePortsService(ctx)
+
+	assert.Equal(t, "myapp-ports", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Equal(t, "Service", result.Kind)
+	assert.Equal(t, "v1", result.APIVersion)
+	assert.Equal(t, deploymentLabels(ctx), result.Labels)
+	assert.Equal(t, map[string]string{"riser.dev/deployment": "myapp"}, result.Spec.Selector)
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "grpc-admin", Protocol: corev1.ProtocolTCP, Port: 9000, TargetPort: intstr.FromInt(9000)},
+		{Name: "http-metrics", Protocol: corev1.ProtocolTCP, Port: 9100, TargetPort: intstr.FromInt(9100)},
+	}, result.Spec.Ports)
+}
+
+func Test_CreateKNativePortsService_NoPorts(t *testing.T) {
+	assert.Nil(t, CreateKNativePortsService(newTestDeploymentContext(withDeploymentWorkload())))
+}
+
+func Test_CreateKNativePortsService_Worker(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.Expose = nil
+
+	assert.Nil(t, CreateKNativePortsService(ctx))
+}
+
+func Test_KNativePortsServiceMeta(t *testing.T) {
+	result := KNativePortsServiceMeta(newTestDeploymentContext(withDeploymentWorkload()))
+
+	assert.Equal(t, "myapp-ports", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Empty(t, result.Spec.Ports)
+}
@@ -25,8 +25,10 @@ func Test_CreateKNativeRoute_ExposeCluster(t *testing.T) {
 		ctx := &core.DeploymentContext{
 			DeploymentConfig: &core.DeploymentConfig{
 				App: &model.AppConfig{
-					Expose: &model.AppConfigExpose{
-						Scope: tt.scope,
+					OverrideableAppConfig: model.OverrideableAppConfig{
+						Expose: &model.AppConfigExpose{
+							Scope: tt.scope,
+						},
 					},
 				},
 			},
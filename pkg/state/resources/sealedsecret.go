@@ -35,7 +35,7 @@ func CreateSealedSecret(plaintextSecret string, secretMeta *core.SecretMeta, cer
 		return nil, errors.Wrap(err, "Error parsing public key")
 	}
 	objectMeta := metav1.ObjectMeta{
-		Name:      fmt.Sprintf("%s-%s-%d", secretMeta.App.Name, secretMeta.Name, secretMeta.Revision),
+		Name:      secretResourceName(secretMeta.App.Name, secretMeta.Name, secretMeta.Revision),
 		Namespace: secretMeta.App.Namespace,
 		Annotations: map[string]string{
 			riserLabel("revision"):       fmt.Sprintf("%d", secretMeta.Revision),
@@ -57,7 +57,7 @@ func CreateSealedSecret(plaintextSecret string, secretMeta *core.SecretMeta, cer
 		},
 		Spec: SealedSecretSpec{
 			EncryptedData: map[string][]byte{
-				"data": ciphertext,
+				secretDataKey: ciphertext,
 			},
 		},
 	}, nil
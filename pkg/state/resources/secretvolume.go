@@ -0,0 +1,69 @@
+package resources
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// secretDataKey is the key in each secret that contains the secret's value
+const secretDataKey = "data"
+
+// secretResourceName is the name of the Secret for a revision of an app's secret (e.g. myapp-mysecret-3)
+func secretResourceName(appName string, secretName string, revision int64) string {
+	return fmt.Sprintf("%s-%s-%d", appName, secretName, revision)
+}
+
+// mountedSecrets returns the secrets that have a path in the app config sorted by name. Secrets that are not mounted are only available
+// as env vars.
+func mountedSecrets(ctx *core.DeploymentContext) []core.SecretMeta {
+	mounted := []core.SecretMeta{}
+	for _, secret := range ctx.Secrets {
+		if ctx.DeploymentConfig.App.Secrets[secret.Name].Path != "" {
+			mounted = append(mounted, secret)
+		}
+	}
+	sort.Slice(mounted, func(i, j int) bool {
+		return mounted[i].Name < mounted[j].Name
+	})
+	return mounted
+}
+
+// secretVolumeName uses the index since secret names are not guaranteed to be valid volume names
+func secretVolumeName(idx int) string {
+	return fmt.Sprintf("riser-secret-%d", idx)
+}
+
+func secretVolumes(ctx *core.DeploymentContext) []corev1.Volume {
+	var volumes []corev1.Volume
+	for idx, secret := range mountedSecrets(ctx) {
+		volumes = append(volumes, corev1.Volume{
+			Name: secretVolumeName(idx),
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: secretResourceName(string(ctx.DeploymentConfig.App.Name), secret.Name, secret.Revision),
+					Items: []corev1.KeyToPath{
+						{Key: secretDataKey, Path: secretDataKey},
+					},
+					DefaultMode: ctx.DeploymentConfig.App.Secrets[secret.Name].FileMode(),
+				},
+			},
+		})
+	}
+	return volumes
+}
+
+func secretVolumeMounts(ctx *core.DeploymentContext) []corev1.VolumeMount {
+	var mounts []corev1.VolumeMount
+	for idx, secret := range mountedSecrets(ctx) {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      secretVolumeName(idx),
+			MountPath: ctx.DeploymentConfig.App.Secrets[secret.Name].Path,
+			SubPath:   secretDataKey,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
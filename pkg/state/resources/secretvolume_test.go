@@ -0,0 +1,83 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func Test_secretResourceName(t *testing.T) {
+	assert.Equal(t, "myapp-mysecret-3", secretResourceName("myapp", "mysecret", 3))
+}
+
+func Test_secretVolumes(t *testing.T) {
+	ctx := newTestDeploymentContext(withSecrets(map[string]model.AppConfigSecret{
+		"tls-key": {Path: "/etc/tls/tls.key", Mode: "0400"},
+		"creds":   {Path: "/etc/creds.json"},
+		"envonly": {},
+	}))
+
+	result := secretVolumes(ctx)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, "riser-secret-0", result[0].Name)
+	assert.Equal(t, "myapp-creds-2", result[0].Secret.SecretName)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "data", Path: "data"}}, result[0].Secret.Items)
+	assert.Nil(t, result[0].Secret.DefaultMode)
+	assert.Equal(t, "riser-secret-1", result[1].Name)
+	assert.Equal(t, "myapp-tls-key-5", result[1].Secret.SecretName)
+	assert.Equal(t, util.PtrInt32(0400), result[1].Secret.DefaultMode)
+}
+
+func Test_secretVolumeMounts(t *testing.T) {
+	ctx := newTestDeploymentContext(withSecrets(map[string]model.AppConfigSecret{
+		"tls-key": {Path: "/etc/tls/tls.key", Mode: "0400"},
+		"creds":   {Path: "/etc/creds.json"},
+		"envonly": {},
+	}))
+
+	result := secretVolumeMounts(ctx)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, corev1.VolumeMount{Name: "riser-secret-0", MountPath: "/etc/creds.json", SubPath: "data", ReadOnly: true}, result[0])
+	assert.Equal(t, corev1.VolumeMount{Name: "riser-secret-1", MountPath: "/etc/tls/tls.key", SubPath: "data", ReadOnly: true}, result[1])
+}
+
+func Test_secretVolumes_NotMounted(t *testing.T) {
+	ctx := newTestDeploymentContext(withSecrets(nil))
+
+	assert.Nil(t, secretVolumes(ctx))
+	assert.Nil(t, secretVolumeMounts(ctx))
+}
+
+func Test_createPodSpec_FilesAndSecrets(t *testing.T) {
+	ctx := newTestDeploymentContext(withSecrets(map[string]model.AppConfigSecret{"creds": {Path: "/etc/creds.json"}}))
+	ctx.DeploymentConfig.App.Files = map[string]string{"/etc/config.yaml": "a"}
+	ctx.DeploymentConfig.App.Expose = &model.AppConfigExpose{ContainerPort: 8080}
+
+	result := createPodSpec(ctx)
+
+	require.Len(t, result.Volumes, 2)
+	assert.Equal(t, "riser-files", result.Volumes[0].Name)
+	assert.Equal(t, "riser-secret-0", result.Volumes[1].Name)
+	require.Len(t, result.Containers[0].VolumeMounts, 2)
+	assert.Equal(t, "/etc/config.yaml", result.Containers[0].VolumeMounts[0].MountPath)
+	assert.Equal(t, "/etc/creds.json", result.Containers[0].VolumeMounts[1].MountPath)
+}
+
+// withSecrets sets the app's secrets. The secret metas include a revision for each of the secrets used by these tests.
+func withSecrets(secrets map[string]model.AppConfigSecret) testDeploymentContextOption {
+	return func(ctx *core.DeploymentContext) {
+		ctx.DeploymentConfig.App.Secrets = secrets
+		ctx.Secrets = []core.SecretMeta{
+			{Name: "tls-key", Revision: 5},
+			{Name: "envonly", Revision: 1},
+			{Name: "creds", Revision: 2},
+		}
+	}
+}
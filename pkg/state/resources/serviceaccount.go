@@ -0,0 +1,54 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/riser-platform/riser-server/pkg/core"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServiceAccountName returns the name of the service account that an app runs under. All deployments of an app share the service
+// account so that the app has one identity (e.g. for expose.allowFrom and cloud IAM) in each environment.
+func ServiceAccountName(ctx *core.DeploymentContext) string {
+	return AppServiceAccountName(string(ctx.DeploymentConfig.App.Name))
+}
+
+// AppServiceAccountName returns the name of the service account that all deployments of an app run under. The name is prefixed so that an
+// app may not take over a service account that riser does not manage, such as the namespace's "default" service account (e.g. an app named
+// "default"). The prefix fits in the characters that app names reserve.
+//
+// Pods no longer run under the "default" service account, so any imagePullSecrets attached to it no longer apply. Registry credentials
+// must be provided to the nodes instead (e.g. a kubelet credential provider or the node's service account with access to the registry).
+func AppServiceAccountName(app string) string {
+	return fmt.Sprintf("riser-%s", app)
+}
+
+// CreateServiceAccount creates the app's service account with the app's serviceAccount.annotations. The annotations are validated
+// against the environment's allowed annotations and the app's other deployments before deploying.
+func CreateServiceAccount(ctx *core.DeploymentContext) *corev1.ServiceAccount {
+	serviceAccount := ServiceAccountMeta(ServiceAccountName(ctx), ctx.DeploymentConfig.Namespace)
+	serviceAccount.Labels = map[string]string{
+		riserLabel("app"):         string(ctx.DeploymentConfig.App.Name),
+		riserLabel("environment"): ctx.DeploymentConfig.EnvironmentName,
+	}
+	if ctx.DeploymentConfig.App.ServiceAccount != nil {
+		serviceAccount.Annotations = ctx.DeploymentConfig.App.ServiceAccount.Annotations
+	}
+	return serviceAccount
+}
+
+// ServiceAccountMeta returns a service account without labels or annotations. This identifies the service account of an app that no
+// longer has any deployments so that it can be removed.
+func ServiceAccountMeta(name, namespace string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "ServiceAccount",
+			APIVersion: "v1",
+		},
+	}
+}
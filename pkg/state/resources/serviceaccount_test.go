@@ -0,0 +1,38 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ServiceAccountName(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.Name = "myapp-canary"
+
+	assert.Equal(t, "riser-myapp", ServiceAccountName(ctx))
+}
+
+func Test_CreateServiceAccount(t *testing.T) {
+	ctx := newTestDeploymentContext(withDeploymentWorkload())
+	ctx.DeploymentConfig.App.ServiceAccount = &model.AppConfigServiceAccount{
+		Annotations: map[string]string{"iam.gke.io/gcp-service-account": "myapp@myproject.iam.gserviceaccount.com"},
+	}
+
+	result := CreateServiceAccount(ctx)
+
+	assert.Equal(t, "riser-myapp", result.Name)
+	assert.Equal(t, "apps", result.Namespace)
+	assert.Equal(t, "ServiceAccount", result.Kind)
+	assert.Equal(t, "v1", result.APIVersion)
+	assert.Equal(t, map[string]string{"riser.dev/app": "myapp", "riser.dev/environment": "dev"}, result.Labels)
+	assert.Equal(t, map[string]string{"iam.gke.io/gcp-service-account": "myapp@myproject.iam.gserviceaccount.com"}, result.Annotations)
+}
+
+func Test_CreateServiceAccount_NoAnnotations(t *testing.T) {
+	result := CreateServiceAccount(newTestDeploymentContext(withDeploymentWorkload()))
+
+	assert.Equal(t, "riser-myapp", result.Name)
+	assert.Nil(t, result.Annotations)
+}
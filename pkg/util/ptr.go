@@ -16,6 +16,10 @@ func PtrFloat32(v float32) *float32 {
 	return &v
 }
 
+func PtrFloat64(v float64) *float64 {
+	return &v
+}
+
 func PtrBool(v bool) *bool {
 	return &v
 }
@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/riser-platform/riser-server/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// MaxDeliveryAttempts is the number of attempts before a delivery is marked as failed
+	MaxDeliveryAttempts = 8
+	SignatureHeader     = "X-Riser-Signature"
+	EventHeader         = "X-Riser-Event"
+	DeliveryHeader      = "X-Riser-Delivery"
+
+	backoffBase      = 15 * time.Second
+	backoffMax       = time.Hour
+	deliveryTimeout  = 10 * time.Second
+	deliveryLease    = time.Minute
+	deliveryPageSize = 25
+)
+
+// Dispatcher delivers pending webhook events. Deliveries are persisted, so undelivered events survive a server restart.
+type Dispatcher struct {
+	webhooks   core.WebhookRepository
+	deliveries core.WebhookDeliveryRepository
+	client     *http.Client
+	logger     *logrus.Logger
+	now        func() time.Time
+}
+
+func NewDispatcher(webhooks core.WebhookRepository, deliveries core.WebhookDeliveryRepository, logger *logrus.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		client:     &http.Client{Timeout: deliveryTimeout},
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// Run polls for due deliveries until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context, pollInterval time.Duration) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := d.DispatchDue()
+			if err != nil {
+				d.logger.WithField("category", "webhook").Errorf("Error dispatching webhooks: %s", err)
+			}
+		}
+	}
+}
+
+// DispatchDue attempts delivery of all deliveries that are due
+func (d *Dispatcher) DispatchDue() error {
+	for {
+		now := d.now().UTC()
+		due, err := d.deliveries.ClaimDue(now, now.Add(deliveryLease), deliveryPageSize)
+		if err != nil {
+			return err
+		}
+
+		for idx := range due {
+			d.deliver(&due[idx])
+			err = d.deliveries.UpdateAttempt(&due[idx])
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(due) < deliveryPageSize {
+			return nil
+		}
+	}
+}
+
+// deliver attempts delivery and updates the delivery with the result
+func (d *Dispatcher) deliver(delivery *core.WebhookDelivery) {
+	delivery.Attempts++
+	delivery.Doc.ResponseCode = 0
+	delivery.Doc.LastError = ""
+
+	webhook, err := d.webhooks.Get(delivery.WebhookId)
+	if err != nil {
+		d.recordFailure(delivery, fmt.Sprintf("error retrieving webhook: %s", err))
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.Doc.Url, bytes.NewReader(delivery.Doc.Payload))
+	if err != nil {
+		d.recordFailure(delivery, err.Error())
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("riser-server/%s", util.VersionString))
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, delivery.Id.String())
+	req.Header.Set(SignatureHeader, Sign(webhook.Doc.Secret, delivery.Doc.Payload))
+
+	response, err := d.client.Do(req)
+	if err != nil {
+		d.recordFailure(delivery, err.Error())
+		return
+	}
+	defer response.Body.Close()
+
+	delivery.Doc.ResponseCode = response.StatusCode
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		d.recordFailure(delivery, fmt.Sprintf("unexpected status code %d", response.StatusCode))
+		return
+	}
+
+	delivery.Status = model.WebhookDeliveryStatusSucceeded
+}
+
+func (d *Dispatcher) recordFailure(delivery *core.WebhookDelivery, message string) {
+	delivery.Doc.LastError = message
+	if delivery.Attempts >= MaxDeliveryAttempts {
+		delivery.Status = model.WebhookDeliveryStatusFailed
+		return
+	}
+	delivery.Status = model.WebhookDeliveryStatusPending
+	delivery.NextAttemptAt = d.now().UTC().Add(backoff(delivery.Attempts))
+}
+
+// backoff returns the exponential backoff duration after the specified number of failed attempts
+func backoff(attempts int) time.Duration {
+	delay := time.Duration(float64(backoffBase) * math.Pow(2, float64(attempts-1)))
+	if delay > backoffMax {
+		return backoffMax
+	}
+	return delay
+}
+
+// Sign returns the value of the signature header for a payload (e.g. "sha256=<hex encoded HMAC-SHA256>")
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
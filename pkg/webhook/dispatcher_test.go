@@ -0,0 +1,140 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_DispatchDue_Delivers(t *testing.T) {
+	payload := []byte(`{"type":"deployment.updated"}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, payload, body)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, model.WebhookEvent_DeploymentUpdated, r.Header.Get(EventHeader))
+		assert.NotEmpty(t, r.Header.Get(DeliveryHeader))
+		assert.Equal(t, Sign("mysecret", payload), r.Header.Get(SignatureHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	dispatcher, deliveries := newTestDispatcher(server.URL, payload)
+	deliveries.UpdateAttemptFn = func(delivery *core.WebhookDelivery) error {
+		assert.Equal(t, model.WebhookDeliveryStatusSucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.Doc.ResponseCode)
+		assert.Empty(t, delivery.Doc.LastError)
+		return nil
+	}
+
+	err := dispatcher.DispatchDue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deliveries.UpdateAttemptCallCount)
+}
+
+func Test_DispatchDue_SchedulesRetryWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	dispatcher, deliveries := newTestDispatcher(server.URL, []byte("{}"))
+	deliveries.UpdateAttemptFn = func(delivery *core.WebhookDelivery) error {
+		assert.Equal(t, model.WebhookDeliveryStatusPending, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusBadGateway, delivery.Doc.ResponseCode)
+		assert.Equal(t, "unexpected status code 502", delivery.Doc.LastError)
+		assert.Equal(t, testNow.Add(15*time.Second), delivery.NextAttemptAt)
+		return nil
+	}
+
+	err := dispatcher.DispatchDue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deliveries.UpdateAttemptCallCount)
+}
+
+func Test_DispatchDue_FailsAfterMaxAttempts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	dispatcher, deliveries := newTestDispatcher(server.URL, []byte("{}"))
+	claimDue := deliveries.ClaimDueFn
+	deliveries.ClaimDueFn = func(now time.Time, leaseUntil time.Time, limit int) ([]core.WebhookDelivery, error) {
+		due, err := claimDue(now, leaseUntil, limit)
+		due[0].Attempts = MaxDeliveryAttempts - 1
+		return due, err
+	}
+	deliveries.UpdateAttemptFn = func(delivery *core.WebhookDelivery) error {
+		assert.Equal(t, model.WebhookDeliveryStatusFailed, delivery.Status)
+		assert.Equal(t, MaxDeliveryAttempts, delivery.Attempts)
+		return nil
+	}
+
+	err := dispatcher.DispatchDue()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deliveries.UpdateAttemptCallCount)
+}
+
+func Test_backoff(t *testing.T) {
+	assert.Equal(t, 15*time.Second, backoff(1))
+	assert.Equal(t, 30*time.Second, backoff(2))
+	assert.Equal(t, 2*time.Minute, backoff(4))
+	assert.Equal(t, time.Hour, backoff(20))
+}
+
+func Test_Sign(t *testing.T) {
+	result := Sign("mysecret", []byte(`{"foo":"bar"}`))
+
+	mac := hmac.New(sha256.New, []byte("mysecret"))
+	mac.Write([]byte(`{"foo":"bar"}`))
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), result)
+}
+
+func newTestDispatcher(url string, payload []byte) (*Dispatcher, *core.FakeWebhookDeliveryRepository) {
+	webhookId := uuid.New()
+	webhooks := &core.FakeWebhookRepository{
+		GetFn: func(id uuid.UUID) (*core.Webhook, error) {
+			return &core.Webhook{Id: webhookId, Doc: core.WebhookDoc{Url: url, Secret: "mysecret"}}, nil
+		},
+	}
+	claimed := false
+	deliveries := &core.FakeWebhookDeliveryRepository{
+		ClaimDueFn: func(now time.Time, leaseUntil time.Time, limit int) ([]core.WebhookDelivery, error) {
+			if claimed {
+				return []core.WebhookDelivery{}, nil
+			}
+			claimed = true
+			return []core.WebhookDelivery{
+				{
+					Id:        uuid.New(),
+					WebhookId: webhookId,
+					EventType: model.WebhookEvent_DeploymentUpdated,
+					Status:    model.WebhookDeliveryStatusPending,
+					Doc:       core.WebhookDeliveryDoc{Payload: payload},
+				},
+			}, nil
+		},
+	}
+
+	dispatcher := NewDispatcher(webhooks, deliveries, logrus.New())
+	dispatcher.now = func() time.Time { return testNow }
+	return dispatcher, deliveries
+}
@@ -0,0 +1,32 @@
+package webhook
+
+import (
+	"github.com/google/uuid"
+	"github.com/riser-platform/riser-server/pkg/core"
+)
+
+type FakeService struct {
+	CreateFn         func(webhook *core.Webhook) error
+	CreateCallCount  int
+	DeleteFn         func(id uuid.UUID) error
+	DeleteCallCount  int
+	PublishFn        func(event *core.WebhookEvent)
+	PublishCallCount int
+}
+
+func (f *FakeService) Create(webhook *core.Webhook) error {
+	f.CreateCallCount++
+	return f.CreateFn(webhook)
+}
+
+func (f *FakeService) Delete(id uuid.UUID) error {
+	f.DeleteCallCount++
+	return f.DeleteFn(id)
+}
+
+func (f *FakeService) Publish(event *core.WebhookEvent) {
+	f.PublishCallCount++
+	if f.PublishFn != nil {
+		f.PublishFn(event)
+	}
+}
@@ -0,0 +1,158 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/sirupsen/logrus"
+)
+
+type Service interface {
+	Create(webhook *core.Webhook) error
+	Delete(id uuid.UUID) error
+	// Publish enqueues an event for each matching webhook. Delivery is performed asynchronously by the Dispatcher.
+	// Events are published after the change that they describe has been committed, so an error enqueuing an event is logged
+	// instead of being returned to the caller.
+	Publish(event *core.WebhookEvent)
+}
+
+type service struct {
+	webhooks   core.WebhookRepository
+	deliveries core.WebhookDeliveryRepository
+	logger     *logrus.Logger
+	now        func() time.Time
+}
+
+func NewService(webhooks core.WebhookRepository, deliveries core.WebhookDeliveryRepository, logger *logrus.Logger) Service {
+	return &service{webhooks, deliveries, logger, time.Now}
+}
+
+func (s *service) Create(webhook *core.Webhook) error {
+	webhook.Id = uuid.New()
+	webhook.Created = s.now().UTC()
+	err := s.webhooks.Create(webhook)
+	if err != nil {
+		return errors.Wrap(err, "error creating webhook")
+	}
+	return nil
+}
+
+func (s *service) Delete(id uuid.UUID) error {
+	err := s.webhooks.Delete(id)
+	if err != nil {
+		if err == core.ErrNotFound {
+			return err
+		}
+		return errors.Wrap(err, "error deleting webhook")
+	}
+	return nil
+}
+
+func (s *service) Publish(event *core.WebhookEvent) {
+	err := s.enqueue(event)
+	if err != nil {
+		s.logger.WithFields(logrus.Fields{
+			"category":    "webhook",
+			"event":       event.Type,
+			"namespace":   event.Namespace,
+			"environment": event.EnvironmentName,
+			"name":        event.Name,
+		}).Errorf("Error publishing webhook event: %s", err)
+	}
+}
+
+func (s *service) enqueue(event *core.WebhookEvent) error {
+	webhooks, err := s.webhooks.List()
+	if err != nil {
+		return errors.Wrap(err, "error listing webhooks")
+	}
+
+	now := s.now().UTC()
+	var payload []byte
+	for _, webhook := range webhooks {
+		if !webhook.Matches(event) {
+			continue
+		}
+
+		// All webhooks receive an identical payload for the same event
+		if payload == nil {
+			payload, err = json.Marshal(&model.WebhookPayload{
+				Id:            uuid.New(),
+				Type:          event.Type,
+				Timestamp:     now,
+				Namespace:     event.Namespace,
+				Environment:   event.EnvironmentName,
+				Name:          event.Name,
+				RiserRevision: event.RiserRevision,
+				Data:          event.Data,
+			})
+			if err != nil {
+				return errors.Wrap(err, fmt.Sprintf("error serializing %q event", event.Type))
+			}
+		}
+
+		err = s.deliveries.Create(&core.WebhookDelivery{
+			Id:            uuid.New(),
+			WebhookId:     webhook.Id,
+			EventType:     event.Type,
+			Status:        model.WebhookDeliveryStatusPending,
+			NextAttemptAt: now,
+			Created:       now,
+			Doc: core.WebhookDeliveryDoc{
+				Payload: payload,
+			},
+		})
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("error enqueuing %q event for webhook %q", event.Type, webhook.Id))
+		}
+	}
+
+	return nil
+}
+
+// RevisionStatusEvents returns an event for each revision that has transitioned to a ready or unhealthy state
+func RevisionStatusEvents(name *core.NamespacedName, envName string, previous *core.DeploymentStatus, current *core.DeploymentStatus) []core.WebhookEvent {
+	previousStatuses := map[int64]string{}
+	if previous != nil {
+		for _, revision := range previous.Revisions {
+			previousStatuses[revision.RiserRevision] = revision.RevisionStatus
+		}
+	}
+
+	events := []core.WebhookEvent{}
+	for _, revision := range current.Revisions {
+		if previousStatuses[revision.RiserRevision] == revision.RevisionStatus {
+			continue
+		}
+
+		eventType := ""
+		switch revision.RevisionStatus {
+		case model.RevisionStatusReady:
+			eventType = model.WebhookEvent_RevisionReady
+		case model.RevisionStatusUnhealthy:
+			eventType = model.WebhookEvent_RevisionFailed
+		default:
+			continue
+		}
+
+		events = append(events, core.WebhookEvent{
+			Type:            eventType,
+			Namespace:       name.Namespace,
+			EnvironmentName: envName,
+			Name:            name.Name,
+			RiserRevision:   revision.RiserRevision,
+			Data: map[string]string{
+				"revisionName": revision.Name,
+				"dockerImage":  revision.DockerImage,
+				"reason":       revision.RevisionStatusReason,
+			},
+		})
+	}
+
+	return events
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/riser-platform/riser-server/api/v1/model"
+	"github.com/riser-platform/riser-server/pkg/core"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testNow = time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+
+func Test_Create(t *testing.T) {
+	webhooks := &core.FakeWebhookRepository{
+		CreateFn: func(webhook *core.Webhook) error {
+			assert.NotEqual(t, uuid.Nil, webhook.Id)
+			assert.Equal(t, testNow, webhook.Created)
+			return nil
+		},
+	}
+
+	svc := service{webhooks: webhooks, now: func() time.Time { return testNow }}
+
+	err := svc.Create(&core.Webhook{Doc: core.WebhookDoc{Url: "https://acme.org"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, webhooks.CreateCallCount)
+}
+
+func Test_Delete_NotFound(t *testing.T) {
+	webhooks := &core.FakeWebhookRepository{
+		DeleteFn: func(uuid.UUID) error {
+			return core.ErrNotFound
+		},
+	}
+
+	svc := service{webhooks: webhooks}
+
+	err := svc.Delete(uuid.New())
+
+	assert.Equal(t, core.ErrNotFound, err)
+}
+
+func Test_Publish(t *testing.T) {
+	matchingId := uuid.New()
+	webhooks := &core.FakeWebhookRepository{
+		ListFn: func() ([]core.Webhook, error) {
+			return []core.Webhook{
+				{Id: matchingId, Doc: core.WebhookDoc{Namespace: "myns"}},
+				{Id: uuid.New(), Doc: core.WebhookDoc{Namespace: "otherns"}},
+			}, nil
+		},
+	}
+
+	deliveries := &core.FakeWebhookDeliveryRepository{
+		CreateFn: func(delivery *core.WebhookDelivery) error {
+			assert.NotEqual(t, uuid.Nil, delivery.Id)
+			assert.Equal(t, matchingId, delivery.WebhookId)
+			assert.Equal(t, model.WebhookEvent_DeploymentUpdated, delivery.EventType)
+			assert.Equal(t, model.WebhookDeliveryStatusPending, delivery.Status)
+			assert.Equal(t, testNow, delivery.NextAttemptAt)
+			payload := &model.WebhookPayload{}
+			require.NoError(t, json.Unmarshal(delivery.Doc.Payload, payload))
+			assert.Equal(t, model.WebhookEvent_DeploymentUpdated, payload.Type)
+			assert.Equal(t, "myns", payload.Namespace)
+			assert.Equal(t, "dev", payload.Environment)
+			assert.Equal(t, "mydep", payload.Name)
+			assert.EqualValues(t, 3, payload.RiserRevision)
+			assert.Equal(t, testNow, payload.Timestamp)
+			return nil
+		},
+	}
+
+	svc := service{webhooks: webhooks, deliveries: deliveries, now: func() time.Time { return testNow }}
+
+	svc.Publish(&core.WebhookEvent{
+		Type:            model.WebhookEvent_DeploymentUpdated,
+		Namespace:       "myns",
+		EnvironmentName: "dev",
+		Name:            "mydep",
+		RiserRevision:   3,
+	})
+
+	assert.Equal(t, 1, deliveries.CreateCallCount)
+}
+
+func Test_Publish_LogsCreateError(t *testing.T) {
+	webhooks := &core.FakeWebhookRepository{
+		ListFn: func() ([]core.Webhook, error) {
+			return []core.Webhook{{Id: uuid.New()}}, nil
+		},
+	}
+
+	deliveries := &core.FakeWebhookDeliveryRepository{
+		CreateFn: func(delivery *core.WebhookDelivery) error {
+			return errors.New("test")
+		},
+	}
+	logger, hook := logrustest.NewNullLogger()
+
+	svc := service{webhooks: webhooks, deliveries: deliveries, logger: logger, now: time.Now}
+
+	svc.Publish(&core.WebhookEvent{Type: model.WebhookEvent_DeploymentDeleted, Name: "mydep"})
+
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, logrus.ErrorLevel, hook.LastEntry().Level)
+	assert.Contains(t, hook.LastEntry().Message, `error enqueuing "deployment.deleted" event for webhook`)
+	assert.Equal(t, "mydep", hook.LastEntry().Data["name"])
+}
+
+func Test_RevisionStatusEvents(t *testing.T) {
+	name := core.NewNamespacedName("mydep", "myns")
+	previous := &core.DeploymentStatus{
+		Revisions: []core.DeploymentRevisionStatus{
+			{RiserRevision: 1, RevisionStatus: model.RevisionStatusReady},
+			{RiserRevision: 2, RevisionStatus: model.RevisionStatusWaiting},
+			{RiserRevision: 3, RevisionStatus: model.RevisionStatusWaiting},
+		},
+	}
+	current := &core.DeploymentStatus{
+		Revisions: []core.DeploymentRevisionStatus{
+			{RiserRevision: 1, RevisionStatus: model.RevisionStatusReady},
+			{RiserRevision: 2, RevisionStatus: model.RevisionStatusUnhealthy, RevisionStatusReason: "CrashLoopBackOff"},
+			{RiserRevision: 3, RevisionStatus: model.RevisionStatusReady},
+			{RiserRevision: 4, RevisionStatus: model.RevisionStatusWaiting},
+		},
+	}
+
+	result := RevisionStatusEvents(name, "dev", previous, current)
+
+	require.Len(t, result, 2)
+	assert.Equal(t, model.WebhookEvent_RevisionFailed, result[0].Type)
+	assert.EqualValues(t, 2, result[0].RiserRevision)
+	assert.Equal(t, "CrashLoopBackOff", result[0].Data.(map[string]string)["reason"])
+	assert.Equal(t, model.WebhookEvent_RevisionReady, result[1].Type)
+	assert.EqualValues(t, 3, result[1].RiserRevision)
+	assert.Equal(t, "mydep", result[1].Name)
+	assert.Equal(t, "myns", result[1].Namespace)
+	assert.Equal(t, "dev", result[1].EnvironmentName)
+}
+
+func Test_RevisionStatusEvents_NoPreviousStatus(t *testing.T) {
+	current := &core.DeploymentStatus{
+		Revisions: []core.DeploymentRevisionStatus{
+			{RiserRevision: 1, RevisionStatus: model.RevisionStatusReady},
+		},
+	}
+
+	result := RevisionStatusEvents(core.NewNamespacedName("mydep", "myns"), "dev", nil, current)
+
+	require.Len(t, result, 1)
+	assert.Equal(t, model.WebhookEvent_RevisionReady, result[0].Type)
+}